@@ -0,0 +1,67 @@
+package dapplink_vrf
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/config"
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/synchronizer"
+	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// 回填配置的历史区块范围，主节点和每个备用节点各建立一个连接，分片轮流使用这些连接
+func RunBackfill(ctx context.Context, cfg *config.Config) error {
+	logSource, err := synchronizer.ParseLogSourceMode(cfg.Chain.LogSource)
+	if err != nil {
+		return err
+	}
+	clientCfg, err := syncClientConfig(cfg.Chain, nil)
+	if err != nil {
+		return err
+	}
+	// 每个连接只对应一个节点，多节点一致性读取不适用
+	clientCfg.ReadQuorum = 0
+
+	rpcUrls := append([]string{cfg.Chain.ChainRpcUrl}, cfg.Chain.FallbackRpcUrls...)
+	clients := make([]node.EthClient, 0, len(rpcUrls))
+	for _, rpcUrl := range rpcUrls {
+		client, err := node.DialEthClient(ctx, clientCfg, rpcUrl)
+		if err != nil {
+			log.Error("new backfill eth client fail", "url", rpcUrl, "err", err)
+			return err
+		}
+		clients = append(clients, client)
+	}
+
+	db, err := database.NewDB(ctx, cfg.MasterDB)
+	if err != nil {
+		log.Error("new database fail", "err", err)
+		return err
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Error("close database fail", "err", err)
+		}
+	}()
+
+	backfillCfg := synchronizer.BackfillConfig{
+		From:      new(big.Int).SetUint64(cfg.Chain.BackfillFrom),
+		ShardSize: cfg.Chain.BackfillShardSize,
+		Workers:   cfg.Chain.BackfillWorkers,
+		ChainId:   cfg.Chain.ChainId,
+		LogSource: logSource,
+
+		SparseHeaders:        cfg.Chain.SparseHeaders,
+		SparseHeaderInterval: cfg.Chain.SparseHeaderInterval,
+	}
+	if cfg.Chain.BackfillTo > 0 {
+		backfillCfg.To = new(big.Int).SetUint64(cfg.Chain.BackfillTo)
+	}
+	backfiller, err := synchronizer.NewBackfiller(db, clients, backfillCfg)
+	if err != nil {
+		return err
+	}
+	return backfiller.Run(ctx)
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"math/big"
 
 	dapplink_vrf "github.com/WJX2001/contract-caller"
 	"github.com/WJX2001/contract-caller/common/cliapp"
@@ -9,6 +11,9 @@ import (
 	"github.com/WJX2001/contract-caller/config"
 	"github.com/WJX2001/contract-caller/database"
 	flag2 "github.com/WJX2001/contract-caller/flags"
+	"github.com/WJX2001/contract-caller/vrf"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/urfave/cli/v2"
 )
@@ -51,6 +56,159 @@ func runMigrations(ctx *cli.Context) error {
 	return db.ExecuteSQLMigration(cfg.Migrations)
 }
 
+// 并发回填历史区块，完成后退出
+// 使用场景：首次部署时追赶大量历史数据，之后再运行 index 从回填的位置继续同步
+func runBackfill(ctx *cli.Context) error {
+	log.Info("Running backfill...")
+	cfg, err := config.LoadConfig(ctx)
+	if err != nil {
+		log.Error("failed to load config", "err", err)
+		return err
+	}
+
+	ctx.Context = opio.CancelOnInterrupt(ctx.Context)
+	return dapplink_vrf.RunBackfill(ctx.Context, &cfg)
+}
+
+// 重新解析一段历史区块中已存储的合约事件，补写业务表中缺失的行后退出
+// 使用场景：修复事件处理函数的问题后重新生成业务数据，或为新增的业务表填充历史数据
+func runReplay(ctx *cli.Context) error {
+	log.Info("Running replay...")
+	cfg, err := config.LoadConfig(ctx)
+	if err != nil {
+		log.Error("failed to load config", "err", err)
+		return err
+	}
+
+	ctx.Context = opio.CancelOnInterrupt(ctx.Context)
+	from := new(big.Int).SetUint64(ctx.Uint64(flag2.ReplayFromFlag.Name))
+	to := new(big.Int).SetUint64(ctx.Uint64(flag2.ReplayToFlag.Name))
+	return dapplink_vrf.RunReplay(ctx.Context, &cfg, from, to)
+}
+
+// 通过工厂合约部署新的 VRF 代理并立即加入监听
+func runDeployProxy(ctx *cli.Context) error {
+	log.Info("Deploying vrf proxy...")
+	cfg, err := config.LoadConfig(ctx)
+	if err != nil {
+		log.Error("failed to load config", "err", err)
+		return err
+	}
+
+	ctx.Context = opio.CancelOnInterrupt(ctx.Context)
+	implementation := common.HexToAddress(ctx.String(flag2.DeployImplementationFlag.Name))
+	dapplinkAddress := common.HexToAddress(ctx.String(flag2.DeployDappLinkAddressFlag.Name))
+	proxy, err := dapplink_vrf.RunDeployProxy(ctx.Context, &cfg, implementation, dapplinkAddress)
+	if err != nil {
+		return err
+	}
+	fmt.Println(proxy.String())
+	return nil
+}
+
+// 修改同步器的监听地址，运行中的同步器在下一批区块生效
+func runWatch(action func(db *database.DB, ctx *cli.Context) error) cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		cfg, err := config.LoadConfig(ctx)
+		if err != nil {
+			log.Error("failed to load config", "err", err)
+			return err
+		}
+		db, err := database.NewDB(ctx.Context, cfg.MasterDB)
+		if err != nil {
+			log.Error("failed to connect to database", "err", err)
+			return err
+		}
+		defer func(db *database.DB) {
+			err := db.Close()
+			if err != nil {
+				return
+			}
+		}(db)
+		return action(db, ctx)
+	}
+}
+
+func watchAddress(ctx *cli.Context) (common.Address, error) {
+	address := ctx.String(flag2.WatchAddressFlag.Name)
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("invalid address: %s", address)
+	}
+	return common.HexToAddress(address), nil
+}
+
+func runWatchAdd(db *database.DB, ctx *cli.Context) error {
+	address, err := watchAddress(ctx)
+	if err != nil {
+		return err
+	}
+	var backfillFrom *big.Int
+	if ctx.IsSet(flag2.WatchBackfillFromFlag.Name) {
+		backfillFrom = new(big.Int).SetUint64(ctx.Uint64(flag2.WatchBackfillFromFlag.Name))
+	}
+	if err := db.WatchAddresses.AddWatchAddress(address, backfillFrom); err != nil {
+		return err
+	}
+	log.Info("watch address added", "address", address, "backfillFrom", backfillFrom)
+	return nil
+}
+
+func runWatchRemove(db *database.DB, ctx *cli.Context) error {
+	address, err := watchAddress(ctx)
+	if err != nil {
+		return err
+	}
+	if err := db.WatchAddresses.RemoveWatchAddress(address); err != nil {
+		return err
+	}
+	log.Info("watch address removed", "address", address)
+	return nil
+}
+
+func runWatchList(db *database.DB, ctx *cli.Context) error {
+	watchAddresses, err := db.WatchAddresses.WatchAddresses()
+	if err != nil {
+		return err
+	}
+	for _, watchAddress := range watchAddresses {
+		fmt.Printf("%s enabled=%t backfillFrom=%v backfilled=%t\n", watchAddress.Address, watchAddress.Enabled, watchAddress.BackfillFrom, watchAddress.Backfilled)
+	}
+	return nil
+}
+
+// 生成新的 VRF 私钥写入 vrf-key-file，输出公钥供链上验证合约登记
+func runVrfKeyGenerate(ctx *cli.Context) error {
+	path := ctx.String(flag2.VrfKeyFileFlag.Name)
+	if path == "" {
+		return fmt.Errorf("--%s is required", flag2.VrfKeyFileFlag.Name)
+	}
+	key, err := vrf.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := vrf.SaveKeyFile(path, key); err != nil {
+		return err
+	}
+	log.Info("vrf key generated", "file", path)
+	printVrfPublicKey(key.PublicKey())
+	return nil
+}
+
+// 输出 vrf-key-file 中私钥对应的公钥
+func runVrfKeyShow(ctx *cli.Context) error {
+	key, err := vrf.LoadKeyFile(ctx.String(flag2.VrfKeyFileFlag.Name))
+	if err != nil {
+		return err
+	}
+	printVrfPublicKey(key.PublicKey())
+	return nil
+}
+
+func printVrfPublicKey(publicKey *vrf.PublicKey) {
+	x, y := publicKey.Coordinates()
+	fmt.Printf("publicKey=%s x=%s y=%s\n", hexutil.Encode(publicKey.Bytes()), x, y)
+}
+
 func NewCli(GitCommit string, GitData string) *cli.App {
 	flags := flag2.Flags
 	return &cli.App{
@@ -70,6 +228,66 @@ func NewCli(GitCommit string, GitData string) *cli.App {
 				Description: "Runs the database migrations",
 				Action:      runMigrations,
 			},
+			{
+				Name:        "backfill",
+				Flags:       flags,
+				Description: "Backfills historical block headers and contract events in parallel",
+				Action:      runBackfill,
+			},
+			{
+				Name:        "replay",
+				Flags:       append(append([]cli.Flag{}, flags...), flag2.ReplayFromFlag, flag2.ReplayToFlag),
+				Description: "Reprocesses stored contract events in a block range, overwriting the derived data",
+				Action:      runReplay,
+			},
+			{
+				Name:        "deploy-proxy",
+				Flags:       append(append([]cli.Flag{}, flags...), flag2.DeployImplementationFlag, flag2.DeployDappLinkAddressFlag),
+				Description: "Deploys a new vrf proxy through the factory and starts watching it",
+				Action:      runDeployProxy,
+			},
+			{
+				Name:        "watch",
+				Description: "Manages the contract addresses watched by the synchronizer",
+				Subcommands: []*cli.Command{
+					{
+						Name:        "add",
+						Flags:       append(append([]cli.Flag{}, flags...), flag2.WatchAddressFlag, flag2.WatchBackfillFromFlag),
+						Description: "Watches an address, optionally backfilling its history",
+						Action:      runWatch(runWatchAdd),
+					},
+					{
+						Name:        "remove",
+						Flags:       append(append([]cli.Flag{}, flags...), flag2.WatchAddressFlag),
+						Description: "Stops watching an address",
+						Action:      runWatch(runWatchRemove),
+					},
+					{
+						Name:        "list",
+						Flags:       flags,
+						Description: "Lists the addresses added or removed at runtime",
+						Action:      runWatch(runWatchList),
+					},
+				},
+			},
+			{
+				Name:        "vrf-key",
+				Description: "Manages the key of the ecvrf random source",
+				Subcommands: []*cli.Command{
+					{
+						Name:        "generate",
+						Flags:       []cli.Flag{flag2.VrfKeyFileFlag},
+						Description: "Generates a new vrf key into a new file and prints its public key",
+						Action:      runVrfKeyGenerate,
+					},
+					{
+						Name:        "show",
+						Flags:       []cli.Flag{flag2.VrfKeyFileFlag},
+						Description: "Prints the public key of a vrf key file",
+						Action:      runVrfKeyShow,
+					},
+				},
+			},
 			{
 				Name:        "version",
 				Description: "print version",
@@ -11,28 +11,54 @@ import (
 
 const (
 	defaultConfirmations = 64
-	defaultLoopInterval  = 5000
+	defaultLoopInterval  = 5 * time.Second
 )
 
 type Config struct {
-	Migrations     string      // 数据库迁移文件路径
-	Chain          ChainConfig // 区块链配置
-	MasterDB       DBConfig    // 主数据库配置
-	SlaveDB        DBConfig    // 从数据库配置
-	SlaveDbEnable  bool        // 是否启用从数据库
-	ApiCacheEnable bool        // 是否启用 API 缓存
+	Migrations     string          // 数据库迁移文件路径
+	Chain          ChainConfig     // 区块链配置
+	MasterDB       DBConfig        // 主数据库配置
+	SlaveDB        DBConfig        // 从数据库配置
+	SlaveDbEnable  bool            // 是否启用从数据库
+	ApiCacheEnable bool            // 是否启用 API 缓存
+	Metrics        MetricsConfig   // 指标服务配置
+	Publisher      PublisherConfig // 消息总线发布配置
+	Signer         SignerConfig    // 外部签名后端配置
 }
 
 type ChainConfig struct {
 	ChainRpcUrl                       string           // 区块链节点 RPC 地址
 	ChainId                           uint             // 链ID
 	StartingHeight                    uint64           // 起始区块高度
-	Confirmations                     uint64           // 确认数（需要多少个确认区块才认为交易或事件是安全的）
+	Confirmations                     uint64           // 确认数（需要多少个确认区块才认为交易或事件是安全的），也是同步器能自动处理的最大重组深度
+	SyncConfirmations                 uint64           // 同步器落后链头的区块数，0 表示与 Confirmations 相同
+	SyncFollow                        string           // 同步器跟随的链头：latest（减去 SyncConfirmations）、safe 或 finalized
+	AheadOfProviderPolicy             string           // 同步进度超过节点链头时的处理方式：wait、rewind 或 fail
+	SyncCatchUpThreshold              uint64           // 落后链头超过该区块数时同步器连续同步不等待，0 表示与 BlockStep 相同
+	SyncPipelineDepth                 uint64           // 同步流水线各阶段之间最多缓存的批次数，0 使用默认值
+	GapCheckInterval                  time.Duration    // 检查并修复已存储区块头缺失和不连续的间隔，0 表示不检查
+	IndexTransactions                 bool             // 同步时索引 to 地址为监听合约的交易
+	IndexReceipts                     bool             // 索引交易时同时记录回执中的执行状态和 gas 用量
+	LogSource                         string           // 同步器拉取日志的方式：range、per-block 或 auto
+	SparseHeaders                     bool             // 只存储包含监听日志的区块头以及周期性的区块头
+	SparseHeaderInterval              uint64           // 稀疏模式下周期性存储区块头的间隔，0 使用默认值
+	BackfillFrom                      uint64           // 历史回填的起始区块高度
+	BackfillTo                        uint64           // 历史回填的结束区块高度，0 表示最新的 finalized 区块
+	BackfillShardSize                 uint64           // 历史回填时每个分片的区块数
+	BackfillWorkers                   int              // 历史回填时并发处理的分片数
 	BlockStep                         uint64           // 区块步长（扫块时每次跨多少个区块）
 	Contracts                         []common.Address // 合约地址列表
 	MainLoopInterval                  time.Duration    // 主循环执行间隔
 	EventInterval                     time.Duration    // 事件处理间隔
+	EventEpoch                        uint64           // 事件处理每一轮最多处理的区块数，0 使用默认值
+	EventWorkers                      int              // 并发解析事件的合约数，0 使用默认值
 	CallInterval                      time.Duration    // 普通合约调用间隔
+	MaxConcurrentFulfillments         int              // 同时回填的请求数，0 使用默认值
+	MaxFulfillAttempts                int              // 请求最多回填次数，达到后置为失败，0 使用默认值
+	FulfillRetryBackoff               time.Duration    // 回填失败后的重试间隔，之后每次翻倍，0 使用默认值
+	FulfillMaxRetryBackoff            time.Duration    // 最长重试间隔，0 使用默认值
+	RequestTTL                        time.Duration    // 请求有效期，超过后标记为过期不再回填，0 表示不过期
+	RequestExpiredWebhook             string           // 请求过期时通知的 webhook，为空时不通知
 	PrivateKey                        string           // 钱包私钥
 	DappLinkVrfContractAddress        string           // VRF合约地址
 	DappLinkVrfFactoryContractAddress string           // VRF工厂合约地址（用于创建VRF实例）
@@ -42,6 +68,80 @@ type ChainConfig struct {
 	Mnemonic                          string           // 助记词
 	CallerHDPath                      string           // HD钱包的派生路径
 	Passphrase                        string           // 助记词的额外密码（如果有）
+	MaxGasFeeCap                      uint64           // gasFeeCap 上限（wei），0 表示不限制
+	MaxGasTipCap                      uint64           // gasTipCap 上限（wei），0 表示不限制
+	MinGasFeeCap                      uint64           // gasFeeCap 下限（wei），0 表示不限制
+	MinGasTipCap                      uint64           // gasTipCap 下限（wei），0 表示不限制
+	GasLimitMultiplier                float64          // 回填交易估算 gas 的余量倍数，0 使用默认值
+	MaxGasLimit                       uint64           // 回填交易的 gas 上限，0 表示不限制
+	MulticallAddress                  string           // 批量回填使用的 Multicall3 聚合合约地址，为空时逐个回填
+	MaxFulfillBatchSize               int              // 每笔批量回填交易最多包含的请求数，0 使用默认值
+	TipPercentile                     float64          // 按 fee history 定价时小费的百分位，0 使用默认值
+	BaseFeeMultiplier                 float64          // 按 fee history 定价时 baseFee 的余量倍数，0 使用默认值
+	BalanceCheckInterval              time.Duration    // 调用者余额检查间隔
+	LowBalanceWarning                 uint64           // 调用者余额告警阈值（gwei），0 表示不告警
+	MinBalance                        uint64           // 调用者余额低于该值（gwei）时暂停回填，0 表示不暂停
+	VerifyContractSelectors           bool             // 启动时检查合约字节码包含期望方法的选择器
+	FallbackRpcUrls                   []string         // 同步器的备用 RPC 地址，主节点不可用时自动切换
+	RpcDialTimeout                    time.Duration    // 同步器连接单个 RPC 节点的总超时
+	RpcDialAttempts                   int              // 同步器连接单个 RPC 节点的最大尝试次数
+	RpcRequestTimeout                 time.Duration    // 同步器单次 RPC 调用的超时
+	EnableTraceTransaction            bool             // 是否允许调用 debug_traceTransaction
+	RpcBatchSize                      int              // 批量获取区块头时每组的区块数
+	RpcBatchConcurrency               int              // 批量获取区块头时并发的组数
+	RpcBatchPerCall                   bool             // 批量获取区块头时逐个区块调用
+	RpcBatchRetries                   int              // 批量获取区块头时每组的重试次数
+	RpcReadQuorum                     int              // 关键读取需要结果一致的 RPC 节点数，小于 2 时不启用
+	RpcCacheSize                      int              // 已最终确认区块头和交易的缓存容量，0 使用默认值，小于 0 不缓存
+	RpcHeaders                        []string         // 请求 RPC 节点时附加的请求头，格式为 "Name: value"
+	RpcBearerToken                    string           // 请求 RPC 节点时使用的 Bearer Token
+	RpcBasicAuth                      string           // 请求 RPC 节点时使用的 Basic 认证，格式为 "user:password"
+	RpcJWTSecret                      string           // Engine API 风格 JWT 密钥文件路径
+	RpcProxyUrl                       string           // 访问 RPC 节点的 HTTP 代理
+	RpcTLSCAFile                      string           // 访问 RPC 节点时信任的 CA 证书文件
+	BroadcastRpcUrls                  []string         // 额外广播交易的 RPC 地址
+	StuckTxThreshold                  time.Duration    // 交易超过该时长未上链视为卡住，0 表示不检测
+	TxSendTimeout                     time.Duration    // 单笔交易从发送到确认的最长耗时，0 使用默认值
+	MaxSpendPerHour                   uint64           // 每小时交易花费上限（gwei），0 表示不限制
+	MaxSpendPerDay                    uint64           // 每天交易花费上限（gwei），0 表示不限制
+	UseAccessList                     bool             // 是否为交易附加 EIP-2930 access list
+	DryRun                            bool             // 演练模式，只模拟执行交易不广播
+	SupportsEIP1559                   bool             // 链是否支持 EIP-1559，不支持时按 eth_gasPrice 发送 legacy 交易
+	RandomSource                      string           // 回填随机数的来源：crypto 或 hmac
+	RandomSourceKey                   string           // hmac 随机数来源的密钥（十六进制）
+	VrfKeyFile                        string           // ecvrf 随机数来源的私钥文件
+	DrandUrl                          string           // drand 随机数来源的 HTTP API 地址
+	DrandChainHash                    string           // drand 网络的 chain hash，为空时使用默认网络
+}
+
+type MetricsConfig struct {
+	Enabled bool
+	Host    string
+	Port    int
+}
+
+type PublisherConfig struct {
+	Type  string   // kafka 或 nats，为空时不发布
+	Urls  []string // Kafka broker 地址或 NATS 服务器地址
+	Topic string   // Kafka topic 或 NATS subject
+}
+
+type SignerConfig struct {
+	Type               string // aws-kms、gcp-kms 或 vault，为空时使用私钥或助记词签名
+	KMSKeyId           string // AWS KMS 密钥 ID 或 ARN；GCP KMS 密钥版本的资源名
+	KMSRegion          string // AWS KMS 所在区域，为空时使用默认配置
+	GCPCredentialsFile string // GCP 服务账号凭证文件，为空时使用默认凭证
+	Vault              VaultConfig
+}
+
+type VaultConfig struct {
+	Addr     string // Vault 地址
+	Token    string // 访问 token，为空时使用 AppRole 登录
+	RoleId   string // AppRole role_id
+	SecretId string // AppRole secret_id
+	Mount    string // KV v2 挂载点
+	Path     string // 保存私钥的密钥路径
+	KeyField string // 私钥所在字段，为空时使用 private_key
 }
 
 type DBConfig struct {
@@ -61,10 +161,18 @@ func LoadConfig(cliCtx *cli.Context) (Config, error) {
 		cfg.Chain.Confirmations = defaultConfirmations
 	}
 
+	if cfg.Chain.SyncConfirmations == 0 {
+		cfg.Chain.SyncConfirmations = cfg.Chain.Confirmations
+	}
+
 	if cfg.Chain.MainLoopInterval == 0 {
 		cfg.Chain.MainLoopInterval = defaultLoopInterval
 	}
 
+	if cfg.Chain.SyncCatchUpThreshold == 0 {
+		cfg.Chain.SyncCatchUpThreshold = cfg.Chain.BlockStep
+	}
+
 	log.Info("loaded chain config", "config", cfg.Chain)
 	return cfg, nil
 }
@@ -85,11 +193,44 @@ func NewConfig(ctx *cli.Context) Config {
 			ChainRpcUrl:                       ctx.String(flags.ChainRpcFlag.Name),
 			StartingHeight:                    ctx.Uint64(flags.StartingHeightFlag.Name),
 			Confirmations:                     ctx.Uint64(flags.ConfirmationsFlag.Name),
+			SyncConfirmations:                 ctx.Uint64(flags.SyncConfirmationsFlag.Name),
+			SyncFollow:                        ctx.String(flags.SyncFollowFlag.Name),
+			AheadOfProviderPolicy:             ctx.String(flags.AheadOfProviderPolicyFlag.Name),
+			SyncCatchUpThreshold:              ctx.Uint64(flags.SyncCatchUpThresholdFlag.Name),
+			SyncPipelineDepth:                 ctx.Uint64(flags.SyncPipelineDepthFlag.Name),
+			GapCheckInterval:                  ctx.Duration(flags.GapCheckIntervalFlag.Name),
+			IndexTransactions:                 ctx.Bool(flags.IndexTransactionsFlag.Name),
+			IndexReceipts:                     ctx.Bool(flags.IndexReceiptsFlag.Name),
+			LogSource:                         ctx.String(flags.LogSourceFlag.Name),
+			SparseHeaders:                     ctx.Bool(flags.SparseHeadersFlag.Name),
+			SparseHeaderInterval:              ctx.Uint64(flags.SparseHeaderIntervalFlag.Name),
+			BackfillFrom:                      ctx.Uint64(flags.BackfillFromFlag.Name),
+			BackfillTo:                        ctx.Uint64(flags.BackfillToFlag.Name),
+			BackfillShardSize:                 ctx.Uint64(flags.BackfillShardSizeFlag.Name),
+			BackfillWorkers:                   ctx.Int(flags.BackfillWorkersFlag.Name),
 			BlockStep:                         ctx.Uint64(flags.BlocksStepFlag.Name),
 			Contracts:                         LoadContracts(),
 			MainLoopInterval:                  ctx.Duration(flags.MainIntervalFlag.Name),
 			EventInterval:                     ctx.Duration(flags.EventIntervalFlag.Name),
+			EventEpoch:                        ctx.Uint64(flags.EventEpochFlag.Name),
+			EventWorkers:                      ctx.Int(flags.EventWorkersFlag.Name),
+			GasLimitMultiplier:                ctx.Float64(flags.GasLimitMultiplierFlag.Name),
+			MaxGasLimit:                       ctx.Uint64(flags.MaxGasLimitFlag.Name),
+			MulticallAddress:                  ctx.String(flags.MulticallAddressFlag.Name),
+			MaxFulfillBatchSize:               ctx.Int(flags.MaxFulfillBatchSizeFlag.Name),
+			TipPercentile:                     ctx.Float64(flags.TipPercentileFlag.Name),
+			BaseFeeMultiplier:                 ctx.Float64(flags.BaseFeeMultiplierFlag.Name),
+			BalanceCheckInterval:              ctx.Duration(flags.BalanceCheckIntervalFlag.Name),
+			LowBalanceWarning:                 ctx.Uint64(flags.LowBalanceWarningFlag.Name),
+			MinBalance:                        ctx.Uint64(flags.MinBalanceFlag.Name),
+			VerifyContractSelectors:           ctx.Bool(flags.VerifyContractSelectorsFlag.Name),
 			CallInterval:                      ctx.Duration(flags.CallIntervalFlag.Name),
+			MaxConcurrentFulfillments:         ctx.Int(flags.MaxConcurrentFulfillmentsFlag.Name),
+			MaxFulfillAttempts:                ctx.Int(flags.MaxFulfillAttemptsFlag.Name),
+			FulfillRetryBackoff:               ctx.Duration(flags.FulfillRetryBackoffFlag.Name),
+			FulfillMaxRetryBackoff:            ctx.Duration(flags.FulfillMaxRetryBackoffFlag.Name),
+			RequestTTL:                        ctx.Duration(flags.RequestTTLFlag.Name),
+			RequestExpiredWebhook:             ctx.String(flags.RequestExpiredWebhookFlag.Name),
 			PrivateKey:                        ctx.String(flags.PrivateKeyFlag.Name),
 			DappLinkVrfContractAddress:        ctx.String(flags.DappLinkVrfContractAddressFlag.Name),
 			DappLinkVrfFactoryContractAddress: ctx.String(flags.DappLinkVrfFactoryContractAddressFlag.Name),
@@ -99,6 +240,40 @@ func NewConfig(ctx *cli.Context) Config {
 			Mnemonic:                          ctx.String(flags.MnemonicFlag.Name),
 			CallerHDPath:                      ctx.String(flags.CallerHDPathFlag.Name),
 			Passphrase:                        ctx.String(flags.PassphraseFlag.Name),
+			MaxGasFeeCap:                      ctx.Uint64(flags.MaxGasFeeCapFlag.Name),
+			MaxGasTipCap:                      ctx.Uint64(flags.MaxGasTipCapFlag.Name),
+			MinGasFeeCap:                      ctx.Uint64(flags.MinGasFeeCapFlag.Name),
+			MinGasTipCap:                      ctx.Uint64(flags.MinGasTipCapFlag.Name),
+			FallbackRpcUrls:                   ctx.StringSlice(flags.FallbackRpcUrlsFlag.Name),
+			RpcDialTimeout:                    ctx.Duration(flags.RpcDialTimeoutFlag.Name),
+			RpcDialAttempts:                   ctx.Int(flags.RpcDialAttemptsFlag.Name),
+			RpcRequestTimeout:                 ctx.Duration(flags.RpcRequestTimeoutFlag.Name),
+			EnableTraceTransaction:            ctx.Bool(flags.EnableTraceTransactionFlag.Name),
+			RpcBatchSize:                      ctx.Int(flags.RpcBatchSizeFlag.Name),
+			RpcBatchConcurrency:               ctx.Int(flags.RpcBatchConcurrencyFlag.Name),
+			RpcBatchPerCall:                   ctx.Bool(flags.RpcBatchPerCallFlag.Name),
+			RpcBatchRetries:                   ctx.Int(flags.RpcBatchRetriesFlag.Name),
+			RpcReadQuorum:                     ctx.Int(flags.RpcReadQuorumFlag.Name),
+			RpcCacheSize:                      ctx.Int(flags.RpcCacheSizeFlag.Name),
+			RpcHeaders:                        ctx.StringSlice(flags.RpcHeadersFlag.Name),
+			RpcBearerToken:                    ctx.String(flags.RpcBearerTokenFlag.Name),
+			RpcBasicAuth:                      ctx.String(flags.RpcBasicAuthFlag.Name),
+			RpcJWTSecret:                      ctx.String(flags.RpcJWTSecretFlag.Name),
+			RpcProxyUrl:                       ctx.String(flags.RpcProxyUrlFlag.Name),
+			RpcTLSCAFile:                      ctx.String(flags.RpcTLSCAFileFlag.Name),
+			BroadcastRpcUrls:                  ctx.StringSlice(flags.BroadcastRpcUrlsFlag.Name),
+			StuckTxThreshold:                  ctx.Duration(flags.StuckTxThresholdFlag.Name),
+			TxSendTimeout:                     ctx.Duration(flags.TxSendTimeoutFlag.Name),
+			MaxSpendPerHour:                   ctx.Uint64(flags.MaxSpendPerHourFlag.Name),
+			MaxSpendPerDay:                    ctx.Uint64(flags.MaxSpendPerDayFlag.Name),
+			UseAccessList:                     ctx.Bool(flags.UseAccessListFlag.Name),
+			DryRun:                            ctx.Bool(flags.DryRunFlag.Name),
+			SupportsEIP1559:                   ctx.Bool(flags.SupportsEIP1559Flag.Name),
+			RandomSource:                      ctx.String(flags.RandomSourceFlag.Name),
+			RandomSourceKey:                   ctx.String(flags.RandomSourceKeyFlag.Name),
+			VrfKeyFile:                        ctx.String(flags.VrfKeyFileFlag.Name),
+			DrandUrl:                          ctx.String(flags.DrandUrlFlag.Name),
+			DrandChainHash:                    ctx.String(flags.DrandChainHashFlag.Name),
 		},
 		MasterDB: DBConfig{
 			Host:     ctx.String(flags.MasterDbHostFlag.Name),
@@ -115,5 +290,31 @@ func NewConfig(ctx *cli.Context) Config {
 			Password: ctx.String(flags.SlaveDbPasswordFlag.Name),
 		},
 		SlaveDbEnable: ctx.Bool(flags.SlaveDbEnableFlag.Name),
+		Metrics: MetricsConfig{
+			Enabled: ctx.Bool(flags.MetricsEnabledFlag.Name),
+			Host:    ctx.String(flags.MetricsHostFlag.Name),
+			Port:    ctx.Int(flags.MetricsPortFlag.Name),
+		},
+		Publisher: PublisherConfig{
+			Type:  ctx.String(flags.PublisherFlag.Name),
+			Urls:  ctx.StringSlice(flags.PublisherUrlsFlag.Name),
+			Topic: ctx.String(flags.PublisherTopicFlag.Name),
+		},
+		Signer: SignerConfig{
+			Type:      ctx.String(flags.SignerFlag.Name),
+			KMSKeyId:  ctx.String(flags.KMSKeyIdFlag.Name),
+			KMSRegion: ctx.String(flags.KMSRegionFlag.Name),
+
+			GCPCredentialsFile: ctx.String(flags.GCPCredentialsFileFlag.Name),
+			Vault: VaultConfig{
+				Addr:     ctx.String(flags.VaultAddrFlag.Name),
+				Token:    ctx.String(flags.VaultTokenFlag.Name),
+				RoleId:   ctx.String(flags.VaultRoleIdFlag.Name),
+				SecretId: ctx.String(flags.VaultSecretIdFlag.Name),
+				Mount:    ctx.String(flags.VaultMountFlag.Name),
+				Path:     ctx.String(flags.VaultPathFlag.Name),
+				KeyField: ctx.String(flags.VaultKeyFieldFlag.Name),
+			},
+		},
 	}
 }
@@ -2,33 +2,70 @@ package dapplink_vrf
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	common2 "github.com/WJX2001/contract-caller/common"
+	"github.com/WJX2001/contract-caller/common/metrics"
 	"github.com/WJX2001/contract-caller/config"
 	"github.com/WJX2001/contract-caller/database"
 	"github.com/WJX2001/contract-caller/driver"
 	"github.com/WJX2001/contract-caller/event"
+	"github.com/WJX2001/contract-caller/publisher"
+	"github.com/WJX2001/contract-caller/signer"
 	"github.com/WJX2001/contract-caller/synchronizer"
 	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/WJX2001/contract-caller/txmgr"
+	"github.com/WJX2001/contract-caller/vrf"
 	"github.com/WJX2001/contract-caller/worker"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type DappLinkVrf struct {
+	cfg           *config.Config
+	registry      *prometheus.Registry
+	metricsServer *metrics.Server
 	db            *database.DB
 	synchronizer  *synchronizer.Synchronizer
 	eventsHandler *event.EventsHandler
 	worker        *worker.Worker
+	publisher     publisher.Publisher
 	shutdown      context.CancelCauseFunc
 	stopped       atomic.Bool
 }
 
 func NewDappLinkVrf(ctx context.Context, cfg *config.Config, shutdown context.CancelCauseFunc) (*DappLinkVrf, error) {
-	// 创建以太坊客户端
-	ethClient, err := node.DialEthClient(ctx, cfg.Chain.ChainRpcUrl)
+	// 各模块的指标统一注册到这个 registry
+	registry := metrics.NewRegistry()
+	txMetrics := txmgr.NewPrometheusMetrics(metrics.Namespace)
+	registry.MustRegister(txMetrics.Collectors()...)
+	rpcMetrics := node.NewPrometheusMetrics(metrics.Namespace)
+	registry.MustRegister(rpcMetrics.Collectors()...)
+	syncMetrics := synchronizer.NewPrometheusMetrics(metrics.Namespace)
+	registry.MustRegister(syncMetrics.Collectors()...)
+	eventMetrics := event.NewPrometheusMetrics(metrics.Namespace)
+	registry.MustRegister(eventMetrics.Collectors()...)
+	driverMetrics := driver.NewPrometheusMetrics(metrics.Namespace)
+	registry.MustRegister(driverMetrics.Collectors()...)
+	workerMetrics := worker.NewPrometheusMetrics(metrics.Namespace)
+	registry.MustRegister(workerMetrics.Collectors()...)
+
+	// 创建以太坊客户端，配置了备用节点时使用节点池
+	rpcUrls := append([]string{cfg.Chain.ChainRpcUrl}, cfg.Chain.FallbackRpcUrls...)
+	clientCfg, err := syncClientConfig(cfg.Chain, rpcMetrics)
+	if err != nil {
+		return nil, err
+	}
+	ethClient, err := node.DialEthClient(ctx, clientCfg, rpcUrls...)
 	if err != nil {
 		log.Error("new eth client fail", "err", err)
 		return nil, err
@@ -42,60 +79,80 @@ func NewDappLinkVrf(ctx context.Context, cfg *config.Config, shutdown context.Ca
 	}
 
 	// 3. 创建同步器
-	synchronizerS, err := synchronizer.NewSynchronizer(cfg, db, ethClient, shutdown)
+	synchronizerS, err := synchronizer.NewSynchronizer(cfg, db, ethClient, syncMetrics, shutdown)
 	if err != nil {
 		log.Error("new synchronizer fail", "err", err)
 		return nil, err
 	}
 
+	// 配置了消息总线时发布解析出的事件和随机数回填结果
+	eventPublisher, err := publisher.NewPublisher(publisher.Config{
+		Type:  cfg.Publisher.Type,
+		Urls:  cfg.Publisher.Urls,
+		Topic: cfg.Publisher.Topic,
+	})
+	if err != nil {
+		log.Error("new publisher fail", "err", err)
+		return nil, err
+	}
+
 	eventConfigm := &event.EventsHandlerConfig{
 		DappLinkVrfAddress:        cfg.Chain.DappLinkVrfContractAddress,
 		DappLinkVrfFactoryAddress: cfg.Chain.DappLinkVrfFactoryContractAddress,
 		LoopInterval:              cfg.Chain.EventInterval,
 		StartHeight:               big.NewInt(int64(cfg.Chain.StartingHeight)),
-		Epoch:                     500,
+		Epoch:                     cfg.Chain.EventEpoch,
+		Workers:                   cfg.Chain.EventWorkers,
+		Publisher:                 eventPublisher,
 	}
 
 	// 4. 创建事件处理器
-	eventHandler, err := event.NewEventsHandler(db, eventConfigm, shutdown)
+	eventHandler, err := event.NewEventsHandler(db, eventConfigm, eventMetrics, shutdown)
 	if err != nil {
 		return nil, err
 	}
 
 	// 5. 创建驱动引擎
-	ethcli, err := driver.EthClientWithTimeout(ctx, cfg.Chain.ChainRpcUrl)
+	eingine, err := newDriverEngine(ctx, cfg, db, txMetrics, driverMetrics)
 	if err != nil {
-		log.Error("new eth client fail", "err", err)
 		return nil, err
 	}
 
-	callerPrivateKey, _, err := common2.ParseWalletPrivKeyAndContractAddr(
-		"ContractCaller",
-		cfg.Chain.Mnemonic,
-		cfg.Chain.CallerHDPath,
-		cfg.Chain.PrivateKey,
-		cfg.Chain.DappLinkVrfContractAddress,
-		cfg.Chain.Passphrase,
-	)
-
-	decg := &driver.DriverEngineConfig{
-		ChainClient:               ethcli,
-		ChainId:                   big.NewInt(int64(cfg.Chain.ChainId)),
-		DappLinkVrfAddress:        common.HexToAddress(cfg.Chain.DappLinkVrfContractAddress),
-		CallerAddress:             common.HexToAddress(cfg.Chain.CallerAddress),
-		PrivateKey:                callerPrivateKey,
-		NumConfirmations:          cfg.Chain.Confirmations,
-		SafeAbortNonceTooLowCount: cfg.Chain.SafeAbortNonceTooLowCount,
+	randomSourceKey := common.FromHex(cfg.Chain.RandomSourceKey)
+	if cfg.Chain.VrfKeyFile != "" {
+		vrfKey, err := vrf.LoadKeyFile(cfg.Chain.VrfKeyFile)
+		if err != nil {
+			log.Error("load vrf key fail", "err", err)
+			return nil, err
+		}
+		randomSourceKey = vrfKey.Bytes()
+		log.Info("loaded vrf key", "publicKey", hexutil.Encode(vrfKey.PublicKey().Bytes()))
 	}
-
-	eingine, err := driver.NewDriverEngine(ctx, decg)
+	randomSource, err := worker.NewRandomSource(ctx, worker.RandomSourceConfig{
+		Kind:           cfg.Chain.RandomSource,
+		Key:            randomSourceKey,
+		DrandUrl:       cfg.Chain.DrandUrl,
+		DrandChainHash: cfg.Chain.DrandChainHash,
+		Headers:        ethClient,
+	})
 	if err != nil {
-		log.Error("new driver eingine fail", "err", err)
+		log.Error("new random source fail", "err", err)
 		return nil, err
 	}
 
 	workerConfig := &worker.WorkerConfig{
 		LoopInterval: cfg.Chain.CallInterval,
+		Publisher:    eventPublisher,
+		RandomSource: randomSource,
+		Metrics:      workerMetrics,
+
+		MaxConcurrentFulfillments: cfg.Chain.MaxConcurrentFulfillments,
+		MaxAttempts:               cfg.Chain.MaxFulfillAttempts,
+		RetryBackoff:              cfg.Chain.FulfillRetryBackoff,
+		MaxRetryBackoff:           cfg.Chain.FulfillMaxRetryBackoff,
+
+		RequestTTL:        cfg.Chain.RequestTTL,
+		ExpiredWebhookUrl: cfg.Chain.RequestExpiredWebhook,
 	}
 
 	// 6. 创建工作器
@@ -106,17 +163,161 @@ func NewDappLinkVrf(ctx context.Context, cfg *config.Config, shutdown context.Ca
 	}
 	// 7. 返回完整的 DappLinkVrf 对象
 	return &DappLinkVrf{
+		cfg:           cfg,
+		registry:      registry,
 		db:            db,
 		synchronizer:  synchronizerS,
 		eventsHandler: eventHandler,
 		worker:        workerProcessor,
+		publisher:     eventPublisher,
 		shutdown:      shutdown,
 	}, nil
 }
 
+// 按配置创建驱动引擎，metrics 为 nil 时不采集，回填交易按请求记录到 db
+func newDriverEngine(ctx context.Context, cfg *config.Config, db *database.DB, txMetrics txmgr.Metrics, driverMetrics driver.Metrics) (*driver.DriverEngine, error) {
+	// 发送交易的节点与同步器使用同一份认证配置
+	rpcAuth, err := rpcAuthConfig(cfg.Chain)
+	if err != nil {
+		log.Error("invalid rpc auth config", "err", err)
+		return nil, err
+	}
+	dialOpts, err := rpcAuth.DialOptions()
+	if err != nil {
+		log.Error("invalid rpc auth config", "err", err)
+		return nil, err
+	}
+	ethcli, err := driver.EthClientWithTimeout(ctx, cfg.Chain.ChainRpcUrl, dialOpts...)
+	if err != nil {
+		log.Error("new eth client fail", "err", err)
+		return nil, err
+	}
+
+	// 配置了外部签名后端时不读取私钥
+	chainId := big.NewInt(int64(cfg.Chain.ChainId))
+	callerSigner, err := signer.NewSigner(ctx, signer.Config{
+		Type:      cfg.Signer.Type,
+		KMSKeyId:  cfg.Signer.KMSKeyId,
+		KMSRegion: cfg.Signer.KMSRegion,
+
+		GCPCredentialsFile: cfg.Signer.GCPCredentialsFile,
+		Vault:              signer.VaultConfig(cfg.Signer.Vault),
+	}, chainId)
+	if err != nil {
+		log.Error("new signer fail", "err", err)
+		return nil, err
+	}
+	if callerSigner == nil {
+		callerPrivateKey, _, err := common2.ParseWalletPrivKeyAndContractAddr(
+			"ContractCaller",
+			cfg.Chain.Mnemonic,
+			cfg.Chain.CallerHDPath,
+			cfg.Chain.PrivateKey,
+			cfg.Chain.DappLinkVrfContractAddress,
+			cfg.Chain.Passphrase,
+		)
+		if err != nil {
+			log.Error("parse caller private key fail", "err", err)
+			return nil, err
+		}
+		callerSigner = driver.NewPrivateKeySigner(callerPrivateKey, chainId)
+	}
+	if cfg.Chain.CallerAddress != "" && common.HexToAddress(cfg.Chain.CallerAddress) != callerSigner.Address() {
+		return nil, fmt.Errorf("caller address %s does not match private key address %s", cfg.Chain.CallerAddress, callerSigner.Address())
+	}
+
+	// 额外广播交易的节点
+	var broadcastClients []*ethclient.Client
+	for _, rpcUrl := range cfg.Chain.BroadcastRpcUrls {
+		client, err := driver.EthClientWithTimeout(ctx, rpcUrl, dialOpts...)
+		if err != nil {
+			log.Error("new broadcast eth client fail", "url", rpcUrl, "err", err)
+			return nil, err
+		}
+		broadcastClients = append(broadcastClients, client)
+	}
+
+	decg := &driver.DriverEngineConfig{
+		ChainClient:               ethcli,
+		ChainId:                   chainId,
+		DappLinkVrfAddress:        common.HexToAddress(cfg.Chain.DappLinkVrfContractAddress),
+		DappLinkVrfFactoryAddress: common.HexToAddress(cfg.Chain.DappLinkVrfFactoryContractAddress),
+		VerifyContractSelectors:   cfg.Chain.VerifyContractSelectors,
+		Signer:                    callerSigner,
+		NumConfirmations:          cfg.Chain.Confirmations,
+		SafeAbortNonceTooLowCount: cfg.Chain.SafeAbortNonceTooLowCount,
+		TxMetrics:                 txMetrics,
+		BroadcastClients:          broadcastClients,
+		StuckTxThreshold:          cfg.Chain.StuckTxThreshold,
+		TxSendTimeout:             cfg.Chain.TxSendTimeout,
+		UseAccessList:             cfg.Chain.UseAccessList,
+		DryRun:                    cfg.Chain.DryRun,
+		FailOnRevert:              true,
+		GasLimitMultiplier:        cfg.Chain.GasLimitMultiplier,
+		MaxGasLimit:               cfg.Chain.MaxGasLimit,
+		MulticallAddress:          common.HexToAddress(cfg.Chain.MulticallAddress),
+		MaxFulfillBatchSize:       cfg.Chain.MaxFulfillBatchSize,
+		TipPercentile:             cfg.Chain.TipPercentile,
+		BaseFeeMultiplier:         cfg.Chain.BaseFeeMultiplier,
+		BalanceCheckInterval:      cfg.Chain.BalanceCheckInterval,
+		Metrics:                   driverMetrics,
+	}
+	txRecorder := worker.NewTxRecorder(db)
+	decg.OnTxPublished = txRecorder.OnPublished
+	decg.OnTxBumped = txRecorder.OnBumped
+	decg.OnTxMined = txRecorder.OnMined
+	if !cfg.Chain.SupportsEIP1559 {
+		decg.TxType = txmgr.LegacyTxType
+	}
+	if cfg.Chain.MaxGasFeeCap > 0 {
+		decg.MaxGasFeeCap = new(big.Int).SetUint64(cfg.Chain.MaxGasFeeCap)
+	}
+	if cfg.Chain.MaxGasTipCap > 0 {
+		decg.MaxGasTipCap = new(big.Int).SetUint64(cfg.Chain.MaxGasTipCap)
+	}
+	if cfg.Chain.MinGasFeeCap > 0 {
+		decg.MinGasFeeCap = new(big.Int).SetUint64(cfg.Chain.MinGasFeeCap)
+	}
+	if cfg.Chain.MinGasTipCap > 0 {
+		decg.MinGasTipCap = new(big.Int).SetUint64(cfg.Chain.MinGasTipCap)
+	}
+	if cfg.Chain.LowBalanceWarning > 0 {
+		decg.LowBalanceWarning = gweiToWei(cfg.Chain.LowBalanceWarning)
+	}
+	if cfg.Chain.MinBalance > 0 {
+		decg.MinBalance = gweiToWei(cfg.Chain.MinBalance)
+	}
+	var budgets []txmgr.Budget
+	if cfg.Chain.MaxSpendPerHour > 0 {
+		budgets = append(budgets, txmgr.NewWindowBudget(time.Hour, gweiToWei(cfg.Chain.MaxSpendPerHour)))
+	}
+	if cfg.Chain.MaxSpendPerDay > 0 {
+		budgets = append(budgets, txmgr.NewWindowBudget(24*time.Hour, gweiToWei(cfg.Chain.MaxSpendPerDay)))
+	}
+	if len(budgets) > 0 {
+		decg.Budget = txmgr.Budgets(budgets...)
+	}
+
+	eingine, err := driver.NewDriverEngine(ctx, decg)
+	if err != nil {
+		log.Error("new driver eingine fail", "err", err)
+		return nil, err
+	}
+	return eingine, nil
+}
+
 // 启动所有服务
 // 启动定时同步任务
 func (dvrf *DappLinkVrf) Start(ctx context.Context) error {
+	// 0. 启动指标服务
+	if dvrf.cfg.Metrics.Enabled {
+		metricsServer, err := metrics.StartServer(dvrf.registry, dvrf.cfg.Metrics.Host, dvrf.cfg.Metrics.Port)
+		if err != nil {
+			return err
+		}
+		dvrf.metricsServer = metricsServer
+	}
+
 	// 1. 启动同步器
 	err := dvrf.synchronizer.Start()
 	if err != nil {
@@ -155,9 +356,88 @@ func (dvrf *DappLinkVrf) Stop(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+
+	// 4. 关闭消息总线发布者，发送缓冲中的消息
+	if dvrf.publisher != nil {
+		if err := dvrf.publisher.Close(); err != nil {
+			return err
+		}
+	}
+
+	// 5. 关闭指标服务
+	if dvrf.metricsServer != nil {
+		if err := dvrf.metricsServer.Stop(ctx); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// 根据配置生成同步器使用的 RPC 客户端配置
+func syncClientConfig(chain config.ChainConfig, rpcMetrics node.Metrics) (node.ClientConfig, error) {
+	clientCfg := node.ClientConfig{
+		DialTimeout:    chain.RpcDialTimeout,
+		DialAttempts:   chain.RpcDialAttempts,
+		RequestTimeout: chain.RpcRequestTimeout,
+		Metrics:        rpcMetrics,
+		EnableTracing:  chain.EnableTraceTransaction,
+		CacheSize:      chain.RpcCacheSize,
+		ReadQuorum:     chain.RpcReadQuorum,
+	}
+	// 配置了批量参数时覆盖该链的内置批量方案
+	if chain.RpcBatchSize > 0 || chain.RpcBatchConcurrency > 0 || chain.RpcBatchPerCall || chain.RpcBatchRetries > 0 {
+		clientCfg.BatchingProfiles = map[uint]node.BatchingProfile{
+			chain.ChainId: {
+				MaxBatchSize: chain.RpcBatchSize,
+				Concurrency:  chain.RpcBatchConcurrency,
+				PerCall:      chain.RpcBatchPerCall,
+				Retries:      chain.RpcBatchRetries,
+			},
+		}
+	}
+	rpcAuth, err := rpcAuthConfig(chain)
+	if err != nil {
+		log.Error("invalid rpc auth config", "err", err)
+		return node.ClientConfig{}, err
+	}
+	clientCfg.Auth = rpcAuth
+	return clientCfg, nil
+}
+
+// 根据配置生成访问私有 RPC 节点的认证配置
+func rpcAuthConfig(chain config.ChainConfig) (node.AuthConfig, error) {
+	var auth node.AuthConfig
+	var err error
+	if len(chain.RpcHeaders) > 0 {
+		if auth.Headers, err = node.ParseHeaders(chain.RpcHeaders); err != nil {
+			return auth, err
+		}
+	}
+	auth.BearerToken = chain.RpcBearerToken
+	if chain.RpcBasicAuth != "" {
+		user, password, ok := strings.Cut(chain.RpcBasicAuth, ":")
+		if !ok {
+			return auth, errors.New("rpc basic auth must be in user:password form")
+		}
+		auth.BasicUser, auth.BasicPassword = user, password
+	}
+	if chain.RpcJWTSecret != "" {
+		if auth.JWTSecret, err = node.LoadJWTSecret(chain.RpcJWTSecret); err != nil {
+			return auth, err
+		}
+	}
+	if chain.RpcProxyUrl != "" || chain.RpcTLSCAFile != "" {
+		if auth.HTTPClient, err = node.NewHTTPClient(chain.RpcProxyUrl, chain.RpcTLSCAFile); err != nil {
+			return auth, err
+		}
+	}
+	return auth, nil
+}
+
+func gweiToWei(gwei uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(gwei), big.NewInt(params.GWei))
+}
+
 func (dvrf *DappLinkVrf) Stopped() bool {
 	return dvrf.stopped.Load()
 }
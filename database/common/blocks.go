@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BlockHeader struct {
@@ -18,12 +19,21 @@ type BlockHeader struct {
 	Number     *big.Int    `gorm:"serializer:u256"`
 	Timestamp  uint64
 	RLPHeader  *utils.RLPHeader `gorm:"serializer:rlp;column:rlp_bytes"` // RLP 编码后的区块头，存储在数据库字段 rlp_bytes
+	Finality   utils.Finality   // 最终性状态，写入时为 unsafe，由同步器随链上 safe / finalized 区块更新
 }
 
 func (BlockHeader) TableName() string {
 	return "block_headers"
 }
 
+// 已存储区块头中的一段缺失或不连续的区间
+// 缺失时 [From, To] 为缺少的区块高度；不连续时 From、To 为高度相邻但 ParentHash 对不上的两个已存储区块
+type BlockHeaderGap struct {
+	From          *big.Int
+	To            *big.Int
+	Discontinuity bool
+}
+
 // 只读查询接口
 type BlocksView interface {
 	BlockHeader(common.Hash) (*BlockHeader, error)
@@ -31,12 +41,20 @@ type BlocksView interface {
 	BlockHeaderWithFilter(BlockHeader) (*BlockHeader, error)
 	BlockHeaderWithScope(func(db *gorm.DB) *gorm.DB) (*BlockHeader, error)
 	LatestBlockHeader() (*BlockHeader, error)
+	BlockHeadersByRange(*big.Int, *big.Int) ([]BlockHeader, error)
+	BlockHeaderGaps(*big.Int, *big.Int) ([]BlockHeaderGap, error)
+	LatestBlockHeaderWithFinality(utils.Finality) (*BlockHeader, error)
 }
 
 // 在原先基础上，增加了写操作，方便区分 只读数据库和读写数据库
 type BlocksDB interface {
 	BlocksView
 	StoreBlockHeaders([]BlockHeader) error
+	DeleteBlockHeadersAfter(*big.Int) error
+	DeleteBlockHeader(common.Hash) error
+	LockBlockHeader(common.Hash) (bool, error)
+	LockBlockHeadersAfter(*big.Int) error
+	MarkBlockHeadersFinality(*big.Int, utils.Finality) (int64, error)
 }
 
 type blocksDB struct {
@@ -84,13 +102,101 @@ func (b blocksDB) LatestBlockHeader() (*BlockHeader, error) {
 	return &header, nil
 }
 
+// 查询最终性不低于 finality 的最新区块头，下游按需要的一致性级别读取
+func (b blocksDB) LatestBlockHeaderWithFinality(finality utils.Finality) (*BlockHeader, error) {
+	return b.BlockHeaderWithScope(func(db *gorm.DB) *gorm.DB {
+		return db.Where("finality >= ?", finality).Order("number DESC")
+	})
+}
+
+// 查询高度在 [from, to] 范围内已存储的区块头，按高度升序
+func (b blocksDB) BlockHeadersByRange(from, to *big.Int) ([]BlockHeader, error) {
+	var headers []BlockHeader
+	result := b.gorm.Table("block_headers").Where("number >= ? AND number <= ?", from, to).Order("number ASC").Find(&headers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return headers, nil
+}
+
+// 查询高度在 [from, to] 范围内已存储区块头之间的缺失和不连续区间，按高度升序
+// 相邻的两个已存储区块高度不相连视为缺失，高度相连但 parent_hash 与前一个区块的 hash 不同视为不连续
+func (b blocksDB) BlockHeaderGaps(from, to *big.Int) ([]BlockHeaderGap, error) {
+	var rows []struct {
+		PrevNumber *big.Int `gorm:"serializer:u256"`
+		Number     *big.Int `gorm:"serializer:u256"`
+	}
+	result := b.gorm.Raw(`SELECT prev_number, number FROM (
+			SELECT number, parent_hash,
+				LAG(number) OVER (ORDER BY number) AS prev_number,
+				LAG(hash) OVER (ORDER BY number) AS prev_hash
+			FROM block_headers WHERE number >= ? AND number <= ?
+		) t WHERE prev_number IS NOT NULL AND (number > prev_number + 1 OR parent_hash <> prev_hash)
+		ORDER BY number ASC`, from, to).Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	gaps := make([]BlockHeaderGap, 0, len(rows))
+	for _, row := range rows {
+		gapFrom := new(big.Int).Add(row.PrevNumber, big.NewInt(1))
+		if gapFrom.Cmp(row.Number) == 0 {
+			gaps = append(gaps, BlockHeaderGap{From: row.PrevNumber, To: row.Number, Discontinuity: true})
+			continue
+		}
+		gaps = append(gaps, BlockHeaderGap{From: gapFrom, To: new(big.Int).Sub(row.Number, big.NewInt(1))})
+	}
+	return gaps, nil
+}
+
 func (b blocksDB) StoreBlockHeaders(headers []BlockHeader) error {
 	// 将 headers中每一条数据插入数据库
 	// 这里数据不是大批量，否则使用CreateInBatches，小批量 使用 Create 更简洁
-	result := b.gorm.Table("block_headers").Omit("guid").Create(&headers)
+	// 已存在的区块头（哈希相同）跳过；同一高度已存在不同哈希的区块头时仍然报错，需要先按重组回滚
+	onConflict := clause.OnConflict{Columns: []clause.Column{{Name: "hash"}}, DoNothing: true}
+	result := b.gorm.Table("block_headers").Omit("guid").Clauses(onConflict).Create(&headers)
 	return result.Error
 }
 
+// 删除高度大于 number 的区块头，用于重组后回滚被孤立的区块
+func (b blocksDB) DeleteBlockHeadersAfter(number *big.Int) error {
+	result := b.gorm.Table("block_headers").Where("number > ?", number).Delete(&BlockHeader{})
+	return result.Error
+}
+
+// 删除指定哈希的区块头，用于修复不在规范链上的孤块
+func (b blocksDB) DeleteBlockHeader(hash common.Hash) error {
+	result := b.gorm.Table("block_headers").Where(&BlockHeader{Hash: hash}).Delete(&BlockHeader{})
+	return result.Error
+}
+
+// 在事务中对区块头加共享锁直到事务结束，返回区块头是否存在
+// 与 LockBlockHeadersAfter 配合，保证基于该区块写入的数据不会与删除该区块的回滚交错
+func (b blocksDB) LockBlockHeader(hash common.Hash) (bool, error) {
+	var header BlockHeader
+	result := b.gorm.Table("block_headers").Clauses(clause.Locking{Strength: "SHARE"}).Where(&BlockHeader{Hash: hash}).Take(&header)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, result.Error
+	}
+	return true, nil
+}
+
+// 在事务中对高度大于 number 的区块头加排他锁直到事务结束，回滚时在删除任何数据之前调用
+func (b blocksDB) LockBlockHeadersAfter(number *big.Int) error {
+	var hashes []string
+	result := b.gorm.Table("block_headers").Clauses(clause.Locking{Strength: "UPDATE"}).Where("number > ?", number).Pluck("hash", &hashes)
+	return result.Error
+}
+
+// 把高度不超过 number 且最终性低于 finality 的区块头标记为 finality，返回更新的数量
+func (b blocksDB) MarkBlockHeadersFinality(number *big.Int, finality utils.Finality) (int64, error) {
+	result := b.gorm.Table("block_headers").Where("number <= ? AND finality < ?", number, finality).Update("finality", finality)
+	return result.RowsAffected, result.Error
+}
+
 func NewBlocksDB(db *gorm.DB) BlocksDB {
 	return &blocksDB{gorm: db}
 }
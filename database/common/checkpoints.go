@@ -0,0 +1,74 @@
+package common
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/WJX2001/contract-caller/database/utils"
+	_ "github.com/WJX2001/contract-caller/database/utils/serializers"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 同步进度检查点，独立于 block_headers 表记录遍历到的最后一个区块头
+// 区块头表被清理或不存储区块头时，重启后仍能从检查点继续同步
+type Checkpoint struct {
+	Name      string           `gorm:"primaryKey"` // 检查点名称，每个遍历器一条记录
+	Hash      common.Hash      `gorm:"serializer:bytes"`
+	Number    *big.Int         `gorm:"serializer:u256"`
+	RLPHeader *utils.RLPHeader `gorm:"serializer:rlp;column:rlp_bytes"`
+	UpdatedAt uint64
+}
+
+func (Checkpoint) TableName() string {
+	return "sync_checkpoints"
+}
+
+func NewCheckpoint(name string, header *types.Header) Checkpoint {
+	return Checkpoint{
+		Name:      name,
+		Hash:      header.Hash(),
+		Number:    header.Number,
+		RLPHeader: (*utils.RLPHeader)(header),
+		UpdatedAt: uint64(time.Now().Unix()),
+	}
+}
+
+type CheckpointsView interface {
+	Checkpoint(name string) (*Checkpoint, error)
+}
+
+type CheckpointsDB interface {
+	CheckpointsView
+	StoreCheckpoint(Checkpoint) error
+}
+
+type checkpointsDB struct {
+	gorm *gorm.DB
+}
+
+// 查询检查点，不存在时返回 nil
+func (c checkpointsDB) Checkpoint(name string) (*Checkpoint, error) {
+	var checkpoint Checkpoint
+	result := c.gorm.Where("name = ?", name).Take(&checkpoint)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &checkpoint, nil
+}
+
+// 写入检查点，已存在时覆盖
+func (c checkpointsDB) StoreCheckpoint(checkpoint Checkpoint) error {
+	result := c.gorm.Clauses(clause.OnConflict{UpdateAll: true}).Create(&checkpoint)
+	return result.Error
+}
+
+func NewCheckpointsDB(db *gorm.DB) CheckpointsDB {
+	return &checkpointsDB{gorm: db}
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	_ "github.com/WJX2001/contract-caller/database/utils/serializers"
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 运行时维护的监听地址
+// Enabled 为 true 时在 proxy_created 的地址之外额外监听该地址，为 false 时即使是代理合约地址也不再监听
+type WatchAddress struct {
+	Address      common.Address `gorm:"primaryKey;serializer:bytes"`
+	Enabled      bool
+	BackfillFrom *big.Int `gorm:"serializer:u256"` // 需要回填历史日志的起始高度，为 nil 时不回填
+	Backfilled   bool     // 历史日志是否已回填完成
+	UpdatedAt    uint64
+}
+
+func (WatchAddress) TableName() string {
+	return "watch_addresses"
+}
+
+type WatchAddressesView interface {
+	WatchAddresses() ([]WatchAddress, error)
+	PendingBackfillWatchAddresses() ([]WatchAddress, error)
+}
+
+type WatchAddressesDB interface {
+	WatchAddressesView
+	AddWatchAddress(address common.Address, backfillFrom *big.Int) error
+	RemoveWatchAddress(address common.Address) error
+	MarkWatchAddressBackfilled(address common.Address) error
+}
+
+type watchAddressesDB struct {
+	gorm *gorm.DB
+}
+
+func NewWatchAddressesDB(db *gorm.DB) WatchAddressesDB {
+	return &watchAddressesDB{gorm: db}
+}
+
+func (db watchAddressesDB) WatchAddresses() ([]WatchAddress, error) {
+	var addresses []WatchAddress
+	if err := db.gorm.Table("watch_addresses").Find(&addresses).Error; err != nil {
+		return nil, fmt.Errorf("query watch addresses failed: %w", err)
+	}
+	return addresses, nil
+}
+
+// 已启用且尚未完成历史回填的地址
+func (db watchAddressesDB) PendingBackfillWatchAddresses() ([]WatchAddress, error) {
+	var addresses []WatchAddress
+	err := db.gorm.Table("watch_addresses").Where("enabled AND NOT backfilled AND backfill_from IS NOT NULL").Find(&addresses).Error
+	if err != nil {
+		return nil, fmt.Errorf("query pending backfill watch addresses failed: %w", err)
+	}
+	return addresses, nil
+}
+
+// 添加或重新启用监听地址，backfillFrom 不为 nil 时同步器会回填该地址从这个高度开始的历史日志
+func (db watchAddressesDB) AddWatchAddress(address common.Address, backfillFrom *big.Int) error {
+	watchAddress := WatchAddress{
+		Address:      address,
+		Enabled:      true,
+		BackfillFrom: backfillFrom,
+		Backfilled:   backfillFrom == nil,
+		UpdatedAt:    uint64(time.Now().Unix()),
+	}
+	return db.gorm.Clauses(clause.OnConflict{UpdateAll: true}).Create(&watchAddress).Error
+}
+
+// 停止监听地址，已同步的事件保留
+func (db watchAddressesDB) RemoveWatchAddress(address common.Address) error {
+	watchAddress := WatchAddress{
+		Address:    address,
+		Enabled:    false,
+		Backfilled: true,
+		UpdatedAt:  uint64(time.Now().Unix()),
+	}
+	return db.gorm.Clauses(clause.OnConflict{UpdateAll: true}).Create(&watchAddress).Error
+}
+
+func (db watchAddressesDB) MarkWatchAddressBackfilled(address common.Address) error {
+	result := db.gorm.Table("watch_addresses").Where(&WatchAddress{Address: address}).
+		Updates(map[string]interface{}{"backfilled": true, "updated_at": time.Now().Unix()})
+	return result.Error
+}
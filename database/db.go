@@ -18,14 +18,20 @@ import (
 /*
   - Blocks (database/common.BlocksDB): 区块头表的读写层。存/查 block_headers（Hash、ParentHash、Number、Timestamp、RLPHeader）。用于记录同步过的区块高度与去重校验；被同步器用来获取最新已索引区块等。
   - ContractEvent (database/event.ContractEventDB): 合约事件表的读写层。把链上 types.Log 以 RLP 完整落库，同时平铺 BlockHash/TxHash/Address/Topic0 等索引字段，支持按区块范围和过滤条件查询；被同步器/事件处理器用于存取事件。
+  - ContractTx (database/event.ContractTransactionDB): 发往监听合约的交易表。同步器开启交易索引后写入 to 地址为监听合约的交易及可选的回执执行结果，用于 calldata 层面的分析。
   - EventBlocks (database/worker.EventBlocksDB): 事件处理进度用的“事件区块头”表。提供查询最新事件区块高度和批量写入，用于事件轮询的位点管理，避免重复或漏扫。
-  - FillRandomWords (database/worker.FillRandomWordsDB): 业务结果表，记录已回填的随机数结果（RequestId、RandomWords、时间戳），支持批量写入；由工作器在完成 VRF 回填后落库。
+  - FillRandomWords (database/worker.FillRandomWordsDB): 业务结果表，记录已回填的随机数结果（RequestId、VrfAddress、RandomWords、时间戳），按 (RequestId, VrfAddress) 唯一，支持批量写入；由工作器在完成 VRF 回填后落库。
   - RequestSend (database/worker.RequestSendDB): 请求任务表，记录合约请求的待处理任务（RequestId、VrfAddress、NumWords、Status）。提供：
-    查询未处理列表（status=0）
-    标记处理完成（status=1）
+    查询到了重试时间的未处理列表（pending）
+    按状态机转换状态：pending -> in_flight -> fulfilled / failed，以及 expired、skipped（见 request_status.go）
+    记录回填失败次数、错误和下一次重试时间
     批量写入请求
     工作器据此拉取任务并驱动链上回填。
   - PoxyCreated (database/worker.PoxyCreatedDB): 代理/子合约地址表。提供查询全部代理地址列表、批量写入。同步器会先查这张表拿到需要监听的合约地址集合，再用 FilterLogs 拉取这些地址的事件。
+  - FulfillmentCost (database/worker.FulfillmentCostDB): 回填交易的 gas 花费表。工作器拿到回执后按请求记录 gas 用量、实际 gas 价格和花费，支持按 VRF 合约/代理汇总。
+  - FulfillmentTx (database/worker.FulfillmentTxDB): 回填交易表。按请求记录发出的每一笔交易哈希、nonce，以及被替换、上链的状态，用于查询回填某个请求的交易和排查卡住的交易。
+  - Checkpoints (database/common.CheckpointsDB): 同步进度检查点表。独立于区块头表记录遍历到的最后一个区块头，重启时优先从检查点恢复。
+  - WatchAddresses (database/common.WatchAddressesDB): 运行时维护的监听地址表。添加的地址与代理地址一起监听，移除的地址不再监听，同步器每批区块都会重新读取；添加时可指定回填历史日志的起始高度。
 */
 
 // 实现一个数据库访问层的封装实现
@@ -33,12 +39,17 @@ import (
 
 type DB struct {
 	gorm            *gorm.DB
-	Blocks          common.BlocksDB       // 区块头表的读写层
-	ContractEvent   event.ContractEventDB // 合约事件的日志存储
-	EventBlocks     worker.EventBlocksDB  // 事件同步进度管理
+	Blocks          common.BlocksDB             // 区块头表的读写层
+	ContractEvent   event.ContractEventDB       // 合约事件的日志存储
+	ContractTx      event.ContractTransactionDB // 发往监听合约的交易
+	EventBlocks     worker.EventBlocksDB        // 事件同步进度管理
 	FillRandomWords worker.FillRandomWordsDB
 	RequestSend     worker.RequestSendDB
 	PoxyCreated     worker.PoxyCreatedDB
+	FulfillmentCost worker.FulfillmentCostDB
+	FulfillmentTx   worker.FulfillmentTxDB
+	Checkpoints     common.CheckpointsDB    // 同步进度检查点
+	WatchAddresses  common.WatchAddressesDB // 运行时维护的监听地址
 }
 
 func NewDB(ctx context.Context, dbConfig config.DBConfig) (*DB, error) {
@@ -72,17 +83,25 @@ func NewDB(ctx context.Context, dbConfig config.DBConfig) (*DB, error) {
 		return nil, err
 	}
 
-	db := &DB{
+	return NewDBFromGorm(gorm), nil
+}
+
+// 用已建立的连接组合各个子数据模块
+func NewDBFromGorm(gorm *gorm.DB) *DB {
+	return &DB{
 		gorm:            gorm,
 		Blocks:          common.NewBlocksDB(gorm),
 		ContractEvent:   event.NewContractEventsDB(gorm),
+		ContractTx:      event.NewContractTransactionsDB(gorm),
 		EventBlocks:     worker.NewEventBlocksDB(gorm),
 		FillRandomWords: worker.NewFillRandomWordsDB(gorm),
 		RequestSend:     worker.NewRequestSendDB(gorm),
 		PoxyCreated:     worker.NewPoxyCreatedDB(gorm),
+		FulfillmentCost: worker.NewFulfillmentCostDB(gorm),
+		FulfillmentTx:   worker.NewFulfillmentTxDB(gorm),
+		Checkpoints:     common.NewCheckpointsDB(gorm),
+		WatchAddresses:  common.NewWatchAddressesDB(gorm),
 	}
-
-	return db, nil
 }
 
 // 让传入的函数 fn 在同一个数据库事务中执行
@@ -90,16 +109,7 @@ func NewDB(ctx context.Context, dbConfig config.DBConfig) (*DB, error) {
 // 事务成功就自动提交，失败就自动回滚
 func (db *DB) Transaction(fn func(db *DB) error) error {
 	return db.gorm.Transaction(func(tx *gorm.DB) error {
-		txDB := &DB{
-			gorm:            tx,
-			Blocks:          common.NewBlocksDB(tx),
-			ContractEvent:   event.NewContractEventsDB(tx),
-			EventBlocks:     worker.NewEventBlocksDB(tx),
-			FillRandomWords: worker.NewFillRandomWordsDB(tx),
-			RequestSend:     worker.NewRequestSendDB(tx),
-			PoxyCreated:     worker.NewPoxyCreatedDB(tx),
-		}
-		return fn(txDB)
+		return fn(NewDBFromGorm(tx))
 	})
 }
 
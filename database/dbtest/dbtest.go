@@ -0,0 +1,119 @@
+package dbtest
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/database/utils/serializers"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+/*
+	sqlite 内存数据库：
+		- 表结构与 migrations 中的业务表一致，UINT256 列使用 NUMERIC
+		- 引用本包会把 u256 序列化器替换为兼容 sqlite 的版本，不能在非测试代码中引用
+*/
+
+// sqlite 把 NUMERIC 列中的整数读回为 int64，转为十进制文本后交给 u256 序列化器
+type sqliteU256Serializer struct {
+	serializers.U256Serializer
+}
+
+func (s sqliteU256Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	if value, ok := dbValue.(int64); ok {
+		dbValue = strconv.FormatInt(value, 10)
+	}
+	return s.U256Serializer.Scan(ctx, field, dst, dbValue)
+}
+
+func init() {
+	schema.RegisterSerializer("u256", sqliteU256Serializer{})
+}
+
+var testSchema = []string{
+	`CREATE TABLE request_sent (
+		guid                 VARCHAR PRIMARY KEY,
+		request_id           NUMERIC NOT NULL,
+		num_words            NUMERIC NOT NULL,
+		vrf_address          VARCHAR NOT NULL,
+		status               SMALLINT NOT NULL DEFAULT 0,
+		block_number         NUMERIC NOT NULL DEFAULT 0,
+		block_timestamp      INTEGER NOT NULL DEFAULT 0,
+		transaction_hash     VARCHAR,
+		log_index            INTEGER,
+		fulfill_tx_hash      VARCHAR,
+		fulfill_block_number NUMERIC,
+		failure_reason       VARCHAR NOT NULL DEFAULT '',
+		vrf_proof            VARCHAR NOT NULL DEFAULT '',
+		drand_round          BIGINT NOT NULL DEFAULT 0,
+		drand_signature      VARCHAR NOT NULL DEFAULT '',
+		attempt_count        INTEGER NOT NULL DEFAULT 0,
+		last_error           VARCHAR NOT NULL DEFAULT '',
+		next_retry_at        INTEGER NOT NULL DEFAULT 0,
+		timestamp            INTEGER NOT NULL CHECK (timestamp > 0)
+	)`,
+	`CREATE UNIQUE INDEX request_sent_request_id_vrf_address ON request_sent(request_id, vrf_address)`,
+	`CREATE UNIQUE INDEX request_sent_transaction_hash_log_index ON request_sent(transaction_hash, log_index)`,
+	`CREATE TABLE fill_random_words (
+		guid             VARCHAR PRIMARY KEY,
+		request_id       NUMERIC NOT NULL,
+		vrf_address      VARCHAR,
+		random_words     VARCHAR NOT NULL,
+		block_number     NUMERIC NOT NULL DEFAULT 0,
+		transaction_hash VARCHAR,
+		log_index        INTEGER,
+		timestamp        INTEGER NOT NULL CHECK (timestamp > 0)
+	)`,
+	`CREATE UNIQUE INDEX fill_random_words_request_id_vrf_address ON fill_random_words(request_id, vrf_address)`,
+	`CREATE UNIQUE INDEX fill_random_words_transaction_hash_log_index ON fill_random_words(transaction_hash, log_index)`,
+	`CREATE TABLE proxy_created (
+		guid             VARCHAR PRIMARY KEY,
+		proxy_address    VARCHAR NOT NULL,
+		block_number     NUMERIC NOT NULL DEFAULT 0,
+		transaction_hash VARCHAR,
+		log_index        INTEGER,
+		timestamp        INTEGER NOT NULL CHECK (timestamp > 0)
+	)`,
+	`CREATE UNIQUE INDEX proxy_created_unique_proxy_address ON proxy_created(proxy_address)`,
+	`CREATE UNIQUE INDEX proxy_created_transaction_hash_log_index ON proxy_created(transaction_hash, log_index)`,
+	`CREATE TABLE fulfillment_costs (
+		guid                VARCHAR PRIMARY KEY,
+		request_id          NUMERIC NOT NULL,
+		vrf_address         VARCHAR NOT NULL,
+		transaction_hash    VARCHAR NOT NULL,
+		block_number        NUMERIC NOT NULL,
+		status              INTEGER NOT NULL,
+		gas_used            NUMERIC NOT NULL,
+		effective_gas_price NUMERIC NOT NULL,
+		fee                 NUMERIC NOT NULL,
+		timestamp           INTEGER NOT NULL,
+		UNIQUE (request_id, transaction_hash)
+	)`,
+}
+
+// 创建建好业务表的内存数据库，测试结束时关闭
+func NewGorm(t testing.TB) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{SkipDefaultTransaction: true})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// 内存数据库每个连接相互独立
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	for _, stmt := range testSchema {
+		require.NoError(t, db.Exec(stmt).Error)
+	}
+	return db
+}
+
+// 创建使用内存数据库的 database.DB
+func NewDB(t testing.TB) *database.DB {
+	return database.NewDBFromGorm(NewGorm(t))
+}
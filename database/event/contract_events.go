@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/WJX2001/contract-caller/database/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ContractEvent struct {
@@ -19,7 +22,9 @@ type ContractEvent struct {
 	LogIndex        uint64
 	EventSignature  common.Hash `gorm:"serializer:bytes"`
 	Timestamp       uint64
-	RLPLog          *types.Log `gorm:"serializer:rlp;column:rlp_bytes"`
+	RLPLog          *types.Log     `gorm:"serializer:rlp;column:rlp_bytes"`
+	Finality        utils.Finality // 所在区块的最终性状态，与区块头一起更新
+	DecodedArgs     map[string]any `gorm:"serializer:json"` // 按合约 ABI 解码的事件参数，无法解码时为 nil
 }
 
 // 从链上日志构造事件
@@ -36,6 +41,7 @@ func ContractEventFromLog(log *types.Log, timestamp uint64) ContractEvent {
 		BlockHash:       log.BlockHash,
 		TransactionHash: log.TxHash,
 		ContractAddress: log.Address,
+		LogIndex:        uint64(log.Index),
 		EventSignature:  eventSig,
 		Timestamp:       timestamp,
 		RLPLog:          log,
@@ -48,12 +54,17 @@ type ContractEventsView interface {
 	ContractEventWithFilter(ContractEvent) (*ContractEvent, error)
 	ContractEventsWithFilter(ContractEvent, *big.Int, *big.Int) ([]ContractEvent, error)
 	LatestContractEventWithFilter(ContractEvent) (*ContractEvent, error)
+	ContractEventsWithFinality(ContractEvent, utils.Finality, *big.Int, *big.Int) ([]ContractEvent, error)
 }
 
 // 读写接口
 type ContractEventDB interface {
 	ContractEventsView
 	StoreContractEvents([]ContractEvent) error
+	DeleteContractEventsAfter(*big.Int) error
+	DeleteContractEventsInRange([]common.Address, *big.Int, *big.Int) error
+	DeleteContractEventsByBlockHash(common.Hash) (int64, error)
+	MarkContractEventsFinality(*big.Int, utils.Finality) error
 }
 
 type contractEventDB struct {
@@ -78,8 +89,47 @@ func (db *contractEventDB) LatestContractEventWithFilter(filter ContractEvent) (
 }
 
 func (db *contractEventDB) StoreContractEvents(events []ContractEvent) error {
-	// 一次性插入所有事件
-	result := db.gorm.CreateInBatches(&events, len(events))
+	// 一次性插入所有事件，同一区块中已存在的日志不会重复写入，只更新解码的参数，重新回填即可为之前的事件补上参数
+	onConflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "block_hash"}, {Name: "log_index"}},
+		DoUpdates: clause.AssignmentColumns([]string{"decoded_args"}),
+	}
+	result := db.gorm.Clauses(onConflict).CreateInBatches(&events, len(events))
+	return result.Error
+}
+
+// 删除高度大于 number 的区块中的事件，需要在删除区块头之前调用
+func (db *contractEventDB) DeleteContractEventsAfter(number *big.Int) error {
+	orphaned := db.gorm.Table("block_headers").Select("hash").Where("number > ?", number)
+	result := db.gorm.Table("contract_events").Where("block_hash IN (?)", orphaned).Delete(&ContractEvent{})
+	return result.Error
+}
+
+// 删除指定合约在高度 [fromHeight, toHeight] 的区块中的事件，用于重新回填这些合约的历史日志
+func (db *contractEventDB) DeleteContractEventsInRange(addresses []common.Address, fromHeight, toHeight *big.Int) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	// contract_address 以十六进制字符串存储，原生 SQL 条件不会经过序列化器
+	hexAddresses := make([]string, len(addresses))
+	for i, address := range addresses {
+		hexAddresses[i] = hexutil.Encode(address.Bytes())
+	}
+	blocks := db.gorm.Table("block_headers").Select("hash").Where("number >= ? AND number <= ?", fromHeight, toHeight)
+	result := db.gorm.Table("contract_events").Where("contract_address IN (?) AND block_hash IN (?)", hexAddresses, blocks).Delete(&ContractEvent{})
+	return result.Error
+}
+
+// 删除指定区块中的事件，返回删除的数量，需要在删除区块头之前调用
+func (db *contractEventDB) DeleteContractEventsByBlockHash(hash common.Hash) (int64, error) {
+	result := db.gorm.Where(&ContractEvent{BlockHash: hash}).Delete(&ContractEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// 把高度不超过 number 的区块中最终性低于 finality 的事件标记为 finality
+func (db *contractEventDB) MarkContractEventsFinality(number *big.Int, finality utils.Finality) error {
+	blocks := db.gorm.Table("block_headers").Select("hash").Where("number <= ?", number)
+	result := db.gorm.Table("contract_events").Where("finality < ? AND block_hash IN (?)", finality, blocks).Update("finality", finality)
 	return result.Error
 }
 
@@ -102,6 +152,11 @@ func (db *contractEventDB) ContractEventWithFilter(filter ContractEvent) (*Contr
 
 // 按条件 + 区块高度范围查询多条事件
 func (db *contractEventDB) ContractEventsWithFilter(filter ContractEvent, fromHeight, toHeight *big.Int) ([]ContractEvent, error) {
+	return db.ContractEventsWithFinality(filter, utils.FinalityUnsafe, fromHeight, toHeight)
+}
+
+// 按条件 + 区块高度范围查询最终性不低于 finality 的事件
+func (db *contractEventDB) ContractEventsWithFinality(filter ContractEvent, finality utils.Finality, fromHeight, toHeight *big.Int) ([]ContractEvent, error) {
 	if fromHeight == nil {
 		fromHeight = big.NewInt(0)
 	}
@@ -117,8 +172,11 @@ func (db *contractEventDB) ContractEventsWithFilter(filter ContractEvent, fromHe
 	query := db.gorm.Table("contract_events").Where(&filter)
 	query = query.Joins("INNER JOIN block_headers ON contract_events.block_hash = block_headers.hash")
 	query = query.Where("block_headers.number >= ? AND block_headers.number <= ?", fromHeight, toHeight)
-	// 按照高度升序排序，指定只选回 contract_events 的列，便于后续处理
-	query = query.Order("block_headers.number ASC").Select("contract_events.*")
+	if finality > utils.FinalityUnsafe {
+		query = query.Where("contract_events.finality >= ?", finality)
+	}
+	// 按照高度和区块内的日志序号升序排序，指定只选回 contract_events 的列，便于后续处理
+	query = query.Order("block_headers.number ASC, contract_events.log_index ASC").Select("contract_events.*")
 	var events []ContractEvent
 	// 执行查询并把结果映射到 events 切片
 	result := query.Find(&events)
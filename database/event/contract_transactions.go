@@ -0,0 +1,138 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+	发往监听合约的交易：
+		- 同步器开启交易索引后，每批区块中 to 地址为监听合约的交易都会写入，包括没有产生日志的调用（例如执行失败的请求）
+		- MethodId 为 calldata 前 4 字节，完整交易以 RLP 存储，可还原 calldata 做进一步分析
+		- 同时索引回执时记录执行状态和 gas 用量，否则为空
+*/
+
+type ContractTransaction struct {
+	GUID             uuid.UUID   `gorm:"primaryKey"`
+	BlockHash        common.Hash `gorm:"serializer:bytes"`
+	BlockNumber      *big.Int    `gorm:"serializer:u256"`
+	TransactionHash  common.Hash `gorm:"serializer:bytes"`
+	TransactionIndex uint64
+	FromAddress      common.Address `gorm:"serializer:bytes"`
+	ToAddress        common.Address `gorm:"serializer:bytes"`
+	MethodId         string         // calldata 前 4 字节的十六进制，calldata 不足 4 字节时为 0x
+	Timestamp        uint64
+	Status           *uint64            // 回执中的执行状态，未索引回执时为 nil
+	GasUsed          *uint64            // 回执中的 gas 用量，未索引回执时为 nil
+	RLPTransaction   *types.Transaction `gorm:"serializer:rlp;column:rlp_bytes"`
+}
+
+func (ContractTransaction) TableName() string {
+	return "contract_transactions"
+}
+
+// 从区块中的交易构造记录，receipt 为 nil 时不记录执行结果
+func ContractTransactionFromTx(tx *types.Transaction, index int, from common.Address, header *types.Header, receipt *types.Receipt) ContractTransaction {
+	methodId := tx.Data()
+	if len(methodId) > 4 {
+		methodId = methodId[:4]
+	}
+	contractTx := ContractTransaction{
+		GUID:             uuid.New(),
+		BlockHash:        header.Hash(),
+		BlockNumber:      header.Number,
+		TransactionHash:  tx.Hash(),
+		TransactionIndex: uint64(index),
+		FromAddress:      from,
+		ToAddress:        *tx.To(),
+		MethodId:         hexutil.Encode(methodId),
+		Timestamp:        header.Time,
+		RLPTransaction:   tx,
+	}
+	if receipt != nil {
+		contractTx.Status = &receipt.Status
+		contractTx.GasUsed = &receipt.GasUsed
+	}
+	return contractTx
+}
+
+type ContractTransactionsView interface {
+	ContractTransaction(common.Hash) (*ContractTransaction, error)
+	ContractTransactionsWithFilter(ContractTransaction, *big.Int, *big.Int) ([]ContractTransaction, error)
+}
+
+type ContractTransactionDB interface {
+	ContractTransactionsView
+	StoreContractTransactions([]ContractTransaction) error
+	DeleteContractTransactionsAfter(*big.Int) error
+	DeleteContractTransactionsByBlockHash(common.Hash) error
+}
+
+type contractTransactionDB struct {
+	gorm *gorm.DB
+}
+
+func NewContractTransactionsDB(db *gorm.DB) ContractTransactionDB {
+	return &contractTransactionDB{gorm: db}
+}
+
+// 按交易哈希查询
+func (db *contractTransactionDB) ContractTransaction(hash common.Hash) (*ContractTransaction, error) {
+	var contractTx ContractTransaction
+	result := db.gorm.Where(&ContractTransaction{TransactionHash: hash}).Take(&contractTx)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &contractTx, nil
+}
+
+// 按条件 + 区块高度范围查询多条交易，按高度和交易序号升序
+func (db *contractTransactionDB) ContractTransactionsWithFilter(filter ContractTransaction, fromHeight, toHeight *big.Int) ([]ContractTransaction, error) {
+	if fromHeight == nil {
+		fromHeight = big.NewInt(0)
+	}
+	if toHeight == nil {
+		return nil, errors.New("end height unspecified")
+	}
+	if fromHeight.Cmp(toHeight) > 0 {
+		return nil, fmt.Errorf("fromHeight %d is greater than toHeight %d", fromHeight, toHeight)
+	}
+
+	var contractTxs []ContractTransaction
+	result := db.gorm.Where(&filter).Where("block_number >= ? AND block_number <= ?", fromHeight, toHeight).
+		Order("block_number ASC, transaction_index ASC").Find(&contractTxs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return contractTxs, nil
+}
+
+// 批量写入，已存在的交易跳过
+func (db *contractTransactionDB) StoreContractTransactions(contractTxs []ContractTransaction) error {
+	onConflict := clause.OnConflict{Columns: []clause.Column{{Name: "transaction_hash"}}, DoNothing: true}
+	result := db.gorm.Clauses(onConflict).CreateInBatches(&contractTxs, len(contractTxs))
+	return result.Error
+}
+
+// 删除高度大于 number 的交易，用于重组后回滚
+func (db *contractTransactionDB) DeleteContractTransactionsAfter(number *big.Int) error {
+	result := db.gorm.Where("block_number > ?", number).Delete(&ContractTransaction{})
+	return result.Error
+}
+
+// 删除指定区块中的交易
+func (db *contractTransactionDB) DeleteContractTransactionsByBlockHash(hash common.Hash) error {
+	result := db.gorm.Where(&ContractTransaction{BlockHash: hash}).Delete(&ContractTransaction{})
+	return result.Error
+}
@@ -21,6 +21,10 @@ type BytesSerializer struct{}
 type BytesInterface interface{ Bytes() []byte }
 type SetBytesInterface interface{ SetBytes([]byte) }
 
+func init() {
+	schema.RegisterSerializer("bytes", BytesSerializer{})
+}
+
 // Scan 方法：用于从数据库扫描数据并设置到目标值
 func (BytesSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
 	// 空值检查
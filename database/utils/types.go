@@ -55,3 +55,12 @@ func (b Bytes) Bytes() []byte {
 func (b *Bytes) SetBytes(bytes []byte) {
 	*b = bytes
 }
+
+// 区块及其事件的最终性状态，数值越大越不可能被重组
+type Finality uint8
+
+const (
+	FinalityUnsafe    Finality = iota // 尚未达到 safe
+	FinalitySafe                      // 不高于节点的 safe 区块
+	FinalityFinalized                 // 不高于节点的 finalized 区块
+)
@@ -27,6 +27,7 @@ type BlocksView interface {
 type EventBlocksDB interface {
 	BlocksView
 	StoreEventBlocks([]EventBlocks) error
+	DeleteEventBlocksAfter(*big.Int) error
 }
 
 type eventBlocksDB struct {
@@ -51,6 +52,11 @@ func (e eventBlocksDB) StoreEventBlocks(eventBlocks []EventBlocks) error {
 	return result.Error
 }
 
+func (e eventBlocksDB) DeleteEventBlocksAfter(number *big.Int) error {
+	result := e.gorm.Table("event_blocks").Where("number > ?", number).Delete(&EventBlocks{})
+	return result.Error
+}
+
 func NewEventBlocksDB(db *gorm.DB) EventBlocksDB {
 	return &eventBlocksDB{gorm: db}
 }
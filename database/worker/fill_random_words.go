@@ -4,15 +4,22 @@ import (
 	"math/big"
 
 	_ "github.com/WJX2001/contract-caller/database/utils/serializers"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type FillRandomWords struct {
-	GUID        uuid.UUID `gorm:"primaryKey" json:"guid"`
-	RequestId   *big.Int  `json:"request_id" gorm:"serializer:u256"`
-	RandomWords string    `json:"random_words"`
-	Timestamp   uint64
+	GUID        uuid.UUID      `gorm:"primaryKey" json:"guid"`
+	RequestId   *big.Int       `json:"request_id" gorm:"serializer:u256"`
+	VrfAddress  common.Address `json:"vrf_address" gorm:"serializer:bytes"` // 触发事件的 VRF 合约或代理，不同代理的请求 ID 会重复
+	RandomWords string         `json:"random_words"`
+	BlockNumber *big.Int       `json:"block_number" gorm:"serializer:u256"` // 事件所在区块高度，重组回滚时按高度删除
+	// 产生该行的日志，(TransactionHash, LogIndex) 唯一，旧数据为 nil
+	TransactionHash *common.Hash `json:"transaction_hash" gorm:"serializer:bytes"`
+	LogIndex        *uint64      `json:"log_index"`
+	Timestamp       uint64
 }
 
 type FillRandomWordsView interface {
@@ -22,6 +29,8 @@ type FillRandomWordsDB interface {
 	FillRandomWordsView
 
 	StoreFillRandomWords([]FillRandomWords) error
+	UpsertFillRandomWords([]FillRandomWords) error
+	DeleteFillRandomWordsAfter(*big.Int) error
 }
 
 type fillRandomWordsDB struct {
@@ -32,7 +41,37 @@ func NewFillRandomWordsDB(db *gorm.DB) FillRandomWordsDB {
 	return &fillRandomWordsDB{gorm: db}
 }
 
+// 按产生回填结果的日志去重，同一条日志已写入时不做任何修改
 func (db fillRandomWordsDB) StoreFillRandomWords(FillRandomWordsList []FillRandomWords) error {
-	result := db.gorm.Table("fill_random_words").CreateInBatches(&FillRandomWordsList, len(FillRandomWordsList))
+	return db.storeFillRandomWords(FillRandomWordsList, onLogConflictDoNothing)
+}
+
+// 按产生回填结果的日志覆盖已写入的行，用于重新解析历史事件
+func (db fillRandomWordsDB) UpsertFillRandomWords(FillRandomWordsList []FillRandomWords) error {
+	return db.storeFillRandomWords(FillRandomWordsList, onLogConflictUpdate("request_id", "vrf_address", "random_words", "block_number"))
+}
+
+func (db fillRandomWordsDB) storeFillRandomWords(FillRandomWordsList []FillRandomWords, onConflict clause.OnConflict) error {
+	rows := make([]FillRandomWords, 0, len(FillRandomWordsList))
+	for _, frw := range FillRandomWordsList {
+		if frw.TransactionHash != nil {
+			insert, err := claimBusinessKey(db.gorm, "fill_random_words", &FillRandomWords{RequestId: frw.RequestId, VrfAddress: frw.VrfAddress}, logKey{TransactionHash: frw.TransactionHash, LogIndex: frw.LogIndex})
+			if err != nil {
+				return err
+			} else if !insert {
+				continue
+			}
+		}
+		rows = append(rows, frw)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	result := db.gorm.Table("fill_random_words").Clauses(onConflict).CreateInBatches(&rows, len(rows))
+	return result.Error
+}
+
+func (db fillRandomWordsDB) DeleteFillRandomWordsAfter(number *big.Int) error {
+	result := db.gorm.Table("fill_random_words").Where("block_number > ?", number).Delete(&FillRandomWords{})
 	return result.Error
 }
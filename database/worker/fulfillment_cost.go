@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"math/big"
+	"time"
+
+	_ "github.com/WJX2001/contract-caller/database/utils/serializers"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+	回填交易的 gas 花费：
+		- 工作器拿到回填交易的回执后写入，执行失败的交易同样记录
+		- Fee = GasUsed * EffectiveGasPrice，一笔交易回填多个请求时 GasUsed 和 Fee 按请求数均摊
+		- VrfAddress 为请求所在的 VRF 合约或代理合约，按它汇总即可得到每个代理 / 调用方的花费
+*/
+
+type FulfillmentCost struct {
+	GUID              uuid.UUID      `gorm:"primaryKey" json:"guid"`
+	RequestId         *big.Int       `json:"request_id" gorm:"serializer:u256"`
+	VrfAddress        common.Address `json:"vrf_address" gorm:"serializer:bytes"`
+	TransactionHash   common.Hash    `json:"transaction_hash" gorm:"serializer:bytes"`
+	BlockNumber       *big.Int       `json:"block_number" gorm:"serializer:u256"`
+	Status            uint64         `json:"status"` // 回执中的执行状态
+	GasUsed           *big.Int       `json:"gas_used" gorm:"serializer:u256"`
+	EffectiveGasPrice *big.Int       `json:"effective_gas_price" gorm:"serializer:u256"`
+	Fee               *big.Int       `json:"fee" gorm:"serializer:u256"`
+	Timestamp         uint64
+}
+
+// 按 VRF 合约汇总的花费
+type FulfillmentSpend struct {
+	VrfAddress   common.Address `gorm:"serializer:bytes"`
+	Fulfillments int64
+	GasUsed      *big.Int `gorm:"serializer:u256"`
+	Fee          *big.Int `gorm:"serializer:u256"`
+}
+
+// 从回填交易的回执构造记录，交易回填了 requests 个请求
+func FulfillmentCostFromReceipt(requestId *big.Int, vrfAddress common.Address, receipt *types.Receipt, requests int) FulfillmentCost {
+	if requests < 1 {
+		requests = 1
+	}
+	price := new(big.Int)
+	if receipt.EffectiveGasPrice != nil {
+		price.Set(receipt.EffectiveGasPrice)
+	}
+	gasUsed := new(big.Int).SetUint64(receipt.GasUsed)
+	fee := new(big.Int).Mul(gasUsed, price)
+	share := big.NewInt(int64(requests))
+	return FulfillmentCost{
+		GUID:              uuid.New(),
+		RequestId:         requestId,
+		VrfAddress:        vrfAddress,
+		TransactionHash:   receipt.TxHash,
+		BlockNumber:       receipt.BlockNumber,
+		Status:            receipt.Status,
+		GasUsed:           gasUsed.Div(gasUsed, share),
+		EffectiveGasPrice: price,
+		Fee:               fee.Div(fee, share),
+		Timestamp:         uint64(time.Now().Unix()),
+	}
+}
+
+type FulfillmentCostView interface {
+	QueryFulfillmentCosts(*big.Int) ([]FulfillmentCost, error)
+	QueryFulfillmentSpend() ([]FulfillmentSpend, error)
+}
+
+type FulfillmentCostDB interface {
+	FulfillmentCostView
+
+	StoreFulfillmentCosts([]FulfillmentCost) error
+}
+
+type fulfillmentCostDB struct {
+	gorm *gorm.DB
+}
+
+func NewFulfillmentCostDB(db *gorm.DB) FulfillmentCostDB {
+	return &fulfillmentCostDB{gorm: db}
+}
+
+// 查询一个请求的全部回填交易花费，包括失败后重试的交易
+func (db fulfillmentCostDB) QueryFulfillmentCosts(requestId *big.Int) ([]FulfillmentCost, error) {
+	var costs []FulfillmentCost
+	result := db.gorm.Table("fulfillment_costs").Where(&FulfillmentCost{RequestId: requestId}).
+		Order("block_number ASC").Find(&costs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return costs, nil
+}
+
+// 按 VRF 合约汇总回填次数、gas 用量和花费
+func (db fulfillmentCostDB) QueryFulfillmentSpend() ([]FulfillmentSpend, error) {
+	var spend []FulfillmentSpend
+	result := db.gorm.Table("fulfillment_costs").
+		Select("vrf_address, COUNT(*) AS fulfillments, SUM(gas_used) AS gas_used, SUM(fee) AS fee").
+		Group("vrf_address").Order("fee DESC").Scan(&spend)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return spend, nil
+}
+
+// 同一请求的同一笔交易只记录一次
+func (db fulfillmentCostDB) StoreFulfillmentCosts(costs []FulfillmentCost) error {
+	onConflict := clause.OnConflict{Columns: []clause.Column{{Name: "request_id"}, {Name: "transaction_hash"}}, DoNothing: true}
+	result := db.gorm.Table("fulfillment_costs").Clauses(onConflict).CreateInBatches(&costs, len(costs))
+	return result.Error
+}
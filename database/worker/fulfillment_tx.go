@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"math/big"
+
+	_ "github.com/WJX2001/contract-caller/database/utils/serializers"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+	回填交易与请求的对应关系：
+		- 交易广播时按请求写入，提价替换后旧交易标记为已替换并记录新交易，新交易另外写入一行
+		- 交易上链时记录区块高度和回执状态
+		- 状态仍为已广播的交易即尚未上链，可按请求排查卡住的交易
+*/
+
+// 回填交易的状态
+const (
+	FulfillmentTxPublished uint8 = 0 // 已广播，尚未上链
+	FulfillmentTxReplaced  uint8 = 1 // 被提价后的交易替换
+	FulfillmentTxMined     uint8 = 2 // 已上链
+)
+
+type FulfillmentTx struct {
+	GUID            uuid.UUID      `gorm:"primaryKey" json:"guid"`
+	RequestId       *big.Int       `json:"request_id" gorm:"serializer:u256"`
+	VrfAddress      common.Address `json:"vrf_address" gorm:"serializer:bytes"`
+	TransactionHash common.Hash    `json:"transaction_hash" gorm:"serializer:bytes"`
+	Nonce           uint64         `json:"nonce"`
+	Status          uint8          `json:"status"`                              // 0:已广播,1:已替换,2:已上链
+	ReplacedBy      *common.Hash   `json:"replaced_by" gorm:"serializer:bytes"` // 替换该交易的新交易
+	BlockNumber     *big.Int       `json:"block_number" gorm:"serializer:u256"` // 上链区块高度
+	ReceiptStatus   *uint64        `json:"receipt_status"`                      // 回执中的执行状态，未上链时为 nil
+	Timestamp       uint64
+}
+
+type FulfillmentTxView interface {
+	QueryFulfillmentTxs(*big.Int) ([]FulfillmentTx, error)
+	QueryUnminedFulfillmentTxs() ([]FulfillmentTx, error)
+}
+
+type FulfillmentTxDB interface {
+	FulfillmentTxView
+
+	StoreFulfillmentTxs([]FulfillmentTx) error
+	MarkFulfillmentTxReplaced(common.Hash, common.Hash) error
+	MarkFulfillmentTxMined(common.Hash, *big.Int, uint64) error
+}
+
+type fulfillmentTxDB struct {
+	gorm *gorm.DB
+}
+
+func NewFulfillmentTxDB(db *gorm.DB) FulfillmentTxDB {
+	return &fulfillmentTxDB{gorm: db}
+}
+
+// 查询回填一个请求发出的全部交易，按广播时间升序
+func (db fulfillmentTxDB) QueryFulfillmentTxs(requestId *big.Int) ([]FulfillmentTx, error) {
+	var txs []FulfillmentTx
+	result := db.gorm.Table("fulfillment_txs").Where(&FulfillmentTx{RequestId: requestId}).
+		Order("timestamp ASC").Find(&txs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return txs, nil
+}
+
+// 查询已广播但尚未上链、也没有被替换的交易
+func (db fulfillmentTxDB) QueryUnminedFulfillmentTxs() ([]FulfillmentTx, error) {
+	var txs []FulfillmentTx
+	result := db.gorm.Table("fulfillment_txs").Where("status = ?", FulfillmentTxPublished).
+		Order("timestamp ASC").Find(&txs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return txs, nil
+}
+
+// 同一请求的同一笔交易只记录一次，重新广播不会覆盖状态
+func (db fulfillmentTxDB) StoreFulfillmentTxs(txs []FulfillmentTx) error {
+	onConflict := clause.OnConflict{Columns: []clause.Column{{Name: "request_id"}, {Name: "transaction_hash"}}, DoNothing: true}
+	result := db.gorm.Table("fulfillment_txs").Clauses(onConflict).CreateInBatches(&txs, len(txs))
+	return result.Error
+}
+
+func (db fulfillmentTxDB) MarkFulfillmentTxReplaced(txHash common.Hash, replacedBy common.Hash) error {
+	result := db.gorm.Table("fulfillment_txs").Where(&FulfillmentTx{TransactionHash: txHash}).
+		Where("status = ?", FulfillmentTxPublished).
+		Select("status", "replaced_by").
+		Updates(&FulfillmentTx{Status: FulfillmentTxReplaced, ReplacedBy: &replacedBy})
+	return result.Error
+}
+
+func (db fulfillmentTxDB) MarkFulfillmentTxMined(txHash common.Hash, blockNumber *big.Int, receiptStatus uint64) error {
+	result := db.gorm.Table("fulfillment_txs").Where(&FulfillmentTx{TransactionHash: txHash}).
+		Select("status", "block_number", "receipt_status").
+		Updates(&FulfillmentTx{Status: FulfillmentTxMined, BlockNumber: blockNumber, ReceiptStatus: &receiptStatus})
+	return result.Error
+}
@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+	业务表（request_sent、fill_random_words、proxy_created）按产生该行的日志去重：
+		- (transaction_hash, log_index) 唯一，事件处理器重复解析同一条日志（扫描窗口重叠）时 ON CONFLICT DO NOTHING，不会覆盖已有的行
+		- 重新解析历史事件（replay）时按日志覆盖已有行中由事件解析出的列，处理状态等工作器维护的列保持不变
+		- 业务键（请求、代理地址）同样唯一，写入前先按业务键检查已存在的行，见 claimBusinessKey
+*/
+
+// 按日志去重的冲突处理
+var onLogConflictDoNothing = clause.OnConflict{
+	Columns:   []clause.Column{{Name: "transaction_hash"}, {Name: "log_index"}},
+	DoNothing: true,
+}
+
+// 按日志覆盖 columns 的冲突处理，columns 为由事件解析出的列
+func onLogConflictUpdate(columns ...string) clause.OnConflict {
+	return clause.OnConflict{
+		Columns:   []clause.Column{{Name: "transaction_hash"}, {Name: "log_index"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}
+}
+
+// 产生业务表中一行的日志，旧数据两列均为 NULL
+type logKey struct {
+	TransactionHash *common.Hash `gorm:"serializer:bytes"`
+	LogIndex        *uint64
+}
+
+func (k logKey) equal(other logKey) bool {
+	return k.TransactionHash != nil && other.TransactionHash != nil && *k.TransactionHash == *other.TransactionHash &&
+		k.LogIndex != nil && other.LogIndex != nil && *k.LogIndex == *other.LogIndex
+}
+
+// 写入一行前按业务键检查 table 中已存在的行，返回是否还需要插入这一行，businessKey 为对应表的模型，只设置业务键的字段
+//   - 没有业务键相同的行，或者该行记录的就是这条日志：插入，日志冲突由插入时的冲突处理跳过或覆盖
+//   - 业务键相同的行记录的是另一条日志：业务键只保留最先写入的一行，跳过
+//   - 业务键相同的旧数据（没有记录日志）：把日志补到这一行上，随后的插入因日志冲突而跳过或覆盖
+//   - 迁移前同步的合约事件没有记录日志序号（均为 0），同一交易中的多条事件无法区分，序号为 0 时不补写旧数据，也不插入
+//   - 这条日志已经记录在另一行上时不补写旧数据，也不插入，避免日志或业务键冲突
+func claimBusinessKey(db *gorm.DB, table string, businessKey interface{}, key logKey) (bool, error) {
+	var existing logKey
+	result := db.Table(table).Where(businessKey).Select("transaction_hash", "log_index").Limit(1).Find(&existing)
+	if result.Error != nil {
+		return false, result.Error
+	} else if result.RowsAffected == 0 || existing.equal(key) {
+		return true, nil
+	} else if existing.TransactionHash != nil {
+		return false, nil
+	}
+
+	if key.LogIndex == nil || *key.LogIndex == 0 {
+		return false, nil
+	}
+	var claimed int64
+	result = db.Table(table).Where("transaction_hash = ? AND log_index = ?", key.TransactionHash.Hex(), *key.LogIndex).Count(&claimed)
+	if result.Error != nil {
+		return false, result.Error
+	} else if claimed > 0 {
+		return false, nil
+	}
+	result = db.Table(table).Where(businessKey).Where("transaction_hash IS NULL").
+		Select("transaction_hash", "log_index").Updates(&key)
+	return true, result.Error
+}
@@ -0,0 +1,127 @@
+package worker_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/WJX2001/contract-caller/database/dbtest"
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var testVrfAddress = common.HexToAddress("0x1234")
+
+func newRequestSend(requestId int64, txHash *common.Hash, logIndex *uint64) worker.RequestSend {
+	return worker.RequestSend{
+		GUID:            uuid.New(),
+		RequestId:       big.NewInt(requestId),
+		VrfAddress:      testVrfAddress,
+		NumWords:        big.NewInt(1),
+		BlockNumber:     big.NewInt(10),
+		TransactionHash: txHash,
+		LogIndex:        logIndex,
+		Timestamp:       1,
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestStoreRequestSendClaimsLegacyRow(t *testing.T) {
+	db := dbtest.NewGorm(t)
+	requests := worker.NewRequestSendDB(db)
+	require.NoError(t, requests.StoreRequestSend([]worker.RequestSend{newRequestSend(1, nil, nil)}))
+
+	txHash := common.HexToHash("0xaa")
+	require.NoError(t, requests.StoreRequestSend([]worker.RequestSend{newRequestSend(1, &txHash, ptr[uint64](3))}))
+
+	pending, err := requests.QueryRequestSendListByStatus(worker.RequestSendPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, txHash, *pending[0].TransactionHash)
+	require.Equal(t, uint64(3), *pending[0].LogIndex)
+}
+
+func TestStoreRequestSendSkipsLegacyRowsWithoutLogIndex(t *testing.T) {
+	db := dbtest.NewGorm(t)
+	requests := worker.NewRequestSendDB(db)
+	require.NoError(t, requests.StoreRequestSend([]worker.RequestSend{newRequestSend(1, nil, nil), newRequestSend(2, nil, nil)}))
+
+	// 迁移前同步的两条事件在同一交易中，日志序号都为 0
+	txHash := common.HexToHash("0xaa")
+	require.NoError(t, requests.StoreRequestSend([]worker.RequestSend{
+		newRequestSend(1, &txHash, ptr[uint64](0)),
+		newRequestSend(2, &txHash, ptr[uint64](0)),
+	}))
+
+	pending, err := requests.QueryRequestSendListByStatus(worker.RequestSendPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	for _, request := range pending {
+		require.Nil(t, request.TransactionHash)
+	}
+}
+
+func TestStoreRequestSendSkipsBusinessKeyOfAnotherLog(t *testing.T) {
+	db := dbtest.NewGorm(t)
+	requests := worker.NewRequestSendDB(db)
+	first := common.HexToHash("0xaa")
+	require.NoError(t, requests.StoreRequestSend([]worker.RequestSend{newRequestSend(1, &first, ptr[uint64](1))}))
+
+	second := common.HexToHash("0xbb")
+	require.NoError(t, requests.StoreRequestSend([]worker.RequestSend{newRequestSend(1, &second, ptr[uint64](2))}))
+
+	pending, err := requests.QueryRequestSendListByStatus(worker.RequestSendPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, first, *pending[0].TransactionHash)
+}
+
+func TestUpsertRequestSendKeepsStatus(t *testing.T) {
+	db := dbtest.NewGorm(t)
+	requests := worker.NewRequestSendDB(db)
+	txHash := common.HexToHash("0xaa")
+	request := newRequestSend(1, &txHash, ptr[uint64](1))
+	require.NoError(t, requests.StoreRequestSend([]worker.RequestSend{request}))
+	require.NoError(t, requests.MarkRequestSendInFlight(request))
+
+	replayed := newRequestSend(1, &txHash, ptr[uint64](1))
+	replayed.NumWords = big.NewInt(3)
+	require.NoError(t, requests.StoreRequestSend([]worker.RequestSend{replayed}))
+	inFlight, err := requests.QueryRequestSendListByStatus(worker.RequestSendInFlight)
+	require.NoError(t, err)
+	require.Len(t, inFlight, 1)
+	require.Equal(t, big.NewInt(1), inFlight[0].NumWords)
+
+	require.NoError(t, requests.UpsertRequestSend([]worker.RequestSend{replayed}))
+	inFlight, err = requests.QueryRequestSendListByStatus(worker.RequestSendInFlight)
+	require.NoError(t, err)
+	require.Len(t, inFlight, 1)
+	require.Equal(t, request.GUID, inFlight[0].GUID)
+	require.Equal(t, big.NewInt(3), inFlight[0].NumWords)
+}
+
+func TestStorePoxyCreatedSkipsBusinessKeyOfAnotherLog(t *testing.T) {
+	db := dbtest.NewGorm(t)
+	proxies := worker.NewPoxyCreatedDB(db)
+	proxy := common.HexToAddress("0x5678")
+	first := common.HexToHash("0xaa")
+	second := common.HexToHash("0xbb")
+	for _, txHash := range []common.Hash{first, second} {
+		require.NoError(t, proxies.StorePoxyCreated([]worker.PoxyCreated{{
+			GUID:            uuid.New(),
+			ProxyAddress:    proxy,
+			BlockNumber:     big.NewInt(10),
+			TransactionHash: &txHash,
+			LogIndex:        ptr[uint64](1),
+			Timestamp:       1,
+		}}))
+	}
+
+	addresses, err := proxies.QueryPoxyCreatedAddressList()
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{proxy}, addresses)
+}
@@ -2,16 +2,22 @@ package worker
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PoxyCreated struct {
 	GUID         uuid.UUID      `gorm:"primaryKey" json:"guid"`
 	ProxyAddress common.Address `json:"proxy_address" gorm:"serializer:bytes"`
-	Timestamp    uint64
+	BlockNumber  *big.Int       `json:"block_number" gorm:"serializer:u256"` // 事件所在区块高度，重组回滚时按高度删除
+	// 产生该行的日志，(TransactionHash, LogIndex) 唯一，旧数据为 nil
+	TransactionHash *common.Hash `json:"transaction_hash" gorm:"serializer:bytes"`
+	LogIndex        *uint64      `json:"log_index"`
+	Timestamp       uint64
 }
 
 type PoxyCreatedView interface {
@@ -22,6 +28,8 @@ type PoxyCreatedDB interface {
 	PoxyCreatedView
 
 	StorePoxyCreated([]PoxyCreated) error
+	UpsertPoxyCreated([]PoxyCreated) error
+	DeletePoxyCreatedAfter(*big.Int) error
 }
 
 type poxyCreatedDB struct {
@@ -32,8 +40,38 @@ func NewPoxyCreatedDB(db *gorm.DB) PoxyCreatedDB {
 	return &poxyCreatedDB{gorm: db}
 }
 
+// 按产生代理记录的日志去重，同一条日志已写入时不做任何修改
 func (db poxyCreatedDB) StorePoxyCreated(PoxyCreatedList []PoxyCreated) error {
-	result := db.gorm.Table("proxy_created").CreateInBatches(&PoxyCreatedList, len(PoxyCreatedList))
+	return db.storePoxyCreated(PoxyCreatedList, onLogConflictDoNothing)
+}
+
+// 按产生代理记录的日志覆盖已写入的行，用于重新解析历史事件
+func (db poxyCreatedDB) UpsertPoxyCreated(PoxyCreatedList []PoxyCreated) error {
+	return db.storePoxyCreated(PoxyCreatedList, onLogConflictUpdate("proxy_address", "block_number"))
+}
+
+func (db poxyCreatedDB) storePoxyCreated(PoxyCreatedList []PoxyCreated, onConflict clause.OnConflict) error {
+	rows := make([]PoxyCreated, 0, len(PoxyCreatedList))
+	for _, pc := range PoxyCreatedList {
+		if pc.TransactionHash != nil {
+			insert, err := claimBusinessKey(db.gorm, "proxy_created", &PoxyCreated{ProxyAddress: pc.ProxyAddress}, logKey{TransactionHash: pc.TransactionHash, LogIndex: pc.LogIndex})
+			if err != nil {
+				return err
+			} else if !insert {
+				continue
+			}
+		}
+		rows = append(rows, pc)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	result := db.gorm.Table("proxy_created").Clauses(onConflict).CreateInBatches(&rows, len(rows))
+	return result.Error
+}
+
+func (db poxyCreatedDB) DeletePoxyCreatedAfter(number *big.Int) error {
+	result := db.gorm.Table("proxy_created").Where("block_number > ?", number).Delete(&PoxyCreated{})
 	return result.Error
 }
 
@@ -9,26 +9,50 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RequestSend struct {
-	GUID       uuid.UUID      `gorm:"primaryKey" json:"guid"`
-	RequestId  *big.Int       `json:"request_id" gorm:"serializer:u256"`
-	VrfAddress common.Address `json:"vrf_address" gorm:"serializer:bytes"`
-	NumWords   *big.Int       `json:"num_words" gorm:"serializer:u256"`
-	Status     uint8          `json:"status"` // 0:扫到合约事件,1:已经上传随机数
-	Timestamp  uint64
+	GUID               uuid.UUID      `gorm:"primaryKey" json:"guid"`
+	RequestId          *big.Int       `json:"request_id" gorm:"serializer:u256"`
+	VrfAddress         common.Address `json:"vrf_address" gorm:"serializer:bytes"`
+	NumWords           *big.Int       `json:"num_words" gorm:"serializer:u256"`
+	Status             uint8          `json:"status"`                                      // 见 request_status.go 中的状态机
+	BlockNumber        *big.Int       `json:"block_number" gorm:"serializer:u256"`         // 事件所在区块高度，重组回滚时按高度删除
+	BlockTimestamp     uint64         `json:"block_timestamp"`                             // 事件所在区块的时间，用于判断请求是否过期，旧数据可能为 0
+	TransactionHash    *common.Hash   `json:"transaction_hash" gorm:"serializer:bytes"`    // 产生该请求的日志所在交易，与 LogIndex 一起唯一，旧数据为 nil
+	LogIndex           *uint64        `json:"log_index"`                                   // 产生该请求的日志序号
+	FulfillTxHash      *common.Hash   `json:"fulfill_tx_hash" gorm:"serializer:bytes"`     // 链上回填随机数的交易，未解析到 FillRandomWords 事件时为 nil
+	FulfillBlockNumber *big.Int       `json:"fulfill_block_number" gorm:"serializer:u256"` // 回填交易所在区块高度
+	FailureReason      string         `json:"failure_reason"`                              // 最近一次回填失败的回滚原因，完成后清空
+	VrfProof           string         `json:"vrf_proof"`                                   // 回填随机数的 ECVRF 证明（十六进制），随机数来源不提供证明时为空
+	DrandRound         uint64         `json:"drand_round"`                                 // 回填随机数使用的 drand 轮次，未使用 drand 时为 0
+	DrandSignature     string         `json:"drand_signature"`                             // 该轮 drand 信标的 BLS 签名（十六进制）
+	AttemptCount       int            `json:"attempt_count"`                               // 已失败的回填次数
+	LastError          string         `json:"last_error"`                                  // 最近一次回填失败的错误
+	NextRetryAt        uint64         `json:"next_retry_at"`                               // 下一次重试的时间（unix 秒），之前工作器不会处理该请求
+	Timestamp          uint64
 }
 
 type RequestSendView interface {
-	QueryUnHandleRequestSendList() ([]RequestSend, error)
+	QueryUnHandleRequestSendList(uint64) ([]RequestSend, error)
+	QueryRequestSendListByStatus(uint8) ([]RequestSend, error)
 }
 
 type RequestSendDB interface {
 	RequestSendView
 
+	MarkRequestSendInFlight(RequestSend) error
+	MarkRequestSendPending(RequestSend) error
 	MarkRequestSendFinish(RequestSend) error
+	MarkRequestSendFulfilled(*big.Int, common.Address, common.Hash, *big.Int) (int64, error)
+	MarkRequestSendAttemptFailed(RequestSend) error
+	MarkRequestSendSkipped(RequestSend) error
+	MarkRequestSendExpired(RequestSend) error
 	StoreRequestSend([]RequestSend) error
+	UpsertRequestSend([]RequestSend) error
+	DeleteRequestSendAfter(*big.Int) error
+	RevertRequestSendFulfilledAfter(*big.Int) error
 }
 
 type requestSendDB struct {
@@ -39,11 +63,11 @@ func NewRequestSendDB(db *gorm.DB) RequestSendDB {
 	return &requestSendDB{gorm: db}
 }
 
-// 查询未处理的请求
-func (db requestSendDB) QueryUnHandleRequestSendList() ([]RequestSend, error) {
+// 查询未处理且到了重试时间（不晚于 now）的请求
+func (db requestSendDB) QueryUnHandleRequestSendList(now uint64) ([]RequestSend, error) {
 	var requestSendList []RequestSend
 	// status = 0 表示未处理的事件
-	err := db.gorm.Table("request_sent").Where("status = ?", 0).Find(&requestSendList).Error
+	err := db.gorm.Table("request_sent").Where("status = ? AND next_retry_at <= ?", RequestSendPending, now).Find(&requestSendList).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("query unhandle request sent list failed: %w", err)
@@ -51,24 +75,74 @@ func (db requestSendDB) QueryUnHandleRequestSendList() ([]RequestSend, error) {
 	return requestSendList, nil
 }
 
+// 回填交易执行成功，发送中的请求标记为已完成；FillRandomWords 事件已先把请求标记为完成时不做修改
 func (db requestSendDB) MarkRequestSendFinish(requestSent RequestSend) error {
-	var requestSendSingle = RequestSend{}
-	result := db.gorm.Table("request_sent").Where(&RequestSend{GUID: requestSent.GUID}).Take(&requestSendSingle)
+	requestSent.FailureReason = ""
+	err := db.transition(requestSent, RequestSendInFlight, RequestSendFulfilled, "failure_reason")
+	if !errors.Is(err, ErrRequestStatusConflict) {
+		return err
+	}
+	var current RequestSend
+	result := db.gorm.Table("request_sent").Where(&RequestSend{GUID: requestSent.GUID}).Take(&current)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil
 		}
 		return result.Error
 	}
-	requestSendSingle.Status = 1
-	err := db.gorm.Table("request_sent").Save(&requestSendSingle).Error
-	if err != nil {
-		return err
+	if current.Status == RequestSendFulfilled {
+		return nil
 	}
-	return nil
+	return err
+}
+
+// 按链上的 FillRandomWords 事件把请求标记为已完成并记录回填交易，返回更新的行数
+// 无论由哪个调用方回填，请求都不会再被工作器处理
+func (db requestSendDB) MarkRequestSendFulfilled(requestId *big.Int, vrfAddress common.Address, txHash common.Hash, blockNumber *big.Int) (int64, error) {
+	result := db.gorm.Table("request_sent").Where(&RequestSend{RequestId: requestId, VrfAddress: vrfAddress}).
+		Select("status", "fulfill_tx_hash", "fulfill_block_number", "failure_reason").
+		Updates(&RequestSend{Status: RequestSendFulfilled, FulfillTxHash: &txHash, FulfillBlockNumber: blockNumber})
+	return result.RowsAffected, result.Error
 }
 
+// 按产生请求的日志去重，同一条日志已写入时不做任何修改，处理状态不会被重置
 func (db requestSendDB) StoreRequestSend(RequestSendList []RequestSend) error {
-	result := db.gorm.Table("request_sent").CreateInBatches(&RequestSendList, len(RequestSendList))
+	return db.storeRequestSend(RequestSendList, onLogConflictDoNothing)
+}
+
+// 按产生请求的日志覆盖已写入的行中由事件解析出的列，处理状态和回填结果保持不变，用于重新解析历史事件
+func (db requestSendDB) UpsertRequestSend(RequestSendList []RequestSend) error {
+	return db.storeRequestSend(RequestSendList, onLogConflictUpdate("request_id", "vrf_address", "num_words", "block_number", "block_timestamp"))
+}
+
+func (db requestSendDB) storeRequestSend(RequestSendList []RequestSend, onConflict clause.OnConflict) error {
+	rows := make([]RequestSend, 0, len(RequestSendList))
+	for _, rs := range RequestSendList {
+		if rs.TransactionHash != nil {
+			insert, err := claimBusinessKey(db.gorm, "request_sent", &RequestSend{RequestId: rs.RequestId, VrfAddress: rs.VrfAddress}, logKey{TransactionHash: rs.TransactionHash, LogIndex: rs.LogIndex})
+			if err != nil {
+				return err
+			} else if !insert {
+				continue
+			}
+		}
+		rows = append(rows, rs)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	result := db.gorm.Table("request_sent").Clauses(onConflict).CreateInBatches(&rows, len(rows))
+	return result.Error
+}
+
+func (db requestSendDB) DeleteRequestSendAfter(number *big.Int) error {
+	result := db.gorm.Table("request_sent").Where("block_number > ?", number).Delete(&RequestSend{})
+	return result.Error
+}
+
+// 回填交易所在区块高度大于 number 的请求恢复为未完成，用于重组后回滚
+func (db requestSendDB) RevertRequestSendFulfilledAfter(number *big.Int) error {
+	result := db.gorm.Table("request_sent").Where("fulfill_block_number > ? AND status = ?", number, RequestSendFulfilled).
+		Updates(map[string]interface{}{"status": RequestSendPending, "fulfill_tx_hash": nil, "fulfill_block_number": nil})
 	return result.Error
 }
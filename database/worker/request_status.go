@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+/*
+	请求状态机：
+		- pending -> in_flight：工作器发送回填交易前占用请求，同一时间只有一个发送方处理该请求
+		- in_flight -> fulfilled：回填交易执行成功；in_flight -> pending：回填失败等待重试或未发送就释放；in_flight -> failed：失败次数达到上限
+		- pending -> failed / expired / skipped：请求被放弃、超过有效期或请求本身无效，不再处理
+		- FillRandomWords 事件以链上为准，任何状态都会被标记为 fulfilled；重组回滚时 fulfilled 恢复为 pending
+		- 其余状态变化都通过 transition 完成：先检查转换是否允许，再按当前状态条件更新，当前状态已被其他进程修改时返回 ErrRequestStatusConflict
+		- 进程在发送过程中退出后请求停留在 in_flight，不会被再次回填，由工作器确认交易结果后再释放
+*/
+
+// 请求的处理状态
+const (
+	RequestSendPending   uint8 = 0 // 扫到合约事件，等待回填
+	RequestSendFulfilled uint8 = 1 // 已经上传随机数
+	RequestSendFailed    uint8 = 2 // 回填失败次数达到上限，不再重试
+	RequestSendInFlight  uint8 = 3 // 回填交易发送中
+	RequestSendExpired   uint8 = 4 // 超过有效期，不再回填
+	RequestSendSkipped   uint8 = 5 // 请求无效，不回填
+)
+
+var (
+	ErrInvalidStatusTransition = errors.New("invalid request status transition")
+	ErrRequestStatusConflict   = errors.New("request status changed concurrently")
+)
+
+// 允许的状态转换
+var requestSendTransitions = map[uint8][]uint8{
+	RequestSendPending:  {RequestSendInFlight, RequestSendFailed, RequestSendExpired, RequestSendSkipped},
+	RequestSendInFlight: {RequestSendPending, RequestSendFulfilled, RequestSendFailed},
+}
+
+// 状态的名称，用于日志
+func RequestSendStatusName(status uint8) string {
+	switch status {
+	case RequestSendPending:
+		return "pending"
+	case RequestSendFulfilled:
+		return "fulfilled"
+	case RequestSendFailed:
+		return "failed"
+	case RequestSendInFlight:
+		return "in_flight"
+	case RequestSendExpired:
+		return "expired"
+	case RequestSendSkipped:
+		return "skipped"
+	default:
+		return fmt.Sprintf("unknown(%d)", status)
+	}
+}
+
+// 查询处于 status 的请求
+func (db requestSendDB) QueryRequestSendListByStatus(status uint8) ([]RequestSend, error) {
+	var requestSendList []RequestSend
+	err := db.gorm.Table("request_sent").Where("status = ?", status).Find(&requestSendList).Error
+	if err != nil {
+		return nil, fmt.Errorf("query %s request sent list failed: %w", RequestSendStatusName(status), err)
+	}
+	return requestSendList, nil
+}
+
+// 占用请求准备发送回填交易，同时保存随机数来源记录的审计信息
+func (db requestSendDB) MarkRequestSendInFlight(requestSent RequestSend) error {
+	return db.transition(requestSent, RequestSendPending, RequestSendInFlight, "vrf_proof", "drand_round", "drand_signature")
+}
+
+// 释放发送中的请求，不计为失败
+func (db requestSendDB) MarkRequestSendPending(requestSent RequestSend) error {
+	return db.transition(requestSent, RequestSendInFlight, RequestSendPending)
+}
+
+// 记录一次回填失败：失败次数、错误、回滚原因、下一次重试时间，请求恢复为 pending 或在达到上限时置为 failed
+func (db requestSendDB) MarkRequestSendAttemptFailed(requestSent RequestSend) error {
+	return db.transition(requestSent, RequestSendInFlight, requestSent.Status, "failure_reason", "attempt_count", "last_error", "next_retry_at")
+}
+
+// 请求本身无效，不再回填
+func (db requestSendDB) MarkRequestSendSkipped(requestSent RequestSend) error {
+	return db.transition(requestSent, RequestSendPending, RequestSendSkipped, "last_error")
+}
+
+// 请求超过有效期，不再回填
+func (db requestSendDB) MarkRequestSendExpired(requestSent RequestSend) error {
+	return db.transition(requestSent, RequestSendPending, RequestSendExpired, "last_error")
+}
+
+// 按当前状态 from 条件更新为 to，并写入 columns 中的字段
+func (db requestSendDB) transition(requestSent RequestSend, from uint8, to uint8, columns ...string) error {
+	if !slices.Contains(requestSendTransitions[from], to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, RequestSendStatusName(from), RequestSendStatusName(to))
+	}
+	requestSent.Status = to
+	result := db.gorm.Table("request_sent").Where("guid = ? AND status = ?", requestSent.GUID, from).
+		Select(append([]string{"status"}, columns...)).Updates(&requestSent)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: request %s is no longer %s", ErrRequestStatusConflict, requestSent.RequestId, RequestSendStatusName(from))
+	}
+	return nil
+}
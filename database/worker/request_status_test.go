@@ -0,0 +1,147 @@
+package worker_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/WJX2001/contract-caller/database/dbtest"
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// 写入一个 pending 请求
+func storePending(t *testing.T, requests worker.RequestSendDB, requestId int64) worker.RequestSend {
+	txHash := common.BigToHash(big.NewInt(requestId))
+	request := newRequestSend(requestId, &txHash, ptr[uint64](1))
+	require.NoError(t, requests.StoreRequestSend([]worker.RequestSend{request}))
+	return request
+}
+
+func requireStatus(t *testing.T, requests worker.RequestSendDB, status uint8, want ...worker.RequestSend) []worker.RequestSend {
+	list, err := requests.QueryRequestSendListByStatus(status)
+	require.NoError(t, err)
+	guids := make([]any, 0, len(list))
+	for _, request := range list {
+		guids = append(guids, request.GUID)
+	}
+	wantGuids := make([]any, 0, len(want))
+	for _, request := range want {
+		wantGuids = append(wantGuids, request.GUID)
+	}
+	require.ElementsMatch(t, wantGuids, guids, "requests in %s", worker.RequestSendStatusName(status))
+	return list
+}
+
+func TestRequestSendLifecycle(t *testing.T) {
+	requests := worker.NewRequestSendDB(dbtest.NewGorm(t))
+	request := storePending(t, requests, 1)
+
+	request.VrfProof = "0x01"
+	require.NoError(t, requests.MarkRequestSendInFlight(request))
+	inFlight := requireStatus(t, requests, worker.RequestSendInFlight, request)
+	require.Equal(t, "0x01", inFlight[0].VrfProof)
+
+	require.NoError(t, requests.MarkRequestSendPending(request))
+	requireStatus(t, requests, worker.RequestSendPending, request)
+
+	require.NoError(t, requests.MarkRequestSendInFlight(request))
+	request.FailureReason = "reverted"
+	require.NoError(t, requests.MarkRequestSendFinish(request))
+	fulfilled := requireStatus(t, requests, worker.RequestSendFulfilled, request)
+	require.Empty(t, fulfilled[0].FailureReason)
+}
+
+func TestRequestSendTransitionConflict(t *testing.T) {
+	requests := worker.NewRequestSendDB(dbtest.NewGorm(t))
+	request := storePending(t, requests, 1)
+	require.NoError(t, requests.MarkRequestSendInFlight(request))
+
+	// 另一个进程已经占用了请求
+	require.ErrorIs(t, requests.MarkRequestSendInFlight(request), worker.ErrRequestStatusConflict)
+	require.ErrorIs(t, requests.MarkRequestSendSkipped(request), worker.ErrRequestStatusConflict)
+	require.ErrorIs(t, requests.MarkRequestSendExpired(request), worker.ErrRequestStatusConflict)
+	requireStatus(t, requests, worker.RequestSendInFlight, request)
+
+	require.NoError(t, requests.MarkRequestSendPending(request))
+	require.ErrorIs(t, requests.MarkRequestSendPending(request), worker.ErrRequestStatusConflict)
+	requireStatus(t, requests, worker.RequestSendPending, request)
+}
+
+func TestRequestSendInvalidTransition(t *testing.T) {
+	requests := worker.NewRequestSendDB(dbtest.NewGorm(t))
+	request := storePending(t, requests, 1)
+	require.NoError(t, requests.MarkRequestSendInFlight(request))
+
+	for _, status := range []uint8{worker.RequestSendInFlight, worker.RequestSendExpired, worker.RequestSendSkipped} {
+		request.Status = status
+		require.ErrorIs(t, requests.MarkRequestSendAttemptFailed(request), worker.ErrInvalidStatusTransition)
+	}
+	requireStatus(t, requests, worker.RequestSendInFlight, request)
+}
+
+func TestRequestSendAttemptFailed(t *testing.T) {
+	requests := worker.NewRequestSendDB(dbtest.NewGorm(t))
+	retried := storePending(t, requests, 1)
+	failed := storePending(t, requests, 2)
+	for _, request := range []worker.RequestSend{retried, failed} {
+		require.NoError(t, requests.MarkRequestSendInFlight(request))
+	}
+
+	retried.Status = worker.RequestSendPending
+	retried.AttemptCount = 1
+	retried.LastError = "tx not sent"
+	retried.NextRetryAt = 100
+	require.NoError(t, requests.MarkRequestSendAttemptFailed(retried))
+	failed.Status = worker.RequestSendFailed
+	failed.AttemptCount = 3
+	require.NoError(t, requests.MarkRequestSendAttemptFailed(failed))
+
+	// 到重试时间前不会被查询到
+	unhandled, err := requests.QueryUnHandleRequestSendList(99)
+	require.NoError(t, err)
+	require.Empty(t, unhandled)
+	unhandled, err = requests.QueryUnHandleRequestSendList(100)
+	require.NoError(t, err)
+	require.Len(t, unhandled, 1)
+	require.Equal(t, retried.GUID, unhandled[0].GUID)
+	require.Equal(t, 1, unhandled[0].AttemptCount)
+	require.Equal(t, "tx not sent", unhandled[0].LastError)
+
+	list := requireStatus(t, requests, worker.RequestSendFailed, failed)
+	require.Equal(t, 3, list[0].AttemptCount)
+}
+
+func TestRequestSendTerminalStatuses(t *testing.T) {
+	requests := worker.NewRequestSendDB(dbtest.NewGorm(t))
+	skipped := storePending(t, requests, 1)
+	expired := storePending(t, requests, 2)
+
+	skipped.LastError = "invalid number of words"
+	require.NoError(t, requests.MarkRequestSendSkipped(skipped))
+	require.NoError(t, requests.MarkRequestSendExpired(expired))
+	list := requireStatus(t, requests, worker.RequestSendSkipped, skipped)
+	require.Equal(t, "invalid number of words", list[0].LastError)
+	requireStatus(t, requests, worker.RequestSendExpired, expired)
+
+	// 结束状态的请求不能再被占用
+	require.ErrorIs(t, requests.MarkRequestSendInFlight(skipped), worker.ErrRequestStatusConflict)
+	unhandled, err := requests.QueryUnHandleRequestSendList(0)
+	require.NoError(t, err)
+	require.Empty(t, unhandled)
+}
+
+func TestRequestSendFulfilledByEvent(t *testing.T) {
+	requests := worker.NewRequestSendDB(dbtest.NewGorm(t))
+	request := storePending(t, requests, 1)
+	require.NoError(t, requests.MarkRequestSendInFlight(request))
+
+	// FillRandomWords 事件先于工作器确认回执，工作器完成时不报冲突
+	fulfillTx := common.HexToHash("0xff")
+	rows, err := requests.MarkRequestSendFulfilled(request.RequestId, request.VrfAddress, fulfillTx, big.NewInt(20))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+	require.NoError(t, requests.MarkRequestSendFinish(request))
+	fulfilled := requireStatus(t, requests, worker.RequestSendFulfilled, request)
+	require.Equal(t, fulfillTx, *fulfilled[0].FulfillTxHash)
+}
@@ -0,0 +1,62 @@
+package dapplink_vrf
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/config"
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/google/uuid"
+)
+
+// 通过工厂合约部署 VRF 代理，并写入代理表使同步器立即开始监听，返回代理地址
+// implementation 为零地址时使用配置的 VRF 合约，dapplinkAddress 为零地址时使用调用者地址
+func RunDeployProxy(ctx context.Context, cfg *config.Config, implementation, dapplinkAddress common.Address) (common.Address, error) {
+	db, err := database.NewDB(ctx, cfg.MasterDB)
+	if err != nil {
+		log.Error("new database fail", "err", err)
+		return common.Address{}, err
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Error("close database fail", "err", err)
+		}
+	}()
+
+	engine, err := newDriverEngine(ctx, cfg, db, nil, nil)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if implementation == (common.Address{}) {
+		implementation = engine.Cfg.DappLinkVrfAddress
+	}
+	if dapplinkAddress == (common.Address{}) {
+		dapplinkAddress = engine.Cfg.Signer.Address()
+	}
+
+	proxy, receipt, err := engine.DeployProxy(implementation, dapplinkAddress)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	var timestamp uint64
+	if header, err := engine.Cfg.ChainClient.HeaderByNumber(ctx, receipt.BlockNumber); err == nil {
+		timestamp = header.Time
+	} else {
+		log.Warn("unable to query block of create proxy tx", "number", receipt.BlockNumber, "err", err)
+	}
+	// 事件处理器稍后解析到同一个 ProxyCreated 事件时只更新区块高度
+	if err := db.PoxyCreated.StorePoxyCreated([]worker.PoxyCreated{{
+		GUID:         uuid.New(),
+		ProxyAddress: proxy,
+		BlockNumber:  new(big.Int).Set(receipt.BlockNumber),
+		Timestamp:    timestamp,
+	}}); err != nil {
+		log.Error("store proxy created fail", "proxy", proxy, "err", err)
+		return proxy, err
+	}
+	return proxy, nil
+}
@@ -0,0 +1,65 @@
+package driver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// 通过 eth_createAccessList 为交易生成 access list
+// 附带 access list 后估算的 gas 更低时返回带 access list 的候选交易（未签名），否则原样返回
+// 节点不支持或生成失败时不影响发送，直接使用原交易
+func (de *DriverEngine) applyAccessList(ctx context.Context, tx *types.Transaction) *types.Transaction {
+	msg := ethereum.CallMsg{
+		From:  de.Cfg.Signer.Address(),
+		To:    tx.To(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+
+	accessList, _, vmErr, err := gethclient.New(de.Cfg.ChainClient.Client()).CreateAccessList(ctx, msg)
+	if err == nil && vmErr != "" {
+		err = errors.New(vmErr)
+	}
+	if err != nil {
+		log.Warn("create access list fail, sending without it", "err", err)
+		return tx
+	}
+	if accessList == nil || len(*accessList) == 0 {
+		return tx
+	}
+
+	msg.AccessList = *accessList
+	estimate, err := de.Cfg.ChainClient.EstimateGas(ctx, msg)
+	if err != nil {
+		log.Warn("estimate gas with access list fail, sending without it", "err", err)
+		return tx
+	}
+	// 与不带 access list 的交易使用相同的余量和上限
+	gas, err := de.gasLimit(estimate)
+	if err != nil {
+		log.Warn("gas with access list exceeds limit, sending without it", "err", err)
+		return tx
+	}
+	if gas >= tx.Gas() {
+		log.Debug("access list does not reduce gas", "gasWithAccessList", gas, "gas", tx.Gas())
+		return tx
+	}
+
+	log.Info("using access list", "entries", len(*accessList), "gasWithAccessList", gas, "gas", tx.Gas())
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:    tx.ChainId(),
+		Nonce:      tx.Nonce(),
+		GasTipCap:  tx.GasTipCap(),
+		GasFeeCap:  tx.GasFeeCap(),
+		Gas:        gas,
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: *accessList,
+	})
+}
@@ -0,0 +1,67 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	调用者余额监控：
+		- 每隔 BalanceCheckInterval 查询一次调用者地址的余额并记录指标
+		- 低于 LowBalanceWarning 时告警；低于 MinBalance 时暂停新的回填，直到余额恢复，避免余额不足时反复发送注定失败的交易
+		- 暂停期间 FulfillRandomWords / FulfillRandomWordsBatch 直接返回 ErrBalanceTooLow
+*/
+
+// 默认的余额检查间隔
+const defaultBalanceCheckInterval = time.Minute
+
+// 调用者余额低于 MinBalance 时回填返回的错误
+var ErrBalanceTooLow = errors.New("driver: caller balance below minimum, fulfillments paused")
+
+// 定时检查调用者余额，阻塞直到 ctx 结束
+func (de *DriverEngine) MonitorBalance(ctx context.Context) {
+	interval := de.Cfg.BalanceCheckInterval
+	if interval <= 0 {
+		interval = defaultBalanceCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := de.checkBalance(ctx); err != nil && ctx.Err() == nil {
+			log.Warn("check caller balance fail", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 查询一次余额，更新指标和暂停状态
+func (de *DriverEngine) checkBalance(ctx context.Context) error {
+	address := de.Cfg.Signer.Address()
+	balance, err := de.Cfg.ChainClient.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return err
+	}
+	de.metrics.RecordBalance(balance)
+
+	paused := de.Cfg.MinBalance != nil && balance.Cmp(de.Cfg.MinBalance) < 0
+	if paused != de.balancePaused.Swap(paused) {
+		if paused {
+			log.Error("caller balance below minimum, pausing fulfillments", "address", address, "balance", balance, "min", de.Cfg.MinBalance)
+		} else {
+			log.Info("caller balance recovered, resuming fulfillments", "address", address, "balance", balance)
+		}
+	}
+	de.metrics.RecordPaused(paused)
+
+	if !paused && de.Cfg.LowBalanceWarning != nil && balance.Cmp(de.Cfg.LowBalanceWarning) < 0 {
+		log.Warn("caller balance is low", "address", address, "balance", balance, "warning", de.Cfg.LowBalanceWarning)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	批量回填：
+		- 通过 Multicall3 的 aggregate3 在一笔交易中调用多次 fulfillRandomWords，积压较多时节省每个请求的固定 gas 开销
+		- 任意一次调用失败时整笔交易回滚（allowFailure=false），不会出现部分回填
+		- 合约按 msg.sender 校验调用方，需要部署由调用者控制的聚合合约并在 VRF 合约中授权，公共的 Multicall3 无法通过校验
+		- 一笔交易只回填同一个 VRF 合约或代理上的请求，工作器按 vrf_address 分组，每组按 FulfillBatchSize 拆成多笔
+		- 未配置 MulticallAddress 时 FulfillBatchSize 为 0，工作器逐个请求单独回填
+*/
+
+// 默认每笔批量交易最多包含的请求数
+const defaultMaxFulfillBatchSize = 20
+
+// Multicall3 aggregate3 的 ABI
+const multicall3Abi = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+// 一个待回填的请求
+type FulfillRequest struct {
+	RequestId   *big.Int
+	RandomWords []*big.Int
+}
+
+// aggregate3 的单次调用
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// 每笔批量交易最多包含的请求数，未配置 MulticallAddress 时为 0，表示逐个请求单独回填
+func (de *DriverEngine) FulfillBatchSize() int {
+	if de.Cfg.MulticallAddress == (common.Address{}) {
+		return 0
+	}
+	if de.Cfg.MaxFulfillBatchSize <= 0 {
+		return defaultMaxFulfillBatchSize
+	}
+	return de.Cfg.MaxFulfillBatchSize
+}
+
+// 在一笔交易中回填 vrfAddress（VRF 合约或工厂创建的代理）上的多个请求，batch 不能超过 FulfillBatchSize
+func (de *DriverEngine) FulfillRandomWordsBatchAt(vrfAddress common.Address, batch []FulfillRequest) (*types.Receipt, error) {
+	if de.balancePaused.Load() {
+		return nil, ErrBalanceTooLow
+	}
+	if len(batch) == 0 || len(batch) > de.FulfillBatchSize() {
+		return nil, fmt.Errorf("%w: batch of %d requests exceeds batch size %d", ErrTxNotSent, len(batch), de.FulfillBatchSize())
+	}
+	tx, err := de.fulfillRandomWordsBatch(de.Ctx, vrfAddress, batch)
+	if err != nil {
+		log.Error("build batch fulfill random words tx fail", "vrfAddress", vrfAddress, "requests", len(batch), "err", err)
+		return nil, fmt.Errorf("%w: %w", ErrTxNotSent, err)
+	}
+	correlationID := fmt.Sprintf("%s-%s", batch[0].RequestId, batch[len(batch)-1].RequestId)
+	metadata := &RequestMetadata{VrfAddress: vrfAddress}
+	for _, request := range batch {
+		metadata.RequestIds = append(metadata.RequestIds, request.RequestId)
+	}
+	receipt, err := de.send(tx, correlationID, metadata)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("batch fulfilled random words", "vrfAddress", vrfAddress, "requests", len(batch), "tx", receipt.TxHash, "gasUsed", receipt.GasUsed)
+	return receipt, nil
+}
+
+// 构造一笔通过 aggregate3 回填 batch 中所有请求的交易（未签名发送）
+func (de *DriverEngine) fulfillRandomWordsBatch(ctx context.Context, vrfAddress common.Address, batch []FulfillRequest) (tx *types.Transaction, err error) {
+	multicallAbi, err := abi.JSON(strings.NewReader(multicall3Abi))
+	if err != nil {
+		return nil, err
+	}
+	calls := make([]call3, 0, len(batch))
+	for _, request := range batch {
+		callData, err := de.DappLinkVrfContractAbi.Pack("fulfillRandomWords", request.RequestId, request.RandomWords)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, call3{Target: vrfAddress, CallData: callData})
+	}
+	data, err := multicallAbi.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, err
+	}
+
+	// 批量交易的 gas 取决于请求组合，每次单独估算
+	estimate, err := de.Cfg.ChainClient.EstimateGas(ctx, ethereum.CallMsg{
+		From: de.Cfg.Signer.Address(),
+		To:   &de.Cfg.MulticallAddress,
+		Data: data,
+	})
+	if err != nil {
+		// 只有执行回滚时调用方才改为逐个回填，RPC 失败原样返回，避免节点故障时成倍增加请求
+		if !isExecutionReverted(err) {
+			return nil, err
+		}
+		return nil, de.simulationError(de.Cfg.MulticallAddress, err)
+	}
+	gasLimit, err := de.gasLimit(estimate)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := de.nonces.Reserve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			de.abandonNonce(nonce)
+		}
+	}()
+	opts := de.transactOpts(ctx)
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	opts.NoSend = true
+	opts.GasLimit = gasLimit
+	if err = de.setFees(ctx, opts); err != nil {
+		return nil, err
+	}
+
+	multicall := bind.NewBoundContract(de.Cfg.MulticallAddress, multicallAbi, de.Cfg.ChainClient, de.Cfg.ChainClient, de.Cfg.ChainClient)
+	tx, err = multicall.RawTransact(opts, data)
+	switch {
+	case err == nil:
+		return tx, nil
+	case de.isMaxPriorityFeePerGasNotFoundError(err):
+		log.Info("Don't support priority fee")
+		opts.GasTipCap = FallbackGasTipCap
+		return multicall.RawTransact(opts, data)
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,62 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	启动时校验合约部署：
+		- 通过 eth_getCode 确认 VRF 合约（以及配置了地址的工厂合约）在当前链上有代码，地址或链配置错误时直接失败，而不是在第一次调用时回滚
+		- 开启 VerifyContractSelectors 时进一步检查字节码中包含期望方法的选择器（PUSH4 selector）
+		- 通过代理部署的合约字节码中不包含实现合约的选择器，此时不要开启选择器检查
+*/
+
+// 期望合约实现的方法
+var (
+	vrfExpectedMethods     = []string{"fulfillRandomWords"}
+	factoryExpectedMethods = []string{"createProxy"}
+)
+
+const opPush4 = 0x63
+
+// 校验 address 上部署了合约，checkSelectors 为 true 时检查字节码包含 contractAbi 中 methods 的选择器
+func verifyDeployment(ctx context.Context, client *ethclient.Client, name string, address common.Address, contractAbi *abi.ABI, methods []string, checkSelectors bool) error {
+	code, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return fmt.Errorf("unable to query code of %s %s: %w", name, address, err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no contract code at %s address %s, check the address and chain", name, address)
+	}
+	if checkSelectors {
+		for _, method := range methods {
+			abiMethod, ok := contractAbi.Methods[method]
+			if !ok {
+				return fmt.Errorf("method %s not found in %s abi", method, name)
+			}
+			if !bytes.Contains(code, append([]byte{opPush4}, abiMethod.ID...)) {
+				return fmt.Errorf("%s at %s does not implement %s", name, address, abiMethod.Sig)
+			}
+		}
+	}
+	log.Info("verified contract deployment", "contract", name, "address", address, "codeSize", len(code))
+	return nil
+}
+
+// 校验配置的 VRF 合约和工厂合约
+func verifyDeployments(ctx context.Context, cfg *DriverEngineConfig, vrfAbi *abi.ABI, factoryAbi *abi.ABI) error {
+	if err := verifyDeployment(ctx, cfg.ChainClient, "DappLinkVRF", cfg.DappLinkVrfAddress, vrfAbi, vrfExpectedMethods, cfg.VerifyContractSelectors); err != nil {
+		return err
+	}
+	if cfg.DappLinkVrfFactoryAddress == (common.Address{}) {
+		return nil
+	}
+	return verifyDeployment(ctx, cfg.ChainClient, "DappLinkVRFFactory", cfg.DappLinkVrfFactoryAddress, factoryAbi, factoryExpectedMethods, cfg.VerifyContractSelectors)
+}
@@ -2,11 +2,12 @@ package driver
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"errors"
+	"fmt"
 	"math/big"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/WJX2001/contract-caller/bindings"
@@ -36,16 +37,49 @@ var (
 	)
 
 	FallbackGasTipCap = big.NewInt(1500000000)
+
+	// 回填交易在交给交易管理器广播之前失败（构造交易、估算 gas、模拟执行），请求可以安全地重新回填
+	ErrTxNotSent = errors.New("driver: fulfill tx not sent")
 )
 
+// 默认单笔交易从发送到确认的最长耗时，超时后放弃，避免一笔卡住的交易阻塞整轮回填
+const defaultTxSendTimeout = 10 * time.Minute
+
 type DriverEngineConfig struct {
-	ChainClient               *ethclient.Client // 链客户端
-	ChainId                   *big.Int          // 链ID
-	DappLinkVrfAddress        common.Address    // DappLinkVRF 合约地址
-	CallerAddress             common.Address    // 发交易的地址
-	PrivateKey                *ecdsa.PrivateKey // CallerAddress 和 PrivateKey 是一一对应的
-	NumConfirmations          uint64            // 交易确认区块数
-	SafeAbortNonceTooLowCount uint64            // nonce 错误重试上限
+	ChainClient               *ethclient.Client   // 链客户端
+	ChainId                   *big.Int            // 链ID
+	DappLinkVrfAddress        common.Address      // DappLinkVRF 合约地址
+	DappLinkVrfFactoryAddress common.Address      // DappLinkVRF 工厂合约地址，零地址表示不校验
+	VerifyContractSelectors   bool                // 启动时检查合约字节码包含期望方法的选择器，代理部署的合约不要开启
+	Signer                    Signer              // 发交易的账户，负责提供地址和签名
+	NumConfirmations          uint64              // 交易确认区块数
+	SafeAbortNonceTooLowCount uint64              // nonce 错误重试上限
+	MaxGasFeeCap              *big.Int            // gasFeeCap 上限，nil 表示不限制
+	MaxGasTipCap              *big.Int            // gasTipCap 上限，nil 表示不限制
+	MinGasFeeCap              *big.Int            // gasFeeCap 下限，nil 表示不限制
+	MinGasTipCap              *big.Int            // gasTipCap 下限，nil 表示不限制
+	TxType                    txmgr.TxType        // 交易类型，不支持 EIP-1559 的链使用 txmgr.LegacyTxType
+	TxMetrics                 txmgr.Metrics       // 交易管理器指标，nil 表示不采集
+	BroadcastClients          []*ethclient.Client // 额外广播交易的节点
+	StuckTxThreshold          time.Duration       // 交易超过该时长未上链视为卡住，0 表示不检测
+	TxSendTimeout             time.Duration       // 单笔交易从发送到确认的最长耗时，0 使用默认值
+	Budget                    txmgr.Budget        // 交易花费预算，nil 表示不限制
+	UseAccessList             bool                // 是否通过 eth_createAccessList 为交易附加 access list
+	DryRun                    bool                // 演练模式，只模拟执行交易不广播
+	FailOnRevert              bool                // 执行失败（status=0）的交易返回 txmgr.ErrTxReverted，而不是视为发送成功
+	GasLimitMultiplier        float64             // 估算 gas 的余量倍数，0 使用默认值
+	MaxGasLimit               uint64              // 交易 gas 上限，0 表示不限制
+	MulticallAddress          common.Address      // 批量回填使用的 Multicall3 聚合合约，零地址表示逐个回填
+	MaxFulfillBatchSize       int                 // 每笔批量回填交易最多包含的请求数，0 使用默认值
+	TipPercentile             float64             // 按 fee history 定价时小费的百分位（0~100），0 使用默认值
+	BaseFeeMultiplier         float64             // 按 fee history 定价时 baseFee 的余量倍数（至少为 1），0 使用默认值
+	BalanceCheckInterval      time.Duration       // 调用者余额检查间隔，0 使用默认值
+	LowBalanceWarning         *big.Int            // 余额低于该值时告警，nil 表示不告警
+	MinBalance                *big.Int            // 余额低于该值时暂停回填，nil 表示不暂停
+	Metrics                   Metrics             // 驱动引擎指标，nil 表示不采集
+	OnTxPublished             txmgr.TxHookFn      // 交易广播成功后回调，回填交易的 ctx 中带有 RequestMetadata
+	OnTxBumped                txmgr.TxBumpHookFn  // 提价替换旧交易后回调
+	OnTxMined                 txmgr.ReceiptHookFn // 交易首次上链时回调
 }
 
 type DriverEngine struct {
@@ -54,13 +88,20 @@ type DriverEngine struct {
 	DappLinkVrfContract    *bindings.DappLinkVRF
 	RawDappLinkVrfContract *bind.BoundContract
 	DappLinkVrfContractAbi *abi.ABI
-	TxMgr                  txmgr.TxManager // 交易管理器
+	TxMgr                  txmgr.TxManager  // 交易管理器
+	GasPricer              *txmgr.GasPricer // 基于 eth_feeHistory 的 gas 定价
+	signer                 txmgr.SignerFn
+	nonces                 *txmgr.NonceTracker // 与交易管理器共用的 nonce 预留
+	metrics                Metrics
+	balancePaused          atomic.Bool // 余额低于 MinBalance 时暂停回填
+	gasLimitLock           sync.Mutex
+	gasLimits              map[int]uint64 // 按随机数个数缓存的回填交易 gas 上限
 	cancel                 func()
 	wg                     sync.WaitGroup
 }
 
 func NewDriverEngine(ctx context.Context, cfg *DriverEngineConfig) (*DriverEngine, error) {
-	_, cancel := context.WithTimeout(ctx, time.Second*15)
+	ctxt, cancel := context.WithTimeout(ctx, time.Second*15)
 	defer cancel()
 
 	// 解析 ABI JSON
@@ -77,25 +118,77 @@ func NewDriverEngine(ctx context.Context, cfg *DriverEngineConfig) (*DriverEngin
 		return nil, err
 	}
 
-	dappLinkVrfContractAbi, err := bindings.DappLinkVRFFactoryMetaData.GetAbi()
+	dappLinkVrfContractAbi, err := bindings.DappLinkVRFMetaData.GetAbi()
 	if err != nil {
 		log.Error("get dapplink vrf meta data fail", "err", err)
 		return nil, err
 	}
 
+	factoryAbi, err := bindings.DappLinkVRFFactoryMetaData.GetAbi()
+	if err != nil {
+		log.Error("get dapplink vrf factory meta data fail", "err", err)
+		return nil, err
+	}
+	// 合约地址上没有代码时直接失败
+	if err := verifyDeployments(ctxt, cfg, dappLinkVrfContractAbi, factoryAbi); err != nil {
+		log.Error("verify contract deployment fail", "err", err)
+		return nil, err
+	}
+
 	// 构建 RAW 合约绑定器
 	rawDappLinkVrfContract := bind.NewBoundContract(cfg.DappLinkVrfAddress, parsed, cfg.ChainClient, cfg.ChainClient, cfg.ChainClient)
 
+	if cfg.Signer == nil {
+		return nil, errors.New("driver: signer is required")
+	}
+	signer := cfg.Signer.SignTx
+
+	txSendTimeout := cfg.TxSendTimeout
+	if txSendTimeout <= 0 {
+		txSendTimeout = defaultTxSendTimeout
+	}
 	txManagerConfig := txmgr.Config{
 		ResubmissionTimeout:       time.Second * 5,
 		ReceiptQueryInterval:      time.Second,
 		NumConfirmations:          cfg.NumConfirmations,
 		SafeAbortNonceTooLowCount: cfg.SafeAbortNonceTooLowCount,
+		MaxGasFeeCap:              cfg.MaxGasFeeCap,
+		MaxGasTipCap:              cfg.MaxGasTipCap,
+		MinGasFeeCap:              cfg.MinGasFeeCap,
+		MinGasTipCap:              cfg.MinGasTipCap,
+		TxType:                    cfg.TxType,
+		From:                      cfg.Signer.Address(),
+		Metrics:                   cfg.TxMetrics,
+		Signer:                    signer,
+		StuckTxThreshold:          cfg.StuckTxThreshold,
+		TxSendTimeout:             txSendTimeout,
+		Budget:                    cfg.Budget,
+		DryRun:                    cfg.DryRun,
+		FailOnRevert:              cfg.FailOnRevert,
+		OnPublished:               cfg.OnTxPublished,
+		OnBumped:                  cfg.OnTxBumped,
+		OnMined:                   cfg.OnTxMined,
+		// WebSocket 节点通过 newHeads 订阅等待回执，HTTP 节点自动退回轮询
+		SubscribeNewHeads: true,
+	}
+
+	for _, client := range cfg.BroadcastClients {
+		txManagerConfig.BroadcastTo = append(txManagerConfig.BroadcastTo, client.SendTransaction)
 	}
 
 	// 初始化交易管理器
 	txManager := txmgr.NewSimpleTxManager(txManagerConfig, cfg.ChainClient)
 
+	gasPricer, err := newGasPricer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := cfg.Metrics
+	if metrics == nil {
+		metrics = NoopMetrics
+	}
+
 	return &DriverEngine{
 		Ctx:                    ctx,
 		Cfg:                    cfg,
@@ -103,6 +196,11 @@ func NewDriverEngine(ctx context.Context, cfg *DriverEngineConfig) (*DriverEngin
 		RawDappLinkVrfContract: rawDappLinkVrfContract,
 		DappLinkVrfContractAbi: dappLinkVrfContractAbi,
 		TxMgr:                  txManager,
+		GasPricer:              gasPricer,
+		signer:                 signer,
+		nonces:                 txManager.Nonces(),
+		metrics:                metrics,
+		gasLimits:              make(map[int]uint64),
 		cancel:                 cancel,
 	}, nil
 }
@@ -111,18 +209,8 @@ func NewDriverEngine(ctx context.Context, cfg *DriverEngineConfig) (*DriverEngin
 // 构建一个新的交易，复用旧交易的数据（如 nonce 和 data） 用于重新估算 gas
 
 func (de *DriverEngine) UpdateGasPrice(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
-	var opts *bind.TransactOpts
-	var err error
-	// 创建交易配置对象
-	opts, err = bind.NewKeyedTransactorWithChainID(de.Cfg.PrivateKey, de.Cfg.ChainId)
-	// 失败处理
-	if err != nil {
-		log.Error("new keyed transactor with chain id fail", "err", err)
-		return nil, err
-	}
-
-	// 设置交易上下文、nonce、标记为不发送
-	opts.Context = ctx
+	// 创建交易配置对象，设置交易上下文、nonce、标记为不发送
+	opts := de.transactOpts(ctx)
 	// 使用旧交易的 nonce，确保它是同一笔交易的替代
 	/**
 	Nonce 是一个指针类型 *big.Int nonce 通常是 uint64。但是ABI通用处理大数，所以统一使用 *big.Int
@@ -131,6 +219,11 @@ func (de *DriverEngine) UpdateGasPrice(ctx context.Context, tx *types.Transactio
 	opts.Nonce = new(big.Int).SetUint64(tx.Nonce())
 	// 表示只构造交易，不发送到链上
 	opts.NoSend = true
+	// 按 fee history 定价
+	if err := de.setFees(ctx, opts); err != nil {
+		log.Error("suggest fees fail", "err", err)
+		return nil, err
+	}
 	// 使用RawTransact构造一个新的裸交易（原始交易数据 tx.Data()）
 	// 这一步会根据链上情况自动设置 GasFeeCap 和 GasTipCap
 	findalTx, err := de.RawDappLinkVrfContract.RawTransact(opts, tx.Data())
@@ -139,7 +232,7 @@ func (de *DriverEngine) UpdateGasPrice(ctx context.Context, tx *types.Transactio
 	case err == nil:
 		return findalTx, nil
 	case de.isMaxPriorityFeePerGasNotFoundError(err):
-		// 如果链上节点 不支持 EIP-1559，老节点不支持eth_maxPriorityFeePerGas，就使用预设的 FallbackGasTipCap 再试一次
+		// 兜底：bindings 自行定价时老节点不支持 eth_maxPriorityFeePerGas，使用预设的 FallbackGasTipCap 再试一次，这类链应配置为 legacy 模式
 		log.Info("Don't support priority fee")
 		opts.GasTipCap = FallbackGasTipCap
 		return de.RawDappLinkVrfContract.RawTransact(opts, tx.Data())
@@ -148,6 +241,13 @@ func (de *DriverEngine) UpdateGasPrice(ctx context.Context, tx *types.Transactio
 	}
 }
 
+// 后台检测并救援卡住的交易，阻塞直到 ctx 结束
+func (de *DriverEngine) MonitorStuckTxs(ctx context.Context) {
+	if mgr, ok := de.TxMgr.(*txmgr.SimpleTxManager); ok {
+		mgr.MonitorStuckTxs(ctx)
+	}
+}
+
 func (de *DriverEngine) SendTransaction(ctx context.Context, tx *types.Transaction) error {
 	return de.Cfg.ChainClient.SendTransaction(ctx, tx)
 }
@@ -156,28 +256,44 @@ func (de *DriverEngine) isMaxPriorityFeePerGasNotFoundError(err error) bool {
 	return strings.Contains(err.Error(), errMaxPriorityFeePerGasNotFound.Error())
 }
 
-func (de *DriverEngine) fulfillRandomWords(ctx context.Context, requestId *big.Int, randomList []*big.Int) (*types.Transaction, error) {
-	// 通过链上的 RPC 获取当前调用者地址的 nonce
-	nonce, err := de.Cfg.ChainClient.NonceAt(ctx, de.Cfg.CallerAddress, nil)
-	if err != nil {
-		log.Error("get nonce error", "err", err)
-		return nil, err
+func (de *DriverEngine) fulfillRandomWords(ctx context.Context, vrfAddress common.Address, requestId *big.Int, randomList []*big.Int) (tx *types.Transaction, err error) {
+	// 代理合约与 VRF 合约使用相同的 ABI
+	contract := de.DappLinkVrfContract
+	if vrfAddress != de.Cfg.DappLinkVrfAddress {
+		if contract, err = bindings.NewDappLinkVRF(vrfAddress, de.Cfg.ChainClient); err != nil {
+			return nil, err
+		}
 	}
-	// 创建交易配置对象
-	opts, err := bind.NewKeyedTransactorWithChainID(de.Cfg.PrivateKey, de.Cfg.ChainId)
+	// 预留 nonce：以链上 pending nonce 为准并跳过本进程已分配的 nonce，连续回填不会重复使用
+	nonce, err := de.nonces.Reserve(ctx)
 	if err != nil {
-		log.Error("new keyed transactor with chain id fail", "err", err)
+		log.Error("get nonce error", "err", err)
 		return nil, err
 	}
-
-	// 设置上下文，用于取消/超时控制
-	opts.Context = ctx
+	defer func() {
+		if err != nil {
+			de.abandonNonce(nonce)
+		}
+	}()
+	// 创建交易配置对象，设置上下文，用于取消/超时控制
+	opts := de.transactOpts(ctx)
 	// 明确指定这笔交易的 nonce
 	opts.Nonce = new(big.Int).SetUint64(nonce)
 	// 不直接发送交易，只构造交易（用于手动估算 gas, 设置 fee cap 等）
 	opts.NoSend = true
+	// 显式估算 gas 并留出余量
+	opts.GasLimit, err = de.fulfillGasLimit(ctx, vrfAddress, requestId, randomList)
+	if err != nil {
+		log.Error("estimate gas fail", "err", err)
+		return nil, err
+	}
+	// 按 fee history 定价
+	if err = de.setFees(ctx, opts); err != nil {
+		log.Error("suggest fees fail", "err", err)
+		return nil, err
+	}
 
-	tx, err := de.DappLinkVrfContract.FulfillRandomWords(opts, requestId, randomList)
+	tx, err = contract.FulfillRandomWords(opts, requestId, randomList)
 	switch {
 	case err == nil:
 		return tx, nil
@@ -185,29 +301,118 @@ func (de *DriverEngine) fulfillRandomWords(ctx context.Context, requestId *big.I
 	case de.isMaxPriorityFeePerGasNotFoundError(err):
 		log.Info("Don't support priority fee")
 		opts.GasTipCap = FallbackGasTipCap
-		return de.DappLinkVrfContract.FulfillRandomWords(opts, requestId, randomList)
+		return contract.FulfillRandomWords(opts, requestId, randomList)
 
 	default:
 		return nil, err
 	}
 }
 
+// 是否为演练模式，演练模式只模拟执行回填交易，不广播
+func (de *DriverEngine) DryRun() bool {
+	return de.Cfg.DryRun
+}
+
 func (de *DriverEngine) FulfillRandomWords(requestId *big.Int, randomList []*big.Int) (*types.Receipt, error) {
-	tx, err := de.fulfillRandomWords(de.Ctx, requestId, randomList)
+	return de.FulfillRandomWordsAt(de.Cfg.DappLinkVrfAddress, requestId, randomList)
+}
+
+// 回填 vrfAddress（VRF 合约或工厂创建的代理）上的请求
+func (de *DriverEngine) FulfillRandomWordsAt(vrfAddress common.Address, requestId *big.Int, randomList []*big.Int) (*types.Receipt, error) {
+	if de.balancePaused.Load() {
+		return nil, ErrBalanceTooLow
+	}
+	tx, err := de.fulfillRandomWords(de.Ctx, vrfAddress, requestId, randomList)
 	if err != nil {
-		log.Error("build request random words tx fail", "err", err)
-		return nil, err
+		log.Error("build request random words tx fail", "vrfAddress", vrfAddress, "err", err)
+		return nil, fmt.Errorf("%w: %w", ErrTxNotSent, err)
+	}
+	return de.send(tx, requestId.String(), &RequestMetadata{RequestIds: []*big.Int{requestId}, VrfAddress: vrfAddress})
+}
+
+// 对构造好的交易附加 access list、模拟执行后交给交易管理器发送，correlationID 用于追踪交易日志
+// metadata 为回填交易对应的请求，随 ctx 传给交易生命周期回调，非回填交易为 nil
+func (de *DriverEngine) send(tx *types.Transaction, correlationID string, metadata *RequestMetadata) (*types.Receipt, error) {
+	if de.Cfg.UseAccessList {
+		tx = de.applyAccessList(de.Ctx, tx)
+	}
+
+	// 模拟执行失败时不广播
+	if err := de.simulate(de.Ctx, tx); err != nil {
+		log.Error("simulate tx fail", "correlationId", correlationID, "err", err)
+		de.abandonNonce(tx.Nonce())
+		return nil, fmt.Errorf("%w: %w", ErrTxNotSent, err)
+	}
+
+	// 由 GasPricer 根据 fee history 定价，重发时自动提价
+	// legacy 模式下由 txmgr 按 eth_gasPrice 重新定价，只使用候选交易的 nonce、gas、调用数据和 access list
+	updateGasPrice := de.GasPricer.UpdateGasPriceFunc(tx, de.signer)
+	if de.Cfg.TxType == txmgr.LegacyTxType {
+		updateGasPrice = func(ctx context.Context) (*types.Transaction, error) {
+			return tx, nil
+		}
 	}
 
-	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
-		return de.UpdateGasPrice(ctx, tx)
+	// 以 correlationID（回填时为 requestId）作为关联 ID，便于按请求追踪交易日志
+	ctx := txmgr.WithCorrelationID(de.Ctx, correlationID)
+	if metadata != nil {
+		ctx = WithRequestMetadata(ctx, metadata)
 	}
 
 	// 使用状态管理器：自动构造+动态提价+重试发送+等待确认
-	receipt, err := de.TxMgr.Send(de.Ctx, updateGasPrice, de.SendTransaction)
+	receipt, err := de.TxMgr.Send(ctx, updateGasPrice, de.SendTransaction)
+	var revertErr *txmgr.ErrTxReverted
+	switch {
+	case errors.As(err, &revertErr):
+		de.nonces.Done(tx.Nonce())
+		// 执行失败的交易同样消耗 gas
+		de.recordTxCost(tx, revertErr.Receipt)
+		// 按 DappLinkVRF ABI 重新解析，补充自定义错误
+		_, revertErr.Reason = de.RevertReason(err)
+	case err != nil:
+		// 交易可能没有上链，其他回填都结束后重新以链上 pending nonce 为准
+		de.nonces.Done(tx.Nonce())
+		de.nonces.Reset()
+	case de.Cfg.DryRun:
+		// 演练模式不广播，nonce 没有被使用
+		de.nonces.Release(tx.Nonce())
+	default:
+		de.nonces.Done(tx.Nonce())
+		de.recordTxCost(tx, receipt)
+	}
 	if err != nil {
 		log.Error("send tx fail", "err", err)
 		return nil, err
 	}
 	return receipt, nil
 }
+
+// 放弃预留后没有广播的 nonce：后面没有其他预留时直接归还
+// 否则后面的交易在该 nonce 被占用前无法上链，在后台发送同 nonce 的 0 值自转账填补，失败时在途交易结束后以链上 pending nonce 为准
+func (de *DriverEngine) abandonNonce(nonce uint64) {
+	if de.nonces.ReleaseIfLast(nonce) {
+		return
+	}
+	go func() {
+		log.Warn("filling abandoned nonce with a cancel tx", "nonce", nonce)
+		if _, err := de.TxMgr.Cancel(de.Ctx, nonce); err != nil {
+			log.Error("fill abandoned nonce fail", "nonce", nonce, "err", err)
+			de.nonces.Done(nonce)
+			de.nonces.Reset()
+			return
+		}
+		de.nonces.Done(nonce)
+	}()
+}
+
+// 按交易目标合约累计已上链交易的花费，回执没有 effectiveGasPrice 时只累计 gas 用量
+func (de *DriverEngine) recordTxCost(tx *types.Transaction, receipt *types.Receipt) {
+	if receipt == nil || tx.To() == nil {
+		return
+	}
+	fee := new(big.Int)
+	if receipt.EffectiveGasPrice != nil {
+		fee.Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	}
+	de.metrics.RecordTxCost(*tx.To(), receipt.GasUsed, fee)
+}
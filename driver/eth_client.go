@@ -5,10 +5,16 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
-func EthClientWithTimeout(ctx context.Context, url string) (*ethclient.Client, error) {
+// 连接节点，opts 用于私有节点的认证请求头和自定义 HTTP 客户端
+func EthClientWithTimeout(ctx context.Context, url string, opts ...rpc.ClientOption) (*ethclient.Client, error) {
 	ctxt, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
-	return ethclient.DialContext(ctxt, url)
+	client, err := rpc.DialOptions(ctxt, url, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(client), nil
 }
@@ -0,0 +1,53 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WJX2001/contract-caller/txmgr"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+/*
+	构造交易时的费用：
+		- EIP-1559 链上由 GasPricer 按 eth_feeHistory 百分位给出 gasTipCap，gasFeeCap 为下一个区块 baseFee 乘以余量倍数加上小费
+		- 显式设置费用后 bindings 不再调用 eth_maxPriorityFeePerGas
+		- legacy 模式（配置为不支持 EIP-1559 的链）直接按 eth_gasPrice 设置 gasPrice，bindings 构造 legacy 交易
+		- 只有 bindings 自行定价时才会调用 eth_maxPriorityFeePerGas，节点不支持时按错误信息识别并退回 FallbackGasTipCap
+*/
+
+// 按 fee history 设置 opts 的 gasTipCap 和 gasFeeCap，legacy 模式下设置 gasPrice
+func (de *DriverEngine) setFees(ctx context.Context, opts *bind.TransactOpts) error {
+	if de.Cfg.TxType == txmgr.LegacyTxType {
+		gasPrice, err := de.Cfg.ChainClient.SuggestGasPrice(ctx)
+		if err != nil {
+			return err
+		}
+		opts.GasPrice = gasPrice
+		return nil
+	}
+	gasTipCap, gasFeeCap, err := de.GasPricer.SuggestFees(ctx)
+	if err != nil {
+		return err
+	}
+	opts.GasTipCap, opts.GasFeeCap = gasTipCap, gasFeeCap
+	return nil
+}
+
+// 按配置创建 GasPricer，0 值使用默认配置
+func newGasPricer(cfg *DriverEngineConfig) (*txmgr.GasPricer, error) {
+	pricerConfig := txmgr.DefaultGasPricerConfig
+	if cfg.TipPercentile > 0 {
+		pricerConfig.TipPercentile = cfg.TipPercentile
+	}
+	if cfg.BaseFeeMultiplier > 0 {
+		pricerConfig.BaseFeeMultiplier = cfg.BaseFeeMultiplier
+	}
+	if pricerConfig.TipPercentile > 100 {
+		return nil, fmt.Errorf("tip percentile %v must be within [0, 100]", pricerConfig.TipPercentile)
+	}
+	if pricerConfig.BaseFeeMultiplier < 1 {
+		return nil, fmt.Errorf("base fee multiplier %v must be at least 1", pricerConfig.BaseFeeMultiplier)
+	}
+	return txmgr.NewGasPricer(pricerConfig, cfg.ChainClient), nil
+}
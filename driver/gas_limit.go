@@ -0,0 +1,77 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	回填交易的 gas 上限：
+		- 通过 eth_estimateGas 估算，乘以 GasLimitMultiplier 留出余量，超过 MaxGasLimit 时截断到 MaxGasLimit
+		- 估算值本身超过 MaxGasLimit 时交易必然失败，直接返回错误，不发送
+		- 估算时执行回滚返回 ErrSimulationReverted，RPC 失败等其他错误原样返回
+		- 回填交易的 gas 主要取决于随机数个数，按个数缓存估算结果，个数变化时重新估算；估算失败时不缓存
+*/
+
+// 默认的 gas 余量倍数
+const defaultGasLimitMultiplier = 1.2
+
+// 估算回填 wordCount 个随机数的交易的 gas 上限，代理合约与 VRF 合约共用缓存
+func (de *DriverEngine) fulfillGasLimit(ctx context.Context, vrfAddress common.Address, requestId *big.Int, randomList []*big.Int) (uint64, error) {
+	wordCount := len(randomList)
+	de.gasLimitLock.Lock()
+	limit, ok := de.gasLimits[wordCount]
+	de.gasLimitLock.Unlock()
+	if ok {
+		return limit, nil
+	}
+
+	data, err := de.DappLinkVrfContractAbi.Pack("fulfillRandomWords", requestId, randomList)
+	if err != nil {
+		return 0, err
+	}
+	estimate, err := de.Cfg.ChainClient.EstimateGas(ctx, ethereum.CallMsg{
+		From: de.Cfg.Signer.Address(),
+		To:   &vrfAddress,
+		Data: data,
+	})
+	if err != nil {
+		// 估算时执行回滚说明交易必然失败，其他错误原样返回，下一轮重试
+		if !isExecutionReverted(err) {
+			return 0, err
+		}
+		return 0, de.simulationError(vrfAddress, err)
+	}
+	limit, err = de.gasLimit(estimate)
+	if err != nil {
+		return 0, err
+	}
+	log.Info("estimated fulfill random words gas", "words", wordCount, "estimate", estimate, "gasLimit", limit)
+
+	de.gasLimitLock.Lock()
+	de.gasLimits[wordCount] = limit
+	de.gasLimitLock.Unlock()
+	return limit, nil
+}
+
+// 按配置的倍数和上限把估算值转为交易的 gas 上限
+func (de *DriverEngine) gasLimit(estimate uint64) (uint64, error) {
+	if de.Cfg.MaxGasLimit > 0 && estimate > de.Cfg.MaxGasLimit {
+		return 0, fmt.Errorf("estimated gas %d exceeds max gas limit %d", estimate, de.Cfg.MaxGasLimit)
+	}
+	multiplier := de.Cfg.GasLimitMultiplier
+	if multiplier <= 0 {
+		multiplier = defaultGasLimitMultiplier
+	}
+	limit := uint64(math.Ceil(float64(estimate) * multiplier))
+	if de.Cfg.MaxGasLimit > 0 && limit > de.Cfg.MaxGasLimit {
+		limit = de.Cfg.MaxGasLimit
+	}
+	return limit, nil
+}
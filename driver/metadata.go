@@ -0,0 +1,32 @@
+package driver
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+/*
+	交易的业务上下文：
+		- 回填交易发送时把请求 ID 和请求所在的 VRF 合约（或代理）放进传给 txmgr 的 ctx
+		- txmgr 的生命周期回调（DriverEngineConfig.OnTxPublished 等）通过 RequestMetadataFromContext 取出，按请求记录每个交易哈希
+		- 批量回填时一笔交易对应多个请求；部署代理等非回填交易没有业务上下文
+*/
+
+type RequestMetadata struct {
+	RequestIds []*big.Int
+	VrfAddress common.Address
+}
+
+type requestMetadataKey struct{}
+
+func WithRequestMetadata(ctx context.Context, metadata *RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey{}, metadata)
+}
+
+// 返回 ctx 中的业务上下文，不是回填交易时返回 nil
+func RequestMetadataFromContext(ctx context.Context) *RequestMetadata {
+	metadata, _ := ctx.Value(requestMetadataKey{}).(*RequestMetadata)
+	return metadata
+}
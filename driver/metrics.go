@@ -0,0 +1,100 @@
+package driver
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+	驱动引擎的指标采集：
+		- 调用者地址的余额（ether），以及是否因余额不足暂停回填
+		- 按交易目标合约累计已上链交易的 gas 用量和花费（ether），执行失败的交易同样计入
+	DriverEngineConfig.Metrics 为空时不采集，主程序通过 PrometheusMetrics.Collectors 注册到自己的 registry
+*/
+
+type Metrics interface {
+	RecordBalance(balance *big.Int)                                     // 调用者地址的余额（wei）
+	RecordPaused(paused bool)                                           // 是否因余额低于下限暂停回填
+	RecordTxCost(contract common.Address, gasUsed uint64, fee *big.Int) // 一笔已上链交易的 gas 用量和花费（wei）
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) RecordBalance(*big.Int)                        {}
+func (noopMetrics) RecordPaused(bool)                             {}
+func (noopMetrics) RecordTxCost(common.Address, uint64, *big.Int) {}
+
+var NoopMetrics Metrics = noopMetrics{}
+
+type PrometheusMetrics struct {
+	balance prometheus.Gauge
+	paused  prometheus.Gauge
+	gasUsed *prometheus.CounterVec
+	fees    *prometheus.CounterVec
+}
+
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	const subsystem = "driver"
+	return &PrometheusMetrics{
+		balance: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "caller_balance_ether",
+			Help:      "Balance of the caller address in ether",
+		}),
+		paused: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "fulfillments_paused",
+			Help:      "1 when fulfillments are paused because the caller balance is below the minimum",
+		}),
+		gasUsed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "gas_used_total",
+			Help:      "Gas used by mined transactions, by target contract",
+		}, []string{"contract"}),
+		fees: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "fees_paid_ether_total",
+			Help:      "Fees paid for mined transactions in ether, by target contract",
+		}, []string{"contract"}),
+	}
+}
+
+// 返回全部指标，供主程序注册
+func (m *PrometheusMetrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.balance,
+		m.paused,
+		m.gasUsed,
+		m.fees,
+	}
+}
+
+func (m *PrometheusMetrics) RecordBalance(balance *big.Int) {
+	m.balance.Set(toEther(balance))
+}
+
+func (m *PrometheusMetrics) RecordPaused(paused bool) {
+	if paused {
+		m.paused.Set(1)
+	} else {
+		m.paused.Set(0)
+	}
+}
+
+func (m *PrometheusMetrics) RecordTxCost(contract common.Address, gasUsed uint64, fee *big.Int) {
+	m.gasUsed.WithLabelValues(contract.Hex()).Add(float64(gasUsed))
+	m.fees.WithLabelValues(contract.Hex()).Add(toEther(fee))
+}
+
+func toEther(wei *big.Int) float64 {
+	ether, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether)).Float64()
+	return ether
+}
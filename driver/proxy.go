@@ -0,0 +1,92 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/bindings"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	通过工厂合约部署 VRF 代理：
+		- 调用 DappLinkVRFFactory.createProxy(implementation, dapplinkAddress)，与回填交易一样经过模拟执行和交易管理器发送
+		- 从回执中解析 ProxyCreated 事件得到代理地址，由调用方写入代理表，同步器下一批区块即开始监听
+*/
+
+// 部署代理的交易上链但回执中没有 ProxyCreated 事件
+var errProxyCreatedNotFound = errors.New("driver: ProxyCreated event not found in receipt")
+
+// 部署一个以 implementation 为实现合约、dapplinkAddress 为回填方的 VRF 代理，返回代理地址和交易回执
+func (de *DriverEngine) DeployProxy(implementation common.Address, dapplinkAddress common.Address) (common.Address, *types.Receipt, error) {
+	if de.Cfg.DappLinkVrfFactoryAddress == (common.Address{}) {
+		return common.Address{}, nil, errors.New("driver: factory address is not configured")
+	}
+	factory, err := bindings.NewDappLinkVRFFactory(de.Cfg.DappLinkVrfFactoryAddress, de.Cfg.ChainClient)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	tx, err := de.createProxy(de.Ctx, factory, implementation, dapplinkAddress)
+	if err != nil {
+		log.Error("build create proxy tx fail", "err", err)
+		return common.Address{}, nil, err
+	}
+	receipt, err := de.send(tx, fmt.Sprintf("create-proxy-%s", implementation), nil)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		reason, _ := de.ReceiptRevertReason(de.Ctx, receipt)
+		return common.Address{}, receipt, fmt.Errorf("create proxy tx %s reverted: %s", receipt.TxHash, reason)
+	}
+
+	for _, receiptLog := range receipt.Logs {
+		if receiptLog.Address != de.Cfg.DappLinkVrfFactoryAddress {
+			continue
+		}
+		created, err := factory.ParseProxyCreated(*receiptLog)
+		if err != nil {
+			continue
+		}
+		log.Info("deployed vrf proxy", "proxy", created.MintProxyAddress, "implementation", implementation, "tx", receipt.TxHash)
+		return created.MintProxyAddress, receipt, nil
+	}
+	return common.Address{}, receipt, errProxyCreatedNotFound
+}
+
+// 构造调用 createProxy 的交易（未发送）
+func (de *DriverEngine) createProxy(ctx context.Context, factory *bindings.DappLinkVRFFactory, implementation common.Address, dapplinkAddress common.Address) (tx *types.Transaction, err error) {
+	nonce, err := de.nonces.Reserve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			de.abandonNonce(nonce)
+		}
+	}()
+
+	opts := de.transactOpts(ctx)
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	opts.NoSend = true
+	if err = de.setFees(ctx, opts); err != nil {
+		return nil, err
+	}
+
+	tx, err = factory.CreateProxy(opts, implementation, dapplinkAddress)
+	switch {
+	case err == nil:
+		return tx, nil
+	case de.isMaxPriorityFeePerGasNotFoundError(err):
+		log.Info("Don't support priority fee")
+		opts.GasTipCap = FallbackGasTipCap
+		return factory.CreateProxy(opts, implementation, dapplinkAddress)
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,104 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/WJX2001/contract-caller/txmgr"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+/*
+	回滚原因解析：
+		- 从模拟执行、txmgr 的 ErrTxReverted 或 eth_call 的错误中取出 revert data
+		- 依次按 Error(string)、Panic(uint256) 和 DappLinkVRF ABI 中的自定义错误解析，自定义错误格式化为 Name(arg, ...)
+		- 执行失败的回执通过在所在区块上重放交易取得 revert data
+		- 无法解析时返回节点给出的错误信息，没有 revert data 时为空
+*/
+
+// 解析 err 中的回滚原因，返回原始 revert data 和可读的原因
+func (de *DriverEngine) RevertReason(err error) ([]byte, string) {
+	var data []byte
+	var simErr *ErrSimulationReverted
+	var revertErr *txmgr.ErrTxReverted
+	var dataErr rpc.DataError
+	switch {
+	case errors.As(err, &simErr):
+		data = simErr.RevertData
+	case errors.As(err, &revertErr):
+		data = revertErr.RevertData
+	case errors.As(err, &dataErr):
+		if hexData, ok := dataErr.ErrorData().(string); ok {
+			data, _ = hexutil.Decode(hexData)
+		}
+	}
+
+	if reason, ok := de.decodeRevert(data); ok {
+		return data, reason
+	}
+	switch {
+	case simErr != nil && simErr.Reason != "":
+		return data, simErr.Reason
+	case revertErr != nil && revertErr.Reason != "":
+		return data, revertErr.Reason
+	}
+	return data, err.Error()
+}
+
+// 在回执所在区块上重放执行失败的交易，解析回滚原因；回执成功时返回空
+func (de *DriverEngine) ReceiptRevertReason(ctx context.Context, receipt *types.Receipt) (string, error) {
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return "", nil
+	}
+	tx, _, err := de.Cfg.ChainClient.TransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		return "", fmt.Errorf("query reverted tx %s: %w", receipt.TxHash, err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		from = de.Cfg.Signer.Address()
+	}
+	_, err = de.Cfg.ChainClient.CallContract(ctx, ethereum.CallMsg{
+		From:       from,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}, receipt.BlockNumber)
+	if err == nil {
+		return "", nil
+	}
+	_, reason := de.RevertReason(err)
+	return reason, nil
+}
+
+// 按 Error(string)、Panic(uint256) 和 ABI 中的自定义错误解析 revert data
+func (de *DriverEngine) decodeRevert(data []byte) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason, true
+	}
+	abiErr, err := de.DappLinkVrfContractAbi.ErrorByID([4]byte(data[:4]))
+	if err != nil {
+		return "", false
+	}
+	unpacked, err := abiErr.Unpack(data)
+	if err != nil {
+		return abiErr.Name, true
+	}
+	args, _ := unpacked.([]interface{})
+	formatted := make([]string, 0, len(args))
+	for _, arg := range args {
+		formatted = append(formatted, fmt.Sprint(arg))
+	}
+	return fmt.Sprintf("%s(%s)", abiErr.Name, strings.Join(formatted, ", ")), true
+}
@@ -0,0 +1,64 @@
+package driver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/*
+	交易签名：
+		- 驱动引擎只通过 Signer 获取发送地址和签名交易，构造交易的代码不接触私钥
+		- 本地私钥使用 NewPrivateKeySigner，KMS、远程签名服务等实现 Signer 接口即可接入
+*/
+
+// 发送回填交易的账户
+type Signer interface {
+	// 发送交易的地址
+	Address() common.Address
+	// 签名交易，返回签名后的交易
+	SignTx(ctx context.Context, tx *types.Transaction) (*types.Transaction, error)
+}
+
+// 使用本地私钥签名
+type privateKeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+	signer  types.Signer
+}
+
+func NewPrivateKeySigner(key *ecdsa.PrivateKey, chainId *big.Int) Signer {
+	return &privateKeySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+		signer:  types.LatestSignerForChainID(chainId),
+	}
+}
+
+func (s *privateKeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *privateKeySigner) SignTx(_ context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	return types.SignTx(tx, s.signer, s.key)
+}
+
+// 构造由 Signer 签名的交易配置
+func (de *DriverEngine) transactOpts(ctx context.Context) *bind.TransactOpts {
+	from := de.Cfg.Signer.Address()
+	return &bind.TransactOpts{
+		From:    from,
+		Context: ctx,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return de.Cfg.Signer.SignTx(ctx, tx)
+		},
+	}
+}
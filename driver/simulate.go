@@ -0,0 +1,66 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+/*
+	广播前模拟执行：
+		- 构造好的回填交易先在最新区块上通过 eth_call 执行一次，回滚时不广播，返回带解析原因的 ErrSimulationReverted
+		- 避免请求已被回填、随机数个数不符等必然失败的交易消耗 gas，或占用 nonce 卡住后续交易
+		- eth_estimateGas 同样会执行交易，估算阶段的回滚也按模拟失败处理
+		- 只有节点返回 revert data 或 "execution reverted" 时才视为回滚；连接失败、超时、5xx 等错误原样返回，由调用方重试
+*/
+
+// 交易在广播前模拟执行时回滚
+type ErrSimulationReverted struct {
+	To         common.Address
+	Reason     string // 解析出的回滚原因，无法解析时为节点给出的错误信息
+	RevertData []byte // eth_call 返回的原始 revert data
+}
+
+func (e *ErrSimulationReverted) Error() string {
+	return fmt.Sprintf("driver: simulated call to %s reverted: %s", e.To, e.Reason)
+}
+
+// 在最新区块上模拟执行 tx
+func (de *DriverEngine) simulate(ctx context.Context, tx *types.Transaction) error {
+	msg := ethereum.CallMsg{
+		From:       de.Cfg.Signer.Address(),
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}
+	_, err := de.Cfg.ChainClient.CallContract(ctx, msg, nil)
+	if err == nil || !isExecutionReverted(err) {
+		return err
+	}
+	return de.simulationError(*tx.To(), err)
+}
+
+// 判断 eth_call / eth_estimateGas 的错误是否为执行回滚，而不是 RPC 本身的失败
+func isExecutionReverted(err error) bool {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) && dataErr.ErrorData() != nil {
+		return true
+	}
+	return strings.Contains(err.Error(), vm.ErrExecutionReverted.Error())
+}
+
+// 由 eth_call / eth_estimateGas 的回滚错误构造 ErrSimulationReverted
+func (de *DriverEngine) simulationError(to common.Address, err error) error {
+	simErr := &ErrSimulationReverted{To: to}
+	simErr.RevertData, simErr.Reason = de.RevertReason(err)
+	return simErr
+}
@@ -0,0 +1,91 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/bindings"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+/*
+	VRF 合约的只读查询：
+		- RequestStatus 查询请求是否已回填及回填的随机数，工作器在回填前后用它与数据库中的状态核对
+		- PendingRequestIds 按下标遍历合约的 requestIds 数组，返回尚未回填的请求；合约没有提供数组长度，越界调用回滚时视为遍历结束
+		- vrfAddress 可以是 VRF 合约或工厂创建的代理，零地址使用配置的 VRF 合约
+		- HasPendingTxs 比较调用者的 pending 和 latest nonce，判断是否还有已广播未上链的交易
+*/
+
+// 链上请求状态
+type RequestStatus struct {
+	Fulfilled   bool
+	RandomWords []*big.Int
+}
+
+// 查询 vrfAddress 上请求 requestId 的状态
+func (de *DriverEngine) RequestStatus(ctx context.Context, vrfAddress common.Address, requestId *big.Int) (*RequestStatus, error) {
+	caller, err := de.vrfCaller(vrfAddress)
+	if err != nil {
+		return nil, err
+	}
+	status, err := caller.GetRequestStatus(&bind.CallOpts{Context: ctx}, requestId)
+	if err != nil {
+		return nil, err
+	}
+	return &RequestStatus{Fulfilled: status.Fulfilled, RandomWords: status.RandomWords}, nil
+}
+
+// 从下标 from 开始最多检查 limit 个请求，返回其中尚未回填的请求 ID 和下一次遍历的起始下标
+func (de *DriverEngine) PendingRequestIds(ctx context.Context, vrfAddress common.Address, from uint64, limit int) ([]*big.Int, uint64, error) {
+	caller, err := de.vrfCaller(vrfAddress)
+	if err != nil {
+		return nil, from, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+
+	var pending []*big.Int
+	index := from
+	for ; index < from+uint64(limit); index++ {
+		requestId, err := caller.RequestIds(opts, new(big.Int).SetUint64(index))
+		if err != nil {
+			// 越界访问数组会回滚，说明已经遍历到末尾
+			var dataErr rpc.DataError
+			if errors.As(err, &dataErr) {
+				break
+			}
+			return pending, index, err
+		}
+		fulfilled, err := caller.RequestMapping(opts, requestId)
+		if err != nil {
+			return pending, index, err
+		}
+		if !fulfilled {
+			pending = append(pending, requestId)
+		}
+	}
+	return pending, index, nil
+}
+
+func (de *DriverEngine) vrfCaller(vrfAddress common.Address) (*bindings.DappLinkVRFCaller, error) {
+	if vrfAddress == (common.Address{}) {
+		vrfAddress = de.Cfg.DappLinkVrfAddress
+	}
+	return bindings.NewDappLinkVRFCaller(vrfAddress, de.Cfg.ChainClient)
+}
+
+// 调用者是否还有已广播但未上链的交易
+func (de *DriverEngine) HasPendingTxs(ctx context.Context) (bool, error) {
+	address := de.Cfg.Signer.Address()
+	pending, err := de.Cfg.ChainClient.PendingNonceAt(ctx, address)
+	if err != nil {
+		return false, err
+	}
+	latest, err := de.Cfg.ChainClient.NonceAt(ctx, address, nil)
+	if err != nil {
+		return false, err
+	}
+	return pending > latest, nil
+}
@@ -0,0 +1,21 @@
+package contracts
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/database/event"
+)
+
+// 查询事件所在区块的高度
+// 存储的 RLP 日志不包含区块高度，需要通过区块哈希从 block_headers 查询
+func eventBlockNumber(db *database.DB, contractEvent event.ContractEvent) (*big.Int, error) {
+	header, err := db.Blocks.BlockHeader(contractEvent.BlockHash)
+	if err != nil {
+		return nil, err
+	} else if header == nil {
+		return nil, fmt.Errorf("block header %s of contract event %s not found", contractEvent.BlockHash, contractEvent.GUID)
+	}
+	return header.Number, nil
+}
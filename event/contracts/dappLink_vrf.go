@@ -1,7 +1,6 @@
 package contracts
 
 import (
-	"math/big"
 	"time"
 
 	"github.com/WJX2001/contract-caller/bindings"
@@ -39,58 +38,92 @@ func NewDappLinkVrf() (*DappLinkVrf, error) {
 	}, nil
 }
 
-func (dvf *DappLinkVrf) ProcessDappLinkVrfEvent(db *database.DB, dappLinkVrfAddres string, startHeight, endHeight *big.Int) ([]worker.RequestSend, []worker.FillRandomWords, error) {
-	var RequestSentList []worker.RequestSend
-	var FillRandomWordList []worker.FillRandomWords
+// 注册 VRF 合约的 RequestSent 和 FillRandomWords 事件处理函数
+func (dvf *DappLinkVrf) RegisterHandlers(registry *Registry, dappLinkVrfAddress common.Address) error {
+	addresses := []common.Address{dappLinkVrfAddress}
+	if err := registry.Register(addresses, dvf.DlVrfAbi, "RequestSent", dvf.handleRequestSent); err != nil {
+		return err
+	}
+	return registry.Register(addresses, dvf.DlVrfAbi, "FillRandomWords", dvf.handleFillRandomWords)
+}
 
-	// 查询原始事件
-	contactFilter := event.ContractEvent{ContractAddress: common.HexToAddress(dappLinkVrfAddres)}
-	contractEventList, err := db.ContractEvent.ContractEventsWithFilter(contactFilter, startHeight, endHeight)
+// 解析 RequestSent 事件，转为待处理的随机数请求
+func (dvf *DappLinkVrf) handleRequestSent(db *database.DB, contractEvent event.ContractEvent) (StoreFunc, error) {
+	rquestSentEvent, err := dvf.DlVrfFilter.ParseRequestSent(*contractEvent.RLPLog)
+	if err != nil {
+		log.Error("parse request sent fail", "err", err)
+		return nil, err
+	}
+	log.Info("Request sent event", "RequestId", rquestSentEvent.RequestId, "NumWords", rquestSentEvent.NumWords, "Current", rquestSentEvent.Current)
+	blockNumber, err := eventBlockNumber(db, contractEvent)
 	if err != nil {
-		log.Error("query contacts event fail", "err", err)
-		return RequestSentList, FillRandomWordList, err
+		log.Error("query request sent block number fail", "err", err)
+		return nil, err
 	}
+	// 转为业务数据
+	rs := worker.RequestSend{
+		GUID:        uuid.New(),
+		RequestId:   rquestSentEvent.RequestId,
+		VrfAddress:  rquestSentEvent.Current,
+		NumWords:    rquestSentEvent.NumWords,
+		Status:      0, // 未处理状态
+		BlockNumber: blockNumber,
+		// 区块时间用于判断请求是否过期，Timestamp 为写入时间
+		BlockTimestamp:  contractEvent.Timestamp,
+		TransactionHash: &contractEvent.TransactionHash,
+		LogIndex:        &contractEvent.LogIndex,
+		Timestamp:       uint64(time.Now().Unix()),
+	}
+	return func(tx *database.DB, replay bool) error {
+		if replay {
+			return tx.RequestSend.UpsertRequestSend([]worker.RequestSend{rs})
+		}
+		return tx.RequestSend.StoreRequestSend([]worker.RequestSend{rs})
+	}, nil
+}
 
-	for _, contractEvent := range contractEventList {
-		// 解析 RequestSent 事件
-		if contractEvent.EventSignature.String() == dvf.DlVrfAbi.Events["RequestSent"].ID.String() {
-			rquestSentEvent, err := dvf.DlVrfFilter.ParseRequestSent(*contractEvent.RLPLog)
-			if err != nil {
-				log.Error("parse request sent fail", "err", err)
-				return RequestSentList, FillRandomWordList, err
-			}
-			log.Info("Request sent event", "RequestId", rquestSentEvent.RequestId, "NumWords", rquestSentEvent.NumWords, "Current", rquestSentEvent.Current)
-			// 转为业务数据
-			rs := worker.RequestSend{
-				GUID:       uuid.New(),
-				RequestId:  rquestSentEvent.RequestId,
-				VrfAddress: rquestSentEvent.Current,
-				NumWords:   rquestSentEvent.NumWords,
-				Status:     0, // 未处理状态
-				Timestamp:  uint64(time.Now().Unix()),
-			}
-			RequestSentList = append(RequestSentList, rs)
+// 解析 FillRandomWords 事件，记录已回填的随机数
+func (dvf *DappLinkVrf) handleFillRandomWords(db *database.DB, contractEvent event.ContractEvent) (StoreFunc, error) {
+	fillRandomWords, err := dvf.DlVrfFilter.ParseFillRandomWords(*contractEvent.RLPLog)
+	if err != nil {
+		log.Error("parse fill random fail", "err", err)
+		return nil, err
+	}
+	log.Info("Fill random words event", "RequestId", fillRandomWords.RequestId, "RandomWords", fillRandomWords.RandomWords)
+	blockNumber, err := eventBlockNumber(db, contractEvent)
+	if err != nil {
+		log.Error("query fill random words block number fail", "err", err)
+		return nil, err
+	}
+	var randomWords string
+	for _, rword := range fillRandomWords.RandomWords {
+		randomWords = rword.String()
+	}
+	frw := worker.FillRandomWords{
+		GUID:            uuid.New(),
+		RequestId:       fillRandomWords.RequestId,
+		VrfAddress:      contractEvent.ContractAddress,
+		RandomWords:     randomWords,
+		BlockNumber:     blockNumber,
+		TransactionHash: &contractEvent.TransactionHash,
+		LogIndex:        &contractEvent.LogIndex,
+		Timestamp:       uint64(time.Now().Unix()),
+	}
+	return func(tx *database.DB, replay bool) error {
+		store := tx.FillRandomWords.StoreFillRandomWords
+		if replay {
+			store = tx.FillRandomWords.UpsertFillRandomWords
 		}
-		// 解析 FillRandomWords 事件
-		if contractEvent.EventSignature.String() == dvf.DlVrfAbi.Events["FillRandomWords"].ID.String() {
-			fillRandomWords, err := dvf.DlVrfFilter.ParseFillRandomWords(*contractEvent.RLPLog)
-			if err != nil {
-				log.Error("parse fill random fail", "err", err)
-				return RequestSentList, FillRandomWordList, err
-			}
-			log.Info("Fill random words event", "RequestId", fillRandomWords.RequestId, "RandomWords", fillRandomWords.RandomWords)
-			var randomWords string
-			for _, rword := range fillRandomWords.RandomWords {
-				randomWords = rword.String()
-			}
-			frw := worker.FillRandomWords{
-				GUID:        uuid.New(),
-				RequestId:   fillRandomWords.RequestId,
-				RandomWords: randomWords,
-				Timestamp:   uint64(time.Now().Unix()),
-			}
-			FillRandomWordList = append(FillRandomWordList, frw)
+		if err := store([]worker.FillRandomWords{frw}); err != nil {
+			return err
 		}
-	}
-	return RequestSentList, FillRandomWordList, nil
+		// 请求可能由其他调用方回填，以链上事件为准标记为已完成；不同代理的请求 ID 会重复，按触发事件的合约区分
+		updated, err := tx.RequestSend.MarkRequestSendFulfilled(fillRandomWords.RequestId, contractEvent.ContractAddress, contractEvent.TransactionHash, blockNumber)
+		if err != nil {
+			return err
+		} else if updated == 0 {
+			log.Debug("no request sent found for fill random words", "RequestId", fillRandomWords.RequestId, "vrfAddress", contractEvent.ContractAddress)
+		}
+		return nil
+	}, nil
 }
@@ -1,7 +1,6 @@
 package contracts
 
 import (
-	"math/big"
 	"time"
 
 	"github.com/WJX2001/contract-caller/bindings"
@@ -40,32 +39,45 @@ func NewDappLinkVrfFactory() (*DappLinkVrfFactory, error) {
 	}, nil
 }
 
-func (dvff *DappLinkVrfFactory) ProcessDappLinkVrfFactoryEvent(db *database.DB, dappLinkVrfFactoryAddres string, startHeight, endHeight *big.Int) ([]worker.PoxyCreated, error) {
-	var proxyCreatedList []worker.PoxyCreated
-	// 创建合约事件过滤器
-	contactFilter := event.ContractEvent{ContractAddress: common.HexToAddress(dappLinkVrfFactoryAddres)}
-	contractEventList, err := db.ContractEvent.ContractEventsWithFilter(contactFilter, startHeight, endHeight)
+// 注册工厂合约的 ProxyCreated 事件处理函数
+// 新创建的代理合约复用 dappLinkVrfAddress 上的处理函数，同一批中之后由代理合约触发的事件也会被解析，本批写入数据库后才保留
+func (dvff *DappLinkVrfFactory) RegisterHandlers(registry *Registry, dappLinkVrfFactoryAddress common.Address, dappLinkVrfAddress common.Address) error {
+	return registry.Register([]common.Address{dappLinkVrfFactoryAddress}, dvff.DlVrfFactoryAbi, "ProxyCreated", func(db *database.DB, contractEvent event.ContractEvent) (StoreFunc, error) {
+		store, proxyAddress, err := dvff.handleProxyCreated(db, contractEvent)
+		if err != nil {
+			return nil, err
+		}
+		registry.AliasPending(proxyAddress, dappLinkVrfAddress)
+		return store, nil
+	})
+}
+
+// 解析 ProxyCreated 事件，记录新的代理合约地址，同步器随后开始监听该地址
+func (dvff *DappLinkVrfFactory) handleProxyCreated(db *database.DB, contractEvent event.ContractEvent) (StoreFunc, common.Address, error) {
+	// 转为业务模型
+	proxyCreated, err := dvff.DlVrfFactoryFilter.ParseProxyCreated(*contractEvent.RLPLog)
 	if err != nil {
-		log.Error("query contacts event fail", "err", err)
-		return proxyCreatedList, err
+		log.Error("proxy created fail", "err", err)
+		return nil, common.Address{}, err
 	}
-	for _, contractEvent := range contractEventList {
-		// 记录日志
-		if contractEvent.EventSignature.String() == dvff.DlVrfFactoryAbi.Events["ProxyCreated"].ID.String() {
-			// 转为业务模型
-			proxyCreated, err := dvff.DlVrfFactoryFilter.ParseProxyCreated(*contractEvent.RLPLog)
-			if err != nil {
-				log.Error("proxy created fail", "err", err)
-				return proxyCreatedList, err
-			}
-			log.Info("proxy created event", "MintProxyAddress", proxyCreated.MintProxyAddress)
-			pc := worker.PoxyCreated{
-				GUID:         uuid.New(),
-				ProxyAddress: proxyCreated.MintProxyAddress,
-				Timestamp:    uint64(time.Now().Unix()),
-			}
-			proxyCreatedList = append(proxyCreatedList, pc)
-		}
+	log.Info("proxy created event", "MintProxyAddress", proxyCreated.MintProxyAddress)
+	blockNumber, err := eventBlockNumber(db, contractEvent)
+	if err != nil {
+		log.Error("query proxy created block number fail", "err", err)
+		return nil, common.Address{}, err
+	}
+	pc := worker.PoxyCreated{
+		GUID:            uuid.New(),
+		ProxyAddress:    proxyCreated.MintProxyAddress,
+		BlockNumber:     blockNumber,
+		TransactionHash: &contractEvent.TransactionHash,
+		LogIndex:        &contractEvent.LogIndex,
+		Timestamp:       uint64(time.Now().Unix()),
 	}
-	return proxyCreatedList, nil
+	return func(tx *database.DB, replay bool) error {
+		if replay {
+			return tx.PoxyCreated.UpsertPoxyCreated([]worker.PoxyCreated{pc})
+		}
+		return tx.PoxyCreated.StorePoxyCreated([]worker.PoxyCreated{pc})
+	}, proxyCreated.MintProxyAddress, nil
 }
@@ -0,0 +1,156 @@
+package contracts
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/database/event"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+/*
+	合约事件处理函数注册表：
+		- 每个合约解析器通过 Register 注册 (合约地址, ABI 中的事件) -> 处理函数，事件处理器按 (地址, topic0) 查找处理函数
+		- 处理函数解析事件并返回写库函数，事件处理器在同一个事务中按事件顺序执行所有写库函数
+		- 索引新的合约只需要实现处理函数并注册，不需要修改事件处理器
+		- 工厂创建的代理合约通过别名复用实现合约的处理函数：启动时按已记录的代理合约用 Alias 添加
+		- 解析 ProxyCreated 时用 AliasPending 添加的别名只对同一批的解析生效，本批写入数据库后由 CommitPending 保留，写入失败或被回滚时由 DiscardPending 丢弃
+		- 重组回滚删除代理合约后用 Unalias 移除其别名
+*/
+
+// 在事务 tx 中写入解析出的业务数据
+// replay 为 true 时为重新解析历史事件，按日志覆盖已写入的行，否则已写入的日志保持不变
+type StoreFunc func(tx *database.DB, replay bool) error
+
+// 解析一条合约事件，返回的 StoreFunc 为 nil 表示没有需要写入的数据
+type EventHandlerFunc func(db *database.DB, contractEvent event.ContractEvent) (StoreFunc, error)
+
+type eventKey struct {
+	address common.Address
+	topic   common.Hash
+}
+
+type registeredHandler struct {
+	eventName string
+	handler   EventHandlerFunc
+}
+
+type Registry struct {
+	lock     sync.RWMutex
+	handlers map[eventKey]registeredHandler
+	aliases  map[common.Address]common.Address // 别名地址 -> 源地址
+	pending  map[common.Address]common.Address // 本批解析中添加、尚未写入数据库的别名
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: make(map[eventKey]registeredHandler),
+		aliases:  make(map[common.Address]common.Address),
+		pending:  make(map[common.Address]common.Address),
+	}
+}
+
+// 注册 addresses 上 contractAbi 中名为 eventName 的事件的处理函数，同一地址的同一事件只能注册一次
+func (r *Registry) Register(addresses []common.Address, contractAbi *abi.ABI, eventName string, handler EventHandlerFunc) error {
+	abiEvent, ok := contractAbi.Events[eventName]
+	if !ok {
+		return fmt.Errorf("event %s not found in abi", eventName)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, address := range addresses {
+		key := eventKey{address: address, topic: abiEvent.ID}
+		if _, ok := r.handlers[key]; ok {
+			return fmt.Errorf("handler for event %s of %s already registered", eventName, address)
+		}
+		r.handlers[key] = registeredHandler{eventName: eventName, handler: handler}
+	}
+	return nil
+}
+
+// 让 address 上的事件使用 source 上注册的全部处理函数，address 上已注册的事件保持不变
+// 用于代理合约：代理合约触发的事件与实现合约相同
+func (r *Registry) Alias(address common.Address, source common.Address) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.aliases[address] = source
+}
+
+// 添加只对本批解析生效的别名，CommitPending 后才保留
+func (r *Registry) AliasPending(address common.Address, source common.Address) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.pending[address] = source
+}
+
+// 本批已写入数据库，保留本批添加的别名
+func (r *Registry) CommitPending() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for address, source := range r.pending {
+		r.aliases[address] = source
+	}
+	clear(r.pending)
+}
+
+// 本批没有写入数据库，丢弃本批添加的别名
+func (r *Registry) DiscardPending() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	clear(r.pending)
+}
+
+// 移除 address 的别名，address 上直接注册的处理函数保持不变
+func (r *Registry) Unalias(address common.Address) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.aliases, address)
+	delete(r.pending, address)
+}
+
+// 返回已保留的别名地址
+func (r *Registry) Aliases() []common.Address {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	addresses := make([]common.Address, 0, len(r.aliases))
+	for address := range r.aliases {
+		addresses = append(addresses, address)
+	}
+	return addresses
+}
+
+// 查找合约事件的处理函数，地址上没有直接注册时按别名查找源地址上的处理函数
+func (r *Registry) lookup(contractEvent event.ContractEvent) (registeredHandler, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	key := eventKey{address: contractEvent.ContractAddress, topic: contractEvent.EventSignature}
+	if registered, ok := r.handlers[key]; ok {
+		return registered, true
+	}
+	source, ok := r.aliases[key.address]
+	if !ok {
+		source, ok = r.pending[key.address]
+	}
+	if !ok {
+		return registeredHandler{}, false
+	}
+	registered, ok := r.handlers[eventKey{address: source, topic: key.topic}]
+	return registered, ok
+}
+
+// 返回合约事件注册时的事件名，没有注册处理函数时为空
+func (r *Registry) EventName(contractEvent event.ContractEvent) string {
+	registered, _ := r.lookup(contractEvent)
+	return registered.eventName
+}
+
+// 解析一条合约事件，没有注册处理函数的事件返回 nil
+func (r *Registry) Handle(db *database.DB, contractEvent event.ContractEvent) (StoreFunc, error) {
+	registered, ok := r.lookup(contractEvent)
+	if !ok {
+		return nil, nil
+	}
+	return registered.handler(db, contractEvent)
+}
@@ -0,0 +1,75 @@
+package contracts_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/database/event"
+	"github.com/WJX2001/contract-caller/event/contracts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sourceAddress = common.HexToAddress("0x1000")
+	proxyAddress  = common.HexToAddress("0x2000")
+)
+
+const testAbi = `[{"type":"event","name":"Ping","inputs":[{"name":"value","type":"uint256","indexed":false}],"anonymous":false}]`
+
+func newTestRegistry(t *testing.T) (*contracts.Registry, common.Hash) {
+	contractAbi, err := abi.JSON(strings.NewReader(testAbi))
+	require.NoError(t, err)
+	registry := contracts.NewRegistry()
+	require.NoError(t, registry.Register([]common.Address{sourceAddress}, &contractAbi, "Ping", func(*database.DB, event.ContractEvent) (contracts.StoreFunc, error) {
+		return func(*database.DB, bool) error { return nil }, nil
+	}))
+	return registry, contractAbi.Events["Ping"].ID
+}
+
+func contractEvent(address common.Address, topic common.Hash) event.ContractEvent {
+	return event.ContractEvent{ContractAddress: address, EventSignature: topic}
+}
+
+func TestRegistryPendingAlias(t *testing.T) {
+	registry, topic := newTestRegistry(t)
+	proxyEvent := contractEvent(proxyAddress, topic)
+	require.Empty(t, registry.EventName(proxyEvent))
+
+	// 本批解析中添加的别名立即生效，但写入前不保留
+	registry.AliasPending(proxyAddress, sourceAddress)
+	require.Equal(t, "Ping", registry.EventName(proxyEvent))
+	store, err := registry.Handle(nil, proxyEvent)
+	require.NoError(t, err)
+	require.NotNil(t, store)
+	require.Empty(t, registry.Aliases())
+
+	registry.DiscardPending()
+	require.Empty(t, registry.EventName(proxyEvent))
+	store, err = registry.Handle(nil, proxyEvent)
+	require.NoError(t, err)
+	require.Nil(t, store)
+
+	registry.AliasPending(proxyAddress, sourceAddress)
+	registry.CommitPending()
+	require.Equal(t, []common.Address{proxyAddress}, registry.Aliases())
+	// 已保留的别名不受之后丢弃的影响
+	registry.DiscardPending()
+	require.Equal(t, "Ping", registry.EventName(proxyEvent))
+}
+
+func TestRegistryUnalias(t *testing.T) {
+	registry, topic := newTestRegistry(t)
+	registry.Alias(proxyAddress, sourceAddress)
+	// 源地址自身注册的处理函数不是别名
+	registry.Unalias(sourceAddress)
+	require.Equal(t, "Ping", registry.EventName(contractEvent(sourceAddress, topic)))
+	require.Equal(t, "Ping", registry.EventName(contractEvent(proxyAddress, topic)))
+
+	registry.Unalias(proxyAddress)
+	require.Empty(t, registry.EventName(contractEvent(proxyAddress, topic)))
+	require.Empty(t, registry.Aliases())
+	require.Equal(t, "Ping", registry.EventName(contractEvent(sourceAddress, topic)))
+}
@@ -2,8 +2,10 @@ package event
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/WJX2001/contract-caller/common/bigint"
@@ -12,13 +14,23 @@ import (
 	"github.com/WJX2001/contract-caller/database/common"
 	"github.com/WJX2001/contract-caller/database/worker"
 	"github.com/WJX2001/contract-caller/event/contracts"
+	"github.com/WJX2001/contract-caller/publisher"
 	"github.com/WJX2001/contract-caller/synchronizer/retry"
+	common2 "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-var blocksLimit = 10_000
+// 本批区块在写入前已被同步器回滚
+var errBatchOrphaned = errors.New("event batch orphaned by reorg")
+
+const (
+	// 每一轮事件处理默认最多处理的区块数
+	defaultEpoch = 10_000
+	// 默认并发解析事件的合约数
+	defaultWorkers = 4
+)
 
 /*
 	此文件是 VRF 系统的事件处理器，负责：
@@ -29,28 +41,34 @@ var blocksLimit = 10_000
 */
 
 type EventsHandlerConfig struct {
-	DappLinkVrfAddress        string        // VRF 主合约地址
-	DappLinkVrfFactoryAddress string        // VRF 工厂合约地址
-	LoopInterval              time.Duration // 处理循环间隔
-	StartHeight               *big.Int      // 起始处理高度
-	Epoch                     uint64        // 处理批次大小
+	DappLinkVrfAddress        string              // VRF 主合约地址
+	DappLinkVrfFactoryAddress string              // VRF 工厂合约地址
+	LoopInterval              time.Duration       // 处理循环间隔
+	StartHeight               *big.Int            // 起始处理高度
+	Epoch                     uint64              // 每一轮最多处理的区块数，0 使用默认值
+	Workers                   int                 // 并发解析事件的合约数，0 使用默认值
+	Publisher                 publisher.Publisher // 发布解析出的事件，为 nil 时不发布
 }
 
 type EventsHandler struct {
-	dappLinkVrf        *contracts.DappLinkVrf        // VRF 合约解析器
-	dappLinkVrfFactory *contracts.DappLinkVrfFactory // VRF 工厂合约解析器
+	registry *contracts.Registry // 合约事件处理函数注册表
 
 	db                  *database.DB         // 数据库连接
 	eventsHandlerConfig *EventsHandlerConfig // 配置参数
 
 	latestBlockHeader *common.BlockHeader // 最新处理的区块头
 
+	metrics    Metrics    // 指标采集
+	statusLock sync.Mutex // 保护 status
+	status     Status     // 运行状态，通过 Status() 获取快照
+
 	resourceCtx    context.Context    // 资源上下文
 	resourceCancel context.CancelFunc // 资源取消函数
 	tasks          tasks.Group        // 任务组管理器
 }
 
-func NewEventsHandler(db *database.DB, eventsHandlerConfig *EventsHandlerConfig, shutdown context.CancelCauseFunc) (*EventsHandler, error) {
+// metrics 为 nil 时不采集指标
+func NewEventsHandler(db *database.DB, eventsHandlerConfig *EventsHandlerConfig, metrics Metrics, shutdown context.CancelCauseFunc) (*EventsHandler, error) {
 	// 创建合约解析器
 	dappLinkVrf, err := contracts.NewDappLinkVrf()
 	if err != nil {
@@ -63,6 +81,21 @@ func NewEventsHandler(db *database.DB, eventsHandlerConfig *EventsHandlerConfig,
 		log.Error("new dapplink vrf factory fail", "err", err)
 		return nil, err
 	}
+
+	// 注册各合约的事件处理函数，新增合约时在这里注册
+	registry := contracts.NewRegistry()
+	dappLinkVrfAddress := common2.HexToAddress(eventsHandlerConfig.DappLinkVrfAddress)
+	if err := dappLinkVrf.RegisterHandlers(registry, dappLinkVrfAddress); err != nil {
+		return nil, err
+	}
+	if err := dappLinkVrfFactory.RegisterHandlers(registry, common2.HexToAddress(eventsHandlerConfig.DappLinkVrfFactoryAddress), dappLinkVrfAddress); err != nil {
+		return nil, err
+	}
+	// 已记录的代理合约与 VRF 主合约触发相同的事件，之后创建的代理合约在解析 ProxyCreated 时添加
+	if err := syncProxyAliases(db, registry, dappLinkVrfAddress); err != nil {
+		return nil, err
+	}
+
 	// 初始化事件处理器
 	ltBlockHeader, err := db.EventBlocks.LatestEventBlockHeader()
 	if err != nil {
@@ -70,20 +103,83 @@ func NewEventsHandler(db *database.DB, eventsHandlerConfig *EventsHandlerConfig,
 		return nil, err
 	}
 
+	if metrics == nil {
+		metrics = NoopMetrics
+	}
+
 	resCtx, resCancel := context.WithCancel(context.Background())
 
-	return &EventsHandler{
-		dappLinkVrf:         dappLinkVrf,
-		dappLinkVrfFactory:  dappLinkVrfFactory,
+	eh := &EventsHandler{
+		registry:            registry,
 		db:                  db,
 		eventsHandlerConfig: eventsHandlerConfig,
 		latestBlockHeader:   ltBlockHeader,
+		metrics:             metrics,
 		resourceCtx:         resCtx,
 		resourceCancel:      resCancel,
 		tasks: tasks.Group{HandleCrit: func(err error) {
 			shutdown(fmt.Errorf("critical error in bridge processor: %w", err))
 		}},
-	}, nil
+	}
+	if ltBlockHeader != nil {
+		eh.recordProcessedHeight(ltBlockHeader.Number)
+	}
+	return eh, nil
+}
+
+// 同步器在重组后会删除孤块及其事件区块记录，已处理到的区块不存在时从剩余的最新事件区块继续
+func (eh *EventsHandler) resumeAfterRollback() error {
+	if eh.latestBlockHeader == nil {
+		return nil
+	}
+	header, err := eh.db.Blocks.BlockHeader(eh.latestBlockHeader.Hash)
+	if err != nil {
+		log.Error("query latest processed block header fail", "err", err)
+		return err
+	} else if header != nil {
+		return nil
+	}
+
+	ltBlockHeader, err := eh.db.EventBlocks.LatestEventBlockHeader()
+	if err != nil {
+		log.Error("fetch latest block header fail", "err", err)
+		return err
+	}
+	resumeFrom := eh.eventsHandlerConfig.StartHeight
+	if ltBlockHeader != nil {
+		resumeFrom = ltBlockHeader.Number
+	}
+	log.Warn("latest processed block was rolled back, resuming event processing", "orphaned", eh.latestBlockHeader.Number, "resumeFrom", resumeFrom)
+	// 回滚删除的代理合约不再使用 VRF 主合约的处理函数
+	if err := syncProxyAliases(eh.db, eh.registry, common2.HexToAddress(eh.eventsHandlerConfig.DappLinkVrfAddress)); err != nil {
+		return err
+	}
+	eh.latestBlockHeader = ltBlockHeader
+	if resumeFrom != nil {
+		eh.recordProcessedHeight(resumeFrom)
+	}
+	return nil
+}
+
+// 按 proxy_created 中记录的代理合约更新注册表中的别名：添加缺少的别名，移除已被回滚的代理合约的别名
+func syncProxyAliases(db *database.DB, registry *contracts.Registry, dappLinkVrfAddress common2.Address) error {
+	proxyAddresses, err := db.PoxyCreated.QueryPoxyCreatedAddressList()
+	if err != nil {
+		log.Error("query proxy created address list fail", "err", err)
+		return err
+	}
+	recorded := make(map[common2.Address]struct{}, len(proxyAddresses))
+	for _, proxyAddress := range proxyAddresses {
+		recorded[proxyAddress] = struct{}{}
+		registry.Alias(proxyAddress, dappLinkVrfAddress)
+	}
+	for _, alias := range registry.Aliases() {
+		if _, ok := recorded[alias]; !ok {
+			log.Warn("proxy contract rolled back, removing its event handlers", "proxy", alias)
+			registry.Unalias(alias)
+		}
+	}
+	return nil
 }
 
 // 启动方法
@@ -91,7 +187,15 @@ func (eh *EventsHandler) Start() error {
 	log.Info("starting event processor...")
 	tickerEventWorker := time.NewTicker(eh.eventsHandlerConfig.LoopInterval)
 	eh.tasks.Go(func() error {
-		for range tickerEventWorker.C {
+		defer tickerEventWorker.Stop()
+		for {
+			// Close 取消 resourceCtx 后立即退出，不再等待下一次 tick
+			select {
+			case <-eh.resourceCtx.Done():
+				log.Info("event processor stopped")
+				return nil
+			case <-tickerEventWorker.C:
+			}
 			/*
 				定期执行：
 					1. 处理区块链事件
@@ -100,16 +204,29 @@ func (eh *EventsHandler) Start() error {
 			*/
 			log.Info("start parse event logs")
 			err := eh.processEvent()
+			eh.recordRound(err)
 			if err != nil {
+				// 关闭时中断的重试不视为失败
+				if eh.resourceCtx.Err() != nil {
+					log.Info("event processor stopped")
+					return nil
+				}
 				log.Info("process event error", "err", err)
 				return err
 			}
 		}
-		return nil
 	})
 	return nil
 }
 
+// 每一轮最多处理的区块数
+func (eh *EventsHandler) epoch() uint64 {
+	if eh.eventsHandlerConfig.Epoch == 0 {
+		return defaultEpoch
+	}
+	return eh.eventsHandlerConfig.Epoch
+}
+
 func (eh *EventsHandler) Close() error {
 	eh.resourceCancel()    // 取消上下文
 	return eh.tasks.Wait() // 等待所有任务完成
@@ -122,11 +239,25 @@ func (eh *EventsHandler) Close() error {
 4. 批量存储处理结果到数据库
 */
 func (eh *EventsHandler) processEvent() error {
+	if err := eh.resumeAfterRollback(); err != nil {
+		return err
+	}
+	// 同步器已存储的最新高度，用于计算处理延迟
+	syncedHeader, err := eh.db.Blocks.LatestBlockHeader()
+	if err != nil {
+		log.Error("query latest synced block header fail", "err", err)
+		return err
+	} else if syncedHeader != nil {
+		eh.recordSyncedHeight(syncedHeader.Number)
+	}
+
 	lastBlockNumber := eh.eventsHandlerConfig.StartHeight
 	if eh.latestBlockHeader != nil {
 		lastBlockNumber = eh.latestBlockHeader.Number
 	}
 	log.Info("process event latest block number", "lastBlockNumber", lastBlockNumber)
+	// 稀疏存储模式下按已存储的区块头计数
+	epoch := int(eh.epoch())
 	latestHeaderScope := func(db *gorm.DB) *gorm.DB {
 		// 开启一个新的查询，不被之前的查询条件干扰
 		newQuery := db.Session(&gorm.Session{NewDB: true})
@@ -146,7 +277,7 @@ func (eh *EventsHandler) processEvent() error {
 			  ) AS block_numbers
 			);
 		*/
-		return db.Where("number = (?)", newQuery.Table("(?) as block_numbers", headers.Order("number ASC").Limit(blocksLimit)).Select("MAX(number)"))
+		return db.Where("number = (?)", newQuery.Table("(?) as block_numbers", headers.Order("number ASC").Limit(epoch)).Select("MAX(number)"))
 	}
 
 	if latestHeaderScope == nil {
@@ -164,14 +295,18 @@ func (eh *EventsHandler) processEvent() error {
 
 	// 生成事件区块记录的逻辑
 	fromHeight, toHeight := new(big.Int).Add(lastBlockNumber, bigint.One), latestBlockHeader.Number
+	if fromHeight.Cmp(toHeight) > 0 {
+		log.Debug("no new block for process event")
+		return nil
+	}
+	// 一次范围查询取出窗口内已存储的区块头，稀疏存储模式下区块头不连续，只处理实际存在的区块
+	blockHeaders, err := eh.db.Blocks.BlockHeadersByRange(fromHeight, toHeight)
+	if err != nil {
+		return err
+	}
 	// 第二个参数 预分配容量
-	eventBlocks := make([]worker.EventBlocks, 0, toHeight.Uint64()-fromHeight.Uint64())
-	// 逐个查询区块头
-	for index := fromHeight.Uint64(); index < toHeight.Uint64(); index++ {
-		blockHeader, err := eh.db.Blocks.BlockHeaderByNumber(big.NewInt(int64(index)))
-		if err != nil {
-			return err
-		}
+	eventBlocks := make([]worker.EventBlocks, 0, len(blockHeaders))
+	for _, blockHeader := range blockHeaders {
 		// 将区块头信息转换为 事件区块记录
 		/*
 			记录作用：
@@ -191,38 +326,36 @@ func (eh *EventsHandler) processEvent() error {
 
 	// 合约事件处理
 	/*
-				数据库原始事件 → 合约解析器 → 业务数据
-		     ↓              ↓           ↓
-		ContractEvent → DappLinkVrf → RequestSend
-		              → DappLinkVrf → FillRandomWords
-		              → DappLinkVrfFactory → PoxyCreated
+				数据库原始事件 → 注册表中按 (合约地址, topic0) 找到的处理函数 → 业务数据
+		     ↓              ↓                                  ↓
+		ContractEvent → DappLinkVrf.handleRequestSent        → RequestSend
+		              → DappLinkVrf.handleFillRandomWords    → FillRandomWords
+		              → DappLinkVrfFactory.handleProxyCreated → PoxyCreated
 	*/
-
-	// 主合约事件处理
-	requestSentList, fillRandomWordList, err := eh.dappLinkVrf.ProcessDappLinkVrfEvent( // 随机数请求，随机数回填
-		eh.db,
-		eh.eventsHandlerConfig.DappLinkVrfAddress,
-		fromHeight,
-		toHeight,
-	)
-
+	stores, parsed, err := eh.handleEvents(fromHeight, toHeight)
 	if err != nil {
-		log.Error("process dapplink vrf event fail", "err", err)
 		return err
 	}
-
-	// 工厂合约事件处理
-	proxyCreatedList, err := eh.dappLinkVrfFactory.ProcessDappLinkVrfFactoryEvent(
-		eh.db,
-		eh.eventsHandlerConfig.DappLinkVrfFactoryAddress,
-		fromHeight,
-		toHeight,
-	)
-
-	if err != nil {
+	if err := eh.storeEvents(stores, eventBlocks, false); err != nil {
+		if errors.Is(err, errBatchOrphaned) {
+			// 不推进处理进度，下一轮从回滚后剩余的区块继续
+			log.Warn("event batch rolled back by synchronizer before persisting, discarding", "from", fromHeight, "to", toHeight)
+			return nil
+		}
 		return err
 	}
+	eh.publishEvents(parsed, blockHeaders)
+	// 状态更新
+	eh.latestBlockHeader = latestBlockHeader
+	eh.recordProcessedHeight(latestBlockHeader.Number)
+	return nil
+}
 
+// 在同一个事务中写入解析出的业务数据和事件区块记录，eventBlocks 为空时只写业务数据
+// replay 为 true 时按日志覆盖已写入的业务数据，见 StoreFunc
+// 写入前锁定本批最后一个区块头，区块头已被同步器回滚时放弃写入并返回 errBatchOrphaned
+// 写入成功后保留解析本批时添加的代理合约别名，否则丢弃
+func (eh *EventsHandler) storeEvents(stores []contracts.StoreFunc, eventBlocks []worker.EventBlocks, replay bool) error {
 	// 重试策略配置
 	/*
 		处理临时性数据库连接问题
@@ -235,39 +368,32 @@ func (eh *EventsHandler) processEvent() error {
 		MaxJitter: 250,
 	}
 
-	if _, err := retry.Do[interface{}](eh.resourceCtx, 10, retryStrategy, func() (interface{}, error) {
+	start := time.Now()
+	orphaned := false
+	_, err := retry.Do[interface{}](eh.resourceCtx, 10, retryStrategy, func() (interface{}, error) {
 		// 数据库事务处理
 		if err := eh.db.Transaction(func(tx *database.DB) error {
-			// 存储随机数请求
-			if len(requestSentList) > 0 {
-				err := eh.db.RequestSend.StoreRequestSend(requestSentList)
-				if err != nil {
-					log.Error("store request send fail", "err", err)
-					return err
-				}
-			}
-
-			// 存储随机数回填
-			if len(fillRandomWordList) > 0 {
-				err := eh.db.FillRandomWords.StoreFillRandomWords(fillRandomWordList)
+			// 回滚会删除高度大于共同祖先的全部区块头，最后一个区块仍存在说明本批都在规范链上
+			if len(eventBlocks) > 0 {
+				exists, err := tx.Blocks.LockBlockHeader(eventBlocks[len(eventBlocks)-1].Hash)
 				if err != nil {
-					log.Error("store fill random words fail", "err", err)
 					return err
+				} else if !exists {
+					return errBatchOrphaned
 				}
 			}
 
-			// 存储代理创建记录
-			if len(proxyCreatedList) > 0 {
-				err := eh.db.PoxyCreated.StorePoxyCreated(proxyCreatedList)
-				if err != nil {
-					log.Error("store proxy created fail", "err", err)
+			// 按事件顺序存储解析出的业务数据
+			for _, store := range stores {
+				if err := store(tx, replay); err != nil {
+					log.Error("store contract event data fail", "err", err)
 					return err
 				}
 			}
 
 			// 存储事件区块记录
 			if len(eventBlocks) > 0 {
-				err := eh.db.EventBlocks.StoreEventBlocks(eventBlocks)
+				err := tx.EventBlocks.StoreEventBlocks(eventBlocks)
 				if err != nil {
 					log.Error("store event blocks fail", "err", err)
 					return err
@@ -275,14 +401,24 @@ func (eh *EventsHandler) processEvent() error {
 			}
 			return nil
 		}); err != nil {
+			if errors.Is(err, errBatchOrphaned) {
+				orphaned = true
+				return nil, nil
+			}
 			log.Debug("unable to persist batch", err)
 			return nil, fmt.Errorf("unable to persist batch: %w", err)
 		}
 		return nil, nil
-	}); err != nil {
+	})
+	if err != nil {
+		eh.registry.DiscardPending()
 		return err
+	} else if orphaned {
+		eh.registry.DiscardPending()
+		return errBatchOrphaned
 	}
-	// 状态更新
-	eh.latestBlockHeader = latestBlockHeader
+	// 本批创建的代理合约已写入 proxy_created，之后的批次继续使用其处理函数
+	eh.registry.CommitPending()
+	eh.recordBatch(len(stores), time.Since(start))
 	return nil
 }
@@ -0,0 +1,132 @@
+package event
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+	事件处理器的指标采集：
+		- 按事件类型统计解析成功和失败的事件数
+		- 已处理高度、同步器已存储的最新高度以及两者的差值（处理延迟）
+		- 每批业务数据写库耗时，包括重试
+	NewEventsHandler 的 metrics 参数为空时不采集，主程序通过 PrometheusMetrics.Collectors 注册到自己的 registry
+*/
+
+type Metrics interface {
+	RecordEventParsed(eventName string)  // 一条事件解析成功
+	RecordParseFailure(eventName string) // 一条事件解析失败
+	RecordProcessedHeight(height uint64) // 已处理的最新区块高度
+	RecordSyncedHeight(height uint64)    // 同步器已存储的最新区块高度
+	RecordBatchPersist(d time.Duration)  // 一批业务数据写库的耗时，包括重试
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) RecordEventParsed(string)         {}
+func (noopMetrics) RecordParseFailure(string)        {}
+func (noopMetrics) RecordProcessedHeight(uint64)     {}
+func (noopMetrics) RecordSyncedHeight(uint64)        {}
+func (noopMetrics) RecordBatchPersist(time.Duration) {}
+
+var NoopMetrics Metrics = noopMetrics{}
+
+type PrometheusMetrics struct {
+	eventsParsed    *prometheus.CounterVec
+	parseFailures   *prometheus.CounterVec
+	processedHeight prometheus.Gauge
+	syncedHeight    prometheus.Gauge
+	lag             prometheus.Gauge
+	persistDuration prometheus.Histogram
+
+	processed, synced uint64
+}
+
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	const subsystem = "event_processor"
+	return &PrometheusMetrics{
+		eventsParsed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "events_parsed_total",
+			Help:      "Number of contract events parsed, by event type",
+		}, []string{"event"}),
+		parseFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "parse_failures_total",
+			Help:      "Number of contract events that failed to parse, by event type",
+		}, []string{"event"}),
+		processedHeight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "processed_height",
+			Help:      "Height of the latest block processed by the event processor",
+		}),
+		syncedHeight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "synced_height",
+			Help:      "Height of the latest block header stored by the synchronizer",
+		}),
+		lag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "lag_blocks",
+			Help:      "Number of blocks between the synchronizer and the event processor",
+		}),
+		persistDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "batch_persist_seconds",
+			Help:      "Time spent persisting the data derived from a batch of events, including retries",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		}),
+	}
+}
+
+// 返回全部指标，供主程序注册
+func (m *PrometheusMetrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.eventsParsed,
+		m.parseFailures,
+		m.processedHeight,
+		m.syncedHeight,
+		m.lag,
+		m.persistDuration,
+	}
+}
+
+func (m *PrometheusMetrics) RecordEventParsed(eventName string) {
+	m.eventsParsed.WithLabelValues(eventName).Inc()
+}
+
+func (m *PrometheusMetrics) RecordParseFailure(eventName string) {
+	m.parseFailures.WithLabelValues(eventName).Inc()
+}
+
+func (m *PrometheusMetrics) RecordProcessedHeight(height uint64) {
+	m.processed = height
+	m.processedHeight.Set(float64(height))
+	m.updateLag()
+}
+
+func (m *PrometheusMetrics) RecordSyncedHeight(height uint64) {
+	m.synced = height
+	m.syncedHeight.Set(float64(height))
+	m.updateLag()
+}
+
+func (m *PrometheusMetrics) RecordBatchPersist(d time.Duration) {
+	m.persistDuration.Observe(d.Seconds())
+}
+
+// 处理延迟，同步器回滚后已处理高度可能暂时超过已存储高度，此时记为 0
+func (m *PrometheusMetrics) updateLag() {
+	if m.synced > m.processed {
+		m.lag.Set(float64(m.synced - m.processed))
+	} else {
+		m.lag.Set(0)
+	}
+}
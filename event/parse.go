@@ -0,0 +1,116 @@
+package event
+
+import (
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/database/event"
+	"github.com/WJX2001/contract-caller/event/contracts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/sync/errgroup"
+)
+
+/*
+	按合约并发解析事件：
+		- 一批事件按合约地址分组，最多 Workers 个合约同时解析，同一合约的事件按顺序解析
+		- 解析结果按事件在链上的顺序汇总，写库仍在一个事务中按顺序执行，与串行解析的结果相同
+		- 代理合约的处理函数在解析工厂合约的 ProxyCreated 事件时才注册，解析时还没有处理函数的事件在所有合约解析完成后按顺序再解析一次
+		- 解析时注册的代理合约别名只对本批生效，本批写入数据库后才保留，见 storeEvents
+*/
+
+// 一条事件的解析结果
+type handledEvent struct {
+	name    string
+	store   contracts.StoreFunc
+	handled bool
+}
+
+// 解析 [fromHeight, toHeight] 内已存储的合约事件，返回按事件顺序执行的写库函数和解析成功的事件
+func (eh *EventsHandler) handleEvents(fromHeight, toHeight *big.Int) ([]contracts.StoreFunc, []parsedEvent, error) {
+	// 丢弃上一批解析失败、没有写入时留下的代理合约别名
+	eh.registry.DiscardPending()
+
+	contractEvents, err := eh.db.ContractEvent.ContractEventsWithFilter(event.ContractEvent{}, fromHeight, toHeight)
+	if err != nil {
+		log.Error("query contract events fail", "err", err)
+		return nil, nil, err
+	}
+
+	// 按合约分组，保持每个合约内的事件顺序
+	groups := make(map[common.Address][]int)
+	var addresses []common.Address
+	for i, contractEvent := range contractEvents {
+		if _, ok := groups[contractEvent.ContractAddress]; !ok {
+			addresses = append(addresses, contractEvent.ContractAddress)
+		}
+		groups[contractEvent.ContractAddress] = append(groups[contractEvent.ContractAddress], i)
+	}
+
+	results := make([]handledEvent, len(contractEvents))
+	group, ctx := errgroup.WithContext(eh.resourceCtx)
+	group.SetLimit(eh.workers())
+	for _, address := range addresses {
+		indexes := groups[address]
+		group.Go(func() error {
+			for _, i := range indexes {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := eh.handleEvent(contractEvents[i], &results[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	// 同一批中新创建的代理合约的事件
+	for i := range results {
+		if results[i].handled {
+			continue
+		}
+		if err := eh.handleEvent(contractEvents[i], &results[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var stores []contracts.StoreFunc
+	var parsed []parsedEvent
+	for i, result := range results {
+		if !result.handled {
+			continue
+		}
+		if result.store != nil {
+			stores = append(stores, result.store)
+		}
+		parsed = append(parsed, parsedEvent{name: result.name, event: contractEvents[i]})
+	}
+	return stores, parsed, nil
+}
+
+// 解析一条事件，没有注册处理函数时 result 保持未处理
+func (eh *EventsHandler) handleEvent(contractEvent event.ContractEvent, result *handledEvent) error {
+	eventName := eh.registry.EventName(contractEvent)
+	if eventName == "" {
+		return nil
+	}
+	store, err := eh.registry.Handle(eh.db, contractEvent)
+	eh.recordParse(eventName, err)
+	if err != nil {
+		log.Error("handle contract event fail", "event", contractEvent.GUID, "err", err)
+		return err
+	}
+	*result = handledEvent{name: eventName, store: store, handled: true}
+	return nil
+}
+
+// 并发解析事件的合约数
+func (eh *EventsHandler) workers() int {
+	if eh.eventsHandlerConfig.Workers <= 0 {
+		return defaultWorkers
+	}
+	return eh.eventsHandlerConfig.Workers
+}
@@ -0,0 +1,43 @@
+package event
+
+import (
+	"github.com/WJX2001/contract-caller/database/common"
+	"github.com/WJX2001/contract-caller/database/event"
+	"github.com/WJX2001/contract-caller/publisher"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// 解析成功的合约事件及其注册时的事件名
+type parsedEvent struct {
+	name  string
+	event event.ContractEvent
+}
+
+// 业务数据写库成功后按事件顺序发布，发布失败只记录日志
+func (eh *EventsHandler) publishEvents(parsed []parsedEvent, blockHeaders []common.BlockHeader) {
+	if eh.eventsHandlerConfig.Publisher == nil || len(parsed) == 0 {
+		return
+	}
+	numbers := make(map[string]string, len(blockHeaders))
+	for _, header := range blockHeaders {
+		numbers[header.Hash.String()] = header.Number.String()
+	}
+
+	for _, p := range parsed {
+		message := publisher.EventMessage{
+			Type:            publisher.MessageTypeEvent,
+			Name:            p.name,
+			ContractAddress: hexutil.Encode(p.event.ContractAddress.Bytes()),
+			BlockHash:       p.event.BlockHash.String(),
+			BlockNumber:     numbers[p.event.BlockHash.String()],
+			TransactionHash: p.event.TransactionHash.String(),
+			LogIndex:        p.event.LogIndex,
+			Timestamp:       p.event.Timestamp,
+			Args:            p.event.DecodedArgs,
+		}
+		if err := publisher.PublishJSON(eh.resourceCtx, eh.eventsHandlerConfig.Publisher, message.TransactionHash, message); err != nil {
+			log.Warn("publish contract event fail", "event", p.name, "tx", message.TransactionHash, "index", message.LogIndex, "err", err)
+		}
+	}
+}
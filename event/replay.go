@@ -0,0 +1,53 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/common/bigint"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	历史事件重新解析：
+		- 对 [from, to] 内已存储的合约事件重新执行注册表中的处理函数，按 epoch 分段，每段在一个事务中写入
+		- 业务表按产生该行的日志 (transaction_hash, log_index) 写入：缺失的行补写，已写入的行覆盖由事件解析出的列，重复执行结果相同
+		- 请求的处理状态、回填交易等由工作器维护的列不会被覆盖，已完成的请求不会被重新处理
+		- 不修改事件区块记录和处理进度，可以在事件处理器运行时执行，用于修正处理函数写错的数据或填充新增的业务表
+		- 不向消息总线重新发布事件
+		- 只解析已同步的事件，同步器尚未存储的区块需要先回填
+*/
+
+// 重新解析 [from, to] 内的合约事件
+func (eh *EventsHandler) Replay(ctx context.Context, from, to *big.Int) error {
+	if from == nil || to == nil {
+		return fmt.Errorf("replay range unspecified")
+	}
+	if from.Cmp(to) > 0 {
+		return fmt.Errorf("replay from %s is greater than to %s", from, to)
+	}
+
+	epoch := new(big.Int).SetUint64(eh.epoch())
+	for start := new(big.Int).Set(from); start.Cmp(to) <= 0; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := new(big.Int).Add(start, epoch)
+		end.Sub(end, bigint.One)
+		if end.Cmp(to) > 0 {
+			end = new(big.Int).Set(to)
+		}
+
+		stores, _, err := eh.handleEvents(start, end)
+		if err != nil {
+			return err
+		}
+		if err := eh.storeEvents(stores, nil, true); err != nil {
+			return err
+		}
+		log.Info("replayed contract events", "from", start, "to", end, "stores", len(stores))
+		start = new(big.Int).Add(end, bigint.One)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package event
+
+import (
+	"math/big"
+	"time"
+)
+
+/*
+	事件处理器运行状态：
+		- 每轮处理结束后更新，Status 返回一份快照，可在其他 goroutine 中调用
+		- 处理延迟为同步器已存储的最新高度与已处理高度之差
+*/
+
+type Status struct {
+	ProcessedHeight    *big.Int      // 已处理的最新区块高度，尚未处理任何区块时为 nil
+	SyncedHeight       *big.Int      // 同步器已存储的最新区块高度，尚未查询到时为 nil
+	Lag                uint64        // 已存储高度与已处理高度之差
+	EventsParsed       uint64        // 启动以来解析成功的事件数
+	ParseFailures      uint64        // 启动以来解析失败的事件数
+	LastBatchEvents    int           // 最近一批写入业务数据的事件数
+	LastPersistLatency time.Duration // 最近一批的写库耗时
+	LastBatchAt        time.Time     // 最近一批写库成功的时间
+	LastError          string        // 最近一轮失败的原因，成功后清空
+}
+
+// 返回事件处理器当前状态的快照
+func (eh *EventsHandler) Status() Status {
+	eh.statusLock.Lock()
+	defer eh.statusLock.Unlock()
+
+	status := eh.status
+	if status.ProcessedHeight != nil {
+		status.ProcessedHeight = new(big.Int).Set(status.ProcessedHeight)
+	}
+	if status.SyncedHeight != nil {
+		status.SyncedHeight = new(big.Int).Set(status.SyncedHeight)
+	}
+	return status
+}
+
+// 记录同步器已存储的最新区块高度
+func (eh *EventsHandler) recordSyncedHeight(height *big.Int) {
+	eh.metrics.RecordSyncedHeight(height.Uint64())
+
+	eh.statusLock.Lock()
+	defer eh.statusLock.Unlock()
+	eh.status.SyncedHeight = new(big.Int).Set(height)
+	eh.updateLag()
+}
+
+// 记录已处理的最新区块高度，同步器回滚后高度会降低
+func (eh *EventsHandler) recordProcessedHeight(height *big.Int) {
+	eh.metrics.RecordProcessedHeight(height.Uint64())
+
+	eh.statusLock.Lock()
+	defer eh.statusLock.Unlock()
+	eh.status.ProcessedHeight = new(big.Int).Set(height)
+	eh.updateLag()
+}
+
+// 记录一条事件的解析结果
+func (eh *EventsHandler) recordParse(eventName string, err error) {
+	if err != nil {
+		eh.metrics.RecordParseFailure(eventName)
+	} else {
+		eh.metrics.RecordEventParsed(eventName)
+	}
+
+	eh.statusLock.Lock()
+	defer eh.statusLock.Unlock()
+	if err != nil {
+		eh.status.ParseFailures++
+	} else {
+		eh.status.EventsParsed++
+	}
+}
+
+// 记录一批业务数据写库成功
+func (eh *EventsHandler) recordBatch(events int, persist time.Duration) {
+	eh.metrics.RecordBatchPersist(persist)
+
+	eh.statusLock.Lock()
+	defer eh.statusLock.Unlock()
+	eh.status.LastBatchEvents = events
+	eh.status.LastPersistLatency = persist
+	eh.status.LastBatchAt = time.Now()
+}
+
+// 记录一轮处理的结果，err 为 nil 时清空失败原因
+func (eh *EventsHandler) recordRound(err error) {
+	eh.statusLock.Lock()
+	defer eh.statusLock.Unlock()
+	if err != nil {
+		eh.status.LastError = err.Error()
+	} else {
+		eh.status.LastError = ""
+	}
+}
+
+// 调用方需持有 statusLock
+func (eh *EventsHandler) updateLag() {
+	eh.status.Lag = 0
+	if eh.status.ProcessedHeight != nil && eh.status.SyncedHeight != nil && eh.status.SyncedHeight.Cmp(eh.status.ProcessedHeight) > 0 {
+		eh.status.Lag = new(big.Int).Sub(eh.status.SyncedHeight, eh.status.ProcessedHeight).Uint64()
+	}
+}
@@ -43,6 +43,87 @@ var (
 		EnvVars: prefixEnvVars("CONFIRMATIONS"),
 		Value:   64,
 	}
+	SyncConfirmationsFlag = &cli.Uint64Flag{
+		Name:    "sync-confirmations",
+		Usage:   "Number of blocks the synchronizer stays behind the chain head, 0 means the same as confirmations",
+		EnvVars: prefixEnvVars("SYNC_CONFIRMATIONS"),
+	}
+	SyncFollowFlag = &cli.StringFlag{
+		Name:    "sync-follow",
+		Usage:   "Chain head the synchronizer follows: latest (minus sync-confirmations), safe or finalized",
+		EnvVars: prefixEnvVars("SYNC_FOLLOW"),
+		Value:   "latest",
+	}
+	AheadOfProviderPolicyFlag = &cli.StringFlag{
+		Name:    "ahead-of-provider-policy",
+		Usage:   "What the synchronizer does when it is ahead of the RPC provider: wait, rewind or fail",
+		EnvVars: prefixEnvVars("AHEAD_OF_PROVIDER_POLICY"),
+		Value:   "wait",
+	}
+	SyncCatchUpThresholdFlag = &cli.Uint64Flag{
+		Name:    "sync-catch-up-threshold",
+		Usage:   "The synchronizer loops without waiting for main-loop-interval while more than this many blocks behind, 0 means blocks-step",
+		EnvVars: prefixEnvVars("SYNC_CATCH_UP_THRESHOLD"),
+	}
+	SyncPipelineDepthFlag = &cli.Uint64Flag{
+		Name:    "sync-pipeline-depth",
+		Usage:   "Number of batches buffered between the synchronizer's header fetch, log fetch and persist stages, 0 means 2",
+		EnvVars: prefixEnvVars("SYNC_PIPELINE_DEPTH"),
+	}
+	GapCheckIntervalFlag = &cli.DurationFlag{
+		Name:    "gap-check-interval",
+		Usage:   "How often indexed block headers are scanned for missing or unlinked blocks, which are then re-fetched, 0 disables the check",
+		EnvVars: prefixEnvVars("GAP_CHECK_INTERVAL"),
+		Value:   time.Minute * 10,
+	}
+	IndexTransactionsFlag = &cli.BoolFlag{
+		Name:    "index-transactions",
+		Usage:   "Also store transactions sent to watched contracts, fetching every synced block with its transactions",
+		EnvVars: prefixEnvVars("INDEX_TRANSACTIONS"),
+	}
+	IndexReceiptsFlag = &cli.BoolFlag{
+		Name:    "index-receipts",
+		Usage:   "Record the receipt status and gas used of indexed transactions, requires index-transactions",
+		EnvVars: prefixEnvVars("INDEX_RECEIPTS"),
+	}
+	LogSourceFlag = &cli.StringFlag{
+		Name:    "log-source",
+		Usage:   "How logs are fetched: range (one eth_getLogs per batch), per-block, or auto (per-block when the provider rejects the range)",
+		EnvVars: prefixEnvVars("LOG_SOURCE"),
+		Value:   "range",
+	}
+	SparseHeadersFlag = &cli.BoolFlag{
+		Name:    "sparse-headers",
+		Usage:   "Only store block headers that contain watched logs, plus periodic checkpoint headers",
+		EnvVars: prefixEnvVars("SPARSE_HEADERS"),
+	}
+	SparseHeaderIntervalFlag = &cli.Uint64Flag{
+		Name:    "sparse-header-interval",
+		Usage:   "Store every block header whose height is a multiple of this value in sparse mode, 0 means 1000",
+		EnvVars: prefixEnvVars("SPARSE_HEADER_INTERVAL"),
+	}
+	BackfillFromFlag = &cli.Uint64Flag{
+		Name:    "backfill-from",
+		Usage:   "First block height of the historical range indexed by the backfill command",
+		EnvVars: prefixEnvVars("BACKFILL_FROM"),
+	}
+	BackfillToFlag = &cli.Uint64Flag{
+		Name:    "backfill-to",
+		Usage:   "Last block height of the historical range indexed by the backfill command, 0 means the latest finalized block",
+		EnvVars: prefixEnvVars("BACKFILL_TO"),
+	}
+	BackfillShardSizeFlag = &cli.Uint64Flag{
+		Name:    "backfill-shard-size",
+		Usage:   "Number of blocks fetched and written together by one backfill worker",
+		EnvVars: prefixEnvVars("BACKFILL_SHARD_SIZE"),
+		Value:   500,
+	}
+	BackfillWorkersFlag = &cli.IntFlag{
+		Name:    "backfill-workers",
+		Usage:   "Number of shards backfilled concurrently, spread over the primary and fallback RPC endpoints",
+		EnvVars: prefixEnvVars("BACKFILL_WORKERS"),
+		Value:   4,
+	}
 	MainIntervalFlag = &cli.DurationFlag{
 		Name:    "main-loop-interval",
 		Usage:   "The interval of synchronization",
@@ -61,17 +142,113 @@ var (
 		EnvVars: prefixEnvVars("EVENT_LOOP_INTERVAL"),
 		Value:   time.Second * 5,
 	}
+	EventEpochFlag = &cli.Uint64Flag{
+		Name:    "event-epoch",
+		Usage:   "The maximum number of stored blocks each event processing pass covers, 0 means 10000",
+		EnvVars: prefixEnvVars("EVENT_EPOCH"),
+		Value:   10_000,
+	}
+	EventWorkersFlag = &cli.IntFlag{
+		Name:    "event-workers",
+		Usage:   "The number of contracts whose events are parsed concurrently, 0 means 4",
+		EnvVars: prefixEnvVars("EVENT_WORKERS"),
+		Value:   4,
+	}
+	GasLimitMultiplierFlag = &cli.Float64Flag{
+		Name:    "gas-limit-multiplier",
+		Usage:   "Safety multiplier applied to the estimated gas of fulfillment txs, 0 means 1.2",
+		EnvVars: prefixEnvVars("GAS_LIMIT_MULTIPLIER"),
+	}
+	MaxGasLimitFlag = &cli.Uint64Flag{
+		Name:    "max-gas-limit",
+		Usage:   "Absolute gas limit cap for fulfillment txs, 0 means no cap",
+		EnvVars: prefixEnvVars("MAX_GAS_LIMIT"),
+	}
+	MulticallAddressFlag = &cli.StringFlag{
+		Name:    "multicall-address",
+		Usage:   "Multicall3 aggregator used to fulfill several requests in one tx, empty means one tx per request",
+		EnvVars: prefixEnvVars("MULTICALL_ADDRESS"),
+	}
+	MaxFulfillBatchSizeFlag = &cli.IntFlag{
+		Name:    "max-fulfill-batch-size",
+		Usage:   "Max requests fulfilled in one batch tx, 0 means 20",
+		EnvVars: prefixEnvVars("MAX_FULFILL_BATCH_SIZE"),
+	}
+	TipPercentileFlag = &cli.Float64Flag{
+		Name:    "tip-percentile",
+		Usage:   "Percentile of recent priority fees in eth_feeHistory used as the tip of fulfillment txs, 0 means 50",
+		EnvVars: prefixEnvVars("TIP_PERCENTILE"),
+	}
+	BaseFeeMultiplierFlag = &cli.Float64Flag{
+		Name:    "base-fee-multiplier",
+		Usage:   "Headroom multiplier applied to the next base fee when computing the fee cap, 0 means 2",
+		EnvVars: prefixEnvVars("BASE_FEE_MULTIPLIER"),
+	}
+	BalanceCheckIntervalFlag = &cli.DurationFlag{
+		Name:    "balance-check-interval",
+		Usage:   "The interval of checking the caller balance",
+		EnvVars: prefixEnvVars("BALANCE_CHECK_INTERVAL"),
+		Value:   time.Minute,
+	}
+	LowBalanceWarningFlag = &cli.Uint64Flag{
+		Name:    "low-balance-warning",
+		Usage:   "Warn when the caller balance drops below this amount (gwei), 0 means never",
+		EnvVars: prefixEnvVars("LOW_BALANCE_WARNING"),
+	}
+	MinBalanceFlag = &cli.Uint64Flag{
+		Name:    "min-balance",
+		Usage:   "Pause fulfillments while the caller balance is below this amount (gwei), 0 means never",
+		EnvVars: prefixEnvVars("MIN_BALANCE"),
+	}
+	VerifyContractSelectorsFlag = &cli.BoolFlag{
+		Name:    "verify-contract-selectors",
+		Usage:   "Check at startup that the vrf and factory bytecode contain the expected method selectors, do not enable for proxied deployments",
+		EnvVars: prefixEnvVars("VERIFY_CONTRACT_SELECTORS"),
+	}
 	CallIntervalFlag = &cli.DurationFlag{
 		Name:    "call-loop-interval",
 		Usage:   "The interval of contract caller",
 		EnvVars: prefixEnvVars("CALL_LOOP_INTERVAL"),
 		Value:   time.Second * 5,
 	}
+	MaxConcurrentFulfillmentsFlag = &cli.IntFlag{
+		Name:    "max-concurrent-fulfillments",
+		Usage:   "The number of pending requests fulfilled concurrently, each with its own nonce, 0 means 4",
+		EnvVars: prefixEnvVars("MAX_CONCURRENT_FULFILLMENTS"),
+		Value:   4,
+	}
+	MaxFulfillAttemptsFlag = &cli.IntFlag{
+		Name:    "max-fulfill-attempts",
+		Usage:   "The number of failed fulfillments after which a request is marked failed and no longer retried, 0 means 10",
+		EnvVars: prefixEnvVars("MAX_FULFILL_ATTEMPTS"),
+		Value:   10,
+	}
+	FulfillRetryBackoffFlag = &cli.DurationFlag{
+		Name:    "fulfill-retry-backoff",
+		Usage:   "The delay before retrying a failed fulfillment, doubled after each further failure, 0 means 30s",
+		EnvVars: prefixEnvVars("FULFILL_RETRY_BACKOFF"),
+		Value:   time.Second * 30,
+	}
+	FulfillMaxRetryBackoffFlag = &cli.DurationFlag{
+		Name:    "fulfill-max-retry-backoff",
+		Usage:   "The maximum delay between fulfillment retries, 0 means 1h",
+		EnvVars: prefixEnvVars("FULFILL_MAX_RETRY_BACKOFF"),
+		Value:   time.Hour,
+	}
+	RequestTTLFlag = &cli.DurationFlag{
+		Name:    "request-ttl",
+		Usage:   "Requests whose block is older than this are marked expired instead of fulfilled, 0 means requests never expire",
+		EnvVars: prefixEnvVars("REQUEST_TTL"),
+	}
+	RequestExpiredWebhookFlag = &cli.StringFlag{
+		Name:    "request-expired-webhook",
+		Usage:   "URL that receives a JSON POST for each expired request, empty means no notification",
+		EnvVars: prefixEnvVars("REQUEST_EXPIRED_WEBHOOK"),
+	}
 	PrivateKeyFlag = &cli.StringFlag{
-		Name:     "private-key",
-		Usage:    "Ethereum private key for caller contacts",
-		EnvVars:  prefixEnvVars("PRIVATE_KEY"),
-		Required: true,
+		Name:    "private-key",
+		Usage:   "Ethereum private key for caller contacts, not needed when a signer backend is configured",
+		EnvVars: prefixEnvVars("PRIVATE_KEY"),
 	}
 	DappLinkVrfContractAddressFlag = &cli.StringFlag{
 		Name:     "dapplink-vrf-address",
@@ -106,6 +283,179 @@ var (
 		EnvVars: prefixEnvVars("SAFE_ABORT_NONCE_TOO_LOW_COUNT"),
 		Value:   3,
 	}
+	MaxGasFeeCapFlag = &cli.Uint64Flag{
+		Name:    "max-gas-fee-cap",
+		Usage:   "Upper bound in wei of the gasFeeCap of a fulfillment tx, 0 means unlimited",
+		EnvVars: prefixEnvVars("MAX_GAS_FEE_CAP"),
+	}
+	MaxGasTipCapFlag = &cli.Uint64Flag{
+		Name:    "max-gas-tip-cap",
+		Usage:   "Upper bound in wei of the gasTipCap of a fulfillment tx, 0 means unlimited",
+		EnvVars: prefixEnvVars("MAX_GAS_TIP_CAP"),
+	}
+	MinGasFeeCapFlag = &cli.Uint64Flag{
+		Name:    "min-gas-fee-cap",
+		Usage:   "Lower bound in wei of the gasFeeCap of a fulfillment tx, 0 means no floor",
+		EnvVars: prefixEnvVars("MIN_GAS_FEE_CAP"),
+	}
+	MinGasTipCapFlag = &cli.Uint64Flag{
+		Name:    "min-gas-tip-cap",
+		Usage:   "Lower bound in wei of the gasTipCap of a fulfillment tx, 0 means no floor",
+		EnvVars: prefixEnvVars("MIN_GAS_TIP_CAP"),
+	}
+	FallbackRpcUrlsFlag = &cli.StringSliceFlag{
+		Name:    "fallback-rpc-urls",
+		Usage:   "Extra RPC endpoints the synchronizer fails over to when chain-rpc is unhealthy",
+		EnvVars: prefixEnvVars("FALLBACK_RPC_URLS"),
+	}
+	RpcDialTimeoutFlag = &cli.DurationFlag{
+		Name:    "rpc-dial-timeout",
+		Usage:   "Total time allowed to dial a synchronizer RPC endpoint, including retries, 0 means the default of 5s",
+		EnvVars: prefixEnvVars("RPC_DIAL_TIMEOUT"),
+	}
+	RpcDialAttemptsFlag = &cli.IntFlag{
+		Name:    "rpc-dial-attempts",
+		Usage:   "Number of attempts to dial a synchronizer RPC endpoint, 0 means the default of 5",
+		EnvVars: prefixEnvVars("RPC_DIAL_ATTEMPTS"),
+	}
+	RpcRequestTimeoutFlag = &cli.DurationFlag{
+		Name:    "rpc-request-timeout",
+		Usage:   "Timeout of a single synchronizer RPC request, 0 means the default of 100s (15s per endpoint with fallback-rpc-urls)",
+		EnvVars: prefixEnvVars("RPC_REQUEST_TIMEOUT"),
+	}
+	EnableTraceTransactionFlag = &cli.BoolFlag{
+		Name:    "enable-trace-transaction",
+		Usage:   "Allow the synchronizer to call debug_traceTransaction, the RPC provider must expose the debug namespace",
+		EnvVars: prefixEnvVars("ENABLE_TRACE_TRANSACTION"),
+	}
+	RpcBatchSizeFlag = &cli.IntFlag{
+		Name:    "rpc-batch-size",
+		Usage:   "Max blocks per header request group, overrides the built-in batching profile of the chain when any rpc-batch-* flag is set",
+		EnvVars: prefixEnvVars("RPC_BATCH_SIZE"),
+	}
+	RpcBatchConcurrencyFlag = &cli.IntFlag{
+		Name:    "rpc-batch-concurrency",
+		Usage:   "Number of header request groups fetched concurrently",
+		EnvVars: prefixEnvVars("RPC_BATCH_CONCURRENCY"),
+	}
+	RpcBatchPerCallFlag = &cli.BoolFlag{
+		Name:    "rpc-batch-per-call",
+		Usage:   "Fetch headers with one eth_getBlockByNumber call per block instead of batch RPC",
+		EnvVars: prefixEnvVars("RPC_BATCH_PER_CALL"),
+	}
+	RpcBatchRetriesFlag = &cli.IntFlag{
+		Name:    "rpc-batch-retries",
+		Usage:   "Retries for a header request group that failed or returned missing blocks, only missing blocks are re-requested",
+		EnvVars: prefixEnvVars("RPC_BATCH_RETRIES"),
+	}
+	RpcReadQuorumFlag = &cli.IntFlag{
+		Name:    "rpc-read-quorum",
+		Usage:   "Number of RPC endpoints that must agree on latest headers and receipts, requires fallback-rpc-urls, values below 2 disable quorum reads",
+		EnvVars: prefixEnvVars("RPC_READ_QUORUM"),
+	}
+	RpcCacheSizeFlag = &cli.IntFlag{
+		Name:    "rpc-cache-size",
+		Usage:   "Capacity of the in-memory cache for finalized headers and transactions, 0 uses the default and a negative value disables it",
+		EnvVars: prefixEnvVars("RPC_CACHE_SIZE"),
+	}
+	RpcHeadersFlag = &cli.StringSliceFlag{
+		Name:    "rpc-headers",
+		Usage:   "Extra HTTP headers sent to the synchronizer RPC endpoints, in \"Name: value\" form",
+		EnvVars: prefixEnvVars("RPC_HEADERS"),
+	}
+	RpcBearerTokenFlag = &cli.StringFlag{
+		Name:    "rpc-bearer-token",
+		Usage:   "Bearer token sent in the Authorization header to the synchronizer RPC endpoints",
+		EnvVars: prefixEnvVars("RPC_BEARER_TOKEN"),
+	}
+	RpcBasicAuthFlag = &cli.StringFlag{
+		Name:    "rpc-basic-auth",
+		Usage:   "HTTP basic auth credentials for the synchronizer RPC endpoints, in \"user:password\" form",
+		EnvVars: prefixEnvVars("RPC_BASIC_AUTH"),
+	}
+	RpcJWTSecretFlag = &cli.StringFlag{
+		Name:    "rpc-jwt-secret",
+		Usage:   "Path to a hex encoded 32 byte secret used to sign Engine API style JWTs for the synchronizer RPC endpoints",
+		EnvVars: prefixEnvVars("RPC_JWT_SECRET"),
+	}
+	RpcProxyUrlFlag = &cli.StringFlag{
+		Name:    "rpc-proxy-url",
+		Usage:   "HTTP proxy used to reach the synchronizer RPC endpoints",
+		EnvVars: prefixEnvVars("RPC_PROXY_URL"),
+	}
+	RpcTLSCAFileFlag = &cli.StringFlag{
+		Name:    "rpc-tls-ca-file",
+		Usage:   "PEM file with the CA certificates trusted for the synchronizer RPC endpoints",
+		EnvVars: prefixEnvVars("RPC_TLS_CA_FILE"),
+	}
+	BroadcastRpcUrlsFlag = &cli.StringSliceFlag{
+		Name:    "broadcast-rpc-urls",
+		Usage:   "Extra RPC endpoints that fulfillment txs are broadcast to alongside chain-rpc",
+		EnvVars: prefixEnvVars("BROADCAST_RPC_URLS"),
+	}
+	StuckTxThresholdFlag = &cli.DurationFlag{
+		Name:    "stuck-tx-threshold",
+		Usage:   "How long a published fulfillment tx may stay unmined before it is rebroadcast or replaced, 0 disables the check",
+		EnvVars: prefixEnvVars("STUCK_TX_THRESHOLD"),
+	}
+	TxSendTimeoutFlag = &cli.DurationFlag{
+		Name:    "tx-send-timeout",
+		Usage:   "How long sending a fulfillment tx may take until it is confirmed before the send gives up, 0 means 10m",
+		EnvVars: prefixEnvVars("TX_SEND_TIMEOUT"),
+	}
+	MaxSpendPerHourFlag = &cli.Uint64Flag{
+		Name:    "max-spend-per-hour",
+		Usage:   "Upper bound in gwei of the fees spent on fulfillment txs per hour, 0 means unlimited",
+		EnvVars: prefixEnvVars("MAX_SPEND_PER_HOUR"),
+	}
+	MaxSpendPerDayFlag = &cli.Uint64Flag{
+		Name:    "max-spend-per-day",
+		Usage:   "Upper bound in gwei of the fees spent on fulfillment txs per day, 0 means unlimited",
+		EnvVars: prefixEnvVars("MAX_SPEND_PER_DAY"),
+	}
+	UseAccessListFlag = &cli.BoolFlag{
+		Name:    "use-access-list",
+		Usage:   "Attach an EIP-2930 access list from eth_createAccessList to fulfillment txs when it reduces gas",
+		EnvVars: prefixEnvVars("USE_ACCESS_LIST"),
+	}
+	DryRunFlag = &cli.BoolFlag{
+		Name:    "dry-run",
+		Usage:   "Simulate fulfillment txs with eth_call and log them instead of broadcasting",
+		EnvVars: prefixEnvVars("DRY_RUN"),
+	}
+	SupportsEIP1559Flag = &cli.BoolFlag{
+		Name:    "supports-eip1559",
+		Usage:   "Whether the chain supports EIP-1559 dynamic fee txs, set to false to price txs with eth_gasPrice",
+		Value:   true,
+		EnvVars: prefixEnvVars("SUPPORTS_EIP1559"),
+	}
+	RandomSourceFlag = &cli.StringFlag{
+		Name:    "random-source",
+		Usage:   "Source of the fulfilled random words: crypto (crypto/rand), hmac (HMAC-SHA256 of the request keyed by random-source-key), ecvrf (ECVRF proof with the key in vrf-key-file) or drand (drand beacon round after the request block)",
+		Value:   "crypto",
+		EnvVars: prefixEnvVars("RANDOM_SOURCE"),
+	}
+	RandomSourceKeyFlag = &cli.StringFlag{
+		Name:    "random-source-key",
+		Usage:   "Hex encoded key of at least 32 bytes for the hmac random source",
+		EnvVars: prefixEnvVars("RANDOM_SOURCE_KEY"),
+	}
+	VrfKeyFileFlag = &cli.StringFlag{
+		Name:    "vrf-key-file",
+		Usage:   "File holding the hex encoded secp256k1 key of the ecvrf random source",
+		EnvVars: prefixEnvVars("VRF_KEY_FILE"),
+	}
+	DrandUrlFlag = &cli.StringFlag{
+		Name:    "drand-url",
+		Usage:   "Base url of the drand HTTP API used by the drand random source",
+		Value:   "https://api.drand.sh",
+		EnvVars: prefixEnvVars("DRAND_URL"),
+	}
+	DrandChainHashFlag = &cli.StringFlag{
+		Name:    "drand-chain-hash",
+		Usage:   "Chain hash of the drand network, empty uses the default network of drand-url",
+		EnvVars: prefixEnvVars("DRAND_CHAIN_HASH"),
+	}
 
 	MnemonicFlag = &cli.StringFlag{
 		Name: "mnemonic",
@@ -191,6 +541,139 @@ var (
 		Usage:   "The db name of the slave database",
 		EnvVars: prefixEnvVars("SLAVE_DB_NAME"),
 	}
+
+	// MetricsEnabledFlag Metrics flags
+	MetricsEnabledFlag = &cli.BoolFlag{
+		Name:    "metrics-enabled",
+		Usage:   "Whether to expose prometheus metrics",
+		EnvVars: prefixEnvVars("METRICS_ENABLED"),
+	}
+	MetricsHostFlag = &cli.StringFlag{
+		Name:    "metrics-host",
+		Usage:   "The host of the metrics server",
+		EnvVars: prefixEnvVars("METRICS_HOST"),
+		Value:   "0.0.0.0",
+	}
+	MetricsPortFlag = &cli.IntFlag{
+		Name:    "metrics-port",
+		Usage:   "The port of the metrics server",
+		EnvVars: prefixEnvVars("METRICS_PORT"),
+		Value:   7300,
+	}
+
+	// PublisherFlag message bus flags
+	PublisherFlag = &cli.StringFlag{
+		Name:    "publisher",
+		Usage:   "Message bus that parsed events and fulfillment results are published to: kafka or nats, empty to disable",
+		EnvVars: prefixEnvVars("PUBLISHER"),
+	}
+	PublisherUrlsFlag = &cli.StringSliceFlag{
+		Name:    "publisher-urls",
+		Usage:   "Kafka broker addresses or NATS server urls of the publisher",
+		EnvVars: prefixEnvVars("PUBLISHER_URLS"),
+	}
+	PublisherTopicFlag = &cli.StringFlag{
+		Name:    "publisher-topic",
+		Usage:   "Kafka topic or NATS subject that messages are published to",
+		EnvVars: prefixEnvVars("PUBLISHER_TOPIC"),
+	}
+
+	// SignerFlag external signer flags
+	SignerFlag = &cli.StringFlag{
+		Name:    "signer",
+		Usage:   "Signing backend of the caller: aws-kms, gcp-kms or vault, empty to sign with the private key or mnemonic",
+		EnvVars: prefixEnvVars("SIGNER"),
+	}
+	KMSKeyIdFlag = &cli.StringFlag{
+		Name:    "kms-key-id",
+		Usage:   "Id or ARN of the AWS KMS key, or resource name of the GCP KMS key version used by the signer",
+		EnvVars: prefixEnvVars("KMS_KEY_ID"),
+	}
+	KMSRegionFlag = &cli.StringFlag{
+		Name:    "kms-region",
+		Usage:   "Region of the KMS key, empty to use the default AWS config",
+		EnvVars: prefixEnvVars("KMS_REGION"),
+	}
+	GCPCredentialsFileFlag = &cli.StringFlag{
+		Name:    "gcp-credentials-file",
+		Usage:   "Service account credentials file of the GCP KMS signer, empty to use application default credentials",
+		EnvVars: prefixEnvVars("GCP_CREDENTIALS_FILE"),
+	}
+	VaultAddrFlag = &cli.StringFlag{
+		Name:    "vault-addr",
+		Usage:   "Address of the Vault server that stores the caller private key",
+		EnvVars: prefixEnvVars("VAULT_ADDR"),
+	}
+	VaultTokenFlag = &cli.StringFlag{
+		Name:    "vault-token",
+		Usage:   "Vault token, empty to log in with approle",
+		EnvVars: prefixEnvVars("VAULT_TOKEN"),
+	}
+	VaultRoleIdFlag = &cli.StringFlag{
+		Name:    "vault-role-id",
+		Usage:   "Vault approle role id",
+		EnvVars: prefixEnvVars("VAULT_ROLE_ID"),
+	}
+	VaultSecretIdFlag = &cli.StringFlag{
+		Name:    "vault-secret-id",
+		Usage:   "Vault approle secret id",
+		EnvVars: prefixEnvVars("VAULT_SECRET_ID"),
+	}
+	VaultMountFlag = &cli.StringFlag{
+		Name:    "vault-mount",
+		Usage:   "Mount of the Vault KV v2 engine",
+		EnvVars: prefixEnvVars("VAULT_MOUNT"),
+		Value:   "secret",
+	}
+	VaultPathFlag = &cli.StringFlag{
+		Name:    "vault-path",
+		Usage:   "Path of the secret holding the caller private key under the KV mount",
+		EnvVars: prefixEnvVars("VAULT_PATH"),
+	}
+	VaultKeyFieldFlag = &cli.StringFlag{
+		Name:    "vault-key-field",
+		Usage:   "Field of the secret holding the caller private key, empty means private_key",
+		EnvVars: prefixEnvVars("VAULT_KEY_FIELD"),
+	}
+)
+
+// watch 命令使用的参数
+var (
+	WatchAddressFlag = &cli.StringFlag{
+		Name:     "address",
+		Usage:    "Contract address to add to or remove from the synchronizer watch list",
+		Required: true,
+	}
+	WatchBackfillFromFlag = &cli.Uint64Flag{
+		Name:  "from-height",
+		Usage: "Backfill the logs of the added address starting from this block height, omit to only sync new blocks",
+	}
+)
+
+// replay 命令使用的参数
+var (
+	ReplayFromFlag = &cli.Uint64Flag{
+		Name:     "from-height",
+		Usage:    "First block height of the contract events to reprocess",
+		Required: true,
+	}
+	ReplayToFlag = &cli.Uint64Flag{
+		Name:     "to-height",
+		Usage:    "Last block height of the contract events to reprocess",
+		Required: true,
+	}
+)
+
+// deploy-proxy 命令使用的参数
+var (
+	DeployImplementationFlag = &cli.StringFlag{
+		Name:  "implementation",
+		Usage: "Implementation contract of the new vrf proxy, omit to use the configured dapplink vrf address",
+	}
+	DeployDappLinkAddressFlag = &cli.StringFlag{
+		Name:  "dapplink-address",
+		Usage: "Address allowed to fulfill random words on the new proxy, omit to use the caller address",
+	}
 )
 
 var requiredFlags = []cli.Flag{
@@ -205,8 +688,25 @@ var requiredFlags = []cli.Flag{
 	MainIntervalFlag,
 	BlocksStepFlag,
 	EventIntervalFlag,
+	EventEpochFlag,
+	EventWorkersFlag,
+	GasLimitMultiplierFlag,
+	MaxGasLimitFlag,
+	MulticallAddressFlag,
+	MaxFulfillBatchSizeFlag,
+	TipPercentileFlag,
+	BaseFeeMultiplierFlag,
+	BalanceCheckIntervalFlag,
+	LowBalanceWarningFlag,
+	MinBalanceFlag,
+	VerifyContractSelectorsFlag,
 	CallIntervalFlag,
-	PrivateKeyFlag,
+	MaxConcurrentFulfillmentsFlag,
+	MaxFulfillAttemptsFlag,
+	FulfillRetryBackoffFlag,
+	FulfillMaxRetryBackoffFlag,
+	RequestTTLFlag,
+	RequestExpiredWebhookFlag,
 	DappLinkVrfContractAddressFlag,
 	DappLinkVrfFactoryContractAddressFlag,
 	CallerAddressFlag,
@@ -216,16 +716,83 @@ var requiredFlags = []cli.Flag{
 }
 
 var optionalFlags = []cli.Flag{
+	MaxGasFeeCapFlag,
+	MaxGasTipCapFlag,
+	MinGasFeeCapFlag,
+	MinGasTipCapFlag,
+	FallbackRpcUrlsFlag,
+	RpcDialTimeoutFlag,
+	RpcDialAttemptsFlag,
+	RpcRequestTimeoutFlag,
+	EnableTraceTransactionFlag,
+	RpcBatchSizeFlag,
+	RpcBatchConcurrencyFlag,
+	RpcBatchPerCallFlag,
+	RpcBatchRetriesFlag,
+	RpcReadQuorumFlag,
+	RpcCacheSizeFlag,
+	RpcHeadersFlag,
+	RpcBearerTokenFlag,
+	RpcBasicAuthFlag,
+	RpcJWTSecretFlag,
+	RpcProxyUrlFlag,
+	RpcTLSCAFileFlag,
+	BroadcastRpcUrlsFlag,
+	StuckTxThresholdFlag,
+	TxSendTimeoutFlag,
+	MaxSpendPerHourFlag,
+	MaxSpendPerDayFlag,
+	UseAccessListFlag,
+	DryRunFlag,
+	SupportsEIP1559Flag,
+	RandomSourceFlag,
+	RandomSourceKeyFlag,
+	VrfKeyFileFlag,
+	DrandUrlFlag,
+	DrandChainHashFlag,
+	PrivateKeyFlag,
 	MnemonicFlag,
 	CallerHDPathFlag,
 	PassphraseFlag,
 	StartingHeightFlag,
 	ConfirmationsFlag,
+	SyncConfirmationsFlag,
+	SyncFollowFlag,
+	AheadOfProviderPolicyFlag,
+	SyncCatchUpThresholdFlag,
+	SyncPipelineDepthFlag,
+	GapCheckIntervalFlag,
+	IndexTransactionsFlag,
+	IndexReceiptsFlag,
+	LogSourceFlag,
+	SparseHeadersFlag,
+	SparseHeaderIntervalFlag,
+	BackfillFromFlag,
+	BackfillToFlag,
+	BackfillShardSizeFlag,
+	BackfillWorkersFlag,
 	SlaveDbHostFlag,
 	SlaveDbPortFlag,
 	SlaveDbUserFlag,
 	SlaveDbPasswordFlag,
 	SlaveDbNameFlag,
+	MetricsEnabledFlag,
+	MetricsHostFlag,
+	MetricsPortFlag,
+	PublisherFlag,
+	PublisherUrlsFlag,
+	PublisherTopicFlag,
+	SignerFlag,
+	KMSKeyIdFlag,
+	KMSRegionFlag,
+	GCPCredentialsFileFlag,
+	VaultAddrFlag,
+	VaultTokenFlag,
+	VaultRoleIdFlag,
+	VaultSecretIdFlag,
+	VaultMountFlag,
+	VaultPathFlag,
+	VaultKeyFieldFlag,
 }
 
 func init() {
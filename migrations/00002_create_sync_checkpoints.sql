@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS sync_checkpoints (
+    name        VARCHAR PRIMARY KEY,
+    hash        VARCHAR NOT NULL,
+    number      UINT256 NOT NULL,
+    rlp_bytes   VARCHAR NOT NULL,
+    updated_at  INTEGER NOT NULL CHECK (updated_at > 0)
+);
@@ -0,0 +1,8 @@
+ALTER TABLE proxy_created ADD COLUMN IF NOT EXISTS block_number UINT256 NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS proxy_created_block_number ON proxy_created(block_number);
+
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS block_number UINT256 NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS request_sent_block_number ON request_sent(block_number);
+
+ALTER TABLE fill_random_words ADD COLUMN IF NOT EXISTS block_number UINT256 NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS fill_random_words_block_number ON fill_random_words(block_number);
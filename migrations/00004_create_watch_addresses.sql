@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS watch_addresses (
+    address       VARCHAR PRIMARY KEY,
+    enabled       BOOLEAN NOT NULL,
+    backfill_from UINT256,
+    backfilled    BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at    INTEGER NOT NULL CHECK (updated_at > 0)
+);
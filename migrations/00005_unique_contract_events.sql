@@ -0,0 +1,25 @@
+-- 同一个区块中的同一条日志只保留一条事件，重复同步时依赖该索引跳过已存在的事件
+-- 索引只需要建立一次，已存在时跳过下面的清理，避免每次启动都扫描整张表
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_indexes WHERE indexname = 'contract_events_block_hash_log_index') THEN
+        -- 之前写入的事件没有记录 log_index（均为 0），先删除重复同步产生的完全相同的事件
+        DELETE FROM contract_events a
+            USING contract_events b
+            WHERE a.block_hash = b.block_hash
+              AND a.transaction_hash = b.transaction_hash
+              AND a.log_index = b.log_index
+              AND a.rlp_bytes = b.rlp_bytes
+              AND a.guid > b.guid;
+        -- 同一区块中剩下的多条旧事件无法从 rlp_bytes 恢复真实的 log_index，删除这些区块头（事件级联删除）
+        -- 删除后留下的区块缺口用 backfill 命令重新拉取，重新写入的事件带有真实的 log_index
+        DELETE FROM block_headers
+            WHERE hash IN (
+                SELECT block_hash FROM contract_events
+                    WHERE log_index = 0
+                    GROUP BY block_hash
+                    HAVING COUNT(*) > 1
+            );
+        CREATE UNIQUE INDEX contract_events_block_hash_log_index ON contract_events(block_hash, log_index);
+    END IF;
+END $$;
@@ -0,0 +1,19 @@
+-- 发往监听合约的交易，稀疏模式下所在区块头可能不存储，因此不引用 block_headers，重组回滚时按高度删除
+CREATE TABLE IF NOT EXISTS contract_transactions (
+    guid              VARCHAR PRIMARY KEY,
+    block_hash        VARCHAR NOT NULL,
+    block_number      UINT256 NOT NULL,
+    transaction_hash  VARCHAR NOT NULL UNIQUE,
+    transaction_index INTEGER NOT NULL,
+    from_address      VARCHAR NOT NULL,
+    to_address        VARCHAR NOT NULL,
+    method_id         VARCHAR NOT NULL,
+    timestamp         INTEGER NOT NULL CHECK (timestamp > 0),
+    status            INTEGER,
+    gas_used          INTEGER,
+    rlp_bytes         VARCHAR NOT NULL
+);
+CREATE INDEX IF NOT EXISTS contract_transactions_block_number ON contract_transactions(block_number);
+CREATE INDEX IF NOT EXISTS contract_transactions_block_hash ON contract_transactions(block_hash);
+CREATE INDEX IF NOT EXISTS contract_transactions_to_address ON contract_transactions(to_address);
+CREATE INDEX IF NOT EXISTS contract_transactions_method_id ON contract_transactions(method_id);
@@ -0,0 +1,5 @@
+-- 区块头和事件的最终性：0 unsafe，1 safe，2 finalized，由同步器随链上 safe / finalized 区块推进更新
+ALTER TABLE block_headers ADD COLUMN IF NOT EXISTS finality SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE contract_events ADD COLUMN IF NOT EXISTS finality SMALLINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS block_headers_unfinalized ON block_headers(number) WHERE finality < 2;
+CREATE INDEX IF NOT EXISTS contract_events_unfinalized ON contract_events(block_hash) WHERE finality < 2;
@@ -0,0 +1,16 @@
+-- 业务表按事件的业务键唯一，同一个请求或代理只保留一条记录，按产生该行的日志去重见 00020
+-- 先删除重复处理产生的记录，同一请求保留状态最高的一条，避免已上传随机数的请求被重新处理
+DELETE FROM request_sent a
+    USING request_sent b
+    WHERE a.request_id = b.request_id
+      AND a.vrf_address = b.vrf_address
+      AND (a.status < b.status OR (a.status = b.status AND a.guid > b.guid));
+CREATE UNIQUE INDEX IF NOT EXISTS request_sent_request_id_vrf_address ON request_sent(request_id, vrf_address);
+
+-- fill_random_words 的唯一键包含 vrf_address，见 00019
+
+DELETE FROM proxy_created a
+    USING proxy_created b
+    WHERE a.proxy_address = b.proxy_address
+      AND a.guid > b.guid;
+CREATE UNIQUE INDEX IF NOT EXISTS proxy_created_unique_proxy_address ON proxy_created(proxy_address);
@@ -0,0 +1,4 @@
+-- 按合约 ABI 解码的事件参数，可以按业务字段查询事件，例如 decoded_args @> '{"requestId": "1"}'
+-- 之前写入的事件为空，重新回填对应区块时补上
+ALTER TABLE contract_events ADD COLUMN IF NOT EXISTS decoded_args JSONB;
+CREATE INDEX IF NOT EXISTS contract_events_decoded_args ON contract_events USING GIN (decoded_args);
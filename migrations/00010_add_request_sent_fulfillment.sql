@@ -0,0 +1,4 @@
+-- 事件处理器解析到 FillRandomWords 事件时记录回填随机数的交易，并把请求标记为已完成
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS fulfill_tx_hash VARCHAR;
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS fulfill_block_number UINT256;
+CREATE INDEX IF NOT EXISTS request_sent_fulfill_block_number ON request_sent(fulfill_block_number) WHERE fulfill_block_number IS NOT NULL;
@@ -0,0 +1,2 @@
+-- 回填随机数的交易模拟执行或上链执行失败时记录解析出的回滚原因，请求完成后清空
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS failure_reason VARCHAR NOT NULL DEFAULT '';
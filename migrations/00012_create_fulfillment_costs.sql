@@ -0,0 +1,16 @@
+-- 每次回填交易的 gas 花费，执行失败的交易同样消耗 gas，一并记录；同一笔交易回填多个请求时每个请求一行，按请求数均摊
+CREATE TABLE IF NOT EXISTS fulfillment_costs (
+    guid                VARCHAR PRIMARY KEY,
+    request_id          UINT256 NOT NULL,
+    vrf_address         VARCHAR NOT NULL,
+    transaction_hash    VARCHAR NOT NULL,
+    block_number        UINT256 NOT NULL,
+    status              INTEGER NOT NULL,
+    gas_used            UINT256 NOT NULL,
+    effective_gas_price UINT256 NOT NULL,
+    fee                 UINT256 NOT NULL,
+    timestamp           INTEGER NOT NULL,
+    UNIQUE (request_id, transaction_hash)
+);
+CREATE INDEX IF NOT EXISTS fulfillment_costs_vrf_address ON fulfillment_costs(vrf_address);
+CREATE INDEX IF NOT EXISTS fulfillment_costs_block_number ON fulfillment_costs(block_number);
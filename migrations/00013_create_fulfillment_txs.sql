@@ -0,0 +1,16 @@
+-- 回填请求发出的每一笔交易，包括提价替换掉的交易，用于查询回填某个请求的交易和按请求排查卡住的交易
+CREATE TABLE IF NOT EXISTS fulfillment_txs (
+    guid             VARCHAR PRIMARY KEY,
+    request_id       UINT256 NOT NULL,
+    vrf_address      VARCHAR NOT NULL,
+    transaction_hash VARCHAR NOT NULL,
+    nonce            INTEGER NOT NULL,
+    status           SMALLINT NOT NULL DEFAULT 0,
+    replaced_by      VARCHAR,
+    block_number     UINT256,
+    receipt_status   INTEGER,
+    timestamp        INTEGER NOT NULL,
+    UNIQUE (request_id, transaction_hash)
+);
+CREATE INDEX IF NOT EXISTS fulfillment_txs_transaction_hash ON fulfillment_txs(transaction_hash);
+CREATE INDEX IF NOT EXISTS fulfillment_txs_status ON fulfillment_txs(status);
@@ -0,0 +1,2 @@
+-- 随机数来源为 ecvrf 时工作器回填后保存的证明（RFC 9381 编码的十六进制），任何人可以用 VRF 公钥验证回填的随机数
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS vrf_proof VARCHAR NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- 随机数来源为 drand 时回填使用的信标轮次和签名，用于审计
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS drand_round BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS drand_signature VARCHAR NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- 回填重试：记录已尝试次数、最近一次失败的错误和下一次重试的时间（unix 秒），超过最大次数后 status 置为 2（失败），不再重试
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS attempt_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS last_error VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS next_retry_at INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS request_sent_pending_retry ON request_sent(next_retry_at) WHERE status = 0;
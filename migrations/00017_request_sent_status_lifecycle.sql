@@ -0,0 +1,5 @@
+-- 请求状态：0 pending，1 fulfilled，2 failed，3 in_flight，4 expired，5 skipped
+-- 工作器发送回填交易前把请求置为 in_flight，进程在发送过程中退出后不会被当作未处理的请求再次回填
+ALTER TABLE request_sent DROP CONSTRAINT IF EXISTS request_sent_status_check;
+ALTER TABLE request_sent ADD CONSTRAINT request_sent_status_check CHECK (status IN (0, 1, 2, 3, 4, 5));
+CREATE INDEX IF NOT EXISTS request_sent_status ON request_sent(status);
@@ -0,0 +1,5 @@
+-- 请求事件所在区块的时间，用于按请求有效期判断过期；已有的请求从已存储的区块头补齐
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS block_timestamp INTEGER NOT NULL DEFAULT 0;
+UPDATE request_sent SET block_timestamp = block_headers.timestamp
+    FROM block_headers
+    WHERE request_sent.block_timestamp = 0 AND block_headers.number = request_sent.block_number;
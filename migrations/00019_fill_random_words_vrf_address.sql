@@ -0,0 +1,15 @@
+-- 不同代理合约的请求 ID 会重复，回填结果按 (request_id, vrf_address) 唯一
+ALTER TABLE fill_random_words ADD COLUMN IF NOT EXISTS vrf_address VARCHAR;
+-- 已有的回填结果从记录了同一回填区块的请求补齐合约地址，找不到对应请求的保持为 NULL
+UPDATE fill_random_words SET vrf_address = request_sent.vrf_address
+    FROM request_sent
+    WHERE fill_random_words.vrf_address IS NULL
+      AND request_sent.request_id = fill_random_words.request_id
+      AND request_sent.fulfill_block_number = fill_random_words.block_number;
+DROP INDEX IF EXISTS fill_random_words_unique_request_id;
+DELETE FROM fill_random_words a
+    USING fill_random_words b
+    WHERE a.request_id = b.request_id
+      AND a.vrf_address = b.vrf_address
+      AND a.guid > b.guid;
+CREATE UNIQUE INDEX IF NOT EXISTS fill_random_words_request_id_vrf_address ON fill_random_words(request_id, vrf_address);
@@ -0,0 +1,13 @@
+-- 业务表记录产生该行的日志 (transaction_hash, log_index)，同一条日志只能对应一行
+-- 已有的行为 NULL，重新解析（replay）对应区块后补齐
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS transaction_hash VARCHAR;
+ALTER TABLE request_sent ADD COLUMN IF NOT EXISTS log_index INTEGER;
+CREATE UNIQUE INDEX IF NOT EXISTS request_sent_transaction_hash_log_index ON request_sent(transaction_hash, log_index);
+
+ALTER TABLE fill_random_words ADD COLUMN IF NOT EXISTS transaction_hash VARCHAR;
+ALTER TABLE fill_random_words ADD COLUMN IF NOT EXISTS log_index INTEGER;
+CREATE UNIQUE INDEX IF NOT EXISTS fill_random_words_transaction_hash_log_index ON fill_random_words(transaction_hash, log_index);
+
+ALTER TABLE proxy_created ADD COLUMN IF NOT EXISTS transaction_hash VARCHAR;
+ALTER TABLE proxy_created ADD COLUMN IF NOT EXISTS log_index INTEGER;
+CREATE UNIQUE INDEX IF NOT EXISTS proxy_created_transaction_hash_log_index ON proxy_created(transaction_hash, log_index);
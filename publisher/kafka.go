@@ -0,0 +1,33 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// 发布到 Kafka topic，同一 key 的消息写入同一分区
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func newKafkaPublisher(cfg Config) (*kafkaPublisher, error) {
+	if len(cfg.Urls) == 0 || cfg.Topic == "" {
+		return nil, errors.New("kafka publisher requires broker urls and a topic")
+	}
+	return &kafkaPublisher{writer: &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Urls...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}}, nil
+}
+
+func (p *kafkaPublisher) Publish(ctx context.Context, key string, value []byte) error {
+	return p.writer.WriteMessages(ctx, kafka.Message{Key: []byte(key), Value: value})
+}
+
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,38 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+// 发布到 NATS subject，等待服务器确认收到后返回
+type natsPublisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func newNatsPublisher(cfg Config) (*natsPublisher, error) {
+	if len(cfg.Urls) == 0 || cfg.Topic == "" {
+		return nil, errors.New("nats publisher requires server urls and a subject")
+	}
+	conn, err := nats.Connect(strings.Join(cfg.Urls, ","), nats.Name("contracts-caller"))
+	if err != nil {
+		return nil, err
+	}
+	return &natsPublisher{conn: conn, subject: cfg.Topic}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, _ string, value []byte) error {
+	if err := p.conn.Publish(p.subject, value); err != nil {
+		return err
+	}
+	return p.conn.FlushWithContext(ctx)
+}
+
+// 发送缓冲中的消息后关闭连接
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,106 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/*
+	消息总线发布：
+		- 事件处理器在每批业务数据写库成功后，把解析出的合约事件逐条发布；工作器在随机数回填交易上链后发布执行结果，请求回填失败次数达到上限时发布告警
+		- 支持 Kafka（发布到 topic，消息 key 为交易哈希）和 NATS（发布到 subject），未配置时不发布
+		- 消息为 JSON，type 字段区分事件（event）、回填结果（fulfillment）和请求失败告警（request_failed）
+		- 发布失败只记录日志，不影响索引和回填；写库后、发布前进程退出的消息不会补发，下游按 (blockHash, logIndex) 去重
+*/
+
+const (
+	TypeKafka = "kafka"
+	TypeNats  = "nats"
+
+	MessageTypeEvent         = "event"
+	MessageTypeFulfillment   = "fulfillment"
+	MessageTypeRequestFailed = "request_failed"
+
+	// 单条消息的发布超时
+	publishTimeout = 10 * time.Second
+)
+
+type Config struct {
+	Type  string   // kafka 或 nats，为空时不发布
+	Urls  []string // Kafka broker 地址或 NATS 服务器地址
+	Topic string   // Kafka topic 或 NATS subject
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, key string, value []byte) error
+	Close() error
+}
+
+// 解析出的合约事件
+type EventMessage struct {
+	Type            string         `json:"type"`
+	Name            string         `json:"name"`
+	ContractAddress string         `json:"contractAddress"`
+	BlockHash       string         `json:"blockHash"`
+	BlockNumber     string         `json:"blockNumber"`
+	TransactionHash string         `json:"transactionHash"`
+	LogIndex        uint64         `json:"logIndex"`
+	Timestamp       uint64         `json:"timestamp"`
+	Args            map[string]any `json:"args"`
+}
+
+// 随机数回填交易的执行结果
+type FulfillmentMessage struct {
+	Type            string `json:"type"`
+	RequestId       string `json:"requestId"`
+	TransactionHash string `json:"transactionHash"`
+	BlockNumber     string `json:"blockNumber"`
+	Status          uint64 `json:"status"`
+}
+
+// 请求回填失败次数达到上限，不再重试
+type RequestFailedMessage struct {
+	Type       string `json:"type"`
+	RequestId  string `json:"requestId"`
+	VrfAddress string `json:"vrfAddress"`
+	Attempts   int    `json:"attempts"`
+	LastError  string `json:"lastError"`
+}
+
+// 按配置创建发布者，Type 为空时返回 nil
+func NewPublisher(cfg Config) (Publisher, error) {
+	switch cfg.Type {
+	case "":
+		return nil, nil
+	case TypeKafka:
+		p, err := newKafkaPublisher(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case TypeNats:
+		p, err := newNatsPublisher(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unknown publisher %q, expected kafka or nats", cfg.Type)
+	}
+}
+
+// 以 JSON 发布一条消息，p 为 nil 时不发布
+func PublishJSON(ctx context.Context, p Publisher, key string, message any) error {
+	if p == nil {
+		return nil
+	}
+	value, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	return p.Publish(ctx, key, value)
+}
@@ -0,0 +1,41 @@
+package dapplink_vrf
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/config"
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// 重新解析 [from, to] 内已存储的合约事件并补写业务表中缺失的行，完成后退出
+func RunReplay(ctx context.Context, cfg *config.Config, from, to *big.Int) error {
+	db, err := database.NewDB(ctx, cfg.MasterDB)
+	if err != nil {
+		log.Error("new database fail", "err", err)
+		return err
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Error("close database fail", "err", err)
+		}
+	}()
+
+	eventsHandler, err := event.NewEventsHandler(db, &event.EventsHandlerConfig{
+		DappLinkVrfAddress:        cfg.Chain.DappLinkVrfContractAddress,
+		DappLinkVrfFactoryAddress: cfg.Chain.DappLinkVrfFactoryContractAddress,
+		Epoch:                     cfg.Chain.EventEpoch,
+		Workers:                   cfg.Chain.EventWorkers,
+	}, nil, func(error) {})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := eventsHandler.Close(); err != nil {
+			log.Error("close events handler fail", "err", err)
+		}
+	}()
+	return eventsHandler.Replay(ctx, from, to)
+}
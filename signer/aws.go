@@ -0,0 +1,63 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/driver"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	AWS KMS 签名：
+		- 使用 ECC_SECG_P256K1 非对称密钥，凭证按 AWS SDK 的默认方式获取（环境变量、共享配置、实例角色等）
+		- 启动时通过 GetPublicKey 取得公钥并计算地址，签名时以 DIGEST 方式提交交易哈希
+*/
+
+// 创建使用 AWS KMS 密钥 keyId 签名的 Signer，region 为空时使用默认配置中的区域
+func NewAWSKMSSigner(ctx context.Context, keyId string, region string, chainId *big.Int) (driver.Signer, error) {
+	if keyId == "" {
+		return nil, fmt.Errorf("aws kms key id is required")
+	}
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load aws config: %w", err)
+	}
+	client := kms.NewFromConfig(awsCfg)
+
+	output, err := client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(keyId)})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get kms public key: %w", err)
+	}
+	if output.KeySpec != kmstypes.KeySpecEccSecgP256k1 {
+		return nil, fmt.Errorf("kms key %s is %s, expected %s", keyId, output.KeySpec, kmstypes.KeySpecEccSecgP256k1)
+	}
+	publicKey, err := parsePublicKey(output.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newDigestSigner(publicKey, chainId, func(ctx context.Context, digest []byte) ([]byte, error) {
+		output, err := client.Sign(ctx, &kms.SignInput{
+			KeyId:            aws.String(keyId),
+			Message:          digest,
+			MessageType:      kmstypes.MessageTypeDigest,
+			SigningAlgorithm: kmstypes.SigningAlgorithmSpecEcdsaSha256,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("kms sign: %w", err)
+		}
+		return output.Signature, nil
+	})
+	log.Info("using aws kms signer", "keyId", keyId, "address", s.Address())
+	return s, nil
+}
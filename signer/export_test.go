@@ -0,0 +1,6 @@
+package signer
+
+var (
+	NewDigestSigner = newDigestSigner
+	EthSignature    = ethSignature
+)
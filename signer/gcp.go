@@ -0,0 +1,66 @@
+package signer
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/WJX2001/contract-caller/driver"
+	"github.com/ethereum/go-ethereum/log"
+	"google.golang.org/api/option"
+)
+
+/*
+	GCP Cloud KMS 签名：
+		- 使用算法为 EC_SIGN_SECP256K1_SHA256 的密钥版本（软件或 HSM 保护级别均可），按资源名指定：
+		  projects/{project}/locations/{location}/keyRings/{ring}/cryptoKeys/{key}/cryptoKeyVersions/{version}
+		- 凭证优先使用配置的服务账号文件，否则按 Application Default Credentials 获取
+		- 启动时取得 PEM 公钥并计算地址，签名时以 SHA-256 摘要方式提交交易哈希
+*/
+
+// 创建使用 GCP KMS 密钥版本 keyName 签名的 Signer，credentialsFile 为空时使用默认凭证
+func NewGCPKMSSigner(ctx context.Context, keyName string, credentialsFile string, chainId *big.Int) (driver.Signer, error) {
+	if keyName == "" {
+		return nil, fmt.Errorf("gcp kms key name is required")
+	}
+	var opts []option.ClientOption
+	if credentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsFile))
+	}
+	client, err := kms.NewKeyManagementClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create gcp kms client: %w", err)
+	}
+
+	response, err := client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: keyName})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get kms public key: %w", err)
+	}
+	if response.Algorithm != kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256 {
+		return nil, fmt.Errorf("kms key %s is %s, expected %s", keyName, response.Algorithm, kmspb.CryptoKeyVersion_EC_SIGN_SECP256K1_SHA256)
+	}
+	block, _ := pem.Decode([]byte(response.Pem))
+	if block == nil {
+		return nil, fmt.Errorf("unable to decode kms public key pem")
+	}
+	publicKey, err := parsePublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newDigestSigner(publicKey, chainId, func(ctx context.Context, digest []byte) ([]byte, error) {
+		response, err := client.AsymmetricSign(ctx, &kmspb.AsymmetricSignRequest{
+			Name:   keyName,
+			Digest: &kmspb.Digest{Digest: &kmspb.Digest_Sha256{Sha256: digest}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("kms sign: %w", err)
+		}
+		return response.Signature, nil
+	})
+	log.Info("using gcp kms signer", "key", keyName, "address", s.Address())
+	return s, nil
+}
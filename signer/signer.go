@@ -0,0 +1,151 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/driver"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/*
+	外部签名后端：
+		- 私钥保存在 KMS 等外部服务中，进程内只持有公钥，交易哈希交给外部服务签名，生产环境不需要在内存或参数中保存私钥
+		- 支持 AWS KMS 和 GCP Cloud KMS（包括 HSM 保护级别）的 secp256k1 密钥
+		- 外部服务返回 DER 编码的 (r, s)，转为以太坊签名时把 s 规范到低半区（EIP-2），并通过公钥恢复出 v
+		- Vault 只用于在启动时读取私钥，签名在本地完成
+		- Type 为空时返回 nil，由调用方使用本地私钥
+*/
+
+const (
+	TypeAWSKMS = "aws-kms"
+	TypeGCPKMS = "gcp-kms"
+	TypeVault  = "vault"
+)
+
+type Config struct {
+	Type      string // aws-kms、gcp-kms 或 vault，为空时使用本地私钥
+	KMSKeyId  string // AWS KMS 密钥 ID 或 ARN；GCP KMS 密钥版本的资源名
+	KMSRegion string // AWS KMS 所在区域，为空时使用默认配置
+
+	GCPCredentialsFile string // GCP 服务账号凭证文件，为空时使用默认凭证
+
+	Vault VaultConfig
+}
+
+// 按配置创建签名后端，Type 为空时返回 nil
+func NewSigner(ctx context.Context, cfg Config, chainId *big.Int) (driver.Signer, error) {
+	switch cfg.Type {
+	case "":
+		return nil, nil
+	case TypeAWSKMS:
+		return NewAWSKMSSigner(ctx, cfg.KMSKeyId, cfg.KMSRegion, chainId)
+	case TypeGCPKMS:
+		return NewGCPKMSSigner(ctx, cfg.KMSKeyId, cfg.GCPCredentialsFile, chainId)
+	case TypeVault:
+		return NewVaultSigner(ctx, cfg.Vault, chainId)
+	default:
+		return nil, fmt.Errorf("unknown signer %q, expected %s, %s or %s", cfg.Type, TypeAWSKMS, TypeGCPKMS, TypeVault)
+	}
+}
+
+// 对 32 字节摘要签名，返回 DER 编码的 ECDSA 签名
+type signDigestFn func(ctx context.Context, digest []byte) ([]byte, error)
+
+// 通过外部服务签名交易哈希的 Signer
+type digestSigner struct {
+	publicKey []byte // 未压缩公钥，用于恢复 v
+	address   common.Address
+	signer    types.Signer
+	sign      signDigestFn
+}
+
+func newDigestSigner(publicKey *ecdsa.PublicKey, chainId *big.Int, sign signDigestFn) *digestSigner {
+	return &digestSigner{
+		publicKey: crypto.FromECDSAPub(publicKey),
+		address:   crypto.PubkeyToAddress(*publicKey),
+		signer:    types.LatestSignerForChainID(chainId),
+		sign:      sign,
+	}
+}
+
+func (s *digestSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *digestSigner) SignTx(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	hash := s.signer.Hash(tx)
+	der, err := s.sign(ctx, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	sig, err := ethSignature(der, hash[:], s.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(s.signer, sig)
+}
+
+// DER 编码的 SubjectPublicKeyInfo
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// 解析 DER 编码的 secp256k1 公钥
+func parsePublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	var info subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("unable to parse public key: %w", err)
+	}
+	return crypto.UnmarshalPubkey(info.PublicKey.Bytes)
+}
+
+// DER 编码的 ECDSA 签名
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// 把 DER 签名转为 65 字节的以太坊签名 [R || S || V]
+func ethSignature(der []byte, digest []byte, publicKey []byte) ([]byte, error) {
+	var rs ecdsaSignature
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, fmt.Errorf("unable to parse signature: %w", err)
+	}
+	// r、s 必须在 [1, N-1] 内，否则不是有效签名，超过 32 字节时 FillBytes 会 panic
+	if !validScalar(rs.R) || !validScalar(rs.S) {
+		return nil, errors.New("signature r or s out of range")
+	}
+	// 以太坊只接受低 s 值
+	if rs.S.Cmp(secp256k1HalfN) > 0 {
+		rs.S = new(big.Int).Sub(secp256k1N, rs.S)
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	rs.R.FillBytes(sig[:32])
+	rs.S.FillBytes(sig[32:64])
+	for v := byte(0); v < 2; v++ {
+		sig[64] = v
+		recovered, err := crypto.Ecrecover(digest, sig)
+		if err == nil && string(recovered) == string(publicKey) {
+			return sig, nil
+		}
+	}
+	return nil, errors.New("unable to recover signer public key from signature")
+}
+
+func validScalar(x *big.Int) bool {
+	return x != nil && x.Sign() > 0 && x.Cmp(secp256k1N) < 0
+}
@@ -0,0 +1,95 @@
+package signer_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/WJX2001/contract-caller/signer"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+var secp256k1N = crypto.S256().Params().N
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+func encodeDER(t *testing.T, r, s *big.Int) []byte {
+	der, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	require.NoError(t, err)
+	return der
+}
+
+// 用本地私钥模拟外部签名服务，返回 DER 编码的签名，highS 为 true 时返回高半区的 s
+func localSignFn(t *testing.T, key *ecdsa.PrivateKey, highS bool) func(ctx context.Context, digest []byte) ([]byte, error) {
+	return func(ctx context.Context, digest []byte) ([]byte, error) {
+		sig, err := crypto.Sign(digest, key)
+		require.NoError(t, err)
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+		if highS {
+			s.Sub(secp256k1N, s)
+		}
+		return encodeDER(t, r, s), nil
+	}
+}
+
+func TestDigestSignerRecoversAddress(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	chainId := big.NewInt(11155111)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     1,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		Gas:       21000,
+		To:        &common.Address{},
+		Value:     big.NewInt(1),
+	})
+
+	for name, highS := range map[string]bool{"low s": false, "high s": true} {
+		t.Run(name, func(t *testing.T) {
+			s := signer.NewDigestSigner(&key.PublicKey, chainId, localSignFn(t, key, highS))
+			require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), s.Address())
+
+			signed, err := s.SignTx(context.Background(), tx)
+			require.NoError(t, err)
+			_, _, sigS := signed.RawSignatureValues()
+			require.LessOrEqual(t, sigS.Cmp(new(big.Int).Rsh(secp256k1N, 1)), 0)
+
+			sender, err := types.Sender(types.LatestSignerForChainID(chainId), signed)
+			require.NoError(t, err)
+			require.Equal(t, s.Address(), sender)
+		})
+	}
+}
+
+func TestEthSignatureRejectsOutOfRangeScalars(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	publicKey := crypto.FromECDSAPub(&key.PublicKey)
+	digest := crypto.Keccak256([]byte("digest"))
+	one := big.NewInt(1)
+
+	cases := map[string][2]*big.Int{
+		"zero r":     {new(big.Int), one},
+		"zero s":     {one, new(big.Int)},
+		"negative r": {big.NewInt(-1), one},
+		"negative s": {one, big.NewInt(-1)},
+		"r equals n": {secp256k1N, one},
+		"s equals n": {one, secp256k1N},
+		"oversized":  {new(big.Int).Lsh(one, 300), one},
+	}
+	for name, rs := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := signer.EthSignature(encodeDER(t, rs[0], rs[1]), digest, publicKey)
+			require.Error(t, err)
+		})
+	}
+}
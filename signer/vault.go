@@ -0,0 +1,144 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	common2 "github.com/WJX2001/contract-caller/common"
+	"github.com/WJX2001/contract-caller/driver"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	HashiCorp Vault 签名：
+		- Vault transit 引擎不支持 secp256k1，因此改为启动时从 KV v2 读取私钥，私钥不出现在参数和环境变量中，只保存在进程内存
+		- 认证方式：配置了 VaultToken 时直接使用，否则通过 AppRole（role_id + secret_id）登录换取 token
+		- KV 路径为挂载点下的密钥路径（例如 secret/contracts-caller），私钥存放在 KeyField 字段中，默认 private_key
+*/
+
+const (
+	defaultVaultKeyField = "private_key"
+
+	// 单次 Vault 请求的超时
+	vaultRequestTimeout = 10 * time.Second
+)
+
+type VaultConfig struct {
+	Addr     string // Vault 地址，例如 https://vault:8200
+	Token    string // 访问 token，为空时使用 AppRole 登录
+	RoleId   string // AppRole role_id
+	SecretId string // AppRole secret_id
+	Mount    string // KV v2 挂载点
+	Path     string // 挂载点下的密钥路径
+	KeyField string // 私钥所在字段，为空时使用 private_key
+}
+
+// 从 Vault KV 读取私钥并创建本地签名的 Signer
+func NewVaultSigner(ctx context.Context, cfg VaultConfig, chainId *big.Int) (driver.Signer, error) {
+	if cfg.Addr == "" || cfg.Mount == "" || cfg.Path == "" {
+		return nil, fmt.Errorf("vault address, mount and path are required")
+	}
+	client := &vaultClient{addr: strings.TrimSuffix(cfg.Addr, "/"), token: cfg.Token, http: &http.Client{Timeout: vaultRequestTimeout}}
+	if client.token == "" {
+		if err := client.loginAppRole(ctx, cfg.RoleId, cfg.SecretId); err != nil {
+			return nil, err
+		}
+	}
+
+	keyField := cfg.KeyField
+	if keyField == "" {
+		keyField = defaultVaultKeyField
+	}
+	data, err := client.readKV(ctx, cfg.Mount, cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	privKeyStr, ok := data[keyField].(string)
+	if !ok || privKeyStr == "" {
+		return nil, fmt.Errorf("vault secret %s/%s has no field %s", cfg.Mount, cfg.Path, keyField)
+	}
+	privKey, err := common2.ParsePrivateKeyStr(privKeyStr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key from vault: %w", err)
+	}
+
+	s := driver.NewPrivateKeySigner(privKey, chainId)
+	log.Info("using private key from vault", "mount", cfg.Mount, "path", cfg.Path, "address", s.Address())
+	return s, nil
+}
+
+type vaultClient struct {
+	addr  string
+	token string
+	http  *http.Client
+}
+
+// 通过 AppRole 登录，保存返回的 token
+func (c *vaultClient) loginAppRole(ctx context.Context, roleId string, secretId string) error {
+	if roleId == "" || secretId == "" {
+		return fmt.Errorf("vault token or approle role id and secret id are required")
+	}
+	var response struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	body := map[string]string{"role_id": roleId, "secret_id": secretId}
+	if err := c.do(ctx, http.MethodPost, "auth/approle/login", body, &response); err != nil {
+		return fmt.Errorf("vault approle login: %w", err)
+	}
+	if response.Auth.ClientToken == "" {
+		return fmt.Errorf("vault approle login returned no token")
+	}
+	c.token = response.Auth.ClientToken
+	return nil
+}
+
+// 读取 KV v2 中最新版本的密钥数据
+func (c *vaultClient) readKV(ctx context.Context, mount string, path string) (map[string]any, error) {
+	var response struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	apiPath := fmt.Sprintf("%s/data/%s", strings.Trim(mount, "/"), strings.Trim(path, "/"))
+	if err := c.do(ctx, http.MethodGet, apiPath, nil, &response); err != nil {
+		return nil, fmt.Errorf("vault read %s: %w", apiPath, err)
+	}
+	return response.Data.Data, nil
+}
+
+func (c *vaultClient) do(ctx context.Context, method string, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+"/v1/"+path, reader)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
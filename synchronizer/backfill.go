@@ -0,0 +1,287 @@
+package synchronizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/WJX2001/contract-caller/database"
+	common2 "github.com/WJX2001/contract-caller/database/common"
+	"github.com/WJX2001/contract-caller/database/event"
+	"github.com/WJX2001/contract-caller/database/utils"
+	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/WJX2001/contract-caller/synchronizer/retry"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/sync/errgroup"
+)
+
+/*
+	历史区块回填：
+		- 把 [From, To] 按 ShardSize 切成分片，Workers 个分片并发处理，分片轮流分配给各个 RPC 连接
+		- 每个分片独立拉取区块头和日志，在一个事务中写入区块头和合约事件
+		- 写入是幂等的：分片内已存在的区块头跳过，区块头与其事件总在同一事务中写入，已存在的区块头说明事件也已写入
+		- 全部分片完成后校验相邻分片的区块头是否相连，不相连说明回填期间范围内发生了重组
+		- 指定 Addresses 时只回填这些地址的日志，已存在区块中这些地址的事件先删除再写入，用于新增监听地址后补齐历史
+	回填不修改同步器的检查点，适合在首次启动 index 前用于追赶历史数据，默认只回填到 finalized 区块
+*/
+
+const defaultBackfillShardSize = 500
+
+var ErrBackfillHeaderConflict = errors.New("backfilled header conflicts with indexed header")
+
+type BackfillConfig struct {
+	From      *big.Int // 起始区块高度
+	To        *big.Int // 结束区块高度，为 nil 时使用最新的 finalized 区块
+	ShardSize uint64   // 每个分片的区块数
+	Workers   int      // 并发处理的分片数
+	ChainId   uint     // 链 ID，用于选择批量获取区块头的方案
+
+	Addresses []common.Address // 只回填这些地址的日志，为空时回填所有监听地址
+	LogSource LogSourceMode    // 拉取日志的方式
+
+	SparseHeaders        bool   // 只存储包含日志的区块头和周期性的区块头
+	SparseHeaderInterval uint64 // 稀疏模式下周期性存储区块头的间隔，0 使用默认值
+}
+
+type Backfiller struct {
+	db           *database.DB
+	clients      []node.EthClient
+	cfg          BackfillConfig
+	eventTopics  []common.Hash
+	eventDecoder *eventDecoder
+	headerFilter headerFilter
+}
+
+// 分片回填后的首尾区块头，用于校验相邻分片是否相连
+type backfillShard struct {
+	from, to   *big.Int
+	parentHash common.Hash
+	lastHash   common.Hash
+}
+
+func NewBackfiller(db *database.DB, clients []node.EthClient, cfg BackfillConfig) (*Backfiller, error) {
+	if len(clients) == 0 {
+		return nil, errors.New("no rpc client provided for backfill")
+	}
+	if cfg.From == nil {
+		cfg.From = big.NewInt(0)
+	}
+	if cfg.ShardSize == 0 {
+		cfg.ShardSize = defaultBackfillShardSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	eventTopics, err := syncEventTopics()
+	if err != nil {
+		return nil, err
+	}
+	eventDecoder, err := newEventDecoder()
+	if err != nil {
+		return nil, err
+	}
+	return &Backfiller{
+		db:           db,
+		clients:      clients,
+		cfg:          cfg,
+		eventTopics:  eventTopics,
+		eventDecoder: eventDecoder,
+		headerFilter: newHeaderFilter(cfg.SparseHeaders, cfg.SparseHeaderInterval),
+	}, nil
+}
+
+// 回填 [From, To] 范围内的区块头和合约事件，任一分片重试后仍失败时返回错误
+func (b *Backfiller) Run(ctx context.Context) error {
+	to := b.cfg.To
+	if to == nil {
+		finalized, err := b.clients[0].LatestFinalizedBlockHeader()
+		if err != nil {
+			return fmt.Errorf("unable to query latest finalized header: %w", err)
+		}
+		to = finalized.Number
+	}
+	if b.cfg.From.Cmp(to) > 0 {
+		return fmt.Errorf("backfill from %s is greater than to %s", b.cfg.From, to)
+	}
+
+	addressList := b.cfg.Addresses
+	if len(addressList) == 0 {
+		var err error
+		addressList, err = watchAddressList(b.db)
+		if err != nil {
+			return err
+		}
+	}
+
+	var shards []*backfillShard
+	shardSize := new(big.Int).SetUint64(b.cfg.ShardSize)
+	for from := new(big.Int).Set(b.cfg.From); from.Cmp(to) <= 0; from = new(big.Int).Add(from, shardSize) {
+		shardTo := new(big.Int).Add(from, shardSize)
+		shardTo.Sub(shardTo, big.NewInt(1))
+		if shardTo.Cmp(to) > 0 {
+			shardTo = new(big.Int).Set(to)
+		}
+		shards = append(shards, &backfillShard{from: from, to: shardTo})
+	}
+	log.Info("starting backfill", "from", b.cfg.From, "to", to, "shards", len(shards), "workers", b.cfg.Workers, "rpcClients", len(b.clients))
+
+	var done atomic.Int64
+	group, gctx := errgroup.WithContext(ctx)
+	group.SetLimit(b.cfg.Workers)
+	for i, shard := range shards {
+		client := b.clients[i%len(b.clients)]
+		group.Go(func() error {
+			var conflict error
+			retryStrategy := &retry.ExponentialStrategy{Min: 1000, Max: 20_000, MaxJitter: 250}
+			if _, err := retry.Do[interface{}](gctx, 10, retryStrategy, func() (interface{}, error) {
+				err := b.backfillShard(client, shard, addressList)
+				if errors.Is(err, ErrBackfillHeaderConflict) {
+					// 与已存储的数据冲突，重试无法恢复，直接结束重试
+					conflict = err
+					return nil, nil
+				} else if err != nil {
+					log.Warn("unable to backfill shard, retrying", "from", shard.from, "to", shard.to, "err", err)
+				}
+				return nil, err
+			}); err != nil {
+				return fmt.Errorf("unable to backfill blocks %s-%s: %w", shard.from, shard.to, err)
+			} else if conflict != nil {
+				return conflict
+			}
+			log.Info("backfilled shard", "from", shard.from, "to", shard.to, "done", done.Add(1), "shards", len(shards))
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return err
+	}
+
+	for i := 1; i < len(shards); i++ {
+		if shards[i].parentHash != shards[i-1].lastHash {
+			return fmt.Errorf("backfilled shards %s and %s are not linked, the range may have been reorged", shards[i-1].to, shards[i].from)
+		}
+	}
+	log.Info("backfill completed", "from", b.cfg.From, "to", to)
+	return nil
+}
+
+// 拉取并写入一个分片
+func (b *Backfiller) backfillShard(client node.EthClient, shard *backfillShard, addressList []common.Address) error {
+	headers, err := client.BlockHeadersByRange(shard.from, shard.to, b.cfg.ChainId)
+	if err != nil {
+		return err
+	}
+	expected := new(big.Int).Sub(shard.to, shard.from).Uint64() + 1
+	if uint64(len(headers)) != expected {
+		return fmt.Errorf("expected %d headers, got %d", expected, len(headers))
+	}
+	for i := 1; i < len(headers); i++ {
+		if headers[i].ParentHash != headers[i-1].Hash() {
+			return fmt.Errorf("header %s does not link to its parent", headers[i].Number)
+		}
+	}
+	lastHeader := headers[len(headers)-1]
+
+	// 没有需要监听的地址时不拉取日志，空地址列表会匹配所有合约的日志
+	var logs []types.Log
+	if len(addressList) > 0 {
+		result, err := NewLogSource(client, b.cfg.LogSource).FilterLogs(ethereum.FilterQuery{
+			FromBlock: shard.from,
+			ToBlock:   shard.to,
+			Addresses: addressList,
+			Topics:    [][]common.Hash{b.eventTopics},
+		})
+		if err != nil {
+			return err
+		}
+		if result.ToBlockHeader.Hash() != lastHeader.Hash() {
+			return fmt.Errorf("mismatch in FilterLog#ToBlock block hash")
+		}
+		logs = result.Logs
+	}
+
+	if err := b.db.Transaction(func(tx *database.DB) error {
+		return storeBackfillShard(tx, shard, headers, logs, b.cfg.Addresses, b.headerFilter, b.eventDecoder)
+	}); err != nil {
+		return err
+	}
+
+	shard.parentHash = headers[0].ParentHash
+	shard.lastHash = lastHeader.Hash()
+	return nil
+}
+
+// 在事务 tx 中写入分片内尚未存储的区块头及其事件
+// replace 不为空时，已存储区块中这些地址的事件会被替换为本次拉取的日志
+func storeBackfillShard(tx *database.DB, shard *backfillShard, headers []types.Header, logs []types.Log, replace []common.Address, filter headerFilter, decoder *eventDecoder) error {
+	indexed, err := tx.Blocks.BlockHeadersByRange(shard.from, shard.to)
+	if err != nil {
+		return err
+	}
+	indexedHashes := make(map[string]common.Hash, len(indexed))
+	for _, header := range indexed {
+		indexedHashes[header.Number.String()] = header.Hash
+	}
+
+	// 需要写入事件的区块：新写入的区块，以及替换模式下的所有区块
+	withLogs := blocksWithLogs(logs)
+	eventHeaders := make(map[common.Hash]*types.Header, len(headers))
+	blockHeaders := make([]common2.BlockHeader, 0, len(headers))
+	for i := range headers {
+		header := &headers[i]
+		if _, ok := indexedHashes[header.Number.String()]; !ok && !filter.keep(header, withLogs, i == len(headers)-1) {
+			continue
+		}
+		if hash, ok := indexedHashes[header.Number.String()]; ok {
+			if hash != header.Hash() {
+				return fmt.Errorf("%w: block %s indexed as %s, provider returned %s", ErrBackfillHeaderConflict, header.Number, hash, header.Hash())
+			}
+			if len(replace) > 0 {
+				eventHeaders[header.Hash()] = header
+			}
+			continue
+		}
+		eventHeaders[header.Hash()] = header
+		blockHeaders = append(blockHeaders, common2.BlockHeader{
+			Hash:       header.Hash(),
+			ParentHash: header.ParentHash,
+			Number:     header.Number,
+			Timestamp:  header.Time,
+			RLPHeader:  (*utils.RLPHeader)(header),
+		})
+	}
+	if len(eventHeaders) == 0 {
+		return nil
+	}
+
+	var contractEvents []event.ContractEvent
+	for i := range logs {
+		header, ok := eventHeaders[logs[i].BlockHash]
+		if !ok {
+			continue
+		}
+		contractEvent := event.ContractEventFromLog(&logs[i], header.Time)
+		contractEvent.DecodedArgs = decoder.decode(&logs[i])
+		contractEvents = append(contractEvents, contractEvent)
+	}
+
+	if err := tx.ContractEvent.DeleteContractEventsInRange(replace, shard.from, shard.to); err != nil {
+		return err
+	}
+	if len(blockHeaders) > 0 {
+		if err := tx.Blocks.StoreBlockHeaders(blockHeaders); err != nil {
+			return err
+		}
+	}
+	if len(contractEvents) > 0 {
+		if err := tx.ContractEvent.StoreContractEvents(contractEvents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package synchronizer
+
+import (
+	"github.com/WJX2001/contract-caller/database"
+	common2 "github.com/WJX2001/contract-caller/database/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// 同步器在 sync_checkpoints 表中的检查点名称
+const checkpointName = "synchronizer"
+
+// 基于数据库的 node.CheckpointStore
+type checkpointStore struct {
+	db *database.DB
+}
+
+func (s checkpointStore) LoadCheckpoint() (*types.Header, error) {
+	checkpoint, err := s.db.Checkpoints.Checkpoint(checkpointName)
+	if err != nil || checkpoint == nil {
+		return nil, err
+	}
+	return checkpoint.RLPHeader.Header(), nil
+}
+
+func (s checkpointStore) SaveCheckpoint(header *types.Header) error {
+	return s.db.Checkpoints.StoreCheckpoint(common2.NewCheckpoint(checkpointName, header))
+}
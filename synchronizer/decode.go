@@ -0,0 +1,99 @@
+package synchronizer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	事件参数解码：
+		- 按 syncEvents 中的合约 ABI 把日志的 topics 和 data 解码为 参数名 -> 值，随原始日志一起写入 contract_events.decoded_args
+		- 整数以十进制字符串、地址和字节以小写十六进制存储，与其他列的编码一致，可以直接用 SQL 按业务字段查询
+		- 不认识的事件或解码失败时不写入参数，原始日志仍然保存
+*/
+
+type eventDecoder struct {
+	events map[common.Hash]abi.Event
+}
+
+func newEventDecoder() (*eventDecoder, error) {
+	events := make(map[common.Hash]abi.Event)
+	for _, contract := range syncEvents {
+		contractAbi, err := contract.metaData.GetAbi()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range contract.events {
+			if abiEvent, ok := contractAbi.Events[name]; ok {
+				events[abiEvent.ID] = abiEvent
+			}
+		}
+	}
+	return &eventDecoder{events: events}, nil
+}
+
+// 解码一条日志的参数，无法解码时返回 nil
+func (d *eventDecoder) decode(l *types.Log) map[string]any {
+	if len(l.Topics) == 0 {
+		return nil
+	}
+	abiEvent, ok := d.events[l.Topics[0]]
+	if !ok {
+		return nil
+	}
+
+	args := make(map[string]any)
+	if err := abiEvent.Inputs.UnpackIntoMap(args, l.Data); err != nil {
+		log.Warn("unable to decode event data", "event", abiEvent.Name, "tx", l.TxHash, "index", l.Index, "err", err)
+		return nil
+	}
+	var indexed abi.Arguments
+	for _, input := range abiEvent.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(args, indexed, l.Topics[1:]); err != nil {
+		log.Warn("unable to decode event topics", "event", abiEvent.Name, "tx", l.TxHash, "index", l.Index, "err", err)
+		return nil
+	}
+	for name, value := range args {
+		args[name] = decodedValue(value)
+	}
+	return args
+}
+
+// 转为 JSON 中便于查询的表示
+func decodedValue(value any) any {
+	switch v := value.(type) {
+	case *big.Int:
+		return v.String()
+	case []*big.Int:
+		values := make([]string, len(v))
+		for i := range v {
+			values[i] = v[i].String()
+		}
+		return values
+	case common.Address:
+		return hexutil.Encode(v.Bytes())
+	case []common.Address:
+		values := make([]string, len(v))
+		for i := range v {
+			values[i] = hexutil.Encode(v[i].Bytes())
+		}
+		return values
+	case common.Hash:
+		return hexutil.Encode(v.Bytes())
+	case [32]byte:
+		return hexutil.Encode(v[:])
+	case []byte:
+		return hexutil.Encode(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,89 @@
+package synchronizer
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/database/utils"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	区块最终性跟踪：
+		- 后台每隔 loopInterval 查询节点的 safe 和 finalized 区块，把不高于它们的已存储区块头和事件标记为对应的最终性
+		- 只标记到已索引高度；safe / finalized 区块已存储时先校验哈希一致，不一致说明存储了孤块，本次不标记，等待重组回滚或完整性检查修复
+		- 节点不支持 safe / finalized 标签时跳过对应的级别
+		- 之后写入的区块在下一次检查时补上标记，下游通过 finality 列按需要的一致性级别读取
+*/
+
+// 定时更新已存储区块的最终性
+func (syncer *Synchronizer) startFinalizer() {
+	ticker := time.NewTicker(syncer.loopInterval)
+	syncer.tasks.Go(func() error {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-syncer.resourceCtx.Done():
+				return nil
+			case <-ticker.C:
+			}
+			syncer.updateFinality()
+		}
+	})
+}
+
+// 先标记 finalized 再标记 safe，已经 finalized 的区块不会被降级
+func (syncer *Synchronizer) updateFinality() {
+	levels := []struct {
+		finality utils.Finality
+		name     string
+		head     func() (*types.Header, error)
+	}{
+		{utils.FinalityFinalized, "finalized", syncer.ethClient.LatestFinalizedBlockHeader},
+		{utils.FinalitySafe, "safe", syncer.ethClient.LatestSafeBlockHeader},
+	}
+	for _, level := range levels {
+		head, err := level.head()
+		if err != nil {
+			log.Debug("unable to query chain head for finality", "level", level.name, "err", err)
+			continue
+		}
+		if err := syncer.markFinality(head, level.finality); err != nil {
+			log.Warn("unable to update block finality", "level", level.name, "number", head.Number, "err", err)
+			continue
+		}
+		syncer.recordFinality(head.Number, level.finality)
+	}
+}
+
+// 把不高于 head 的已存储区块头和事件标记为 finality
+func (syncer *Synchronizer) markFinality(head *types.Header, finality utils.Finality) error {
+	number := head.Number
+	if indexed := syncer.Status().IndexedHeight; indexed != nil && indexed.Cmp(number) < 0 {
+		number = indexed
+	}
+	stored, err := syncer.db.Blocks.BlockHeaderByNumber(number)
+	if err != nil {
+		return err
+	}
+	if stored != nil && number.Cmp(head.Number) == 0 && stored.Hash != head.Hash() {
+		return fmt.Errorf("stored block %s is %s, chain has %s", number, stored.Hash, head.Hash())
+	}
+
+	return syncer.db.Transaction(func(tx *database.DB) error {
+		marked, err := tx.Blocks.MarkBlockHeadersFinality(new(big.Int).Set(number), finality)
+		if err != nil {
+			return err
+		}
+		if err := tx.ContractEvent.MarkContractEventsFinality(new(big.Int).Set(number), finality); err != nil {
+			return err
+		}
+		if marked > 0 {
+			log.Debug("updated block finality", "finality", finality, "to", number, "headers", marked)
+		}
+		return nil
+	})
+}
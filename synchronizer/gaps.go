@@ -0,0 +1,142 @@
+package synchronizer
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/WJX2001/contract-caller/database"
+	common2 "github.com/WJX2001/contract-caller/database/common"
+	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	区块头完整性检查：
+		- 每隔 gapCheckInterval 扫描一次已存储的区块头，查找缺失的高度和 parent_hash 对不上的相邻区块
+		- 缺失的区间通过 Backfiller 重新拉取区块头和事件写入
+		- 不连续的两个区块与节点的规范链比较，删除不在规范链上的区块头及其事件、交易后重新回填这两个区块
+		- 稀疏模式下区块头本来就不连续，只修复高度相邻的不连续区块
+		- 扫描范围从上次检查通过的高度到已索引高度，没有发现问题时推进起点，修复后下一次重新检查同一范围
+		- 修复只涉及区块头和合约事件，事件处理器已处理过的高度不会重新解析
+*/
+
+// 定时检查并修复已存储区块头中的缺失和不连续
+func (syncer *Synchronizer) startGapCheck() {
+	if syncer.gapCheckInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(syncer.gapCheckInterval)
+	syncer.tasks.Go(func() error {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-syncer.resourceCtx.Done():
+				return nil
+			case <-ticker.C:
+			}
+			if err := syncer.checkGaps(); err != nil && syncer.resourceCtx.Err() == nil {
+				log.Error("failed to repair block header gaps", "err", err)
+			}
+		}
+	})
+}
+
+// 扫描一次并修复发现的问题
+func (syncer *Synchronizer) checkGaps() error {
+	to := syncer.Status().IndexedHeight
+	if to == nil {
+		return nil
+	}
+	from := syncer.gapCheckFrom
+	if from == nil {
+		from = big.NewInt(0)
+	}
+
+	gaps, err := syncer.db.Blocks.BlockHeaderGaps(from, to)
+	if err != nil {
+		return fmt.Errorf("unable to query block header gaps: %w", err)
+	}
+
+	repaired := 0
+	for _, gap := range gaps {
+		if syncer.chainCfg.SparseHeaders && !gap.Discontinuity {
+			continue
+		}
+		log.Warn("detected block header gap", "from", gap.From, "to", gap.To, "discontinuity", gap.Discontinuity)
+		if err := syncer.repairGap(gap); err != nil {
+			return fmt.Errorf("unable to repair blocks %s-%s: %w", gap.From, gap.To, err)
+		}
+		repaired++
+	}
+
+	if repaired == 0 {
+		// 已索引的最后一个区块在下一次检查时作为起点，用于校验与之后区块的连续性
+		syncer.gapCheckFrom = to
+		log.Debug("block header gap check passed", "from", from, "to", to)
+	} else {
+		log.Info("repaired block header gaps", "gaps", repaired, "from", from, "to", to)
+	}
+	return nil
+}
+
+// 重新拉取并写入一段缺失或不连续的区块
+func (syncer *Synchronizer) repairGap(gap common2.BlockHeaderGap) error {
+	if gap.Discontinuity {
+		for _, number := range []*big.Int{gap.From, gap.To} {
+			if err := syncer.removeOrphanedHeader(number); err != nil {
+				return err
+			}
+		}
+	}
+
+	backfiller, err := NewBackfiller(syncer.db, []node.EthClient{syncer.ethClient}, BackfillConfig{
+		From:      gap.From,
+		To:        gap.To,
+		ShardSize: syncer.chainCfg.BackfillShardSize,
+		Workers:   syncer.chainCfg.BackfillWorkers,
+		ChainId:   syncer.chainCfg.ChainId,
+		LogSource: syncer.logSourceMode,
+
+		SparseHeaders:        syncer.chainCfg.SparseHeaders,
+		SparseHeaderInterval: syncer.chainCfg.SparseHeaderInterval,
+	})
+	if err != nil {
+		return err
+	}
+	return backfiller.Run(syncer.resourceCtx)
+}
+
+// 已存储的区块头不在节点的规范链上时，删除该区块头及其事件和交易
+func (syncer *Synchronizer) removeOrphanedHeader(number *big.Int) error {
+	stored, err := syncer.db.Blocks.BlockHeaderByNumber(number)
+	if err != nil || stored == nil {
+		return err
+	}
+	canonical, err := syncer.ethClient.BlockHeaderByNumber(number)
+	if err != nil {
+		return fmt.Errorf("unable to query canonical header %s: %w", number, err)
+	}
+	if canonical.Hash() == stored.Hash {
+		return nil
+	}
+
+	return syncer.db.Transaction(func(tx *database.DB) error {
+		events, err := tx.ContractEvent.DeleteContractEventsByBlockHash(stored.Hash)
+		if err != nil {
+			return err
+		}
+		if err := tx.ContractTx.DeleteContractTransactionsByBlockHash(stored.Hash); err != nil {
+			return err
+		}
+		if err := tx.Blocks.DeleteBlockHeader(stored.Hash); err != nil {
+			return err
+		}
+		if events > 0 {
+			log.Warn("removed orphaned block with contract events, derived data may need to be reprocessed", "number", number, "hash", stored.Hash, "events", events)
+		} else {
+			log.Warn("removed orphaned block", "number", number, "hash", stored.Hash, "canonicalHash", canonical.Hash())
+		}
+		return nil
+	})
+}
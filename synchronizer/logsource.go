@@ -0,0 +1,126 @@
+package synchronizer
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	日志来源：
+		- range：一次 eth_getLogs 查询整批区块，默认方式
+		- per-block：每个区块单独查询 eth_getLogs，适用于不支持范围查询或范围上限很小的节点
+		- auto：先按范围查询，节点返回范围或结果数量超限、方法不支持等错误时，本批改为逐个区块查询
+	同步器和历史回填都通过 LogSource 拉取日志，按链在配置中选择
+*/
+
+type LogSourceMode int
+
+const (
+	LogSourceRange LogSourceMode = iota
+	LogSourcePerBlock
+	LogSourceAuto
+)
+
+func (m LogSourceMode) String() string {
+	switch m {
+	case LogSourceRange:
+		return "range"
+	case LogSourcePerBlock:
+		return "per-block"
+	case LogSourceAuto:
+		return "auto"
+	default:
+		return fmt.Sprintf("LogSourceMode(%d)", int(m))
+	}
+}
+
+// 解析配置中的日志来源，空字符串为 range
+func ParseLogSourceMode(s string) (LogSourceMode, error) {
+	switch s {
+	case "", "range":
+		return LogSourceRange, nil
+	case "per-block":
+		return LogSourcePerBlock, nil
+	case "auto":
+		return LogSourceAuto, nil
+	default:
+		return 0, fmt.Errorf("unknown log source %q, expected range, per-block or auto", s)
+	}
+}
+
+// 拉取 [FromBlock, ToBlock] 内的日志，返回的 ToBlockHeader 用于校验日志与区块头属于同一条链
+type LogSource interface {
+	FilterLogs(ethereum.FilterQuery) (node.Logs, error)
+}
+
+// 按配置的方式创建日志来源
+func NewLogSource(client node.EthClient, mode LogSourceMode) LogSource {
+	switch mode {
+	case LogSourcePerBlock:
+		return perBlockLogSource{client: client}
+	case LogSourceAuto:
+		return autoLogSource{client: client}
+	default:
+		return client
+	}
+}
+
+// 每个区块单独查询
+type perBlockLogSource struct {
+	client node.EthClient
+}
+
+func (s perBlockLogSource) FilterLogs(query ethereum.FilterQuery) (node.Logs, error) {
+	var result node.Logs
+	for number := new(big.Int).Set(query.FromBlock); number.Cmp(query.ToBlock) <= 0; number = new(big.Int).Add(number, big.NewInt(1)) {
+		blockQuery := query
+		blockQuery.FromBlock, blockQuery.ToBlock = number, number
+		logs, err := s.client.FilterLogs(blockQuery)
+		if err != nil {
+			return node.Logs{}, fmt.Errorf("unable to query logs of block %s: %w", number, err)
+		}
+		result.Logs = append(result.Logs, logs.Logs...)
+		result.ToBlockHeader = logs.ToBlockHeader
+	}
+	return result, nil
+}
+
+// 范围查询失败时退回逐个区块查询
+type autoLogSource struct {
+	client node.EthClient
+}
+
+func (s autoLogSource) FilterLogs(query ethereum.FilterQuery) (node.Logs, error) {
+	logs, err := s.client.FilterLogs(query)
+	if err == nil || !isLogRangeError(err) || query.FromBlock.Cmp(query.ToBlock) == 0 {
+		return logs, err
+	}
+	log.Warn("ranged log query rejected by provider, querying per block", "from", query.FromBlock, "to", query.ToBlock, "err", err)
+	return perBlockLogSource{client: s.client}.FilterLogs(query)
+}
+
+// 节点拒绝范围查询的常见错误：范围或结果数量超限、不支持 eth_getLogs
+func isLogRangeError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range []string{
+		"block range",
+		"range limit",
+		"range is too large",
+		"query returned more than",
+		"limit exceeded",
+		"too many results",
+		"method not found",
+		"does not exist",
+		"not supported",
+	} {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package synchronizer
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+	同步器的指标采集：
+		- 已索引高度、跟随的链头高度以及两者的差值（同步延迟）
+		- 写库的区块头数量（按速率即为每秒处理的区块头数）、每批的日志数、每批写库耗时
+		- 连续失败的轮次，成功处理一批或没有新区块时清零
+	NewSynchronizer 的 metrics 参数为空时不采集，主程序通过 PrometheusMetrics.Collectors 注册到自己的 registry
+*/
+
+type Metrics interface {
+	RecordIndexedHeight(height uint64)         // 已写库的最新区块高度
+	RecordChainHead(height uint64)             // 跟随的链头高度（latest 减去确认深度、safe 或 finalized）
+	RecordBatch(headers int, logs int)         // 一批区块写库成功，包含的区块头数和日志数
+	RecordBatchPersist(d time.Duration)        // 一批区块写库的耗时，包括重试
+	RecordConsecutiveFailures(failures uint64) // 当前连续失败的轮次
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) RecordIndexedHeight(uint64)       {}
+func (noopMetrics) RecordChainHead(uint64)           {}
+func (noopMetrics) RecordBatch(int, int)             {}
+func (noopMetrics) RecordBatchPersist(time.Duration) {}
+func (noopMetrics) RecordConsecutiveFailures(uint64) {}
+
+var NoopMetrics Metrics = noopMetrics{}
+
+type PrometheusMetrics struct {
+	indexedHeight       prometheus.Gauge
+	chainHead           prometheus.Gauge
+	lag                 prometheus.Gauge
+	headers             prometheus.Counter
+	logsPerBatch        prometheus.Histogram
+	persistDuration     prometheus.Histogram
+	consecutiveFailures prometheus.Gauge
+
+	indexed, head uint64
+}
+
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	const subsystem = "synchronizer"
+	return &PrometheusMetrics{
+		indexedHeight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "indexed_height",
+			Help:      "Height of the latest block header persisted by the synchronizer",
+		}),
+		chainHead: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "chain_head",
+			Help:      "Height of the chain head followed by the synchronizer",
+		}),
+		lag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "lag_blocks",
+			Help:      "Number of blocks between the followed chain head and the indexed height",
+		}),
+		headers: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "headers_total",
+			Help:      "Number of block headers persisted by the synchronizer",
+		}),
+		logsPerBatch: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "logs_per_batch",
+			Help:      "Number of contract logs extracted per persisted batch",
+			Buckets:   []float64{0, 1, 5, 10, 50, 100, 500, 1000, 5000},
+		}),
+		persistDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "batch_persist_seconds",
+			Help:      "Time spent persisting a batch, including retries",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+		}),
+		consecutiveFailures: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "consecutive_failures",
+			Help:      "Number of consecutive sync rounds that failed",
+		}),
+	}
+}
+
+// 返回全部指标，供主程序注册
+func (m *PrometheusMetrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.indexedHeight,
+		m.chainHead,
+		m.lag,
+		m.headers,
+		m.logsPerBatch,
+		m.persistDuration,
+		m.consecutiveFailures,
+	}
+}
+
+func (m *PrometheusMetrics) RecordIndexedHeight(height uint64) {
+	m.indexed = height
+	m.indexedHeight.Set(float64(height))
+	m.updateLag()
+}
+
+func (m *PrometheusMetrics) RecordChainHead(height uint64) {
+	m.head = height
+	m.chainHead.Set(float64(height))
+	m.updateLag()
+}
+
+func (m *PrometheusMetrics) RecordBatch(headers int, logs int) {
+	m.headers.Add(float64(headers))
+	m.logsPerBatch.Observe(float64(logs))
+}
+
+func (m *PrometheusMetrics) RecordBatchPersist(d time.Duration) {
+	m.persistDuration.Observe(d.Seconds())
+}
+
+func (m *PrometheusMetrics) RecordConsecutiveFailures(failures uint64) {
+	m.consecutiveFailures.Set(float64(failures))
+}
+
+// 同步延迟，已索引高度超过链头（如刚切换到落后的节点）时记为 0
+func (m *PrometheusMetrics) updateLag() {
+	if m.head > m.indexed {
+		m.lag.Set(float64(m.head - m.indexed))
+	} else {
+		m.lag.Set(0)
+	}
+}
@@ -0,0 +1,45 @@
+package node
+
+import (
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	自适应批量大小（AIMD）：
+		- 批量获取区块头失败（超时、节点报错等）后本次请求的区块数减半，最小为 1
+		- 成功后每次增加 maxSize 的 1/10（至少 1），直到恢复到调用方传入的 maxSize
+		- 节点不稳定时自动减小单次请求的压力，恢复后逐步回到原来的吞吐量
+*/
+
+// 本次应请求的区块数，不超过 maxSize
+func (f *HeaderTraversal) nextBatchSize(maxSize uint64) uint64 {
+	if f.batchSize == 0 || f.batchSize > maxSize {
+		f.batchSize = maxSize
+	}
+	return f.batchSize
+}
+
+// 请求失败，批量大小减半
+func (f *HeaderTraversal) shrinkBatchSize() {
+	if f.batchSize <= 1 {
+		return
+	}
+	f.batchSize /= 2
+	log.Warn("header batch request failed, shrinking batch size", "batchSize", f.batchSize)
+}
+
+// 请求成功，批量大小逐步恢复到 maxSize
+func (f *HeaderTraversal) growBatchSize(maxSize uint64) {
+	if f.batchSize >= maxSize {
+		return
+	}
+	f.batchSize = min(maxSize, f.batchSize+max(1, maxSize/10))
+	if f.batchSize == maxSize {
+		log.Info("header batch size recovered", "batchSize", f.batchSize)
+	}
+}
+
+// 当前的批量大小，尚未请求过时为 0
+func (f *HeaderTraversal) BatchSize() uint64 {
+	return f.batchSize
+}
@@ -0,0 +1,82 @@
+package node
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	遍历进度超过节点链头（节点落后或切换到了落后的节点）时的处理方式：
+		- wait：不返回错误，等待节点追上后继续，默认方式
+		- rewind：以节点的链头为准，回退到不高于节点可处理高度的共同祖先，被回退的区块按重组处理
+		- fail：返回 ErrHeaderTraversalAheadOfProvider，由调用方决定是否退出
+*/
+
+type AheadOfProviderPolicy int
+
+const (
+	AheadOfProviderWait AheadOfProviderPolicy = iota
+	AheadOfProviderRewind
+	AheadOfProviderFail
+)
+
+func (p AheadOfProviderPolicy) String() string {
+	switch p {
+	case AheadOfProviderWait:
+		return "wait"
+	case AheadOfProviderRewind:
+		return "rewind"
+	case AheadOfProviderFail:
+		return "fail"
+	default:
+		return fmt.Sprintf("AheadOfProviderPolicy(%d)", int(p))
+	}
+}
+
+// 解析配置中的处理方式，空字符串为 wait
+func ParseAheadOfProviderPolicy(s string) (AheadOfProviderPolicy, error) {
+	switch s {
+	case "", "wait":
+		return AheadOfProviderWait, nil
+	case "rewind":
+		return AheadOfProviderRewind, nil
+	case "fail":
+		return AheadOfProviderFail, nil
+	default:
+		return 0, fmt.Errorf("unknown ahead-of-provider policy %q, expected wait, rewind or fail", s)
+	}
+}
+
+// 设置遍历进度超过节点链头时的处理方式
+func (f *HeaderTraversal) SetAheadOfProviderPolicy(policy AheadOfProviderPolicy) {
+	f.aheadPolicy = policy
+}
+
+// endHeight 为节点当前可处理的最高区块
+func (f *HeaderTraversal) handleAheadOfProvider(endHeight *big.Int, maxSize uint64) ([]types.Header, error) {
+	switch f.aheadPolicy {
+	case AheadOfProviderRewind:
+		event, err := f.rewind(endHeight)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrHeaderTraversalAheadOfProvider, err)
+		}
+		if err := f.rewindCheckpoint(event.CommonAncestor); err != nil {
+			return nil, err
+		}
+		log.Warn("header traversal ahead of provider, rewound to provider head",
+			"ancestor", event.CommonAncestor.Number, "orphanedFrom", event.OrphanedFrom, "orphanedTo", event.OrphanedTo)
+		if f.onReorg != nil {
+			f.onReorg(*event)
+		}
+		return f.NextHeaders(maxSize)
+	case AheadOfProviderFail:
+		return nil, ErrHeaderTraversalAheadOfProvider
+	default:
+		log.Warn("header traversal ahead of provider, waiting for provider to catch up",
+			"traversed", f.lastTraversedHeader.Number, "providerHeight", endHeight)
+		return nil, nil
+	}
+}
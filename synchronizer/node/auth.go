@@ -0,0 +1,149 @@
+package node
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+/*
+	带认证的 RPC 节点：
+		- 支持自定义请求头、Bearer Token、Basic 认证以及 Engine API 风格的 JWT（每次请求重新签发 token）
+		- 支持自定义 http.Client，用于配置代理、TLS 证书等
+		- 同一份认证配置应用到主节点和所有备用节点，以及驱动引擎发送交易和额外广播的节点
+*/
+
+// 连接 RPC 节点时使用的认证配置，零值表示不认证
+type AuthConfig struct {
+	Headers       http.Header  // 每个请求附加的请求头
+	BearerToken   string       // 以 "Authorization: Bearer <token>" 发送
+	BasicUser     string       // Basic 认证用户名
+	BasicPassword string       // Basic 认证密码
+	JWTSecret     []byte       // Engine API 风格的 JWT 密钥，必须为 32 字节
+	HTTPClient    *http.Client // 自定义 HTTP 客户端（代理、TLS 等），为 nil 时使用默认客户端
+}
+
+// 认证方式只能选一种，避免多个 Authorization 头互相覆盖
+func (a AuthConfig) validate() error {
+	methods := 0
+	if a.BearerToken != "" {
+		methods++
+	}
+	if a.BasicUser != "" || a.BasicPassword != "" {
+		methods++
+	}
+	if len(a.JWTSecret) > 0 {
+		methods++
+		if len(a.JWTSecret) != 32 {
+			return fmt.Errorf("jwt secret must be 32 bytes, got %d", len(a.JWTSecret))
+		}
+	}
+	if methods > 1 {
+		return errors.New("only one of bearer token, basic auth and jwt secret may be set")
+	}
+	return nil
+}
+
+// 转换成 rpc.DialOptions 的选项，驱动引擎发送交易的客户端同样使用
+func (a AuthConfig) DialOptions() ([]rpc.ClientOption, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	var opts []rpc.ClientOption
+	if a.HTTPClient != nil {
+		opts = append(opts, rpc.WithHTTPClient(a.HTTPClient))
+	}
+	if len(a.Headers) > 0 {
+		opts = append(opts, rpc.WithHeaders(a.Headers))
+	}
+	switch {
+	case a.BearerToken != "":
+		opts = append(opts, rpc.WithHeader("Authorization", "Bearer "+a.BearerToken))
+	case a.BasicUser != "" || a.BasicPassword != "":
+		opts = append(opts, rpc.WithHTTPAuth(func(h http.Header) error {
+			req := http.Request{Header: h}
+			req.SetBasicAuth(a.BasicUser, a.BasicPassword)
+			return nil
+		}))
+	case len(a.JWTSecret) > 0:
+		opts = append(opts, rpc.WithHTTPAuth(jwtAuth(a.JWTSecret)))
+	}
+	return opts, nil
+}
+
+// Engine API 要求 token 的 iat 与节点时间相差不超过 60 秒，所以每次请求重新签发
+func jwtAuth(secret []byte) rpc.HTTPAuth {
+	return func(h http.Header) error {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iat": &jwt.NumericDate{Time: time.Now()},
+		})
+		s, err := token.SignedString(secret)
+		if err != nil {
+			return fmt.Errorf("failed to create jwt token: %w", err)
+		}
+		h.Set("Authorization", "Bearer "+s)
+		return nil
+	}
+}
+
+// 解析 "Name: value" 形式的请求头
+func ParseHeaders(headers []string) (http.Header, error) {
+	h := make(http.Header)
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", header)
+		}
+		h.Add(name, strings.TrimSpace(value))
+	}
+	return h, nil
+}
+
+// 读取 JWT 密钥文件，文件内容为 32 字节的十六进制字符串，与执行层客户端的 jwtsecret 文件格式一致
+func LoadJWTSecret(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read jwt secret: %w", err)
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid jwt secret in %s: expected 32 bytes hex", path)
+	}
+	return secret, nil
+}
+
+// 创建访问 RPC 节点的 HTTP 客户端，proxyUrl 为空时使用环境变量中的代理，caFile 为空时使用系统根证书
+func NewHTTPClient(proxyUrl, caFile string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyUrl != "" {
+		proxy, err := url.Parse(proxyUrl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,157 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/common/global_const"
+	"github.com/WJX2001/contract-caller/synchronizer/retry"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/sync/errgroup"
+)
+
+/*
+	按链选择批量请求方式：
+		- 不同链的节点对批量 RPC 的限制不同，如 Polygon 节点会拒绝过大的批量请求
+		- BatchingProfile 描述每次请求的区块数、并发数以及是否改为逐个调用
+		- 优先使用 ClientConfig.BatchingProfiles 中按 chainId 配置的方案，其次使用内置方案，最后使用 DefaultBatchingProfile
+		- 各组由有上限的 worker 池并发获取，每组单独重试，重试时只请求缺失的区块，适用于任何批量 RPC 不可靠的链
+*/
+
+type BatchingProfile struct {
+	MaxBatchSize int  // 每组最多包含的区块数，为 0 时整个范围作为一组
+	Concurrency  int  // 同时处理的组数，为 0 时为 1
+	PerCall      bool // 为 true 时组内逐个区块调用 eth_getBlockByNumber，不使用批量 RPC
+	Retries      int  // 每组请求失败或有区块缺失时的重试次数，为 0 时不重试
+}
+
+// 默认方案：整个范围一次批量请求
+var DefaultBatchingProfile = BatchingProfile{}
+
+// 内置的按链方案
+var defaultBatchingProfiles = map[uint]BatchingProfile{
+	// Polygon 节点不接受大批量请求，每组 100 个区块逐个调用
+	uint(global_const.PolygonChainId): {MaxBatchSize: 100, Concurrency: 8, PerCall: true, Retries: 3},
+}
+
+func (c ClientConfig) batchingProfile(chainId uint) BatchingProfile {
+	if profile, ok := c.BatchingProfiles[chainId]; ok {
+		return profile
+	}
+	if profile, ok := defaultBatchingProfiles[chainId]; ok {
+		return profile
+	}
+	return DefaultBatchingProfile
+}
+
+// 按方案分组获取 [startHeight, startHeight+count) 范围内的区块头
+// 各组由最多 Concurrency 个 worker 并发获取，任一组最终失败时取消其余的组
+func (c *clnt) headersByProfile(startHeight *big.Int, count int, profile BatchingProfile) ([]types.Header, error) {
+	groupSize := profile.MaxBatchSize
+	if groupSize <= 0 || groupSize > count {
+		groupSize = count
+	}
+	concurrency := profile.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	headers := make([]*types.Header, count)
+	g, ctx := errgroup.WithContext(context.Background())
+	g.SetLimit(concurrency)
+	// 组边界为左闭右开区间 [start, end)，最后一组截断到 count，保证每个区块恰好属于一组
+	for start := 0; start < count; start += groupSize {
+		end := min(start+groupSize, count)
+		g.Go(func() error {
+			return c.fetchGroup(ctx, startHeight, headers, start, end, profile)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	result := make([]types.Header, count)
+	for i, header := range headers {
+		result[i] = *header
+	}
+	return result, nil
+}
+
+// 获取 headers[start:end]，失败时最多重试 profile.Retries 次，重试只请求还没拿到的区块
+func (c *clnt) fetchGroup(ctx context.Context, startHeight *big.Int, headers []*types.Header, start, end int, profile BatchingProfile) error {
+	_, err := retry.Do(ctx, profile.Retries+1, c.retryStrategy, func() (struct{}, error) {
+		var err error
+		if profile.PerCall {
+			err = c.headersPerCall(ctx, startHeight, headers, start, end)
+		} else {
+			err = c.headersBatch(ctx, startHeight, headers, start, end)
+		}
+		if err != nil {
+			return struct{}{}, err
+		}
+		// 负载均衡后的节点可能还没同步到该高度，返回空结果，同样需要重试
+		for i := start; i < end; i++ {
+			if headers[i] == nil {
+				height := new(big.Int).Add(startHeight, big.NewInt(int64(i)))
+				return struct{}{}, fmt.Errorf("header %s: %w", toBlockNumArg(height), ErrNotFound)
+			}
+		}
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// 一次批量请求获取 headers[start:end] 中缺失的区块头
+func (c *clnt) headersBatch(ctx context.Context, startHeight *big.Int, headers []*types.Header, start, end int) error {
+	var batchElems []rpc.BatchElem
+	for i := start; i < end; i++ {
+		if headers[i] != nil {
+			continue
+		}
+		height := new(big.Int).Add(startHeight, big.NewInt(int64(i)))
+		batchElems = append(batchElems, rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{toBlockNumArg(height), false},
+			Result: &headers[i],
+		})
+	}
+	if len(batchElems) == 0 {
+		return nil
+	}
+
+	ctxwt, cancel := context.WithTimeout(ctx, c.requestTimeout)
+	defer cancel()
+	if err := c.rpc.BatchCallContext(ctxwt, batchElems); err != nil {
+		return newRPCError("eth_getBlockByNumber", err)
+	}
+	// 单个元素失败时其余元素的结果保留，重试时不再请求
+	for _, batchElem := range batchElems {
+		if batchElem.Error != nil {
+			return newRPCError("eth_getBlockByNumber", batchElem.Error)
+		}
+	}
+	return nil
+}
+
+// 逐个区块调用获取 headers[start:end] 中缺失的区块头，单个区块失败不影响组内其他区块
+func (c *clnt) headersPerCall(ctx context.Context, startHeight *big.Int, headers []*types.Header, start, end int) error {
+	var firstErr error
+	for i := start; i < end; i++ {
+		if headers[i] != nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		height := new(big.Int).Add(startHeight, big.NewInt(int64(i)))
+		ctxwt, cancel := context.WithTimeout(ctx, c.requestTimeout)
+		err := c.rpc.CallContext(ctxwt, &headers[i], "eth_getBlockByNumber", toBlockNumArg(height), false)
+		cancel()
+		if err != nil && firstErr == nil {
+			firstErr = newRPCError("eth_getBlockByNumber", err)
+		}
+	}
+	return firstErr
+}
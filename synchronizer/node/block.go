@@ -0,0 +1,90 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	完整区块查询：
+		- BlockByNumber / BlockByHash 返回包含完整交易内容的区块，用于索引发往监听合约的交易及其 calldata
+		- 叔块只校验哈希列表，不额外请求叔块头
+		- 返回的交易和区块头同时写入 LRU 缓存，后续 TxByHash / BlockHeaderByHash 不再请求节点
+*/
+
+type rpcBlock struct {
+	Hash         common.Hash          `json:"hash"`
+	Transactions []*types.Transaction `json:"transactions"`
+	UncleHashes  []common.Hash        `json:"uncles"`
+	Withdrawals  []*types.Withdrawal  `json:"withdrawals,omitempty"`
+}
+
+// 根据区块号获取包含完整交易的区块，区块号为 nil 时获取最新区块
+func (c *clnt) BlockByNumber(number *big.Int) (*types.Block, error) {
+	return c.getBlock("eth_getBlockByNumber", toBlockNumArg(number))
+}
+
+// 根据区块哈希获取包含完整交易的区块
+func (c *clnt) BlockByHash(hash common.Hash) (*types.Block, error) {
+	block, err := c.getBlock("eth_getBlockByHash", hash)
+	if err != nil {
+		return nil, err
+	}
+	if block.Hash() != hash {
+		return nil, errors.New("block mismatch")
+	}
+	return block, nil
+}
+
+func (c *clnt) getBlock(method string, arg any) (*types.Block, error) {
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	var raw json.RawMessage
+	err := c.rpc.CallContext(ctxwt, &raw, method, arg, true)
+	if err != nil {
+		return nil, newRPCError(method, err)
+	} else if len(raw) == 0 || string(raw) == "null" {
+		return nil, fmt.Errorf("block %v: %w", arg, ErrNotFound)
+	}
+
+	// 区块头和区块体字段在同一个 JSON 对象中，分别解析
+	var header types.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+	var body rpcBlock
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+
+	if header.UncleHash == types.EmptyUncleHash && len(body.UncleHashes) > 0 {
+		return nil, errors.New("server returned non-empty uncle list but block header indicates no uncles")
+	}
+	if header.UncleHash != types.EmptyUncleHash && len(body.UncleHashes) == 0 {
+		return nil, errors.New("server returned empty uncle list but block header indicates uncles")
+	}
+	if header.TxHash == types.EmptyTxsHash && len(body.Transactions) > 0 {
+		return nil, errors.New("server returned non-empty transaction list but block header indicates no transactions")
+	}
+	if header.TxHash != types.EmptyTxsHash && len(body.Transactions) == 0 {
+		return nil, errors.New("server returned empty transaction list but block header indicates transactions")
+	}
+
+	c.cache.addHeader(&header)
+	for _, tx := range body.Transactions {
+		c.cache.addTx(tx)
+	}
+
+	block := types.NewBlockWithHeader(&header).WithBody(types.Body{
+		Transactions: body.Transactions,
+		Withdrawals:  body.Withdrawals,
+	})
+	return block, nil
+}
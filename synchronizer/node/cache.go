@@ -0,0 +1,103 @@
+package node
+
+import (
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	不可变链上数据的内存 LRU 缓存：
+		- 按哈希查询的区块头和交易内容不会改变，可以直接缓存
+		- 按高度查询的区块头只有在已最终确认（不超过最近一次查到的 finalized 高度）时才缓存，避免缓存到会被重组的区块
+		- 事件处理和重组检查中重复查询同一数据时不再请求节点
+*/
+
+const defaultCacheSize = 1024
+
+type chainCache struct {
+	headersByHash   *lru.Cache[common.Hash, *types.Header]
+	headersByNumber *lru.Cache[uint64, *types.Header]
+	txs             *lru.Cache[common.Hash, *types.Transaction]
+	finalized       atomic.Uint64 // 最近一次查到的 finalized 区块高度
+}
+
+// size 小于 0 时不缓存，返回 nil
+func newChainCache(size int) *chainCache {
+	if size < 0 {
+		return nil
+	}
+	if size == 0 {
+		size = defaultCacheSize
+	}
+	return &chainCache{
+		headersByHash:   lru.NewCache[common.Hash, *types.Header](size),
+		headersByNumber: lru.NewCache[uint64, *types.Header](size),
+		txs:             lru.NewCache[common.Hash, *types.Transaction](size),
+	}
+}
+
+// 返回副本，避免调用方修改缓存中的区块头
+func (c *chainCache) headerByHash(hash common.Hash) (*types.Header, bool) {
+	if c == nil {
+		return nil, false
+	}
+	header, ok := c.headersByHash.Get(hash)
+	if !ok {
+		return nil, false
+	}
+	return types.CopyHeader(header), true
+}
+
+func (c *chainCache) headerByNumber(number *big.Int) (*types.Header, bool) {
+	if c == nil || number == nil || number.Sign() < 0 {
+		return nil, false
+	}
+	header, ok := c.headersByNumber.Get(number.Uint64())
+	if !ok {
+		return nil, false
+	}
+	return types.CopyHeader(header), true
+}
+
+func (c *chainCache) addHeader(header *types.Header) {
+	if c == nil {
+		return
+	}
+	header = types.CopyHeader(header)
+	c.headersByHash.Add(header.Hash(), header)
+	if header.Number.Uint64() <= c.finalized.Load() {
+		c.headersByNumber.Add(header.Number.Uint64(), header)
+	}
+}
+
+// 记录 finalized 区块，之后不超过该高度的区块头可按高度缓存
+func (c *chainCache) setFinalized(header *types.Header) {
+	if c == nil {
+		return
+	}
+	for {
+		current := c.finalized.Load()
+		if header.Number.Uint64() <= current || c.finalized.CompareAndSwap(current, header.Number.Uint64()) {
+			break
+		}
+	}
+	c.addHeader(header)
+}
+
+func (c *chainCache) tx(hash common.Hash) (*types.Transaction, bool) {
+	if c == nil {
+		return nil, false
+	}
+	return c.txs.Get(hash)
+}
+
+func (c *chainCache) addTx(tx *types.Transaction) {
+	if c == nil {
+		return
+	}
+	c.txs.Add(tx.Hash(), tx)
+}
@@ -0,0 +1,51 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	遍历进度检查点：
+		- CheckpointStore 持久化 lastTraversedHeader，独立于区块头的存储，区块头被清理或不存储时也能恢复进度
+		- 启动时由调用方通过 LoadCheckpoint 取得起始区块头传给 NewHeaderTraversal
+		- 调用方处理完一批区块后调用 SaveCheckpoint；发生重组回退时遍历器自动把检查点回退到共同祖先
+*/
+
+type CheckpointStore interface {
+	LoadCheckpoint() (*types.Header, error) // 没有检查点时返回 nil
+	SaveCheckpoint(*types.Header) error
+}
+
+// 设置检查点存储，为 nil 时不持久化
+func (f *HeaderTraversal) SetCheckpointStore(store CheckpointStore) {
+	f.checkpoints = store
+}
+
+// 保存检查点，header 及之前的区块应已处理完成
+func (f *HeaderTraversal) SaveCheckpoint(header *types.Header) error {
+	if f.checkpoints == nil {
+		return nil
+	}
+	return f.checkpoints.SaveCheckpoint(header)
+}
+
+// 重组后检查点可能指向被丢弃的区块，回退到共同祖先
+// 检查点已经低于共同祖先时保持不变，避免跳过还没处理的区块
+func (f *HeaderTraversal) rewindCheckpoint(ancestor *types.Header) error {
+	if f.checkpoints == nil {
+		return nil
+	}
+	checkpoint, err := f.checkpoints.LoadCheckpoint()
+	if err != nil {
+		return fmt.Errorf("unable to load checkpoint: %w", err)
+	}
+	if checkpoint != nil && checkpoint.Number.Cmp(ancestor.Number) <= 0 {
+		return nil
+	}
+	if err := f.checkpoints.SaveCheckpoint(ancestor); err != nil {
+		return fmt.Errorf("unable to rewind checkpoint: %w", err)
+	}
+	return nil
+}
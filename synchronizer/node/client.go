@@ -4,19 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"net"
 	"net/url"
-	"sync"
 	"time"
 
-	"github.com/WJX2001/contract-caller/common/global_const"
 	"github.com/WJX2001/contract-caller/synchronizer/retry"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -31,108 +29,283 @@ const (
 	defaultDialTimeout    = 5 * time.Second
 	defaultDialAttempts   = 5
 	defaultRequestTimeout = 100 * time.Second
+	defaultRetryAttempts  = 3
 )
 
+// DialEthClient 的配置，零值表示全部使用默认值
+type ClientConfig struct {
+	DialTimeout    time.Duration  // 连接单个节点的总超时（包含重试），为 0 时使用 defaultDialTimeout
+	DialAttempts   int            // 连接单个节点的最大尝试次数，为 0 时使用 defaultDialAttempts
+	RequestTimeout time.Duration  // 单次 RPC 调用的超时，为 0 时使用 defaultRequestTimeout
+	RetryAttempts  int            // 单次调用的最大尝试次数，为 0 时使用 defaultRetryAttempts，为 1 时不重试
+	RetryStrategy  retry.Strategy // 重试间隔策略，为 nil 时使用 retry.Exponential()
+	Metrics        Metrics        // RPC 调用指标，为 nil 表示不采集
+	EnableTracing  bool           // 是否开启 TraceTransaction，需要节点开放 debug 命名空间
+	// 按 chainId 覆盖 BlockHeadersByRange 的批量请求方案，未配置的链使用内置方案
+	BatchingProfiles map[uint]BatchingProfile
+	// 已最终确认的区块头和交易的 LRU 缓存容量，为 0 时使用 defaultCacheSize，小于 0 时不缓存
+	CacheSize int
+	Auth      AuthConfig // 认证私有节点时使用的请求头、token 和 HTTP 客户端
+	// 关键读取（最新区块头、交易回执）需要结果一致的节点数，只在传入多个地址时生效，小于 2 时不启用
+	ReadQuorum int
+}
+
+func (c ClientConfig) dialTimeout() time.Duration {
+	if c.DialTimeout <= 0 {
+		return defaultDialTimeout
+	}
+	return c.DialTimeout
+}
+
+func (c ClientConfig) dialAttempts() int {
+	if c.DialAttempts <= 0 {
+		return defaultDialAttempts
+	}
+	return c.DialAttempts
+}
+
+func (c ClientConfig) requestTimeout() time.Duration {
+	if c.RequestTimeout <= 0 {
+		return defaultRequestTimeout
+	}
+	return c.RequestTimeout
+}
+
+// 节点池中单个节点的请求超时，显式配置了 RequestTimeout 时以其为准
+func (c ClientConfig) endpointTimeout() time.Duration {
+	if c.RequestTimeout <= 0 {
+		return defaultEndpointTimeout
+	}
+	return c.RequestTimeout
+}
+
+func (c ClientConfig) metrics() Metrics {
+	if c.Metrics == nil {
+		return NoopMetrics
+	}
+	return c.Metrics
+}
+
+func (c ClientConfig) retryAttempts() int {
+	if c.RetryAttempts <= 0 {
+		return defaultRetryAttempts
+	}
+	return c.RetryAttempts
+}
+
+func (c ClientConfig) retryStrategy() retry.Strategy {
+	if c.RetryStrategy == nil {
+		return retry.Exponential()
+	}
+	return c.RetryStrategy
+}
+
 type EthClient interface {
 	// 区块头相关
 	BlockHeaderByNumber(*big.Int) (*types.Header, error)  // 根据区块号获取区块头
 	LatestSafeBlockHeader() (*types.Header, error)        // 获取最新的安全区块头
 	LatestFinalizedBlockHeader() (*types.Header, error)   // 获取最新的最终确认区块头
 	BlockHeaderByHash(common.Hash) (*types.Header, error) // 根据区块哈希获取区块头
-	// 批量区块头查询，支持批量获取指定范围内的区块头，对 Polygon 链使用并发请求优化，对其他链使用标准的批量 RPC 调用
+	// 批量区块头查询，支持批量获取指定范围内的区块头，按 chainId 选择的 BatchingProfile 分组、并发请求
 	BlockHeadersByRange(*big.Int, *big.Int, uint) ([]types.Header, error)
 
+	// 完整区块查询，包含所有交易内容
+	BlockByNumber(*big.Int) (*types.Block, error)
+	BlockByHash(common.Hash) (*types.Block, error)
+
 	// 交易查询（根据交易哈希获取交易详情）
 	TxByHash(common.Hash) (*types.Transaction, error)
+	// 交易回执查询，交易未上链时返回 ethereum.NotFound
+	TxReceiptByHash(common.Hash) (*types.Receipt, error)
+	// 批量查询交易回执，一次批量 RPC 调用获取，结果与传入的哈希一一对应
+	TxReceiptsByHashes([]common.Hash) ([]*types.Receipt, error)
+	// 通过 callTracer 获取交易的内部调用树，需开启 ClientConfig.EnableTracing
+	TraceTransaction(common.Hash) (*CallFrame, error)
 
 	// 获取指定地址在指定区块的存储哈希
 	StorageHash(common.Address, *big.Int) (common.Hash, error)
+	// 获取账户和存储槽的完整证明，可用 AccountProof.Verify 针对可信 stateRoot 校验
+	GetProof(common.Address, []common.Hash, *big.Int) (*AccountProof, error)
+
+	// 账户查询，区块号为 nil 时查询最新区块
+	BalanceAt(common.Address, *big.Int) (*big.Int, error) // 获取地址在指定区块的余额
+	NonceAt(common.Address, *big.Int) (uint64, error)     // 获取地址在指定区块的 nonce
+	PendingNonceAt(common.Address) (uint64, error)        // 获取地址包含 pending 交易的 nonce
+
+	// 手续费查询，供交易发送和 gas 预言机使用
+	SuggestGasTipCap() (*big.Int, error) // 节点建议的优先费（eth_maxPriorityFeePerGas）
+	// 最近 blockCount 个区块（截止到 lastBlock，为 nil 时为最新区块）的 baseFee、gas 使用率和各百分位的优先费
+	FeeHistory(blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
 	// 事件日志过滤
 	// 支持按区块范围、地址、主题过滤事件日志
 	// 使用批量 RPC 调用同时获取日志和对应的区块头
 	// 返回自定义的 Logs 结构，包含日志和对应的区块头
 	FilterLogs(ethereum.FilterQuery) (Logs, error)
 
+	// 订阅相关，仅通过 ws:// 或 IPC 连接时可用，HTTP 连接返回 rpc.ErrNotificationsUnsupported
+	// 订阅新区块头，节点每产生一个新区块推送一次
+	SubscribeNewHead(context.Context, chan<- *types.Header) (ethereum.Subscription, error)
+	// 订阅符合过滤条件的新事件日志
+	SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- types.Log) (ethereum.Subscription, error)
+
 	Close()
 }
 
 type clnt struct {
-	rpc RPC
+	rpc             RPC
+	requestTimeout  time.Duration
+	tracing         bool
+	batchingProfile func(chainId uint) BatchingProfile
+	cache           *chainCache
+	retryStrategy   retry.Strategy
+	quorum          *quorumReader // 为 nil 时关键读取只请求一个节点
 }
 
 // 客户端连接
 // 支持 URL 可用性检查
 // 封装底层 RPC 客户端
-func DialEthClient(ctx context.Context, rpcUrl string) (EthClient, error) {
-	ctx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
+// 传入多个地址时返回节点池客户端，按健康程度路由请求并在节点故障时自动切换
+// 单次调用遇到临时故障时按 cfg 的策略重试
+func DialEthClient(ctx context.Context, cfg ClientConfig, rpcUrls ...string) (EthClient, error) {
+	if len(rpcUrls) == 0 {
+		return nil, errors.New("no rpc url provided")
+	}
+	if len(rpcUrls) == 1 {
+		rpcClient, err := dialRPC(ctx, cfg, rpcUrls[0])
+		if err != nil {
+			return nil, err
+		}
+		return &clnt{
+			rpc:             newRetryRPC(newInstrumentedRPC(rpcClient, cfg.metrics()), cfg),
+			requestTimeout:  cfg.requestTimeout(),
+			tracing:         cfg.EnableTracing,
+			batchingProfile: cfg.batchingProfile,
+			cache:           newChainCache(cfg.CacheSize),
+			retryStrategy:   cfg.retryStrategy(),
+		}, nil
+	}
+
+	// 部分节点暂时不可用时仍使用其余节点启动
+	var urls []string
+	var clients []RPC
+	var dialErrs []error
+	for _, rpcUrl := range rpcUrls {
+		rpcClient, err := dialRPC(ctx, cfg, rpcUrl)
+		if err != nil {
+			dialErrs = append(dialErrs, err)
+			continue
+		}
+		urls = append(urls, rpcUrl)
+		clients = append(clients, newInstrumentedRPC(rpcClient, cfg.metrics()))
+	}
+	if len(clients) == 0 {
+		return nil, errors.Join(dialErrs...)
+	}
+
+	pool := newRPCPool(urls, clients, defaultHealthCheckInterval, cfg.endpointTimeout())
+	var quorum *quorumReader
+	if cfg.ReadQuorum > 1 {
+		size := cfg.ReadQuorum
+		if size > len(clients) {
+			log.Warn("read quorum exceeds available rpc endpoints, lowering it", "quorum", size, "endpoints", len(clients))
+			size = len(clients)
+		}
+		quorum = &quorumReader{pool: pool, size: size, metrics: cfg.metrics()}
+	}
+	return &clnt{
+		rpc:             newRetryRPC(pool, cfg),
+		requestTimeout:  cfg.requestTimeout(),
+		tracing:         cfg.EnableTracing,
+		batchingProfile: cfg.batchingProfile,
+		cache:           newChainCache(cfg.CacheSize),
+		retryStrategy:   cfg.retryStrategy(),
+		quorum:          quorum,
+	}, nil
+}
+
+// 带重试地连接单个 RPC 节点
+func dialRPC(ctx context.Context, cfg ClientConfig, rpcUrl string) (*rpc.Client, error) {
+	opts, err := cfg.Auth.DialOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.dialTimeout())
 	defer cancel()
 	bOff := retry.Exponential()
-	rpcClient, err := retry.Do(ctx, defaultDialAttempts, bOff, func() (*rpc.Client, error) {
-		if !IsURLAvailable(rpcUrl) {
+	return retry.Do(ctx, cfg.dialAttempts(), bOff, func() (*rpc.Client, error) {
+		// 自定义 HTTP 客户端可能经过代理访问节点，此时直接 TCP 探测没有意义
+		if cfg.Auth.HTTPClient == nil && !IsURLAvailable(rpcUrl) {
 			return nil, fmt.Errorf("address unavailable (%s)", rpcUrl)
 		}
 
-		client, err := rpc.DialContext(ctx, rpcUrl)
+		client, err := rpc.DialOptions(ctx, rpcUrl, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to dial address (%s): %w", rpcUrl, err)
 		}
 
 		return client, nil
 	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &clnt{rpc: NewRPC(rpcClient)}, nil
 }
 
 // 根据区块哈希获取区块头
 func (c *clnt) BlockHeaderByHash(hash common.Hash) (*types.Header, error) {
-	// 创建一个带超时的 context, 超时时间是 defaultRequestTimeout
+	if header, ok := c.cache.headerByHash(hash); ok {
+		return header, nil
+	}
+	// 创建一个带超时的 context, 超时时间是 ClientConfig.RequestTimeout
 	// 确保函数返回时取消 context, 释放资源，避免 RPC 调用卡死
-	ctxwt, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
 	// 区块头变量
 	var header *types.Header
 
 	err := c.rpc.CallContext(ctxwt, &header, "eth_getBlockByHash", hash, false)
 	if err != nil {
-		return nil, err
+		return nil, newRPCError("eth_getBlockByHash", err)
 	} else if header == nil {
-		return nil, ethereum.NotFound
+		return nil, fmt.Errorf("header %s: %w", hash, ErrNotFound)
 	}
 
 	if header.Hash() != hash {
 		return nil, errors.New("header mismatch")
 	}
 
+	c.cache.addHeader(header)
 	return header, nil
 }
 
 // 根据区块号获取区块头
 func (c *clnt) BlockHeaderByNumber(number *big.Int) (*types.Header, error) {
-	ctxwt, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	// 只有已最终确认的高度会命中缓存
+	if header, ok := c.cache.headerByNumber(number); ok {
+		return header, nil
+	}
+	if number == nil && c.quorum != nil {
+		return c.quorum.header("latest")
+	}
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
 
 	var header *types.Header
 	err := c.rpc.CallContext(ctxwt, &header, "eth_getBlockByNumber", toBlockNumArg(number), false)
 	if err != nil {
-		log.Fatalln("Call eth_getBlockByNumber method fail", "err", err)
-		return nil, err
+		return nil, newRPCError("eth_getBlockByNumber", err)
 	} else if header == nil {
-		log.Println("header not found")
-		return nil, ethereum.NotFound
+		return nil, fmt.Errorf("header %s: %w", toBlockNumArg(number), ErrNotFound)
 	}
 
+	c.cache.addHeader(header)
 	return header, nil
 }
 
 /*
 根据区块高度范围，批量获取这一段的区块头信息
 如果只要一个区块 -> 直接调用 BlockHeaderByNumber
-如果是普通链，以太坊、BSC等，用 BatchCallContext 一次性批量请求，效率高
-如果是 Polygon链，每组最多100个区块，每个区块单独 RPC 请求，避免节点拒绝大批量请求
+否则按该链的 BatchingProfile 分组请求：
+  - 普通链，以太坊、BSC等，用 BatchCallContext 一次性批量请求，效率高
+  - Polygon 链，每组最多100个区块，每个区块单独 RPC 请求，避免节点拒绝大批量请求
+
 最后整理结果，返回结果
 */
 func (c *clnt) BlockHeadersByRange(startHeight, endHeight *big.Int, chainId uint) ([]types.Header, error) {
@@ -145,77 +318,7 @@ func (c *clnt) BlockHeadersByRange(startHeight, endHeight *big.Int, chainId uint
 	}
 
 	count := new(big.Int).Sub(endHeight, startHeight).Uint64() + 1
-	headers := make([]types.Header, count)
-	batchElems := make([]rpc.BatchElem, count)
-
-	// 普通链，非 Polygon
-	if chainId != uint(global_const.PolygonChainId) {
-		for i := uint64(0); i < count; i++ {
-			height := new(big.Int).Add(startHeight, new(big.Int).SetUint64(i))
-			batchElems[i] = rpc.BatchElem{
-				Method: "eth_getBlockByNumber",
-				Args:   []interface{}{toBlockNumArg(height), false},
-				Result: &headers[i],
-			}
-		}
-
-		ctxwt, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
-		defer cancel()
-
-		err := c.rpc.BatchCallContext(ctxwt, batchElems)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		groupSize := 100
-		// 等待一组 goroutine 全部执行完成
-		var wg sync.WaitGroup
-		numGroups := (int(count)-1)/groupSize + 1
-		wg.Add(numGroups)
-
-		// 对 polygon 链做了特殊处理，不能一次性批量请求太多区块，所以分组处理，每组做多100个
-		for i := 0; i < int(count); i += groupSize {
-			start := i
-			end := i + groupSize - 1
-			if end > int(count) {
-				end = int(count) - 1
-			}
-
-			go func(start, end int) {
-				defer wg.Done()
-				for j := start; j <= end; j++ {
-					ctxwt, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
-					defer cancel()
-					height := new(big.Int).Add(startHeight, new(big.Int).SetUint64(uint64(j)))
-					batchElems[j] = rpc.BatchElem{
-						Method: "eth_getBlockByNumber",
-						Result: new(types.Header),
-						Error:  nil,
-					}
-					header := new(types.Header)
-					batchElems[j].Error = c.rpc.CallContext(ctxwt, header, "eth_getBlockByNumber", toBlockNumArg(height), false)
-					batchElems[j].Result = header
-				}
-			}(start, end)
-		}
-		// 等待所有的 goroutine 完成
-		wg.Wait()
-	}
-
-	size := 0
-	for i, batchElem := range batchElems {
-		header, ok := batchElem.Result.(*types.Header)
-		if !ok {
-			return nil, fmt.Errorf("unable to transform rpc response %v into utils.Header", batchElem.Result)
-		}
-
-		headers[i] = *header
-
-		size = size + 1
-	}
-
-	headers = headers[:size]
-	return headers, nil
+	return c.headersByProfile(startHeight, int(count), c.batchingProfile(chainId))
 }
 
 type Logs struct {
@@ -235,7 +338,7 @@ func (c *clnt) FilterLogs(query ethereum.FilterQuery) (Logs, error) {
 	batchElems[0] = rpc.BatchElem{Method: "eth_getBlockByNumber", Args: []interface{}{toBlockNumArg(query.ToBlock), false}, Result: &header}
 	batchElems[1] = rpc.BatchElem{Method: "eth_getLogs", Args: []interface{}{arg}, Result: &logs}
 
-	ctxwt, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
 	err = c.rpc.BatchCallContext(ctxwt, batchElems)
 
@@ -257,39 +360,54 @@ func (c *clnt) FilterLogs(query ethereum.FilterQuery) (Logs, error) {
 
 // 获取最新的安全区块头
 func (c *clnt) LatestSafeBlockHeader() (*types.Header, error) {
-	ctxwt, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	if c.quorum != nil {
+		return c.quorum.header("safe")
+	}
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
 
 	var header *types.Header
 
 	err := c.rpc.CallContext(ctxwt, &header, "eth_getBlockByNumber", "safe", false)
 	if err != nil {
-		return nil, err
+		return nil, newRPCError("eth_getBlockByNumber", err)
 	} else if header == nil {
-		return nil, ethereum.NotFound
+		return nil, fmt.Errorf("header safe: %w", ErrNotFound)
 	}
 	return header, nil
 }
 
 // 获取最新的最终确认区块头
 func (c *clnt) LatestFinalizedBlockHeader() (*types.Header, error) {
-	ctxwt, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	header, err := c.latestFinalizedBlockHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.setFinalized(header)
+	return header, nil
+}
+
+func (c *clnt) latestFinalizedBlockHeader() (*types.Header, error) {
+	if c.quorum != nil {
+		return c.quorum.header("finalized")
+	}
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
 
 	var header *types.Header
 	err := c.rpc.CallContext(ctxwt, &header, "eth_getBlockByNumber", "finalized", false)
 	if err != nil {
-		return nil, err
+		return nil, newRPCError("eth_getBlockByNumber", err)
 	} else if header == nil {
-		return nil, ethereum.NotFound
+		return nil, fmt.Errorf("header finalized: %w", ErrNotFound)
 	}
-
 	return header, nil
 }
 
 // 存储证明，获取指定地址在指定区块的存储哈希
 func (c *clnt) StorageHash(address common.Address, blockNumber *big.Int) (common.Hash, error) {
-	ctxwt, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
 
 	proof := struct{ StorageHash common.Hash }{}
@@ -301,8 +419,102 @@ func (c *clnt) StorageHash(address common.Address, blockNumber *big.Int) (common
 	return proof.StorageHash, nil
 }
 
+// 获取地址在指定区块的余额
+func (c *clnt) BalanceAt(address common.Address, blockNumber *big.Int) (*big.Int, error) {
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	var balance hexutil.Big
+	err := c.rpc.CallContext(ctxwt, &balance, "eth_getBalance", address, toBlockNumArg(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	return (*big.Int)(&balance), nil
+}
+
+// 获取地址在指定区块的 nonce
+func (c *clnt) NonceAt(address common.Address, blockNumber *big.Int) (uint64, error) {
+	return c.nonceAt(address, toBlockNumArg(blockNumber))
+}
+
+// 获取地址包含 pending 交易的 nonce，即下一笔交易应使用的 nonce
+func (c *clnt) PendingNonceAt(address common.Address) (uint64, error) {
+	return c.nonceAt(address, "pending")
+}
+
+func (c *clnt) nonceAt(address common.Address, block string) (uint64, error) {
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	var nonce hexutil.Uint64
+	err := c.rpc.CallContext(ctxwt, &nonce, "eth_getTransactionCount", address, block)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(nonce), nil
+}
+
+// 获取节点建议的优先费
+func (c *clnt) SuggestGasTipCap() (*big.Int, error) {
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	var tipCap hexutil.Big
+	err := c.rpc.CallContext(ctxwt, &tipCap, "eth_maxPriorityFeePerGas")
+	if err != nil {
+		return nil, newRPCError("eth_maxPriorityFeePerGas", err)
+	}
+
+	return (*big.Int)(&tipCap), nil
+}
+
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// 获取历史手续费数据
+func (c *clnt) FeeHistory(blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	var res feeHistoryResult
+	err := c.rpc.CallContext(ctxwt, &res, "eth_feeHistory", hexutil.Uint(blockCount), toBlockNumArg(lastBlock), rewardPercentiles)
+	if err != nil {
+		return nil, newRPCError("eth_feeHistory", err)
+	} else if res.OldestBlock == nil {
+		return nil, fmt.Errorf("fee history: %w", ErrNotFound)
+	}
+
+	reward := make([][]*big.Int, len(res.Reward))
+	for i, r := range res.Reward {
+		reward[i] = make([]*big.Int, len(r))
+		for j, r := range r {
+			reward[i][j] = (*big.Int)(r)
+		}
+	}
+	baseFee := make([]*big.Int, len(res.BaseFee))
+	for i, b := range res.BaseFee {
+		baseFee[i] = (*big.Int)(b)
+	}
+
+	return &ethereum.FeeHistory{
+		OldestBlock:  (*big.Int)(res.OldestBlock),
+		Reward:       reward,
+		BaseFee:      baseFee,
+		GasUsedRatio: res.GasUsedRatio,
+	}, nil
+}
+
 func (c *clnt) TxByHash(hash common.Hash) (*types.Transaction, error) {
-	ctxwt, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	if tx, ok := c.cache.tx(hash); ok {
+		return tx, nil
+	}
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
 
 	var tx *types.Transaction
@@ -313,9 +525,91 @@ func (c *clnt) TxByHash(hash common.Hash) (*types.Transaction, error) {
 		return nil, ethereum.NotFound
 	}
 
+	c.cache.addTx(tx)
 	return tx, nil
 }
 
+func (c *clnt) TxReceiptByHash(hash common.Hash) (*types.Receipt, error) {
+	if c.quorum != nil {
+		receipts, err := c.quorum.receipts([]common.Hash{hash})
+		if err != nil {
+			return nil, err
+		}
+		return receipts[0], nil
+	}
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	var receipt *types.Receipt
+	err := c.rpc.CallContext(ctxwt, &receipt, "eth_getTransactionReceipt", hash)
+	if err != nil {
+		return nil, err
+	} else if receipt == nil {
+		return nil, ethereum.NotFound
+	}
+
+	return receipt, nil
+}
+
+// 批量查询交易回执，任意一笔查询失败或未上链都返回错误
+func (c *clnt) TxReceiptsByHashes(hashes []common.Hash) ([]*types.Receipt, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	if c.quorum != nil {
+		return c.quorum.receipts(hashes)
+	}
+
+	receipts := make([]*types.Receipt, len(hashes))
+	batchElems := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		batchElems[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{hash},
+			Result: &receipts[i],
+		}
+	}
+
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+	if err := c.rpc.BatchCallContext(ctxwt, batchElems); err != nil {
+		return nil, err
+	}
+
+	for i, batchElem := range batchElems {
+		if batchElem.Error != nil {
+			return nil, fmt.Errorf("unable to query receipt %s: %w", hashes[i], batchElem.Error)
+		}
+		if receipts[i] == nil {
+			return nil, fmt.Errorf("receipt %s: %w", hashes[i], ethereum.NotFound)
+		}
+	}
+
+	return receipts, nil
+}
+
+// 订阅新区块头
+func (c *clnt) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	sub, err := c.rpc.EthSubscribe(ctx, ch, "newHeads")
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// 订阅符合过滤条件的事件日志
+func (c *clnt) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	arg, err := toFilterArg(query)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := c.rpc.EthSubscribe(ctx, ch, "logs", arg)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
 func (c *clnt) Close() {
 	c.rpc.Close()
 }
@@ -327,14 +621,22 @@ type RPC interface {
 	CallContext(ctx context.Context, result any, method string, args ...any) error
 	// 一次性批量发器多个 RPC 请求（提高效率）
 	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+	// 发起 eth_subscribe 订阅，推送的数据写入 channel
+	EthSubscribe(ctx context.Context, channel any, args ...any) (*rpc.ClientSubscription, error)
 }
 
 type rpcClient struct {
-	rpc *rpc.Client
+	rpc     *rpc.Client
+	metrics Metrics
 }
 
 func NewRPC(client *rpc.Client) RPC {
-	return &rpcClient{client}
+	return newInstrumentedRPC(client, NoopMetrics)
+}
+
+// 按方法上报调用次数、错误次数和耗时的 RPC 客户端
+func newInstrumentedRPC(client *rpc.Client, metrics Metrics) RPC {
+	return &rpcClient{rpc: client, metrics: metrics}
 }
 
 func (c *rpcClient) Close() {
@@ -342,13 +644,34 @@ func (c *rpcClient) Close() {
 }
 
 func (c *rpcClient) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	start := time.Now()
 	err := c.rpc.CallContext(ctx, result, method, args...)
+	c.metrics.RecordRPCRequest(method, time.Since(start))
+	if err != nil {
+		c.metrics.RecordRPCError(method)
+	}
 	return err
 }
 
 func (c *rpcClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	method := batchMethod(b)
+	start := time.Now()
 	err := c.rpc.BatchCallContext(ctx, b)
-	return err
+	c.metrics.RecordRPCRequest(method, time.Since(start))
+	if err != nil {
+		c.metrics.RecordRPCError(method)
+		return err
+	}
+	for _, elem := range b {
+		if elem.Error != nil {
+			c.metrics.RecordRPCError(elem.Method)
+		}
+	}
+	return nil
+}
+
+func (c *rpcClient) EthSubscribe(ctx context.Context, channel any, args ...any) (*rpc.ClientSubscription, error) {
+	return c.rpc.EthSubscribe(ctx, channel, args...)
 }
 
 // 将区块号转换为 RPC 参数格式
@@ -0,0 +1,48 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+/*
+	客户端返回的错误：
+		- 查询的数据不存在时返回包装了 ErrNotFound 的错误，调用方用 errors.Is 判断
+		- RPC 调用失败时返回 *RPCError，按错误来源区分可重试（连接、超时等临时故障）和不可重试（节点返回的 JSON-RPC 错误）
+	客户端自身不终止进程，由同步器的重试逻辑决定如何处理
+*/
+
+// 查询的区块、交易等数据不存在，与 ethereum.NotFound 相同，errors.Is 对两者都成立
+var ErrNotFound = ethereum.NotFound
+
+// RPC 调用失败
+type RPCError struct {
+	Method    string // 调用的 RPC 方法
+	Retryable bool   // 是否为可重试的临时故障
+	Err       error
+}
+
+func (e *RPCError) Error() string {
+	kind := "permanent"
+	if e.Retryable {
+		kind = "retryable"
+	}
+	return fmt.Sprintf("%s failed (%s): %v", e.Method, kind, e.Err)
+}
+
+func (e *RPCError) Unwrap() error {
+	return e.Err
+}
+
+// 包装 RPC 调用错误并标注是否可重试
+func newRPCError(method string, err error) error {
+	return &RPCError{Method: method, Retryable: isTransientError(err), Err: err}
+}
+
+// 判断错误是否为可重试的 RPC 临时故障
+func IsRetryable(err error) bool {
+	var rpcErr *RPCError
+	return errors.As(err, &rpcErr) && rpcErr.Retryable
+}
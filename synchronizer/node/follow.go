@@ -0,0 +1,79 @@
+package node
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	遍历跟随的链头：
+		- latest：以最新区块减去确认深度为终点，默认方式
+		- safe / finalized：以节点报告的 safe / finalized 区块为终点，忽略确认深度
+		  适用于有快速最终性机制的链，不需要猜测数值深度即可避免索引到会被重组的区块
+*/
+
+type FollowMode int
+
+const (
+	FollowLatest FollowMode = iota
+	FollowSafe
+	FollowFinalized
+)
+
+func (m FollowMode) String() string {
+	switch m {
+	case FollowLatest:
+		return "latest"
+	case FollowSafe:
+		return "safe"
+	case FollowFinalized:
+		return "finalized"
+	default:
+		return fmt.Sprintf("FollowMode(%d)", int(m))
+	}
+}
+
+// 解析配置中的跟随方式，空字符串为 latest
+func ParseFollowMode(s string) (FollowMode, error) {
+	switch s {
+	case "", "latest":
+		return FollowLatest, nil
+	case "safe":
+		return FollowSafe, nil
+	case "finalized":
+		return FollowFinalized, nil
+	default:
+		return 0, fmt.Errorf("unknown follow mode %q, expected latest, safe or finalized", s)
+	}
+}
+
+// 设置遍历跟随的链头
+func (f *HeaderTraversal) SetFollowMode(mode FollowMode) {
+	f.followMode = mode
+}
+
+// 查询跟随的链头，返回链头区块头和本次能处理的最高区块号
+func (f *HeaderTraversal) followedHead() (*types.Header, *big.Int, error) {
+	var header *types.Header
+	var err error
+	switch f.followMode {
+	case FollowSafe:
+		header, err = f.ethClient.LatestSafeBlockHeader()
+	case FollowFinalized:
+		header, err = f.ethClient.LatestFinalizedBlockHeader()
+	default:
+		header, err = f.ethClient.BlockHeaderByNumber(nil)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to query %s block: %w", f.followMode, err)
+	} else if header == nil {
+		return nil, nil, fmt.Errorf("%s header unreported", f.followMode)
+	}
+
+	if f.followMode != FollowLatest {
+		return header, new(big.Int).Set(header.Number), nil
+	}
+	return header, new(big.Int).Sub(header.Number, f.blockConfirmationDepth), nil
+}
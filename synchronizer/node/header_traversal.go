@@ -20,20 +20,39 @@ type HeaderTraversal struct {
 	ethClient EthClient
 	chainId   uint
 
-	latestHeader        *types.Header // 最近一次从链上获取的最新区块头
+	latestHeader        *types.Header // 最近一次从链上获取的跟随的链头（latest / safe / finalized）
 	lastTraversedHeader *types.Header // 上次遍历到的区块头 （当前状态停在这里）
+	targetHeight        *big.Int      // 最近一次计算出的能处理的最高区块号
 
 	blockConfirmationDepth *big.Int // 区块确认深度，确保我们只处理已经确认的区块
+
+	recentHeaders []types.Header         // 最近遍历过的区块头，用于重组时查找共同祖先
+	maxReorgDepth int                    // recentHeaders 最多保留的区块头数量
+	onReorg       func(event ReorgEvent) // 发生重组并回退后调用
+	checkpoints   CheckpointStore        // 遍历进度的持久化存储，为 nil 时不持久化
+	aheadPolicy   AheadOfProviderPolicy  // 遍历进度超过节点链头时的处理方式
+	followMode    FollowMode             // 跟随 latest（减去确认深度）、safe 或 finalized 区块
+	batchSize     uint64                 // 自适应的批量大小，不超过 NextHeaders 的 maxSize
 }
 
 // 构造函数，初始化一个构造器实例
 func NewHeaderTraversal(ethClient EthClient, fromHeader *types.Header, confDepth *big.Int, chainId uint) *HeaderTraversal {
-	return &HeaderTraversal{
+	f := &HeaderTraversal{
 		ethClient:              ethClient,
 		lastTraversedHeader:    fromHeader,
 		blockConfirmationDepth: confDepth,
 		chainId:                chainId,
+		maxReorgDepth:          defaultMaxReorgDepth,
+	}
+	if fromHeader != nil {
+		f.remember([]types.Header{*fromHeader})
 	}
+	return f
+}
+
+// 设置重组回调，NextHeaders 回退到共同祖先后同步调用
+func (f *HeaderTraversal) OnReorg(handler func(event ReorgEvent)) {
+	f.onReorg = handler
 }
 
 // 辅助 getter 方法
@@ -45,19 +64,21 @@ func (f *HeaderTraversal) LastTraversedHeader() *types.Header {
 	return f.lastTraversedHeader
 }
 
+// 最近一次 NextHeaders 计算出的能处理的最高区块号（latest 减去确认深度、safe 或 finalized），尚未查询时为 nil
+func (f *HeaderTraversal) TargetHeight() *big.Int {
+	return f.targetHeight
+}
+
 // 从上次遍历的区块头继续，获取下一批新区块头
 func (f *HeaderTraversal) NextHeaders(maxSize uint64) ([]types.Header, error) {
-	latestHeader, err := f.ethClient.BlockHeaderByNumber(nil)
+	latestHeader, endHeight, err := f.followedHead()
 	if err != nil {
-		return nil, fmt.Errorf("unable to query latest block: %w", err)
-	} else if latestHeader == nil {
-		return nil, fmt.Errorf("latest header unreported")
-	} else {
-		f.latestHeader = latestHeader
+		return nil, err
 	}
+	f.latestHeader = latestHeader
+	f.targetHeight = endHeight
 
 	// 能安全处理的最新区块号
-	endHeight := new(big.Int).Sub(latestHeader.Number, f.blockConfirmationDepth)
 	if endHeight.Sign() < 0 {
 		// No blocks with the provided confirmation depth available
 		return nil, nil
@@ -69,8 +90,8 @@ func (f *HeaderTraversal) NextHeaders(maxSize uint64) ([]types.Header, error) {
 		if cmp == 0 {
 			return nil, nil // 已经是最新的,没有新区块
 		} else if cmp > 0 {
-			// 当前区块号比 endHeight 大，说明内部状态超前链上状态（异常状态）
-			return nil, ErrHeaderTraversalAheadOfProvider
+			// 当前区块号比 endHeight 大，说明内部状态超前链上状态（节点落后或切换了节点）
+			return f.handleAheadOfProvider(endHeight, maxSize)
 		}
 	}
 	// 计算下一个要获取的区块号范围,下一次要获取的区块号 = 上次区块号 + 1
@@ -79,27 +100,53 @@ func (f *HeaderTraversal) NextHeaders(maxSize uint64) ([]types.Header, error) {
 		nextHeight = new(big.Int).Add(f.lastTraversedHeader.Number, bigint.One)
 	}
 
-	// 限制批量大小
-	endHeight = bigint.Clamp(nextHeight, endHeight, maxSize)
+	// 限制批量大小，请求失败后自动减小
+	endHeight = bigint.Clamp(nextHeight, endHeight, f.nextBatchSize(maxSize))
 	// 批量查询区块头
 	headers, err := f.ethClient.BlockHeadersByRange(nextHeight, endHeight, f.chainId)
 	if err != nil {
+		f.shrinkBatchSize()
 		return nil, fmt.Errorf("error querying blocks by range: %w", err)
 	}
+	f.growBatchSize(maxSize)
 	numHeaders := len(headers)
 	if numHeaders == 0 {
 		return nil, nil
 	} else if f.lastTraversedHeader != nil && headers[0].ParentHash != f.lastTraversedHeader.Hash() {
-		// 校验链连续性（防止分叉/状态不一致）
-		// 如果第一个新区块头的 ParentHash 不等于上一个区块的 Hash
-		// 说明链发生了分叉或者 provider 的数据和本地状态不一致
-		fmt.Println(f.lastTraversedHeader.Number)
-		fmt.Println(headers[0].Number)
-		fmt.Println(len(headers))
-		return nil, ErrHeaderTraversalAndProviderMismatchedState
+		// 校验链连续性：第一个新区块头的 ParentHash 不等于上一个区块的 Hash，说明链发生了重组
+		// 回退到共同祖先后从分叉点重新获取
+		event, err := f.rewind(nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.rewindCheckpoint(event.CommonAncestor); err != nil {
+			return nil, err
+		}
+		if f.onReorg != nil {
+			f.onReorg(*event)
+		}
+		return f.NextHeaders(maxSize)
 	}
 
 	// 更新最后遍历到的区块头，并返回本次取到的所有 headers
 	f.lastTraversedHeader = &headers[numHeaders-1]
+	f.remember(headers)
 	return headers, nil
 }
+
+// 把遍历进度重置到 header，之后的区块头会被重新获取，header 为 nil 时从头开始
+// 用于调用方丢弃已遍历但尚未处理的区块头，header 应为已遍历过的区块
+func (f *HeaderTraversal) ResetTo(header *types.Header) {
+	if header == nil {
+		f.lastTraversedHeader = nil
+		f.recentHeaders = nil
+		return
+	}
+	keep := len(f.recentHeaders)
+	for keep > 0 && f.recentHeaders[keep-1].Number.Cmp(header.Number) >= 0 {
+		keep--
+	}
+	f.recentHeaders = f.recentHeaders[:keep]
+	f.lastTraversedHeader = types.CopyHeader(header)
+	f.remember([]types.Header{*f.lastTraversedHeader})
+}
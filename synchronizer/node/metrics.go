@@ -0,0 +1,107 @@
+package node
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+	RPC 调用的指标采集：
+		- 按方法统计调用次数、错误次数和耗时，重试和健康检查的每次请求都会计入
+		- 批量调用按包含的方法记为一个标签，如 batch:eth_getBlockByNumber,eth_getLogs，批量中单个请求的错误按各自的方法计入
+	ClientConfig.Metrics 为空时不采集，主程序通过 PrometheusMetrics.Collectors 注册到自己的 registry
+*/
+
+type Metrics interface {
+	RecordRPCRequest(method string, d time.Duration) // 一次 RPC 请求完成，无论成功与否
+	RecordRPCError(method string)                    // RPC 请求失败
+	RecordQuorumDivergence(method string)            // 多节点一致性读取时节点返回的结果不一致
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) RecordRPCRequest(string, time.Duration) {}
+func (noopMetrics) RecordRPCError(string)                  {}
+func (noopMetrics) RecordQuorumDivergence(string)          {}
+
+var NoopMetrics Metrics = noopMetrics{}
+
+type PrometheusMetrics struct {
+	requests    *prometheus.CounterVec
+	errors      *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	divergences *prometheus.CounterVec
+}
+
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	const subsystem = "rpc"
+	return &PrometheusMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Number of RPC requests sent to the node, by method",
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "errors_total",
+			Help:      "Number of failed RPC requests, by method",
+		}, []string{"method"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of RPC requests, by method",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{"method"}),
+		divergences: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "quorum_divergences_total",
+			Help:      "Number of quorum reads where RPC providers returned different results, by method",
+		}, []string{"method"}),
+	}
+}
+
+// 返回全部指标，供主程序注册
+func (m *PrometheusMetrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.requests,
+		m.errors,
+		m.duration,
+		m.divergences,
+	}
+}
+
+func (m *PrometheusMetrics) RecordRPCRequest(method string, d time.Duration) {
+	m.requests.WithLabelValues(method).Inc()
+	m.duration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+func (m *PrometheusMetrics) RecordRPCError(method string) {
+	m.errors.WithLabelValues(method).Inc()
+}
+
+func (m *PrometheusMetrics) RecordQuorumDivergence(method string) {
+	m.divergences.WithLabelValues(method).Inc()
+}
+
+// 批量调用的指标标签，由去重排序后的方法名组成
+func batchMethod(b []rpc.BatchElem) string {
+	seen := make(map[string]struct{}, len(b))
+	var methods []string
+	for _, elem := range b {
+		if _, ok := seen[elem.Method]; ok {
+			continue
+		}
+		seen[elem.Method] = struct{}{}
+		methods = append(methods, elem.Method)
+	}
+	sort.Strings(methods)
+	return "batch:" + strings.Join(methods, ",")
+}
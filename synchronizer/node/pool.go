@@ -0,0 +1,218 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+/*
+	多 RPC 节点池：
+		- DialEthClient 传入多个地址时，返回一个在多个节点间路由请求的客户端
+		- 后台定期调用 eth_blockNumber 做健康检查，记录每个节点的可用性、区块高度和延迟
+		- 请求优先发给最健康的节点：可用 > 区块高度更高 > 延迟更低
+		- 连接错误、超时、HTTP 错误时自动切换到下一个节点；节点返回的 JSON-RPC 错误（如执行回滚）原样返回，不切换
+*/
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultEndpointTimeout     = 15 * time.Second
+)
+
+// 节点池中的单个节点
+type endpoint struct {
+	url string
+	rpc RPC
+
+	mu       sync.Mutex
+	healthy  bool
+	height   uint64
+	latency  time.Duration
+	failures int // 连续失败次数
+}
+
+func (e *endpoint) recordSuccess() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.healthy = true
+	e.failures = 0
+}
+
+func (e *endpoint) recordFailure() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.healthy = false
+	e.failures++
+}
+
+type endpointState struct {
+	endpoint *endpoint
+	healthy  bool
+	height   uint64
+	latency  time.Duration
+	failures int
+}
+
+func (e *endpoint) state() endpointState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return endpointState{endpoint: e, healthy: e.healthy, height: e.height, latency: e.latency, failures: e.failures}
+}
+
+type rpcPool struct {
+	endpoints       []*endpoint
+	endpointTimeout time.Duration // 单个节点单次请求的超时，超时后切换到下一个节点
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+func newRPCPool(urls []string, clients []RPC, healthCheckInterval, endpointTimeout time.Duration) *rpcPool {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &rpcPool{endpointTimeout: endpointTimeout, cancel: cancel}
+	for i, client := range clients {
+		p.endpoints = append(p.endpoints, &endpoint{url: urls[i], rpc: client, healthy: true})
+	}
+
+	p.checkHealth(ctx)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(healthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.checkHealth(ctx)
+			}
+		}
+	}()
+	return p
+}
+
+// 对所有节点调用 eth_blockNumber，更新可用性、高度和延迟
+func (p *rpcPool) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			ctxwt, cancel := context.WithTimeout(ctx, p.endpointTimeout)
+			defer cancel()
+
+			start := time.Now()
+			var height hexutil.Uint64
+			if err := e.rpc.CallContext(ctxwt, &height, "eth_blockNumber"); err != nil {
+				if ctx.Err() == nil {
+					log.Warn("rpc endpoint health check failed", "url", e.url, "err", err)
+				}
+				e.recordFailure()
+				return
+			}
+			latency := time.Since(start)
+			e.recordSuccess()
+			e.mu.Lock()
+			e.height = uint64(height)
+			e.latency = latency
+			e.mu.Unlock()
+		}(e)
+	}
+	wg.Wait()
+}
+
+// 按健康程度排序的节点列表
+func (p *rpcPool) ranked() []*endpoint {
+	states := make([]endpointState, len(p.endpoints))
+	for i, e := range p.endpoints {
+		states[i] = e.state()
+	}
+	sort.SliceStable(states, func(i, j int) bool {
+		a, b := states[i], states[j]
+		if a.healthy != b.healthy {
+			return a.healthy
+		}
+		if !a.healthy {
+			return a.failures < b.failures
+		}
+		if a.height != b.height {
+			return a.height > b.height
+		}
+		return a.latency < b.latency
+	})
+
+	ranked := make([]*endpoint, len(states))
+	for i, s := range states {
+		ranked[i] = s.endpoint
+	}
+	return ranked
+}
+
+// 按健康程度依次尝试各个节点，直到请求成功或出现不应切换的错误
+func (p *rpcPool) do(ctx context.Context, method string, call func(ctx context.Context, client RPC) error) error {
+	var errs []error
+	for _, e := range p.ranked() {
+		if ctx.Err() != nil {
+			break
+		}
+
+		ctxwt, cancel := context.WithTimeout(ctx, p.endpointTimeout)
+		err := call(ctxwt, e.rpc)
+		cancel()
+
+		if err == nil || !isTransientError(err) {
+			e.recordSuccess()
+			return err
+		}
+		e.recordFailure()
+		log.Warn("rpc endpoint request failed, failing over", "url", e.url, "method", method, "err", err)
+		errs = append(errs, fmt.Errorf("%s: %w", e.url, err))
+	}
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("all rpc endpoints failed for %s: %w", method, errors.Join(errs...))
+}
+
+func (p *rpcPool) Close() {
+	p.cancel()
+	p.wg.Wait()
+	for _, e := range p.endpoints {
+		e.rpc.Close()
+	}
+}
+
+func (p *rpcPool) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	return p.do(ctx, method, func(ctx context.Context, client RPC) error {
+		return client.CallContext(ctx, result, method, args...)
+	})
+}
+
+func (p *rpcPool) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	method := "batch"
+	if len(b) > 0 {
+		method = b[0].Method
+	}
+	return p.do(ctx, method, func(ctx context.Context, client RPC) error {
+		return client.BatchCallContext(ctx, b)
+	})
+}
+
+// 订阅发给最健康的节点，订阅中断后由调用方重新订阅
+func (p *rpcPool) EthSubscribe(ctx context.Context, channel any, args ...any) (*rpc.ClientSubscription, error) {
+	var sub *rpc.ClientSubscription
+	err := p.do(ctx, "eth_subscribe", func(_ context.Context, client RPC) error {
+		var err error
+		// 订阅的生命周期跟随调用方的 ctx，不能使用单次请求的超时
+		sub, err = client.EthSubscribe(ctx, channel, args...)
+		return err
+	})
+	return sub, err
+}
@@ -0,0 +1,183 @@
+package node_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// 节点对该高度的区块头请求返回 JSON-RPC 错误
+const failingHeight = 99
+
+// 模拟的 RPC 节点，down 为 true 时所有请求返回 HTTP 503
+type fakeNode struct {
+	height      uint64
+	extra       map[uint64][]byte // 与其他节点不同的区块头
+	receipts    map[common.Hash]*types.Receipt
+	down        atomic.Bool
+	headerCalls atomic.Int64
+}
+
+func testHeader(number uint64, extra []byte) *types.Header {
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		Difficulty: new(big.Int),
+		GasLimit:   30_000_000,
+		Time:       1_700_000_000 + number*12,
+		Extra:      extra,
+	}
+}
+
+type ethAPI struct {
+	node *fakeNode
+}
+
+func (api *ethAPI) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.node.height)
+}
+
+func (api *ethAPI) GetBlockByNumber(number rpc.BlockNumber, _ bool) (*types.Header, error) {
+	api.node.headerCalls.Add(1)
+	height := api.node.height
+	if number >= 0 {
+		height = uint64(number)
+	}
+	if height == failingHeight {
+		return nil, errors.New("header unavailable")
+	}
+	if height > api.node.height {
+		return nil, nil
+	}
+	return testHeader(height, api.node.extra[height]), nil
+}
+
+func (api *ethAPI) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	return api.node.receipts[hash]
+}
+
+func testReceipt(txHash common.Hash, status uint64) *types.Receipt {
+	return &types.Receipt{
+		Status:            status,
+		CumulativeGasUsed: 21000,
+		GasUsed:           21000,
+		Logs:              []*types.Log{},
+		TxHash:            txHash,
+		BlockHash:         testHeader(10, nil).Hash(),
+		BlockNumber:       big.NewInt(10),
+	}
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if n.down.Load() {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &ethAPI{node: n}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	server.ServeHTTP(w, r)
+}
+
+func dialNodes(t *testing.T, cfg node.ClientConfig, nodes ...*fakeNode) node.EthClient {
+	urls := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		server := httptest.NewServer(n)
+		t.Cleanup(server.Close)
+		urls = append(urls, server.URL)
+	}
+	cfg.RetryAttempts = 1
+	client, err := node.DialEthClient(context.Background(), cfg, urls...)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestPoolFailsOverUnavailableEndpoint(t *testing.T) {
+	preferred := &fakeNode{height: 12}
+	backup := &fakeNode{height: 10}
+	client := dialNodes(t, node.ClientConfig{}, preferred, backup)
+
+	// 最高的节点在健康检查之后下线，请求切换到下一个节点
+	preferred.down.Store(true)
+	header, err := client.BlockHeaderByNumber(big.NewInt(5))
+	require.NoError(t, err)
+	require.Equal(t, testHeader(5, nil).Hash(), header.Hash())
+	require.Equal(t, int64(1), backup.headerCalls.Load())
+
+	// 失败的节点排到最后，之后的请求直接发给可用的节点
+	_, err = client.BlockHeaderByNumber(big.NewInt(6))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), backup.headerCalls.Load())
+}
+
+func TestPoolReturnsJSONRPCErrorsWithoutFailover(t *testing.T) {
+	preferred := &fakeNode{height: 12}
+	backup := &fakeNode{height: 10}
+	client := dialNodes(t, node.ClientConfig{}, preferred, backup)
+
+	_, err := client.BlockHeaderByNumber(big.NewInt(failingHeight))
+	require.Error(t, err)
+	require.False(t, node.IsRetryable(err))
+	require.Equal(t, int64(1), preferred.headerCalls.Load())
+	require.Zero(t, backup.headerCalls.Load())
+}
+
+func TestQuorumHeaderUsesLowestCommonHeight(t *testing.T) {
+	client := dialNodes(t, node.ClientConfig{ReadQuorum: 3}, &fakeNode{height: 12}, &fakeNode{height: 11}, &fakeNode{height: 10})
+
+	header, err := client.BlockHeaderByNumber(nil)
+	require.NoError(t, err)
+	require.Equal(t, testHeader(10, nil).Hash(), header.Hash())
+}
+
+func TestQuorumHeaderDivergence(t *testing.T) {
+	forked := &fakeNode{height: 10, extra: map[uint64][]byte{10: []byte("fork")}}
+	client := dialNodes(t, node.ClientConfig{ReadQuorum: 3}, &fakeNode{height: 10}, &fakeNode{height: 10}, forked)
+
+	_, err := client.BlockHeaderByNumber(nil)
+	var divergence *node.QuorumDivergenceError
+	require.ErrorAs(t, err, &divergence)
+	require.Equal(t, "eth_getBlockByNumber", divergence.Method)
+	require.Len(t, divergence.Results, 3)
+
+	// 不要求一致的读取不受影响
+	header, err := client.BlockHeaderByNumber(big.NewInt(9))
+	require.NoError(t, err)
+	require.Equal(t, testHeader(9, nil).Hash(), header.Hash())
+}
+
+func TestQuorumReceipts(t *testing.T) {
+	txHash := common.HexToHash("0x01")
+	synced := map[common.Hash]*types.Receipt{txHash: testReceipt(txHash, types.ReceiptStatusSuccessful)}
+	reverted := map[common.Hash]*types.Receipt{txHash: testReceipt(txHash, types.ReceiptStatusFailed)}
+
+	client := dialNodes(t, node.ClientConfig{ReadQuorum: 2}, &fakeNode{height: 10, receipts: synced}, &fakeNode{height: 10, receipts: synced})
+	receipts, err := client.TxReceiptsByHashes([]common.Hash{txHash})
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipts[0].Status)
+
+	// 落后的节点还查不到回执时按未上链处理
+	client = dialNodes(t, node.ClientConfig{ReadQuorum: 2}, &fakeNode{height: 10, receipts: synced}, &fakeNode{height: 10})
+	_, err = client.TxReceiptByHash(txHash)
+	require.ErrorIs(t, err, node.ErrNotFound)
+
+	client = dialNodes(t, node.ClientConfig{ReadQuorum: 2}, &fakeNode{height: 10, receipts: synced}, &fakeNode{height: 10, receipts: reverted})
+	_, err = client.TxReceiptByHash(txHash)
+	var divergence *node.QuorumDivergenceError
+	require.ErrorAs(t, err, &divergence)
+	require.Equal(t, "eth_getTransactionReceipt", divergence.Method)
+}
@@ -0,0 +1,164 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+/*
+	账户和存储证明：
+		- GetProof 调用 eth_getProof 返回完整的账户证明和存储证明，StorageHash 只返回其中的存储根
+		- Verify 用可信区块头的 stateRoot 校验证明，校验通过后账户状态和存储值不再依赖 RPC 节点的诚实性
+		- 可用于读取 VRF 合约中请求状态等存储槽时，避免被恶意或出错的节点欺骗
+*/
+
+// 证明与 stateRoot 不匹配，或证明内容与声明的值不一致
+var ErrInvalidProof = errors.New("invalid proof")
+
+// eth_getProof 返回的账户证明
+type AccountProof struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageProof  `json:"storageProof"`
+}
+
+// 单个存储槽的证明
+type StorageProof struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// 部分节点返回的 key 去掉了前导零，统一补齐为 32 字节
+func (s *StorageProof) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		Key   string          `json:"key"`
+		Value *hexutil.Big    `json:"value"`
+		Proof []hexutil.Bytes `json:"proof"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	s.Key = common.HexToHash(dec.Key)
+	s.Value = dec.Value
+	s.Proof = dec.Proof
+	return nil
+}
+
+// 获取账户在指定区块的证明，keys 为需要证明的存储槽
+func (c *clnt) GetProof(address common.Address, keys []common.Hash, blockNumber *big.Int) (*AccountProof, error) {
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	if keys == nil {
+		keys = []common.Hash{}
+	}
+	var proof *AccountProof
+	err := c.rpc.CallContext(ctxwt, &proof, "eth_getProof", address, keys, toBlockNumArg(blockNumber))
+	if err != nil {
+		return nil, newRPCError("eth_getProof", err)
+	} else if proof == nil {
+		return nil, fmt.Errorf("proof %s: %w", address, ErrNotFound)
+	}
+	return proof, nil
+}
+
+// 用区块头的 stateRoot 校验证明
+func (p *AccountProof) VerifyHeader(header *types.Header) error {
+	return p.Verify(header.Root)
+}
+
+// 校验账户证明和所有存储证明，账户不存在时要求证明中的账户字段均为空值
+func (p *AccountProof) Verify(stateRoot common.Hash) error {
+	value, err := trie.VerifyProof(stateRoot, crypto.Keccak256(p.Address.Bytes()), proofDB(p.AccountProof))
+	if err != nil {
+		return fmt.Errorf("%w: account %s: %v", ErrInvalidProof, p.Address, err)
+	}
+
+	account := types.NewEmptyStateAccount()
+	if value != nil {
+		if err := rlp.DecodeBytes(value, account); err != nil {
+			return fmt.Errorf("%w: account %s: %v", ErrInvalidProof, p.Address, err)
+		}
+	}
+	if account.Nonce != uint64(p.Nonce) {
+		return fmt.Errorf("%w: account %s nonce mismatch", ErrInvalidProof, p.Address)
+	}
+	if account.Balance.ToBig().Cmp(bigOrZero(p.Balance)) != 0 {
+		return fmt.Errorf("%w: account %s balance mismatch", ErrInvalidProof, p.Address)
+	}
+	if common.BytesToHash(account.CodeHash) != p.CodeHash {
+		return fmt.Errorf("%w: account %s code hash mismatch", ErrInvalidProof, p.Address)
+	}
+	if account.Root != p.StorageHash {
+		return fmt.Errorf("%w: account %s storage hash mismatch", ErrInvalidProof, p.Address)
+	}
+
+	for _, storage := range p.StorageProof {
+		if err := storage.verify(p.StorageHash); err != nil {
+			return fmt.Errorf("%w: account %s: %v", ErrInvalidProof, p.Address, err)
+		}
+	}
+	return nil
+}
+
+// 返回已证明的存储槽的值，key 不在证明中时 ok 为 false
+func (p *AccountProof) StorageValue(key common.Hash) (value common.Hash, ok bool) {
+	for _, storage := range p.StorageProof {
+		if storage.Key == key {
+			return common.BigToHash(bigOrZero(storage.Value)), true
+		}
+	}
+	return common.Hash{}, false
+}
+
+// 存储槽不存在时值必须为 0
+func (s StorageProof) verify(storageRoot common.Hash) error {
+	value, err := trie.VerifyProof(storageRoot, crypto.Keccak256(s.Key.Bytes()), proofDB(s.Proof))
+	if err != nil {
+		return fmt.Errorf("storage %s: %v", s.Key, err)
+	}
+
+	var proven []byte
+	if value != nil {
+		if _, proven, _, err = rlp.Split(value); err != nil {
+			return fmt.Errorf("storage %s: %v", s.Key, err)
+		}
+	}
+	if !bytes.Equal(proven, bigOrZero(s.Value).Bytes()) {
+		return fmt.Errorf("storage %s value mismatch", s.Key)
+	}
+	return nil
+}
+
+// trie.VerifyProof 按节点哈希查找证明中的节点
+func proofDB(proof []hexutil.Bytes) *memorydb.Database {
+	db := memorydb.New()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+func bigOrZero(b *hexutil.Big) *big.Int {
+	if b == nil {
+		return new(big.Int)
+	}
+	return b.ToInt()
+}
@@ -0,0 +1,151 @@
+package node_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	proofAddress = common.HexToAddress("0xabcd")
+	otherAddress = common.HexToAddress("0xef01")
+	codeHash     = crypto.Keccak256Hash([]byte("code"))
+)
+
+// 收集 Prove 写出的证明节点
+type proofList []hexutil.Bytes
+
+func (l *proofList) Put(_ []byte, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *proofList) Delete([]byte) error {
+	return nil
+}
+
+func newTrie() *trie.Trie {
+	return trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+}
+
+func prove(t *testing.T, tr *trie.Trie, key []byte) []hexutil.Bytes {
+	var proof proofList
+	require.NoError(t, tr.Prove(crypto.Keccak256(key), &proof))
+	return proof
+}
+
+// 构造包含 proofAddress 和另一个账户的状态树，proofAddress 的存储槽 1 的值为 storageValue
+func newTestState(t *testing.T, storageValue *big.Int) (common.Hash, *trie.Trie, *trie.Trie) {
+	storage := newTrie()
+	value, err := rlp.EncodeToBytes(storageValue.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, storage.Update(crypto.Keccak256(common.BigToHash(big.NewInt(1)).Bytes()), value))
+
+	state := newTrie()
+	accounts := map[common.Address]*types.StateAccount{
+		proofAddress: {Nonce: 3, Balance: uint256.NewInt(1000), Root: storage.Hash(), CodeHash: codeHash.Bytes()},
+		otherAddress: types.NewEmptyStateAccount(),
+	}
+	for address, account := range accounts {
+		encoded, err := rlp.EncodeToBytes(account)
+		require.NoError(t, err)
+		require.NoError(t, state.Update(crypto.Keccak256(address.Bytes()), encoded))
+	}
+	return state.Hash(), state, storage
+}
+
+func newAccountProof(t *testing.T, state, storage *trie.Trie, slots ...common.Hash) *node.AccountProof {
+	proof := &node.AccountProof{
+		Address:      proofAddress,
+		AccountProof: prove(t, state, proofAddress.Bytes()),
+		Balance:      (*hexutil.Big)(big.NewInt(1000)),
+		CodeHash:     codeHash,
+		Nonce:        3,
+		StorageHash:  storage.Hash(),
+	}
+	for _, slot := range slots {
+		proof.StorageProof = append(proof.StorageProof, node.StorageProof{Key: slot, Proof: prove(t, storage, slot.Bytes())})
+	}
+	return proof
+}
+
+func TestAccountProofVerify(t *testing.T) {
+	stateRoot, state, storage := newTestState(t, big.NewInt(42))
+	slot, missing := common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(2))
+	proof := newAccountProof(t, state, storage, slot, missing)
+	proof.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(42))
+
+	require.NoError(t, proof.Verify(stateRoot))
+	require.NoError(t, proof.VerifyHeader(&types.Header{Root: stateRoot}))
+	value, ok := proof.StorageValue(slot)
+	require.True(t, ok)
+	require.Equal(t, common.BigToHash(big.NewInt(42)), value)
+	// 不存在的存储槽证明值为 0
+	value, ok = proof.StorageValue(missing)
+	require.True(t, ok)
+	require.Equal(t, common.Hash{}, value)
+}
+
+func TestAccountProofRejectsTamperedFields(t *testing.T) {
+	stateRoot, state, storage := newTestState(t, big.NewInt(42))
+	slot := common.BigToHash(big.NewInt(1))
+
+	tests := map[string]func(*node.AccountProof){
+		"balance":       func(p *node.AccountProof) { p.Balance = (*hexutil.Big)(big.NewInt(1001)) },
+		"nonce":         func(p *node.AccountProof) { p.Nonce = 4 },
+		"code hash":     func(p *node.AccountProof) { p.CodeHash = common.Hash{} },
+		"storage hash":  func(p *node.AccountProof) { p.StorageHash = common.Hash{1} },
+		"storage value": func(p *node.AccountProof) { p.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(43)) },
+		"missing value": func(p *node.AccountProof) { p.StorageProof[0].Value = nil },
+		"address":       func(p *node.AccountProof) { p.Address = otherAddress },
+		"account proof": func(p *node.AccountProof) { p.AccountProof = p.AccountProof[:len(p.AccountProof)-1] },
+	}
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			proof := newAccountProof(t, state, storage, slot)
+			proof.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(42))
+			tamper(proof)
+			require.ErrorIs(t, proof.Verify(stateRoot), node.ErrInvalidProof)
+		})
+	}
+
+	t.Run("state root", func(t *testing.T) {
+		proof := newAccountProof(t, state, storage, slot)
+		proof.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(42))
+		otherRoot, _, _ := newTestState(t, big.NewInt(43))
+		require.ErrorIs(t, proof.Verify(otherRoot), node.ErrInvalidProof)
+	})
+}
+
+func TestAccountProofOfAbsentAccount(t *testing.T) {
+	stateRoot, state, _ := newTestState(t, big.NewInt(42))
+	absent := common.HexToAddress("0x9999")
+	proof := &node.AccountProof{
+		Address:      absent,
+		AccountProof: prove(t, state, absent.Bytes()),
+		CodeHash:     types.EmptyCodeHash,
+		StorageHash:  types.EmptyRootHash,
+	}
+	require.NoError(t, proof.Verify(stateRoot))
+
+	proof.Balance = (*hexutil.Big)(big.NewInt(1))
+	require.ErrorIs(t, proof.Verify(stateRoot), node.ErrInvalidProof)
+}
+
+func TestStorageProofUnmarshalPadsKey(t *testing.T) {
+	var proof node.StorageProof
+	require.NoError(t, proof.UnmarshalJSON([]byte(`{"key":"0x1","value":"0x2a","proof":[]}`)))
+	require.Equal(t, common.BigToHash(big.NewInt(1)), proof.Key)
+	require.Equal(t, big.NewInt(42), proof.Value.ToInt())
+}
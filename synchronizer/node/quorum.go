@@ -0,0 +1,188 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+/*
+	多节点一致性读取（quorum）：
+		- 配置了 ClientConfig.ReadQuorum 且使用节点池时，关键读取同时发给最健康的 N 个节点并比较结果
+		- 最新 / safe / finalized 区块头：先取各节点返回高度的最小值，再在该高度比较区块哈希，节点之间正常的高度差不算分歧
+		- 交易回执：比较所在区块、执行状态、gas 和日志数量；有节点还查不到回执时按未上链处理
+		- 结果不一致时返回 *QuorumDivergenceError 并记录指标，避免单个恶意或落后的节点影响索引和确认逻辑
+*/
+
+// 多个节点对同一请求返回了不同的结果
+type QuorumDivergenceError struct {
+	Method  string
+	Results map[string]string // 节点地址 -> 结果摘要
+}
+
+func (e *QuorumDivergenceError) Error() string {
+	urls := make([]string, 0, len(e.Results))
+	for url := range e.Results {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	results := make([]string, len(urls))
+	for i, url := range urls {
+		results[i] = url + "=" + e.Results[url]
+	}
+	return fmt.Sprintf("%s: rpc providers diverged: %s", e.Method, strings.Join(results, ", "))
+}
+
+type quorumReader struct {
+	pool    *rpcPool
+	size    int
+	metrics Metrics
+}
+
+// 单个节点的结果
+type quorumResult[T any] struct {
+	url   string
+	value T
+}
+
+// 对最健康的 q.size 个节点并发执行 fetch，任一节点失败时返回错误
+func quorumCall[T any](q *quorumReader, method string, fetch func(ctx context.Context, client RPC) (T, error)) ([]quorumResult[T], error) {
+	endpoints := q.pool.ranked()[:q.size]
+	results := make([]quorumResult[T], len(endpoints))
+	errs := make([]error, len(endpoints))
+
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctxwt, cancel := context.WithTimeout(context.Background(), q.pool.endpointTimeout)
+			defer cancel()
+			value, err := fetch(ctxwt, e.rpc)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", e.url, err)
+				return
+			}
+			results[i] = quorumResult[T]{url: e.url, value: value}
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, newRPCError(method, err)
+	}
+	return results, nil
+}
+
+// 比较各节点结果的摘要，不一致时记录并返回 *QuorumDivergenceError
+func checkQuorum[T any](q *quorumReader, method string, results []quorumResult[T], digest func(T) string) error {
+	digests := make(map[string]string, len(results))
+	distinct := make(map[string]struct{})
+	for _, r := range results {
+		d := digest(r.value)
+		digests[r.url] = d
+		distinct[d] = struct{}{}
+	}
+	if len(distinct) <= 1 {
+		return nil
+	}
+
+	q.metrics.RecordQuorumDivergence(method)
+	err := &QuorumDivergenceError{Method: method, Results: digests}
+	log.Error("rpc providers diverged", "method", method, "results", digests)
+	return err
+}
+
+// tag 为 latest / safe / finalized，返回所有节点都已同步到的最高区块头
+func (q *quorumReader) header(tag string) (*types.Header, error) {
+	const method = "eth_getBlockByNumber"
+	tips, err := quorumCall(q, method, func(ctx context.Context, client RPC) (*types.Header, error) {
+		return fetchHeader(ctx, client, tag)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 节点之间有正常的同步延迟，取最低的高度比较
+	lowest := tips[0].value.Number
+	for _, tip := range tips[1:] {
+		if tip.value.Number.Cmp(lowest) < 0 {
+			lowest = tip.value.Number
+		}
+	}
+
+	headers, err := quorumCall(q, method, func(ctx context.Context, client RPC) (*types.Header, error) {
+		return fetchHeader(ctx, client, toBlockNumArg(lowest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkQuorum(q, method, headers, func(h *types.Header) string { return h.Hash().Hex() }); err != nil {
+		return nil, err
+	}
+	return headers[0].value, nil
+}
+
+func fetchHeader(ctx context.Context, client RPC, number string) (*types.Header, error) {
+	var header *types.Header
+	if err := client.CallContext(ctx, &header, "eth_getBlockByNumber", number, false); err != nil {
+		return nil, err
+	} else if header == nil {
+		return nil, fmt.Errorf("header %s: %w", number, ErrNotFound)
+	}
+	return header, nil
+}
+
+// 所有节点都返回一致的回执时才认为交易已上链
+func (q *quorumReader) receipts(hashes []common.Hash) ([]*types.Receipt, error) {
+	const method = "eth_getTransactionReceipt"
+	results, err := quorumCall(q, method, func(ctx context.Context, client RPC) ([]*types.Receipt, error) {
+		receipts := make([]*types.Receipt, len(hashes))
+		batchElems := make([]rpc.BatchElem, len(hashes))
+		for i, hash := range hashes {
+			batchElems[i] = rpc.BatchElem{Method: method, Args: []interface{}{hash}, Result: &receipts[i]}
+		}
+		if err := client.BatchCallContext(ctx, batchElems); err != nil {
+			return nil, err
+		}
+		for _, batchElem := range batchElems {
+			if batchElem.Error != nil {
+				return nil, batchElem.Error
+			}
+		}
+		return receipts, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 落后的节点还查不到回执时按未上链处理，等待下一轮确认
+	for _, r := range results {
+		for i, receipt := range r.value {
+			if receipt == nil {
+				return nil, fmt.Errorf("receipt %s: %w", hashes[i], ErrNotFound)
+			}
+		}
+	}
+
+	if err := checkQuorum(q, method, results, receiptsDigest); err != nil {
+		return nil, err
+	}
+	return results[0].value, nil
+}
+
+func receiptsDigest(receipts []*types.Receipt) string {
+	digests := make([]string, len(receipts))
+	for i, r := range receipts {
+		digests[i] = fmt.Sprintf("%s/%d/%d/%d/%d", r.BlockHash.Hex(), r.BlockNumber, r.Status, r.GasUsed, len(r.Logs))
+	}
+	return strings.Join(digests, ";")
+}
@@ -0,0 +1,82 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	区块重组检测与回退：
+		- HeaderTraversal 保留最近遍历过的 maxReorgDepth 个区块头，默认 defaultMaxReorgDepth，可按链的最终确认深度设置
+		- 新一批区块头的 ParentHash 与上次遍历的区块不一致时，从新到旧逐个与节点当前的规范链比较，找到共同祖先
+		- 回退到共同祖先后通过 OnReorg 回调发出 ReorgEvent，并从分叉点继续遍历
+		- 重组深度超过保留的区块头数量时无法确定共同祖先，返回 ErrHeaderTraversalAndProviderMismatchedState
+*/
+
+// 默认保留的最近区块头数量，即能自动处理的最大重组深度
+const defaultMaxReorgDepth = 128
+
+// 一次区块重组
+type ReorgEvent struct {
+	CommonAncestor *types.Header // 新旧链的共同祖先，遍历从它的下一个区块继续
+	OrphanedFrom   *big.Int      // 被丢弃的第一个区块高度
+	OrphanedTo     *big.Int      // 被丢弃的最后一个区块高度
+	Orphaned       []types.Header
+}
+
+// 记录遍历过的区块头，超出 f.maxReorgDepth 的旧区块头丢弃
+func (f *HeaderTraversal) remember(headers []types.Header) {
+	f.recentHeaders = append(f.recentHeaders, headers...)
+	if drop := len(f.recentHeaders) - f.maxReorgDepth; drop > 0 {
+		f.recentHeaders = append([]types.Header(nil), f.recentHeaders[drop:]...)
+	}
+}
+
+// 设置能自动处理的最大重组深度，通常为链的最终确认深度，更深的重组返回错误
+func (f *HeaderTraversal) SetMaxReorgDepth(depth uint64) {
+	if depth == 0 {
+		depth = defaultMaxReorgDepth
+	}
+	f.maxReorgDepth = int(depth)
+	f.remember(nil)
+}
+
+// 从最近遍历的区块头中找到仍在节点规范链上的最新区块，回退到该区块
+// limit 不为 nil 时只考虑不高于 limit 的区块
+func (f *HeaderTraversal) rewind(limit *big.Int) (*ReorgEvent, error) {
+	for i := len(f.recentHeaders) - 1; i >= 0; i-- {
+		local := &f.recentHeaders[i]
+		if limit != nil && local.Number.Cmp(limit) > 0 {
+			continue
+		}
+		canonical, err := f.ethClient.BlockHeaderByNumber(local.Number)
+		if errors.Is(err, ErrNotFound) {
+			// 新链比旧链短，该高度已不存在
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to query canonical header %s: %w", local.Number, err)
+		}
+		if canonical.Hash() != local.Hash() {
+			continue
+		}
+		if i == len(f.recentHeaders)-1 {
+			// 上次遍历的区块仍在规范链上，新区块头却不与它相连，说明节点返回的数据前后不一致
+			return nil, ErrHeaderTraversalAndProviderMismatchedState
+		}
+
+		ancestor := *local
+		orphaned := append([]types.Header(nil), f.recentHeaders[i+1:]...)
+		f.recentHeaders = f.recentHeaders[:i+1]
+		f.lastTraversedHeader = &ancestor
+		return &ReorgEvent{
+			CommonAncestor: &ancestor,
+			OrphanedFrom:   orphaned[0].Number,
+			OrphanedTo:     orphaned[len(orphaned)-1].Number,
+			Orphaned:       orphaned,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: no common ancestor within the last %d headers", ErrHeaderTraversalAndProviderMismatchedState, len(f.recentHeaders))
+}
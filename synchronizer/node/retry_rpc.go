@@ -0,0 +1,62 @@
+package node
+
+import (
+	"context"
+	"errors"
+
+	"github.com/WJX2001/contract-caller/synchronizer/retry"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+/*
+	单次 RPC 调用的重试：
+		- 连接错误、超时、HTTP 错误等临时故障在客户端内部按 ClientConfig 的策略重试
+		- 节点返回的 JSON-RPC 错误（如执行回滚、参数错误）重试也不会成功，直接返回
+		- 避免偶发的网络抖动一路冒泡到同步器，导致整批区块重新处理
+*/
+
+// 为 CallContext / BatchCallContext 增加重试，订阅不重试
+type retryRPC struct {
+	RPC
+	attempts int
+	strategy retry.Strategy
+}
+
+func newRetryRPC(inner RPC, cfg ClientConfig) RPC {
+	return &retryRPC{RPC: inner, attempts: cfg.retryAttempts(), strategy: cfg.retryStrategy()}
+}
+
+func (r *retryRPC) CallContext(ctx context.Context, result any, method string, args ...any) error {
+	return r.do(ctx, func() error {
+		return r.RPC.CallContext(ctx, result, method, args...)
+	})
+}
+
+func (r *retryRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return r.do(ctx, func() error {
+		return r.RPC.BatchCallContext(ctx, b)
+	})
+}
+
+func (r *retryRPC) do(ctx context.Context, call func() error) error {
+	// 不可重试的错误记录下来并结束重试
+	var permanent error
+	_, err := retry.Do(ctx, r.attempts, r.strategy, func() (struct{}, error) {
+		err := call()
+		if err != nil && !isTransientError(err) {
+			permanent = err
+			return struct{}{}, nil
+		}
+		return struct{}{}, err
+	})
+	if permanent != nil {
+		return permanent
+	}
+	return err
+}
+
+// 节点返回的 JSON-RPC 错误说明请求已被节点处理，重试或切换节点都没有意义
+func isTransientError(err error) bool {
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
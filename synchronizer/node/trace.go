@@ -0,0 +1,74 @@
+package node
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+/*
+	通过 debug_traceTransaction（callTracer）获取交易的内部调用树：
+		- 代理合约等内部调用 VRF 合约时不一定产生事件日志，只能从调用树中识别
+		- 不是所有节点都开放 debug 命名空间，需在 ClientConfig.EnableTracing 开启后才可使用
+*/
+
+var ErrTracingDisabled = errors.New("debug_traceTransaction is disabled, set ClientConfig.EnableTracing to use it")
+
+// callTracer 返回的一次调用，Calls 为其发起的内部调用
+type CallFrame struct {
+	Type         string          `json:"type"` // CALL、DELEGATECALL、STATICCALL、CREATE 等
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []CallFrame     `json:"calls,omitempty"`
+}
+
+// 按深度优先顺序返回调用树中目标为 to 的所有调用，包括最外层调用
+func (f *CallFrame) CallsTo(to common.Address) []*CallFrame {
+	var frames []*CallFrame
+	var walk func(frame *CallFrame)
+	walk = func(frame *CallFrame) {
+		if frame.To != nil && *frame.To == to {
+			frames = append(frames, frame)
+		}
+		for i := range frame.Calls {
+			walk(&frame.Calls[i])
+		}
+	}
+	walk(f)
+	return frames
+}
+
+// 调用是否执行失败（包括回滚）
+func (f *CallFrame) Failed() bool {
+	return f.Error != ""
+}
+
+// 获取交易的内部调用树，未开启 EnableTracing 时返回 ErrTracingDisabled
+func (c *clnt) TraceTransaction(hash common.Hash) (*CallFrame, error) {
+	if !c.tracing {
+		return nil, ErrTracingDisabled
+	}
+
+	ctxwt, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	var frame *CallFrame
+	tracerConfig := map[string]interface{}{"tracer": "callTracer"}
+	err := c.rpc.CallContext(ctxwt, &frame, "debug_traceTransaction", hash, tracerConfig)
+	if err != nil {
+		return nil, newRPCError("debug_traceTransaction", err)
+	} else if frame == nil {
+		return nil, ErrNotFound
+	}
+
+	return frame, nil
+}
@@ -0,0 +1,174 @@
+package synchronizer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/WJX2001/contract-caller/database/event"
+	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/sync/errgroup"
+)
+
+/*
+	同步流水线：
+		- 一轮同步拆成三个阶段：拉取区块头 -> 拉取日志 -> 写库，阶段之间通过容量为 pipelineDepth 的 channel 连接
+		- 各阶段在独立的 goroutine 中运行，写库的同时已经在拉取后面批次的区块头和日志，channel 写满时上游阶段等待
+		- 批次按区块顺序经过各阶段，写库阶段串行执行，检查点总是指向最后一个写库成功的区块
+		- 拉取区块头失败时等已拉取的批次写完再结束本轮；拉取日志或写库失败时立即停止所有阶段
+		- 发生重组时停止所有阶段，回滚孤块数据后从共同祖先重新遍历
+		- 本轮异常结束时，已遍历但未写库的区块头被丢弃，遍历器重置到最后写库成功的区块，下一轮重新拉取
+*/
+
+const defaultPipelineDepth = 2
+
+// 拉取区块头阶段发现重组后结束本轮
+var errPipelineReorged = errors.New("chain reorged during sync round")
+
+// 在流水线各阶段之间传递的一批区块
+type syncBatch struct {
+	headers      []types.Header
+	logs         []types.Log
+	transactions []event.ContractTransaction // 开启交易索引时发往监听合约的交易
+}
+
+// 运行一轮同步流水线，追到链头或任一阶段失败时返回
+func (syncer *Synchronizer) runPipeline() error {
+	ctx, cancel := context.WithCancel(syncer.resourceCtx)
+	defer cancel()
+
+	headerBatches := make(chan *syncBatch, syncer.pipelineDepth)
+	logBatches := make(chan *syncBatch, syncer.pipelineDepth)
+
+	var group errgroup.Group
+	group.Go(func() error {
+		defer close(headerBatches)
+		err := syncer.fetchHeadersStage(ctx, headerBatches)
+		if errors.Is(err, errPipelineReorged) {
+			cancel()
+		}
+		return err
+	})
+	group.Go(func() error {
+		defer close(logBatches)
+		err := syncer.fetchLogsStage(ctx, headerBatches, logBatches)
+		if err != nil {
+			cancel()
+		}
+		return err
+	})
+	group.Go(func() error {
+		err := syncer.persistStage(ctx, logBatches)
+		if err != nil {
+			cancel()
+		}
+		return err
+	})
+	err := group.Wait()
+
+	syncer.resetTraversal()
+	if errors.Is(err, errPipelineReorged) {
+		return syncer.rollback()
+	}
+	return err
+}
+
+// 持续获取新区块头并按批发送给下游，追到链头时返回
+func (syncer *Synchronizer) fetchHeadersStage(ctx context.Context, out chan<- *syncBatch) error {
+	for ctx.Err() == nil {
+		headers, err := syncer.headerTraversal.NextHeaders(syncer.headerBufferSize)
+		if target := syncer.headerTraversal.TargetHeight(); target != nil && target.Sign() >= 0 {
+			syncer.recordChainHead(target)
+		}
+		if errors.Is(err, node.ErrHeaderTraversalAheadOfProvider) {
+			return err
+		} else if err != nil {
+			// RPC 调用出错时结束本轮，下一轮重新拉取
+			// 临时故障（连接、超时）和链头暂时查不到属于预期内的情况，其余错误需要关注
+			if node.IsRetryable(err) || errors.Is(err, node.ErrNotFound) {
+				log.Warn("transient error querying for headers, retrying next tick", "err", err)
+			} else {
+				log.Error("error querying for headers", "err", err)
+			}
+			return err
+		}
+		if syncer.rollbackTo != nil {
+			// 遍历器已回退到共同祖先，下游还未写库的批次可能已被孤立
+			return errPipelineReorged
+		}
+		if len(headers) == 0 {
+			// 如果没有新块，说明同步器已经到 链头
+			log.Debug("no new headers. syncer at head?")
+			return nil
+		}
+
+		select {
+		case out <- &syncBatch{headers: headers}:
+		case <-ctx.Done():
+		}
+	}
+	return nil
+}
+
+// 为每批区块头拉取日志（开启交易索引时还有交易）并发送给写库阶段
+func (syncer *Synchronizer) fetchLogsStage(ctx context.Context, in <-chan *syncBatch, out chan<- *syncBatch) error {
+	for {
+		var batch *syncBatch
+		select {
+		case batch = <-in:
+		case <-ctx.Done():
+			return nil
+		}
+		if batch == nil {
+			return nil
+		}
+		if err := syncer.fetchBatchLogs(batch); err != nil {
+			return err
+		}
+
+		select {
+		case out <- batch:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// 按顺序写入每批区块
+func (syncer *Synchronizer) persistStage(ctx context.Context, in <-chan *syncBatch) error {
+	for {
+		var batch *syncBatch
+		select {
+		case batch = <-in:
+		case <-ctx.Done():
+			return nil
+		}
+		if batch == nil {
+			return nil
+		}
+		if err := syncer.persistBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+}
+
+// 把遍历器重置到最后写库成功的区块，丢弃已遍历但未写库的区块头
+// 有待回滚的重组且共同祖先更低时重置到共同祖先，回滚完成前不会写入祖先之后的区块
+func (syncer *Synchronizer) resetTraversal() {
+	target := syncer.persistedHeader
+	if ancestor := syncer.rollbackTo; ancestor != nil && (target == nil || ancestor.Number.Cmp(target.Number) < 0) {
+		target = ancestor
+	}
+
+	traversed := syncer.headerTraversal.LastTraversedHeader()
+	if traversed == nil && target == nil {
+		return
+	} else if traversed != nil && target != nil && traversed.Hash() == target.Hash() {
+		return
+	}
+	if traversed != nil && target != nil {
+		log.Info("discarding unpersisted headers", "traversed", traversed.Number, "resetTo", target.Number)
+	}
+	syncer.headerTraversal.ResetTo(target)
+}
@@ -0,0 +1,99 @@
+package synchronizer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/WJX2001/contract-caller/synchronizer/retry"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	重组后的数据库回滚：
+		- HeaderTraversal 回退到共同祖先后通过 OnReorg 通知同步器，同步器记录待回滚的祖先区块
+		- 下一次写库前，在同一个事务中删除祖先之后的区块头、合约事件、合约交易、事件区块记录以及由事件解析出的业务数据，并把检查点移到祖先
+		- 回滚失败时不处理新的区块，下一轮继续重试，避免孤块数据和规范链数据混在一起
+		- 事件处理器发现已处理到的区块被删除后，从 event_blocks 中剩余的最新区块继续处理
+*/
+
+// 记录需要回滚到的共同祖先，多次重组时保留高度最低的祖先
+func (syncer *Synchronizer) handleReorg(event node.ReorgEvent) {
+	log.Warn("chain reorg detected, resuming from common ancestor",
+		"ancestor", event.CommonAncestor.Number, "ancestorHash", event.CommonAncestor.Hash(),
+		"orphanedFrom", event.OrphanedFrom, "orphanedTo", event.OrphanedTo)
+
+	// 流水线中未写库的批次可能已被孤立，本轮结束后丢弃并从祖先重新拉取
+	if syncer.rollbackTo == nil || event.CommonAncestor.Number.Cmp(syncer.rollbackTo.Number) < 0 {
+		syncer.rollbackTo = types.CopyHeader(event.CommonAncestor)
+	}
+}
+
+// 删除共同祖先之后的所有数据，成功后清除待回滚状态
+func (syncer *Synchronizer) rollback() error {
+	if syncer.rollbackTo == nil {
+		return nil
+	}
+	ancestor := syncer.rollbackTo
+	if syncer.persistedHeader != nil && syncer.persistedHeader.Number.Cmp(ancestor.Number) <= 0 {
+		// 共同祖先之后还没有写入任何区块，无需删除数据
+		syncer.rollbackTo = nil
+		return nil
+	}
+
+	retryStrategy := &retry.ExponentialStrategy{Min: 1000, Max: 20_000, MaxJitter: 250}
+	if _, err := retry.Do[interface{}](syncer.resourceCtx, 10, retryStrategy, func() (interface{}, error) {
+		if err := syncer.db.Transaction(func(tx *database.DB) error {
+			return rollbackAfter(tx, ancestor)
+		}); err != nil {
+			log.Error("unable to roll back orphaned data", "ancestor", ancestor.Number, "err", err)
+			return nil, fmt.Errorf("unable to roll back orphaned data: %w", err)
+		}
+		return nil, nil
+	}); err != nil {
+		return err
+	}
+
+	log.Info("rolled back orphaned data", "ancestor", ancestor.Number, "ancestorHash", ancestor.Hash())
+	syncer.rollbackTo = nil
+	syncer.persistedHeader = ancestor
+	syncer.recordIndexedHeight(ancestor.Number)
+	return nil
+}
+
+// 在事务 tx 中删除高度大于 ancestor 的数据，并把检查点移到 ancestor
+func rollbackAfter(tx *database.DB, ancestor *types.Header) error {
+	number := new(big.Int).Set(ancestor.Number)
+	// 先锁定要删除的区块头：正在写入这些区块派生数据的事件处理器事务提交后再删除，之后开始的写入会发现区块头已删除
+	if err := tx.Blocks.LockBlockHeadersAfter(number); err != nil {
+		return err
+	}
+	if err := tx.RequestSend.DeleteRequestSendAfter(number); err != nil {
+		return err
+	}
+	if err := tx.RequestSend.RevertRequestSendFulfilledAfter(number); err != nil {
+		return err
+	}
+	if err := tx.FillRandomWords.DeleteFillRandomWordsAfter(number); err != nil {
+		return err
+	}
+	if err := tx.PoxyCreated.DeletePoxyCreatedAfter(number); err != nil {
+		return err
+	}
+	if err := tx.ContractTx.DeleteContractTransactionsAfter(number); err != nil {
+		return err
+	}
+	if err := tx.EventBlocks.DeleteEventBlocksAfter(number); err != nil {
+		return err
+	}
+	// 合约事件通过 block_hash 关联区块头，需要先于区块头删除
+	if err := tx.ContractEvent.DeleteContractEventsAfter(number); err != nil {
+		return err
+	}
+	if err := tx.Blocks.DeleteBlockHeadersAfter(number); err != nil {
+		return err
+	}
+	return (checkpointStore{db: tx}).SaveCheckpoint(ancestor)
+}
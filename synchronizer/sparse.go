@@ -0,0 +1,50 @@
+package synchronizer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	稀疏区块头存储：
+		- 开启后只存储包含监听日志的区块头，以及高度为 interval 整数倍的区块头和每批的最后一个区块头
+		- 包含日志的区块头保证事件能通过 block_hash 关联到区块；周期性和每批最后的区块头用于记录同步进度、重启恢复
+		- 事件处理器按 block_headers 中实际存在的区块推进，不要求区块头连续
+	VRF 活动稀疏时可以大幅减少 block_headers 表的数据量
+*/
+
+const defaultSparseHeaderInterval = 1000
+
+type headerFilter struct {
+	sparse   bool   // 是否只存储部分区块头
+	interval uint64 // 稀疏模式下周期性存储区块头的间隔
+}
+
+func newHeaderFilter(sparse bool, interval uint64) headerFilter {
+	if interval == 0 {
+		interval = defaultSparseHeaderInterval
+	}
+	return headerFilter{sparse: sparse, interval: interval}
+}
+
+// 返回包含日志的区块哈希集合
+func blocksWithLogs(logs []types.Log) map[common.Hash]struct{} {
+	blocks := make(map[common.Hash]struct{}, len(logs))
+	for i := range logs {
+		blocks[logs[i].BlockHash] = struct{}{}
+	}
+	return blocks
+}
+
+// 判断区块头是否需要存储，last 表示该区块是本批的最后一个区块
+func (f headerFilter) keep(header *types.Header, withLogs map[common.Hash]struct{}, last bool) bool {
+	if !f.sparse || last {
+		return true
+	}
+	if _, ok := withLogs[header.Hash()]; ok {
+		return true
+	}
+	return new(big.Int).Mod(header.Number, new(big.Int).SetUint64(f.interval)).Sign() == 0
+}
@@ -0,0 +1,126 @@
+package synchronizer
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/WJX2001/contract-caller/database/utils"
+)
+
+/*
+	同步器运行状态：
+		- 同步循环每轮结束后更新，Status 返回一份快照，可在其他 goroutine 中调用
+		- 吞吐量按最近一批区块头数量除以距上一批写库成功的时间计算
+*/
+
+type Status struct {
+	IndexedHeight       *big.Int      // 已写库的最新区块高度，尚未写入任何区块时为 nil
+	ChainHead           *big.Int      // 跟随的链头高度，尚未查询到链头时为 nil
+	SafeHeight          *big.Int      // 已标记为 safe 的最高区块，尚未标记时为 nil
+	FinalizedHeight     *big.Int      // 已标记为 finalized 的最高区块，尚未标记时为 nil
+	Lag                 uint64        // 链头与已索引高度之差
+	HeadersPerSecond    float64       // 最近一批的写库吞吐量
+	LastBatchHeaders    int           // 最近一批的区块头数量
+	LastBatchLogs       int           // 最近一批的日志数量
+	LastPersistLatency  time.Duration // 最近一批的写库耗时
+	LastBatchAt         time.Time     // 最近一批写库成功的时间
+	ConsecutiveFailures uint64        // 连续失败的轮次
+	LastError           string        // 最近一次失败的原因，成功后清空
+}
+
+// 返回同步器当前状态的快照
+func (syncer *Synchronizer) Status() Status {
+	syncer.statusLock.Lock()
+	defer syncer.statusLock.Unlock()
+
+	status := syncer.status
+	if status.IndexedHeight != nil {
+		status.IndexedHeight = new(big.Int).Set(status.IndexedHeight)
+	}
+	if status.ChainHead != nil {
+		status.ChainHead = new(big.Int).Set(status.ChainHead)
+	}
+	if status.SafeHeight != nil {
+		status.SafeHeight = new(big.Int).Set(status.SafeHeight)
+	}
+	if status.FinalizedHeight != nil {
+		status.FinalizedHeight = new(big.Int).Set(status.FinalizedHeight)
+	}
+	return status
+}
+
+// 记录跟随的链头高度
+func (syncer *Synchronizer) recordChainHead(head *big.Int) {
+	syncer.metrics.RecordChainHead(head.Uint64())
+
+	syncer.statusLock.Lock()
+	defer syncer.statusLock.Unlock()
+	syncer.status.ChainHead = new(big.Int).Set(head)
+	syncer.updateLag()
+}
+
+// 记录已写库的最新区块高度，重组回滚后高度会降低
+func (syncer *Synchronizer) recordIndexedHeight(height *big.Int) {
+	syncer.metrics.RecordIndexedHeight(height.Uint64())
+
+	syncer.statusLock.Lock()
+	defer syncer.statusLock.Unlock()
+	syncer.status.IndexedHeight = new(big.Int).Set(height)
+	syncer.updateLag()
+}
+
+// 记录节点的 safe 或 finalized 区块高度，已存储的区块已按该高度标记最终性
+func (syncer *Synchronizer) recordFinality(height *big.Int, finality utils.Finality) {
+	syncer.statusLock.Lock()
+	defer syncer.statusLock.Unlock()
+	switch finality {
+	case utils.FinalitySafe:
+		syncer.status.SafeHeight = new(big.Int).Set(height)
+	case utils.FinalityFinalized:
+		syncer.status.FinalizedHeight = new(big.Int).Set(height)
+	}
+}
+
+// 记录一批区块写库成功
+func (syncer *Synchronizer) recordBatch(last *big.Int, headers, logs int, persist time.Duration) {
+	syncer.metrics.RecordBatch(headers, logs)
+	syncer.metrics.RecordBatchPersist(persist)
+	syncer.recordIndexedHeight(last)
+
+	syncer.statusLock.Lock()
+	defer syncer.statusLock.Unlock()
+	now := time.Now()
+	if !syncer.status.LastBatchAt.IsZero() {
+		if elapsed := now.Sub(syncer.status.LastBatchAt); elapsed > 0 {
+			syncer.status.HeadersPerSecond = float64(headers) / elapsed.Seconds()
+		}
+	}
+	syncer.status.LastBatchHeaders = headers
+	syncer.status.LastBatchLogs = logs
+	syncer.status.LastPersistLatency = persist
+	syncer.status.LastBatchAt = now
+}
+
+// 记录一轮同步的结果，err 为 nil 时清零连续失败次数
+func (syncer *Synchronizer) recordRound(err error) {
+	syncer.statusLock.Lock()
+	if err != nil {
+		syncer.status.ConsecutiveFailures++
+		syncer.status.LastError = err.Error()
+	} else {
+		syncer.status.ConsecutiveFailures = 0
+		syncer.status.LastError = ""
+	}
+	failures := syncer.status.ConsecutiveFailures
+	syncer.statusLock.Unlock()
+
+	syncer.metrics.RecordConsecutiveFailures(failures)
+}
+
+// 调用方需持有 statusLock
+func (syncer *Synchronizer) updateLag() {
+	syncer.status.Lag = 0
+	if syncer.status.IndexedHeight != nil && syncer.status.ChainHead != nil && syncer.status.ChainHead.Cmp(syncer.status.IndexedHeight) > 0 {
+		syncer.status.Lag = new(big.Int).Sub(syncer.status.ChainHead, syncer.status.IndexedHeight).Uint64()
+	}
+}
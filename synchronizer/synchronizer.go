@@ -2,8 +2,11 @@ package synchronizer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/WJX2001/contract-caller/common/tasks"
@@ -29,15 +32,29 @@ type Synchronizer struct {
 	db        *database.DB   // 数据库连接
 
 	loopInterval     time.Duration         // 同步循环间隔
+	catchUpThreshold uint64                // 落后链头超过该区块数时不等待 loopInterval，连续同步
 	headerBufferSize uint64                // 批量处理大小
+	pipelineDepth    uint64                // 流水线各阶段之间最多缓存的批次数
 	headerTraversal  *node.HeaderTraversal // 区块头遍历器
 
-	headers      []types.Header // 待处理的区块头缓存
-	latestHeader *types.Header  // 最新区块头
+	persistedHeader *types.Header // 最后写库成功的区块头
+	rollbackTo      *types.Header // 重组后待回滚到的共同祖先，为 nil 表示无需回滚
 
 	startHeight       *big.Int            // 起始高度
 	confirmationDepth *big.Int            // 确认深度
 	chainCfg          *config.ChainConfig // 链配置
+	eventTopics       []common.Hash       // 同步的事件签名（topic0）
+	eventDecoder      *eventDecoder       // 解码事件参数
+	logSourceMode     LogSourceMode       // 拉取日志的方式，历史回填使用相同的方式
+	logSource         LogSource           // 拉取日志
+	watchBackfilling  atomic.Bool         // 是否有新增监听地址的历史回填在运行
+	headerFilter      headerFilter        // 稀疏模式下筛选需要存储的区块头
+	gapCheckInterval  time.Duration       // 区块头完整性检查的间隔，0 表示不检查
+	gapCheckFrom      *big.Int            // 下一次完整性检查的起始高度，之前的区块头已检查通过
+
+	metrics    Metrics    // 指标采集
+	statusLock sync.Mutex // 保护 status
+	status     Status     // 运行状态，通过 Status() 获取快照
 
 	resourceCtx    context.Context    // 资源上下文
 	resourceCancel context.CancelFunc // 取消函数
@@ -45,17 +62,26 @@ type Synchronizer struct {
 }
 
 // 创建区块同步器，从链上拉区块头与事件写库
-func NewSynchronizer(cfg *config.Config, db *database.DB, client node.EthClient, shutdown context.CancelCauseFunc) (*Synchronizer, error) {
+// metrics 为 nil 时不采集指标
+func NewSynchronizer(cfg *config.Config, db *database.DB, client node.EthClient, metrics Metrics, shutdown context.CancelCauseFunc) (*Synchronizer, error) {
 
-	// 从数据库获取最后同步的区块头
+	// 优先从检查点恢复同步进度，其次从数据库获取最后同步的区块头
 	// 如果存在，从该区块继续同步，如果不存在且配置了起始高度，从配置的起始高度开始，否则从头开始同步
+	checkpoints := checkpointStore{db: db}
+	checkpoint, err := checkpoints.LoadCheckpoint()
+	if err != nil {
+		return nil, err
+	}
 	latestHeader, err := db.Blocks.LatestBlockHeader()
 	if err != nil {
 		return nil, err
 	}
 
 	var fromHeader *types.Header
-	if latestHeader != nil {
+	if checkpoint != nil {
+		log.Info("sync detected checkpoint", "number", checkpoint.Number, "hash", checkpoint.Hash())
+		fromHeader = checkpoint
+	} else if latestHeader != nil {
 		// 指定高度同步
 		// 当数据库为空的时候，从配置的起始高度开始，适用于首次部署或数据重置场景
 		log.Info("sync detected last indexed block", "number", latestHeader.Number, "hash", latestHeader.Hash)
@@ -72,107 +98,177 @@ func NewSynchronizer(cfg *config.Config, db *database.DB, client node.EthClient,
 		log.Info("no eth wallet indexed state")
 	}
 
-	headerTraversal := node.NewHeaderTraversal(client, fromHeader, big.NewInt(0), cfg.Chain.ChainId)
+	aheadPolicy, err := node.ParseAheadOfProviderPolicy(cfg.Chain.AheadOfProviderPolicy)
+	if err != nil {
+		return nil, err
+	}
+	followMode, err := node.ParseFollowMode(cfg.Chain.SyncFollow)
+	if err != nil {
+		return nil, err
+	}
+
+	// 跟随 latest 时同步器只处理落后链头 SyncConfirmations 个区块的数据，重组深度超过 Confirmations 视为异常
+	confirmationDepth := new(big.Int).SetUint64(cfg.Chain.SyncConfirmations)
+	headerTraversal := node.NewHeaderTraversal(client, fromHeader, confirmationDepth, cfg.Chain.ChainId)
+	headerTraversal.SetMaxReorgDepth(cfg.Chain.Confirmations)
+	headerTraversal.SetCheckpointStore(checkpoints)
+	headerTraversal.SetAheadOfProviderPolicy(aheadPolicy)
+	headerTraversal.SetFollowMode(followMode)
+
+	logSourceMode, err := ParseLogSourceMode(cfg.Chain.LogSource)
+	if err != nil {
+		return nil, err
+	}
+
+	eventTopics, err := syncEventTopics()
+	if err != nil {
+		return nil, err
+	}
+	eventDecoder, err := newEventDecoder()
+	if err != nil {
+		return nil, err
+	}
+
+	if metrics == nil {
+		metrics = NoopMetrics
+	}
+
+	pipelineDepth := cfg.Chain.SyncPipelineDepth
+	if pipelineDepth == 0 {
+		pipelineDepth = defaultPipelineDepth
+	}
 
 	resCtx, resCancel := context.WithCancel(context.Background())
-	return &Synchronizer{
-		loopInterval:     time.Duration(cfg.Chain.MainLoopInterval) * time.Second,
-		headerBufferSize: uint64(cfg.Chain.BlockStep),
-		headerTraversal:  headerTraversal,
-		ethClient:        client,
-		latestHeader:     fromHeader,
-		db:               db,
-		chainCfg:         &cfg.Chain,
-		resourceCtx:      resCtx,
-		resourceCancel:   resCancel,
+	syncer := &Synchronizer{
+		loopInterval:      cfg.Chain.MainLoopInterval,
+		catchUpThreshold:  cfg.Chain.SyncCatchUpThreshold,
+		headerBufferSize:  uint64(cfg.Chain.BlockStep),
+		pipelineDepth:     pipelineDepth,
+		headerTraversal:   headerTraversal,
+		ethClient:         client,
+		persistedHeader:   fromHeader,
+		confirmationDepth: confirmationDepth,
+		db:                db,
+		chainCfg:          &cfg.Chain,
+		eventTopics:       eventTopics,
+		eventDecoder:      eventDecoder,
+		logSourceMode:     logSourceMode,
+		logSource:         NewLogSource(client, logSourceMode),
+		headerFilter:      newHeaderFilter(cfg.Chain.SparseHeaders, cfg.Chain.SparseHeaderInterval),
+		gapCheckInterval:  cfg.Chain.GapCheckInterval,
+		metrics:           metrics,
+		resourceCtx:       resCtx,
+		resourceCancel:    resCancel,
 		tasks: tasks.Group{HandleCrit: func(err error) {
 			shutdown(fmt.Errorf("critical error in Synchronizer: %w", err))
 		}},
-	}, nil
+	}
+	headerTraversal.OnReorg(syncer.handleReorg)
+	if fromHeader != nil {
+		syncer.recordIndexedHeight(fromHeader.Number)
+	}
+	return syncer, nil
 }
 
 // 启动逻辑
+// 每轮结束后按同步进度决定下一轮的等待时间：落后链头超过 catchUpThreshold 个区块时立即开始下一轮，否则等待 loopInterval
+// Close 取消 resourceCtx 后循环退出，正在写库的批次放弃重试，事务保证不会留下部分数据
+// 配置了 gapCheckInterval 时同时在后台定时检查并修复已存储区块头中的缺失和不连续
+// 后台同时跟踪节点的 safe / finalized 区块，更新已存储区块和事件的最终性
 func (syncer *Synchronizer) Start() error {
-	tickerSyncer := time.NewTicker(time.Second * 3)
+	timer := time.NewTimer(syncer.loopInterval)
 	syncer.tasks.Go(func() error {
-		for range tickerSyncer.C {
-			/*
-				每3秒执行一次
-				1. 获取区块头
-				2. 处理区块数据
-				3. 存储到数据库
-			*/
-			if len(syncer.headers) > 0 {
-				// 判断是否有上一次未处理完的 headers
-				// syncer.headers 是一个缓存区块头数组，如果上一次同步失败、没有清空，他会在下一轮重试（避免丢数据）
-				// 否则就去链上拉新的区块头
-				log.Info("retrying previous batch")
-			} else {
-				newHeaders, err := syncer.headerTraversal.NextHeaders(uint64(syncer.chainCfg.BlockStep))
-				if err != nil {
-					// 如果 RPC 调用出错，就跳过
-					log.Error("error querying for headers", "err", err)
-					continue
-				} else if len(newHeaders) == 0 {
-					// 如果没有新块，说明同步器已经到 链头
-					log.Warn("no new headers. syncer at head?")
-				} else {
-					// 将新 headers 存入 syncer.headers 以便后续处理
-					syncer.headers = newHeaders
-				}
-				// 获取最新的区块头
-				latestHeader := syncer.headerTraversal.LatestHeader()
-				if latestHeader != nil {
-					log.Info("Latest header", "latestHeader Number", latestHeader.Number)
-				}
+		defer timer.Stop()
+		for {
+			select {
+			case <-syncer.resourceCtx.Done():
+				return nil
+			case <-timer.C:
 			}
 
-			err := syncer.processBatch(syncer.headers, syncer.chainCfg)
-			if err == nil {
-				syncer.headers = nil
+			err := syncer.syncRound()
+			if syncer.resourceCtx.Err() != nil {
+				// 停止过程中被中断的批次不计为失败，检查点仍停留在最后一次写库成功的区块
+				if err != nil {
+					log.Info("synchronizer stopping, abandoned in-flight batches", "indexedHeight", syncer.Status().IndexedHeight)
+				}
+				return nil
+			} else if errors.Is(err, node.ErrHeaderTraversalAheadOfProvider) {
+				// 配置为 fail 或无法回退时停止服务，避免一直空转
+				syncer.tasks.HandleCrit(err)
+				return err
 			}
+			timer.Reset(syncer.nextLoopInterval(err))
 		}
-		return nil
 	})
+	syncer.startGapCheck()
+	syncer.startFinalizer()
 	return nil
 }
 
 /*
-批量处理区块数据
-对一批区块头做一次：抽取日志 -> 构建区块头结构 -> 构造合约事件 -> 持久化到数据库
+一轮同步
+ 1. 回滚上一轮未完成的重组
+ 2. 通过流水线拉取区块头和日志并写库，直到追上链头
 */
-func (syncer *Synchronizer) processBatch(headers []types.Header, chainCfg *config.ChainConfig) error {
-	if len(headers) == 0 {
-		return nil
+func (syncer *Synchronizer) syncRound() error {
+	// 重组后先回滚孤块数据，失败时本轮不写入新数据
+	if err := syncer.rollback(); err != nil {
+		log.Error("failed to roll back reorged data", "err", err)
+		syncer.recordRound(err)
+		return err
 	}
 
-	firstHeader, lastHeader := headers[0], headers[len(headers)-1]
-	log.Info("extracting batch", "size", len(headers), "startBlock", firstHeader.Number.String(), "endBlock", lastHeader.Number.String())
+	// 新增的监听地址需要回填历史时在后台进行
+	syncer.backfillWatchAddresses()
 
-	headerMap := make(map[common.Hash]*types.Header, len(headers))
-	for i := range headers {
-		header := headers[i]
-		headerMap[header.Hash()] = &header
+	err := syncer.runPipeline()
+	if errors.Is(err, node.ErrHeaderTraversalAheadOfProvider) {
+		return err
+	}
+	syncer.recordRound(err)
+	return err
+}
+
+// 下一轮开始前的等待时间，本轮出错或已追上链头时等待 loopInterval，否则立即继续追赶
+func (syncer *Synchronizer) nextLoopInterval(err error) time.Duration {
+	if err != nil {
+		return syncer.loopInterval
+	}
+	if lag := syncer.Status().Lag; lag > syncer.catchUpThreshold {
+		log.Debug("syncer behind chain head, catching up", "lag", lag)
+		return 0
 	}
+	return syncer.loopInterval
+}
+
+/*
+拉取一批区块的日志
+获取监听地址 -> 按地址和事件签名过滤日志 -> 校验日志与区块头属于同一条链
+*/
+func (syncer *Synchronizer) fetchBatchLogs(batch *syncBatch) error {
+	firstHeader, lastHeader := batch.headers[0], batch.headers[len(batch.headers)-1]
+	log.Info("extracting batch", "size", len(batch.headers), "startBlock", firstHeader.Number.String(), "endBlock", lastHeader.Number.String())
 
 	// 获取监听地址列表
 	// 动态地址列表：从数据库获取需要监听的合约地址
-	// VRF：这些地址是 VRF 代理合约的地址
+	// VRF：这些地址是 VRF 代理合约的地址，加上运行时添加的监听地址，每批区块重新读取
 	// 过滤优化： 只监听相关合约的事件，减少数据量
-	addressList, err := syncer.db.PoxyCreated.QueryPoxyCreatedAddressList()
+	addressList, err := watchAddressList(syncer.db)
 	if err != nil {
-		log.Error("QueryPoxyCreatedAddressList fail", "err", err)
 		return err
 	}
 
+	// 只拉取 VRF 相关事件，代理合约上的其他事件不需要
 	filterQuery := ethereum.FilterQuery{
 		FromBlock: firstHeader.Number,
 		ToBlock:   lastHeader.Number,
 		Addresses: addressList,
+		Topics:    [][]common.Hash{syncer.eventTopics},
 	}
 
 	// 过滤事件日志
-	logs, err := syncer.ethClient.FilterLogs(filterQuery)
+	logs, err := syncer.logSource.FilterLogs(filterQuery)
 	if err != nil {
 		log.Info("failed to extract logs", "err", err)
 		return err
@@ -188,12 +284,35 @@ func (syncer *Synchronizer) processBatch(headers []types.Header, chainCfg *confi
 	if len(logs.Logs) > 0 {
 		log.Info("detected logs", "size", len(logs.Logs))
 	}
+	batch.logs = logs.Logs
+
+	if syncer.chainCfg.IndexTransactions {
+		return syncer.fetchBatchTransactions(batch, addressList)
+	}
+	return nil
+}
+
+/*
+写入一批区块
+构建区块头结构 -> 构造合约事件 -> 持久化到数据库
+*/
+func (syncer *Synchronizer) persistBatch(ctx context.Context, batch *syncBatch) error {
+	headers := batch.headers
+	lastHeader := headers[len(headers)-1]
+
+	headerMap := make(map[common.Hash]*types.Header, len(headers))
+	for i := range headers {
+		header := headers[i]
+		headerMap[header.Hash()] = &header
+	}
 
 	// 区块头数据转换
 	// 把 types.Header 转换成项目内部 common2.BlockHeader 结构，准备写入 DB
-	blockHeaders := make([]common2.BlockHeader, len(headers))
+	// 稀疏模式下只保留包含日志的区块头和周期性的区块头
+	withLogs := blocksWithLogs(batch.logs)
+	blockHeaders := make([]common2.BlockHeader, 0, len(headers))
 	for i := range headers {
-		if headers[i].Number == nil {
+		if headers[i].Number == nil || !syncer.headerFilter.keep(&headers[i], withLogs, i == len(headers)-1) {
 			continue
 		}
 		bHeader := common2.BlockHeader{
@@ -207,14 +326,17 @@ func (syncer *Synchronizer) processBatch(headers []types.Header, chainCfg *confi
 	}
 
 	// 把 RPC 返回的 每个 Log 变成 event.ContractEvent 并把区块时间戳从 headerMap 中取出赋值给事件
-	chainContractEvent := make([]event.ContractEvent, len(logs.Logs))
-	for i := range logs.Logs {
-		logEvent := logs.Logs[i]
+	// 不属于本批区块的日志跳过
+	chainContractEvent := make([]event.ContractEvent, 0, len(batch.logs))
+	for i := range batch.logs {
+		logEvent := batch.logs[i]
 		if _, ok := headerMap[logEvent.BlockHash]; !ok {
 			continue
 		}
 		timestamp := headerMap[logEvent.BlockHash].Time
-		chainContractEvent[i] = event.ContractEventFromLog(&logs.Logs[i], timestamp)
+		contractEvent := event.ContractEventFromLog(&batch.logs[i], timestamp)
+		contractEvent.DecodedArgs = syncer.eventDecoder.decode(&batch.logs[i])
+		chainContractEvent = append(chainContractEvent, contractEvent)
 	}
 
 	// 使用指数退避重试策略尝试做一次事务性的持久化
@@ -222,29 +344,54 @@ func (syncer *Synchronizer) processBatch(headers []types.Header, chainCfg *confi
 	/*
 		最小等待 1s，最大等待20s 抖动 250ms
 	*/
+	persistStart := time.Now()
 	retryStrategy := &retry.ExponentialStrategy{Min: 1000, Max: 20_000, MaxJitter: 250}
-	if _, err := retry.Do[interface{}](syncer.resourceCtx, 10, retryStrategy, func() (interface{}, error) {
+	if _, err := retry.Do[interface{}](ctx, 10, retryStrategy, func() (interface{}, error) {
 		// 每次重试内调用 Transaction 执行 DB操作 成功则提交 失败则返回 error
 		if err := syncer.db.Transaction(func(tx *database.DB) error {
-			if err := tx.Blocks.StoreBlockHeaders(blockHeaders); err != nil {
-				return err
+			// 重试或重新同步时已写入的区块头和事件会被跳过
+			if len(blockHeaders) > 0 {
+				if err := tx.Blocks.StoreBlockHeaders(blockHeaders); err != nil {
+					return err
+				}
+			}
+
+			if len(chainContractEvent) > 0 {
+				if err := tx.ContractEvent.StoreContractEvents(chainContractEvent); err != nil {
+					return err
+				}
 			}
 
-			if err := tx.ContractEvent.StoreContractEvents(chainContractEvent); err != nil {
+			if len(batch.transactions) > 0 {
+				if err := tx.ContractTx.StoreContractTransactions(batch.transactions); err != nil {
+					return err
+				}
+			}
+
+			// 检查点与本批数据在同一事务中写入，重启后从下一个区块继续
+			if err := (checkpointStore{db: tx}).SaveCheckpoint(&lastHeader); err != nil {
 				return err
 			}
 			return nil
 		}); err != nil {
-			log.Info("unable to persist batch", err)
+			log.Info("unable to persist batch", "err", err)
 			return nil, fmt.Errorf("unable to persist batch: %w", err)
 		}
 		return nil, nil
 	}); err != nil {
 		return err
 	}
+	syncer.persistedHeader = &lastHeader
+	syncer.recordBatch(lastHeader.Number, len(headers), len(batch.logs), time.Since(persistStart))
 	return nil
 }
 
+// 停止同步循环和后台回填，等待正在运行的任务退出
 func (syncer *Synchronizer) Close() error {
+	syncer.resourceCancel()
+	if err := syncer.tasks.Wait(); err != nil {
+		return fmt.Errorf("synchronizer stopped with error: %w", err)
+	}
+	log.Info("synchronizer stopped", "indexedHeight", syncer.Status().IndexedHeight)
 	return nil
 }
@@ -0,0 +1,44 @@
+package synchronizer
+
+import (
+	"fmt"
+
+	"github.com/WJX2001/contract-caller/bindings"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+/*
+	同步时只拉取 VRF 相关事件的日志：
+		- 从合约绑定的 ABI 中取 RequestSent、FillRandomWords、ProxyCreated 的事件签名作为 topic0
+		- 代理合约上的其他事件不会被节点返回，也不会写入 contract_events
+	事件处理器新增需要解析的事件时，需要同步加到 syncEvents 中
+*/
+
+// 需要同步的合约事件，按合约 ABI 分组
+var syncEvents = []struct {
+	metaData *bind.MetaData
+	events   []string
+}{
+	{bindings.DappLinkVRFMetaData, []string{"RequestSent", "FillRandomWords"}},
+	{bindings.DappLinkVRFFactoryMetaData, []string{"ProxyCreated"}},
+}
+
+// 返回同步日志时使用的 topic0 列表
+func syncEventTopics() ([]common.Hash, error) {
+	var topics []common.Hash
+	for _, contract := range syncEvents {
+		contractAbi, err := contract.metaData.GetAbi()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range contract.events {
+			abiEvent, ok := contractAbi.Events[name]
+			if !ok {
+				return nil, fmt.Errorf("event %s not found in contract abi", name)
+			}
+			topics = append(topics, abiEvent.ID)
+		}
+	}
+	return topics, nil
+}
@@ -0,0 +1,84 @@
+package synchronizer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/database/event"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	交易索引：
+		- 开启 IndexTransactions 后，拉取日志的阶段同时获取每个区块的完整交易，筛选 to 地址为监听合约的交易
+		- 没有交易的区块（TxHash 为空交易根）不请求节点
+		- 开启 IndexReceipts 时批量获取这些交易的回执，记录执行状态和 gas 用量
+		- 交易与区块头、事件在同一事务中写入，重组回滚时按高度删除；历史回填不索引交易
+*/
+
+// 获取一批区块中发往监听合约的交易
+func (syncer *Synchronizer) fetchBatchTransactions(batch *syncBatch, addressList []common.Address) error {
+	if len(addressList) == 0 {
+		return nil
+	}
+	watched := make(map[common.Address]struct{}, len(addressList))
+	for _, address := range addressList {
+		watched[address] = struct{}{}
+	}
+	signer := types.LatestSignerForChainID(new(big.Int).SetUint64(uint64(syncer.chainCfg.ChainId)))
+
+	var contractTxs []event.ContractTransaction
+	for i := range batch.headers {
+		header := &batch.headers[i]
+		if header.TxHash == types.EmptyTxsHash {
+			continue
+		}
+		block, err := syncer.ethClient.BlockByNumber(header.Number)
+		if err != nil {
+			return fmt.Errorf("unable to query block %s: %w", header.Number, err)
+		}
+		if block.Hash() != header.Hash() {
+			return fmt.Errorf("mismatch in block %s hash", header.Number)
+		}
+
+		for index, tx := range block.Transactions() {
+			if tx.To() == nil {
+				continue
+			}
+			if _, ok := watched[*tx.To()]; !ok {
+				continue
+			}
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				return fmt.Errorf("unable to recover sender of tx %s: %w", tx.Hash(), err)
+			}
+			contractTxs = append(contractTxs, event.ContractTransactionFromTx(tx, index, from, header, nil))
+		}
+	}
+
+	if syncer.chainCfg.IndexReceipts && len(contractTxs) > 0 {
+		hashes := make([]common.Hash, len(contractTxs))
+		for i := range contractTxs {
+			hashes[i] = contractTxs[i].TransactionHash
+		}
+		receipts, err := syncer.ethClient.TxReceiptsByHashes(hashes)
+		if err != nil {
+			return fmt.Errorf("unable to query receipts: %w", err)
+		}
+		for i, receipt := range receipts {
+			if receipt.BlockHash != contractTxs[i].BlockHash {
+				return fmt.Errorf("mismatch in receipt %s block hash", hashes[i])
+			}
+			contractTxs[i].Status = &receipt.Status
+			contractTxs[i].GasUsed = &receipt.GasUsed
+		}
+	}
+
+	if len(contractTxs) > 0 {
+		log.Info("detected contract transactions", "size", len(contractTxs))
+	}
+	batch.transactions = contractTxs
+	return nil
+}
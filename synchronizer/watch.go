@@ -0,0 +1,112 @@
+package synchronizer
+
+import (
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/synchronizer/node"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	运行时维护的监听地址：
+		- 监听地址 = proxy_created 中的代理合约地址 + watch_addresses 中启用的地址 - watch_addresses 中停用的地址
+		- 同步器每批区块都重新读取，通过 watch 命令或 DB.WatchAddresses 修改后下一批生效，无需重启
+		- 添加地址时指定了回填起始高度的，同步器在后台回填该地址从起始高度到当前已索引高度的历史日志，同一时间只运行一个回填
+*/
+
+// 返回当前需要监听的合约地址
+func watchAddressList(db *database.DB) ([]common.Address, error) {
+	proxyAddresses, err := db.PoxyCreated.QueryPoxyCreatedAddressList()
+	if err != nil {
+		log.Error("QueryPoxyCreatedAddressList fail", "err", err)
+		return nil, err
+	}
+	watchAddresses, err := db.WatchAddresses.WatchAddresses()
+	if err != nil {
+		log.Error("query watch addresses fail", "err", err)
+		return nil, err
+	}
+
+	enabled := make(map[common.Address]bool, len(watchAddresses))
+	for _, watchAddress := range watchAddresses {
+		enabled[watchAddress.Address] = watchAddress.Enabled
+	}
+
+	seen := make(map[common.Address]struct{}, len(proxyAddresses)+len(watchAddresses))
+	var addressList []common.Address
+	add := func(address common.Address) {
+		if isEnabled, ok := enabled[address]; ok && !isEnabled {
+			return
+		}
+		if _, ok := seen[address]; ok {
+			return
+		}
+		seen[address] = struct{}{}
+		addressList = append(addressList, address)
+	}
+	for _, address := range proxyAddresses {
+		add(address)
+	}
+	for _, watchAddress := range watchAddresses {
+		add(watchAddress.Address)
+	}
+	return addressList, nil
+}
+
+// 在后台回填新增监听地址的历史日志，已有回填在运行时跳过
+func (syncer *Synchronizer) backfillWatchAddresses() {
+	running := &syncer.watchBackfilling
+	indexed := syncer.Status().IndexedHeight
+	if indexed == nil || !running.CompareAndSwap(false, true) {
+		return
+	}
+
+	pending, err := syncer.db.WatchAddresses.PendingBackfillWatchAddresses()
+	if err != nil || len(pending) == 0 {
+		if err != nil {
+			log.Error("query pending backfill watch addresses fail", "err", err)
+		}
+		running.Store(false)
+		return
+	}
+
+	syncer.tasks.Go(func() error {
+		defer running.Store(false)
+		for _, watchAddress := range pending {
+			// 回填失败时保留待回填状态，下一轮重试
+			if err := syncer.backfillWatchAddress(watchAddress.Address, watchAddress.BackfillFrom, indexed); err != nil {
+				log.Error("backfill watch address fail", "address", watchAddress.Address, "err", err)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// 回填单个地址在 [from, to] 的历史日志，完成后标记为已回填
+func (syncer *Synchronizer) backfillWatchAddress(address common.Address, from, to *big.Int) error {
+	if from.Cmp(to) <= 0 {
+		log.Info("backfilling watch address", "address", address, "from", from, "to", to)
+		backfiller, err := NewBackfiller(syncer.db, []node.EthClient{syncer.ethClient}, BackfillConfig{
+			From:      from,
+			To:        to,
+			ShardSize: syncer.chainCfg.BackfillShardSize,
+			Workers:   syncer.chainCfg.BackfillWorkers,
+			ChainId:   syncer.chainCfg.ChainId,
+			LogSource: syncer.logSourceMode,
+			Addresses: []common.Address{address},
+
+			SparseHeaders:        syncer.chainCfg.SparseHeaders,
+			SparseHeaderInterval: syncer.chainCfg.SparseHeaderInterval,
+		})
+		if err != nil {
+			return err
+		}
+		if err := backfiller.Run(syncer.resourceCtx); err != nil {
+			return err
+		}
+	}
+	return syncer.db.WatchAddresses.MarkWatchAddressBackfilled(address)
+}
@@ -0,0 +1,56 @@
+package txmgr
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	EIP-2930 access list：
+		- updateGasPrice 生成的交易自带 access list 时，定价、提价、重估 gas、替换都会保留它
+		- legacy 模式下带 access list 的交易以 AccessListTx 发送
+		- WithAccessList 用于给不支持 access list 的 updateGasPrice 附加一个固定的 access list
+*/
+
+// 为 updateGasPrice 生成的交易附加 access list 并重新签名，accessList 为空时原样返回
+func WithAccessList(updateGasPrice UpdateGasPriceFunc, accessList types.AccessList, signer SignerFn) UpdateGasPriceFunc {
+	if len(accessList) == 0 {
+		return updateGasPrice
+	}
+
+	return func(ctx context.Context) (*types.Transaction, error) {
+		tx, err := updateGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var inner types.TxData
+		switch tx.Type() {
+		case types.LegacyTxType, types.AccessListTxType:
+			inner = &types.AccessListTx{
+				ChainID:    tx.ChainId(),
+				Nonce:      tx.Nonce(),
+				GasPrice:   tx.GasPrice(),
+				Gas:        tx.Gas(),
+				To:         tx.To(),
+				Value:      tx.Value(),
+				Data:       tx.Data(),
+				AccessList: accessList,
+			}
+		default:
+			inner = &types.DynamicFeeTx{
+				ChainID:    tx.ChainId(),
+				Nonce:      tx.Nonce(),
+				GasTipCap:  tx.GasTipCap(),
+				GasFeeCap:  tx.GasFeeCap(),
+				Gas:        tx.Gas(),
+				To:         tx.To(),
+				Value:      tx.Value(),
+				Data:       tx.Data(),
+				AccessList: accessList,
+			}
+		}
+		return signer(ctx, types.NewTx(inner))
+	}
+}
@@ -0,0 +1,66 @@
+package txmgr
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+/*
+	进行中的 Send 登记表：
+		- 每个 Send（包括 SendAsync、SendBatch、Cancel、Replace）开始时登记，返回时注销
+		- ActiveSends 返回每个 Send 的关联 ID、开始时间和 SendState 快照，供管理/状态接口展示
+*/
+
+// 一个进行中的 Send
+type ActiveSend struct {
+	CorrelationID string            // 关联 ID，与日志中的 correlationId 一致
+	StartedAt     time.Time         // Send 开始时间
+	State         SendStateSnapshot // 发送状态快照
+}
+
+type activeSend struct {
+	correlationID string
+	startedAt     time.Time
+	state         *SendState
+}
+
+// 登记进行中的 Send，返回注销函数
+func (m *SimpleTxManager) trackSend(ctx context.Context, sendState *SendState) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sends[sendState] = &activeSend{
+		correlationID: CorrelationID(ctx),
+		startedAt:     time.Now(),
+		state:         sendState,
+	}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.sends, sendState)
+	}
+}
+
+// 返回所有进行中的 Send，按开始时间排序
+func (m *SimpleTxManager) ActiveSends() []ActiveSend {
+	m.mu.Lock()
+	sends := make([]*activeSend, 0, len(m.sends))
+	for _, send := range m.sends {
+		sends = append(sends, send)
+	}
+	m.mu.Unlock()
+
+	active := make([]ActiveSend, 0, len(sends))
+	for _, send := range sends {
+		active = append(active, ActiveSend{
+			CorrelationID: send.correlationID,
+			StartedAt:     send.startedAt,
+			State:         send.state.Snapshot(),
+		})
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].StartedAt.Before(active[j].StartedAt)
+	})
+	return active
+}
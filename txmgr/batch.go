@@ -0,0 +1,87 @@
+package txmgr
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	批量发送：
+		- 通过 NonceTracker 为每笔交易预留连续的 nonce，与同一账户的其他发送方不会冲突
+		- 每笔交易独立发布、提价、等待确认
+		- 按顺序收集回执，前面的 nonce 失败时取消后续交易并立即返回，避免后续交易卡在 nonce 空洞之后
+*/
+
+// 批量发送中某一笔交易失败时返回的错误
+type ErrBatchItemFailed struct {
+	Index int    // 失败交易在批次中的下标
+	Nonce uint64 // 失败交易的 nonce
+	Err   error
+}
+
+func (e *ErrBatchItemFailed) Error() string {
+	return fmt.Sprintf("txmgr: batch item %d (nonce %d) failed: %v", e.Index, e.Nonce, e.Err)
+}
+
+func (e *ErrBatchItemFailed) Unwrap() error {
+	return e.Err
+}
+
+// 返回 Config.From 的 nonce 预留，后端不支持读取 pending nonce 时为 nil
+// 在 SimpleTxManager 之外构造交易时通过它分配 nonce，避免与批量发送、Queue 冲突
+func (m *SimpleTxManager) Nonces() *NonceTracker {
+	return m.nonces
+}
+
+// 按顺序返回已确认交易的回执，失败时返回失败之前的回执和 ErrBatchItemFailed
+func (m *SimpleTxManager) SendBatch(ctx context.Context, builds []BuildTxFunc, sendTx SendTransactionFunc) ([]*types.Receipt, error) {
+	if len(builds) == 0 {
+		return nil, nil
+	}
+	if m.nonces == nil {
+		return nil, &ErrBackendUnsupported{Method: "eth_getTransactionCount"}
+	}
+	ctx = ensureCorrelationID(ctx)
+	startNonce, err := m.nonces.ReserveN(ctx, len(builds))
+	if err != nil {
+		return nil, err
+	}
+	m.logger(ctx).Info("ContractsCaller sending batch", "size", len(builds), "startNonce", startNonce)
+
+	ctxb, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	futures := make([]*Future, len(builds))
+	for i, build := range builds {
+		nonce := startNonce + uint64(i)
+		updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+			return build(ctx, nonce)
+		}
+		futures[i] = m.SendAsync(ctxb, updateGasPrice, sendTx)
+	}
+	// 返回前等待所有 Send 结束，结束全部 nonce 的预留；有交易失败时重新以链上 pending nonce 为准
+	failed := false
+	defer func() {
+		for i, future := range futures {
+			<-future.Done()
+			m.nonces.Done(startNonce + uint64(i))
+		}
+		if failed {
+			m.nonces.Reset()
+		}
+	}()
+
+	receipts := make([]*types.Receipt, 0, len(builds))
+	for i, future := range futures {
+		<-future.Done()
+		if err := future.Err(); err != nil {
+			cancel()
+			failed = true
+			return receipts, &ErrBatchItemFailed{Index: i, Nonce: startNonce + uint64(i), Err: err}
+		}
+		receipts = append(receipts, future.Receipt())
+	}
+	return receipts, nil
+}
@@ -0,0 +1,53 @@
+package txmgr
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	多节点广播：
+		- Config.BroadcastTo 中的每个节点与调用方传入的 sendTx 同时广播同一笔交易
+		- 任意一个节点接受即视为发布成功，单个节点丢弃交易不影响上链
+		- 全部失败时返回主节点（sendTx）的错误，供 SendState 分类处理
+*/
+
+// 把 sendTx 包装为同时向所有节点广播的函数，没有配置额外节点时原样返回
+func (m *SimpleTxManager) broadcastFunc(sendTx SendTransactionFunc) SendTransactionFunc {
+	if len(m.cfg.BroadcastTo) == 0 {
+		return sendTx
+	}
+
+	return func(ctx context.Context, tx *types.Transaction) error {
+		var wg sync.WaitGroup
+		errs := make([]error, len(m.cfg.BroadcastTo)+1)
+
+		publish := func(i int, send SendTransactionFunc) {
+			defer wg.Done()
+			errs[i] = send(ctx, tx)
+		}
+		wg.Add(len(errs))
+		go publish(0, sendTx)
+		for i, send := range m.cfg.BroadcastTo {
+			go publish(i+1, send)
+		}
+		wg.Wait()
+
+		accepted := false
+		for i, err := range errs {
+			if err == nil {
+				accepted = true
+				continue
+			}
+			if i > 0 {
+				m.logger(ctx).Warn("ContractsCaller broadcast to extra endpoint failed", "txHash", tx.Hash(), "endpoint", i, "err", err)
+			}
+		}
+		if accepted {
+			return nil
+		}
+		return errs[0]
+	}
+}
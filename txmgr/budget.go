@@ -0,0 +1,144 @@
+package txmgr
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	交易花费预算：
+		- Send 发布每笔交易前以 gas * gasFeeCap + value 作为最大花费向预算申请，超出时不再发布新交易；
+		  没有交易发布过则立即返回 ErrBudgetExceeded，否则继续等待已发布交易的回执，超时后返回 ErrBudgetExceeded
+		- 交易确认后按实际花费（gasUsed * effectiveGasPrice + value）记账
+		- 预算耗尽后所有 Send 都会被拒绝，直到窗口内的旧花费过期，相当于暂停发送
+	WindowBudget 为内存实现，需要持久化时可自行实现 Budget 接口
+*/
+
+type Budget interface {
+	// 检查本次花费是否会超出预算
+	Allow(ctx context.Context, cost *big.Int) error
+	// 记录一笔已确认交易的实际花费
+	Spend(ctx context.Context, cost *big.Int) error
+}
+
+// 花费超出预算时返回的错误
+type ErrBudgetExceeded struct {
+	Window time.Duration // 预算窗口
+	Limit  *big.Int      // 窗口内允许的最大花费
+	Spent  *big.Int      // 窗口内已花费
+	Cost   *big.Int      // 本次交易的最大花费
+}
+
+func (e *ErrBudgetExceeded) Error() string {
+	return fmt.Sprintf("txmgr: cost %v exceeds budget, spent %v of %v in the last %s", e.Cost, e.Spent, e.Limit, e.Window)
+}
+
+type spendRecord struct {
+	at     time.Time
+	amount *big.Int
+}
+
+// 滑动窗口预算，window 内的花费总和不超过 limit
+type WindowBudget struct {
+	window time.Duration
+	limit  *big.Int
+
+	mu     sync.Mutex
+	spends []spendRecord
+}
+
+func NewWindowBudget(window time.Duration, limit *big.Int) *WindowBudget {
+	if window <= 0 {
+		panic("txmgr: budget window must be positive")
+	}
+	return &WindowBudget{window: window, limit: limit}
+}
+
+func (b *WindowBudget) Allow(ctx context.Context, cost *big.Int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	spent := b.spentLocked()
+	if new(big.Int).Add(spent, cost).Cmp(b.limit) > 0 {
+		return &ErrBudgetExceeded{Window: b.window, Limit: b.limit, Spent: spent, Cost: cost}
+	}
+	return nil
+}
+
+func (b *WindowBudget) Spend(ctx context.Context, cost *big.Int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.spends = append(b.spends, spendRecord{at: time.Now(), amount: new(big.Int).Set(cost)})
+	return nil
+}
+
+// 窗口内已花费的总额
+func (b *WindowBudget) Spent() *big.Int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.spentLocked()
+}
+
+// 清理过期记录并返回窗口内的花费总和，调用方需持有锁
+func (b *WindowBudget) spentLocked() *big.Int {
+	cutoff := time.Now().Add(-b.window)
+	i := 0
+	for i < len(b.spends) && b.spends[i].at.Before(cutoff) {
+		i++
+	}
+	b.spends = b.spends[i:]
+
+	spent := new(big.Int)
+	for _, record := range b.spends {
+		spent.Add(spent, record.amount)
+	}
+	return spent
+}
+
+// 组合多个预算（如每小时 + 每天），任意一个超出即拒绝
+type multiBudget []Budget
+
+func Budgets(budgets ...Budget) Budget {
+	return multiBudget(budgets)
+}
+
+func (mb multiBudget) Allow(ctx context.Context, cost *big.Int) error {
+	for _, b := range mb {
+		if err := b.Allow(ctx, cost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mb multiBudget) Spend(ctx context.Context, cost *big.Int) error {
+	for _, b := range mb {
+		if err := b.Spend(ctx, cost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 交易的最大花费：gas * gasFeeCap + value
+func maxTxCost(tx *types.Transaction) *big.Int {
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+	return cost.Add(cost, tx.Value())
+}
+
+// 交易的实际花费：gasUsed * effectiveGasPrice + value，回执中没有 effectiveGasPrice 时按 gasFeeCap 计算
+func receiptTxCost(tx *types.Transaction, receipt *types.Receipt) *big.Int {
+	price := receipt.EffectiveGasPrice
+	if price == nil {
+		price = tx.GasFeeCap()
+	}
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), price)
+	return cost.Add(cost, tx.Value())
+}
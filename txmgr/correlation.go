@@ -0,0 +1,43 @@
+package txmgr
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/google/uuid"
+)
+
+/*
+	关联 ID：
+		- 调用方可以通过 WithCorrelationID 把业务 ID（如 VRF requestId）放进 ctx
+		- 没有设置时 Send 自动生成一个 UUID
+		- 同一个 Send 内的所有日志都带上 correlationId，回调收到的 ctx 中也能取到
+*/
+
+type correlationIDKey struct{}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// ctx 中没有关联 ID 时生成一个 UUID
+func ensureCorrelationID(ctx context.Context) context.Context {
+	if CorrelationID(ctx) != "" {
+		return ctx
+	}
+	return WithCorrelationID(ctx, uuid.NewString())
+}
+
+// 返回 ctx 中的关联 ID，没有时返回空字符串
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// 带上 ctx 中关联 ID 的日志
+func (m *SimpleTxManager) logger(ctx context.Context) log.Logger {
+	if id := CorrelationID(ctx); id != "" {
+		return m.l.New("correlationId", id)
+	}
+	return m.l
+}
@@ -0,0 +1,74 @@
+package txmgr
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	演练模式（Config.DryRun）：
+		- 照常构造交易（包括 legacy 定价、重新估算 gas limit 和费用上限检查），但不广播
+		- 通过 eth_call 模拟执行，回滚时返回 ErrTxReverted；通过 eth_estimateGas 估算实际消耗
+		- 记录交易哈希、费用和调用数据后返回一个合成的回执，供预发布环境验证完整流程
+*/
+
+// 模拟执行 updateGasPrice 构造的交易，返回合成回执
+func (m *SimpleTxManager) dryRun(ctx context.Context, updateGasPrice UpdateGasPriceFunc) (*types.Receipt, error) {
+	l := m.logger(ctx)
+
+	tx, err := updateGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkFeeCeiling(tx); err != nil {
+		return nil, err
+	}
+
+	caller, ok := m.backend.(ethereum.ContractCaller)
+	if !ok {
+		return nil, &ErrBackendUnsupported{Method: "eth_call"}
+	}
+	msg := m.callMsg(tx)
+	if _, err := caller.CallContract(ctx, msg, nil); err != nil {
+		revertErr := &ErrTxReverted{TxHash: tx.Hash()}
+		revertErr.RevertData, revertErr.Reason = RevertReason(err)
+		return nil, revertErr
+	}
+
+	// 无法估算时按 gas limit 记为消耗
+	gasUsed := tx.Gas()
+	if estimator, ok := m.backend.(ethereum.GasEstimator); ok {
+		msg.Gas = 0
+		if estimated, err := estimator.EstimateGas(ctx, msg); err == nil {
+			gasUsed = estimated
+		} else {
+			l.Warn("ContractsCaller dry run unable to estimate gas", "txHash", tx.Hash(), "err", err)
+		}
+	}
+
+	height, err := m.backend.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Info("ContractsCaller dry run, transaction not broadcast",
+		"txHash", tx.Hash(), "nonce", tx.Nonce(), "to", tx.To(),
+		"gas", tx.Gas(), "gasUsed", gasUsed,
+		"gasTipCap", tx.GasTipCap(), "gasFeeCap", tx.GasFeeCap(),
+		"data", hexutil.Encode(tx.Data()))
+
+	// 按下一个区块构造回执
+	return &types.Receipt{
+		Type:              tx.Type(),
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: gasUsed,
+		TxHash:            tx.Hash(),
+		GasUsed:           gasUsed,
+		EffectiveGasPrice: tx.GasFeeCap(),
+		BlockNumber:       new(big.Int).SetUint64(height + 1),
+	}, nil
+}
@@ -0,0 +1,90 @@
+package txmgr
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	费用下限：
+		- 安静的测试网上节点可能返回极低的建议价格，交易长时间不被打包
+		- Config.MinGasTipCap / MinGasFeeCap 设置后，把 UpdateGasPriceFunc 生成的交易费用抬到下限并重新签名
+		- legacy / access list 交易的 gasPrice 同时充当小费和费用上限，需不低于两个下限
+*/
+
+// 包装 UpdateGasPriceFunc，把交易费用抬到配置的下限
+func (m *SimpleTxManager) feeFloorFunc(updateGasPrice UpdateGasPriceFunc) UpdateGasPriceFunc {
+	return func(ctx context.Context) (*types.Transaction, error) {
+		tx, err := updateGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		gasTipCap, gasFeeCap := m.feeFloor(tx)
+		if gasTipCap.Cmp(tx.GasTipCap()) == 0 && gasFeeCap.Cmp(tx.GasFeeCap()) == 0 {
+			return tx, nil
+		}
+		m.logger(ctx).Debug("ContractsCaller raised fees to configured floor", "nonce", tx.Nonce(),
+			"gasTipCap", tx.GasTipCap(), "newGasTipCap", gasTipCap,
+			"gasFeeCap", tx.GasFeeCap(), "newGasFeeCap", gasFeeCap)
+		return m.cfg.Signer(ctx, withFees(tx, gasTipCap, gasFeeCap))
+	}
+}
+
+// 计算抬到下限后的 gasTipCap 和 gasFeeCap
+func (m *SimpleTxManager) feeFloor(tx *types.Transaction) (*big.Int, *big.Int) {
+	gasTipCap, gasFeeCap := tx.GasTipCap(), tx.GasFeeCap()
+	if m.cfg.MinGasTipCap != nil {
+		gasTipCap = maxBig(gasTipCap, m.cfg.MinGasTipCap)
+	}
+	if m.cfg.MinGasFeeCap != nil {
+		gasFeeCap = maxBig(gasFeeCap, m.cfg.MinGasFeeCap)
+	}
+	// gasFeeCap 不能低于 gasTipCap
+	gasFeeCap = maxBig(gasFeeCap, gasTipCap)
+
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		return gasFeeCap, gasFeeCap
+	}
+	return gasTipCap, gasFeeCap
+}
+
+// 复制交易并替换费用，返回未签名的交易，legacy / access list 交易使用 gasFeeCap 作为 gasPrice
+func withFees(tx *types.Transaction, gasTipCap, gasFeeCap *big.Int) *types.Transaction {
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: gasFeeCap,
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		})
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   gasFeeCap,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	default:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  gasTipCap,
+			GasFeeCap:  gasFeeCap,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	}
+}
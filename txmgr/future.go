@@ -0,0 +1,59 @@
+package txmgr
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SendAsync 和 Queue.Send 返回的异步结果
+type Future struct {
+	done    chan struct{}
+	receipt *types.Receipt
+	err     error
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+func (f *Future) resolve(receipt *types.Receipt, err error) {
+	f.receipt = receipt
+	f.err = err
+	close(f.done)
+}
+
+// 交易完成（确认或失败）后关闭
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// 交易回执，Done 之前返回 nil
+func (f *Future) Receipt() *types.Receipt {
+	select {
+	case <-f.done:
+		return f.receipt
+	default:
+		return nil
+	}
+}
+
+// 发送失败的原因，Done 之前返回 nil
+func (f *Future) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// 阻塞等待结果
+func (f *Future) Wait(ctx context.Context) (*types.Receipt, error) {
+	select {
+	case <-f.done:
+		return f.receipt, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,96 @@
+package txmgr
+
+import (
+	"context"
+	"math"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	每次重发时重新估算 gas limit：
+		- 两次发布之间链上状态可能变化，沿用最初的估算容易导致 out of gas
+		- Config.ReestimateGas 开启后，每次 updateGasPrice 生成交易后调用 eth_estimateGas
+		- 估算结果乘以 Config.GasLimitMultiplier 作为新的 gas limit，并重新签名
+*/
+
+// 包装调用方的 UpdateGasPriceFunc，用重新估算的 gas limit 替换交易原有的 gas limit
+func (m *SimpleTxManager) reestimateGasFunc(updateGasPrice UpdateGasPriceFunc) UpdateGasPriceFunc {
+	return func(ctx context.Context) (*types.Transaction, error) {
+		tx, err := updateGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		estimator, ok := m.backend.(ethereum.GasEstimator)
+		if !ok {
+			return nil, &ErrBackendUnsupported{Method: "eth_estimateGas"}
+		}
+		msg := ethereum.CallMsg{
+			From:       m.cfg.From,
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+		estimated, err := estimator.EstimateGas(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+
+		gas := scaleGas(estimated, m.cfg.GasLimitMultiplier)
+		if gas == tx.Gas() {
+			return tx, nil
+		}
+		m.logger(ctx).Debug("ContractsCaller re-estimated gas limit", "nonce", tx.Nonce(), "oldGas", tx.Gas(), "newGas", gas)
+		return m.cfg.Signer(ctx, withGas(tx, gas))
+	}
+}
+
+// 按倍数放大 gas 估算值，倍数小于等于 1 时原样返回
+func scaleGas(gas uint64, multiplier float64) uint64 {
+	if multiplier <= 1 {
+		return gas
+	}
+	// 向上取整，放大后的 gas limit 不应小于精确值
+	return uint64(math.Ceil(float64(gas) * multiplier))
+}
+
+// 复制交易并替换 gas limit，返回未签名的交易
+func withGas(tx *types.Transaction, gas uint64) *types.Transaction {
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: tx.GasPrice(),
+			Gas:      gas,
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		})
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   tx.GasPrice(),
+			Gas:        gas,
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	default:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  tx.GasTipCap(),
+			GasFeeCap:  tx.GasFeeCap(),
+			Gas:        gas,
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	}
+}
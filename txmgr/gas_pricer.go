@@ -0,0 +1,127 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	基于 eth_feeHistory 的 gas 定价：
+		- 取最近 BlockCount 个区块在 TipPercentile 百分位上的小费，再取中位数作为 gasTipCap
+		- 以下一个区块的 baseFee 乘以 BaseFeeMultiplier 再加上小费作为 gasFeeCap
+		- UpdateGasPriceFunc 可直接作为 Send 的 updateGasPrice，重发时保证价格不低于上一次提价后的值
+*/
+
+var errEmptyFeeHistory = errors.New("txmgr: empty fee history")
+
+type GasPricerConfig struct {
+	BlockCount        uint64  // 参与统计的最近区块数
+	TipPercentile     float64 // 每个区块小费的百分位，取值 0~100
+	BaseFeeMultiplier float64 // baseFee 的放大倍数，用于覆盖后续区块 baseFee 的上涨
+}
+
+var DefaultGasPricerConfig = GasPricerConfig{
+	BlockCount:        10,
+	TipPercentile:     50,
+	BaseFeeMultiplier: 2,
+}
+
+type GasPricer struct {
+	cfg     GasPricerConfig
+	backend ethereum.FeeHistoryReader
+}
+
+func NewGasPricer(cfg GasPricerConfig, backend ethereum.FeeHistoryReader) *GasPricer {
+	if cfg.BlockCount == 0 {
+		panic("txmgr: GasPricer BlockCount must be positive")
+	}
+	if cfg.TipPercentile < 0 || cfg.TipPercentile > 100 {
+		panic("txmgr: GasPricer TipPercentile must be within [0, 100]")
+	}
+	if cfg.BaseFeeMultiplier < 1 {
+		panic("txmgr: GasPricer BaseFeeMultiplier must be at least 1")
+	}
+	return &GasPricer{cfg: cfg, backend: backend}
+}
+
+// 根据最近的 fee history 给出建议的 gasTipCap 和 gasFeeCap
+func (p *GasPricer) SuggestFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	history, err := p.backend.FeeHistory(ctx, p.cfg.BlockCount, nil, []float64{p.cfg.TipPercentile})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, nil, errEmptyFeeHistory
+	}
+
+	tips := make([]*big.Int, 0, len(history.Reward))
+	for _, reward := range history.Reward {
+		if len(reward) > 0 && reward[0] != nil {
+			tips = append(tips, reward[0])
+		}
+	}
+	gasTipCap := new(big.Int)
+	if len(tips) > 0 {
+		sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+		gasTipCap.Set(tips[len(tips)/2])
+	}
+
+	// BaseFee 的最后一项为下一个区块的 baseFee
+	nextBaseFee := history.BaseFee[len(history.BaseFee)-1]
+	scaledBaseFee, _ := new(big.Float).Mul(
+		new(big.Float).SetInt(nextBaseFee),
+		big.NewFloat(p.cfg.BaseFeeMultiplier),
+	).Int(nil)
+	gasFeeCap := new(big.Int).Add(scaledBaseFee, gasTipCap)
+
+	return gasTipCap, gasFeeCap, nil
+}
+
+// 以 candidate 的 nonce、gas、to、value、data 构造 EIP-1559 交易，按 fee history 定价后签名
+// 每次调用的价格不低于上一次价格提价后的值，避免替换交易因价格过低被节点拒绝
+func (p *GasPricer) UpdateGasPriceFunc(candidate *types.Transaction, signer SignerFn) UpdateGasPriceFunc {
+	var mu sync.Mutex
+	var lastTipCap, lastFeeCap *big.Int
+
+	return func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap, err := p.SuggestFees(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		if lastTipCap != nil {
+			gasTipCap = maxBig(gasTipCap, BumpFee(lastTipCap))
+			gasFeeCap = maxBig(gasFeeCap, BumpFee(lastFeeCap))
+		}
+		gasFeeCap = maxBig(gasFeeCap, gasTipCap)
+		lastTipCap, lastFeeCap = gasTipCap, gasFeeCap
+		mu.Unlock()
+
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:    candidate.ChainId(),
+			Nonce:      candidate.Nonce(),
+			GasTipCap:  gasTipCap,
+			GasFeeCap:  gasFeeCap,
+			Gas:        candidate.Gas(),
+			To:         candidate.To(),
+			Value:      candidate.Value(),
+			Data:       candidate.Data(),
+			AccessList: candidate.AccessList(),
+		})
+		return signer(ctx, tx)
+	}
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
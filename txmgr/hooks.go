@@ -0,0 +1,47 @@
+package txmgr
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	交易生命周期回调，在 Config 中按需注册：
+		- OnPublished：交易成功广播
+		- OnBumped：同一个 Send 中提价后的新交易替换了旧交易
+		- OnMined：交易首次被观察到上链
+		- OnConfirmed：交易满足确认数，Send 即将返回
+	回调在 txmgr 的 goroutine 中同步执行，耗时操作应自行异步处理
+	ctx 为调用 Send 时传入的上下文，可通过它携带业务元数据
+*/
+
+type (
+	TxHookFn      func(ctx context.Context, tx *types.Transaction)
+	TxBumpHookFn  func(ctx context.Context, oldTx, newTx *types.Transaction)
+	ReceiptHookFn func(ctx context.Context, tx *types.Transaction, receipt *types.Receipt)
+)
+
+func (m *SimpleTxManager) onPublished(ctx context.Context, tx *types.Transaction) {
+	if m.cfg.OnPublished != nil {
+		m.cfg.OnPublished(ctx, tx)
+	}
+}
+
+func (m *SimpleTxManager) onBumped(ctx context.Context, oldTx, newTx *types.Transaction) {
+	if m.cfg.OnBumped != nil {
+		m.cfg.OnBumped(ctx, oldTx, newTx)
+	}
+}
+
+func (m *SimpleTxManager) onMined(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) {
+	if m.cfg.OnMined != nil {
+		m.cfg.OnMined(ctx, tx, receipt)
+	}
+}
+
+func (m *SimpleTxManager) onConfirmed(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) {
+	if m.cfg.OnConfirmed != nil {
+		m.cfg.OnConfirmed(ctx, tx, receipt)
+	}
+}
@@ -0,0 +1,90 @@
+package txmgr
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	legacy 模式：面向不支持 EIP-1559 的链（如部分 BSC 分叉）
+		- 调用方的 UpdateGasPriceFunc 只负责提供 nonce、gas limit、to、data 等交易内容
+		- txmgr 通过 eth_gasPrice 获取建议价格，构造 LegacyTx 并签名
+		- 候选交易带有 access list 时改为构造 EIP-2930 交易（AccessListTx）
+		- 每次重发在上一次价格基础上至少提价 priceBumpPercent，保证替换交易被节点接受
+*/
+
+type TxType uint8
+
+const (
+	DynamicFeeTxType TxType = iota // EIP-1559 交易（默认）
+	LegacyTxType                   // 传统 gasPrice 交易
+)
+
+// 对交易进行签名
+type SignerFn func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error)
+
+// 包装调用方的 UpdateGasPriceFunc，把其生成的交易转换为提价后的 LegacyTx
+func (m *SimpleTxManager) legacyGasPriceFunc(updateGasPrice UpdateGasPriceFunc) UpdateGasPriceFunc {
+	var mu sync.Mutex
+	var lastGasPrice *big.Int
+
+	return func(ctx context.Context) (*types.Transaction, error) {
+		candidate, err := updateGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		gasPricer, ok := m.backend.(ethereum.GasPricer)
+		if !ok {
+			return nil, &ErrBackendUnsupported{Method: "eth_gasPrice"}
+		}
+		gasPrice, err := gasPricer.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// 取节点建议价格与上一次价格提价后的较大值
+		// 首次发送时以该 nonce 上一笔已发布交易的价格为基准，保证替换交易不会因价格过低被拒绝
+		mu.Lock()
+		if lastGasPrice == nil {
+			if prev := m.lastPublished(candidate.Nonce()); prev != nil {
+				lastGasPrice = prev.GasPrice()
+			}
+		}
+		if lastGasPrice != nil {
+			if bumped := BumpFee(lastGasPrice); bumped.Cmp(gasPrice) > 0 {
+				gasPrice = bumped
+			}
+		}
+		lastGasPrice = gasPrice
+		mu.Unlock()
+
+		// 候选交易带有 access list 时构造 EIP-2930 交易，仍按 gasPrice 计费
+		if accessList := candidate.AccessList(); len(accessList) > 0 && candidate.ChainId().Sign() > 0 {
+			return m.cfg.Signer(ctx, types.NewTx(&types.AccessListTx{
+				ChainID:    candidate.ChainId(),
+				Nonce:      candidate.Nonce(),
+				GasPrice:   gasPrice,
+				Gas:        candidate.Gas(),
+				To:         candidate.To(),
+				Value:      candidate.Value(),
+				Data:       candidate.Data(),
+				AccessList: accessList,
+			}))
+		}
+
+		tx := types.NewTx(&types.LegacyTx{
+			Nonce:    candidate.Nonce(),
+			GasPrice: gasPrice,
+			Gas:      candidate.Gas(),
+			To:       candidate.To(),
+			Value:    candidate.Value(),
+			Data:     candidate.Data(),
+		})
+		return m.cfg.Signer(ctx, tx)
+	}
+}
@@ -0,0 +1,137 @@
+package txmgr
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+	txmgr 的指标采集：
+		- 发布次数、每笔交易的提价次数
+		- 从开始发送到上链、到满足确认数的耗时
+		- nonce too low 次数以及 Send 提前终止的原因
+	Config.Metrics 为空时不采集，主程序通过 PrometheusMetrics.Collectors 注册到自己的 registry
+*/
+
+// Send 提前终止的原因
+const (
+	AbortReasonFeeCeiling     = "fee_ceiling"
+	AbortReasonNonceTooLow    = "nonce_too_low"
+	AbortReasonUpdateGasPrice = "update_gas_price"
+	AbortReasonSendTimeout    = "send_timeout"
+	AbortReasonNotInMempool   = "not_in_mempool"
+	AbortReasonBudget         = "budget_exceeded"
+
+	// 广播错误导致的终止使用 SendErrorKind.String() 作为原因，如 insufficient_funds、exceeds_gas_limit
+)
+
+type Metrics interface {
+	RecordPublishAttempt()                 // 每次尝试发布交易
+	RecordBumps(bumps int)                 // 交易确认时累计的提价次数
+	RecordTimeToMined(d time.Duration)     // 从开始发送到交易上链的耗时
+	RecordTimeToConfirmed(d time.Duration) // 从开始发送到满足确认数的耗时
+	RecordNonceTooLow()                    // 发布时遇到 nonce too low
+	RecordAbort(reason string)             // Send 提前终止
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) RecordPublishAttempt()               {}
+func (noopMetrics) RecordBumps(int)                     {}
+func (noopMetrics) RecordTimeToMined(time.Duration)     {}
+func (noopMetrics) RecordTimeToConfirmed(time.Duration) {}
+func (noopMetrics) RecordNonceTooLow()                  {}
+func (noopMetrics) RecordAbort(string)                  {}
+
+var NoopMetrics Metrics = noopMetrics{}
+
+type PrometheusMetrics struct {
+	publishAttempts prometheus.Counter
+	bumps           prometheus.Histogram
+	timeToMined     prometheus.Histogram
+	timeToConfirmed prometheus.Histogram
+	nonceTooLow     prometheus.Counter
+	aborts          *prometheus.CounterVec
+}
+
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	const subsystem = "txmgr"
+	return &PrometheusMetrics{
+		publishAttempts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "publish_attempts_total",
+			Help:      "Number of transaction publication attempts",
+		}),
+		bumps: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "bumps_per_tx",
+			Help:      "Number of fee bumps before a transaction was confirmed",
+			Buckets:   []float64{0, 1, 2, 3, 5, 8, 13, 21},
+		}),
+		timeToMined: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "time_to_mined_seconds",
+			Help:      "Time from the start of Send until the transaction was mined",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+		timeToConfirmed: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "time_to_confirmed_seconds",
+			Help:      "Time from the start of Send until the transaction reached the required confirmations",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+		nonceTooLow: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "nonce_too_low_total",
+			Help:      "Number of nonce too low errors returned while publishing",
+		}),
+		aborts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "aborts_total",
+			Help:      "Number of Send calls aborted early, by reason",
+		}, []string{"reason"}),
+	}
+}
+
+// 返回全部指标，供主程序注册
+func (m *PrometheusMetrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.publishAttempts,
+		m.bumps,
+		m.timeToMined,
+		m.timeToConfirmed,
+		m.nonceTooLow,
+		m.aborts,
+	}
+}
+
+func (m *PrometheusMetrics) RecordPublishAttempt() {
+	m.publishAttempts.Inc()
+}
+
+func (m *PrometheusMetrics) RecordBumps(bumps int) {
+	m.bumps.Observe(float64(bumps))
+}
+
+func (m *PrometheusMetrics) RecordTimeToMined(d time.Duration) {
+	m.timeToMined.Observe(d.Seconds())
+}
+
+func (m *PrometheusMetrics) RecordTimeToConfirmed(d time.Duration) {
+	m.timeToConfirmed.Observe(d.Seconds())
+}
+
+func (m *PrometheusMetrics) RecordNonceTooLow() {
+	m.nonceTooLow.Inc()
+}
+
+func (m *PrometheusMetrics) RecordAbort(reason string) {
+	m.aborts.WithLabelValues(reason).Inc()
+}
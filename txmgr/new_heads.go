@@ -0,0 +1,38 @@
+package txmgr
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	通过 newHeads 订阅等待回执：
+		- Config.SubscribeNewHeads 开启且后端支持订阅（WebSocket / IPC）时，每个新区块到来检查一次回执
+		- 相比固定间隔轮询，确认更及时，出块间隔内也不会产生多余的 RPC 请求
+		- HTTP 节点订阅失败时自动退回轮询
+*/
+
+// 订阅新区块头，ethclient.Client 即满足
+type headSubscriber interface {
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+type newHeadsSubscription struct {
+	ethereum.Subscription
+	heads chan *types.Header
+}
+
+func subscribeNewHeads(ctx context.Context, backend ReceiptSource) (*newHeadsSubscription, error) {
+	subscriber, ok := backend.(headSubscriber)
+	if !ok {
+		return nil, &ErrBackendUnsupported{Method: "eth_subscribe(newHeads)"}
+	}
+	heads := make(chan *types.Header, 16)
+	sub, err := subscriber.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+	return &newHeadsSubscription{Subscription: sub, heads: heads}, nil
+}
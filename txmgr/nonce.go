@@ -0,0 +1,143 @@
+package txmgr
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+/*
+	nonce 预留：
+		- 每次预留时读取链上的 pending nonce，与本地已分配到的位置取较大值，既能跳过其他进程发出的交易，也不会重复分配自己尚未被节点看到的 nonce
+		- SimpleTxManager 的批量发送、Queue 和驱动引擎构造交易共用同一个 NonceTracker，多个 goroutine 同时发送的交易不会拿到相同的 nonce
+		- 预留的 nonce 在交易发送结束后通过 Done 结束；预留后没有广播的 nonce 通过 Release 归还，形成空洞，下一次预留优先复用最小的空洞
+		- 链上 pending nonce 已经超过的空洞说明该 nonce 已被使用，直接丢弃
+		- 后面已有其他预留时归还会形成空洞，已广播的后续交易在空洞被占用前无法上链；ReleaseIfLast 只在没有后续预留时归还，否则由调用方发送其他交易占用该 nonce
+		- 发送失败时 Reset：没有其他在途预留时丢弃本地分配位置和空洞，下一次预留以链上 pending nonce 为准；还有在途预留时保持不变，避免与尚未广播的交易重复
+*/
+
+type NonceTracker struct {
+	backend NonceSource
+	from    common.Address
+
+	mu        sync.Mutex
+	nextNonce *uint64             // 下一个可分配的 nonce，nil 表示以链上 pending nonce 为准
+	reserved  map[uint64]struct{} // 已预留、发送尚未结束的 nonce
+	released  []uint64            // 归还的空洞，升序，均小于 nextNonce
+}
+
+func NewNonceTracker(backend NonceSource, from common.Address) *NonceTracker {
+	return &NonceTracker{backend: backend, from: from, reserved: make(map[uint64]struct{})}
+}
+
+// 预留一个 nonce，优先复用归还的空洞
+func (t *NonceTracker) Reserve(ctx context.Context) (uint64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pending, err := t.sync(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(t.released) > 0 && t.released[0] >= pending {
+		nonce := t.released[0]
+		t.released = t.released[1:]
+		t.reserved[nonce] = struct{}{}
+		return nonce, nil
+	}
+	return t.reserveNext(1), nil
+}
+
+// 预留 n 个连续的 nonce，返回第一个；连续的 nonce 总是从本地分配位置之后分配，不复用空洞
+func (t *NonceTracker) ReserveN(ctx context.Context, n int) (uint64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, err := t.sync(ctx); err != nil {
+		return 0, err
+	}
+	return t.reserveNext(n), nil
+}
+
+// 归还预留后没有广播的 nonce，紧挨分配位置时回退分配位置，否则记为空洞
+func (t *NonceTracker) Release(nonce uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.releaseLocked(nonce)
+}
+
+// Release 的实现，调用方需持有锁
+func (t *NonceTracker) releaseLocked(nonce uint64) {
+	delete(t.reserved, nonce)
+	if t.nextNonce == nil || nonce >= *t.nextNonce {
+		return
+	}
+	if i, found := slices.BinarySearch(t.released, nonce); !found {
+		t.released = slices.Insert(t.released, i, nonce)
+	}
+	// 分配位置之前连续的空洞一起回退
+	for len(t.released) > 0 && t.released[len(t.released)-1] == *t.nextNonce-1 {
+		t.released = t.released[:len(t.released)-1]
+		*t.nextNonce--
+	}
+}
+
+// 没有比 nonce 更大的在途预留时归还 nonce 并返回 true；否则保持预留并返回 false，调用方需要用其他交易占用该 nonce 后调用 Done
+func (t *NonceTracker) ReleaseIfLast(nonce uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for reserved := range t.reserved {
+		if reserved > nonce {
+			return false
+		}
+	}
+	t.releaseLocked(nonce)
+	return true
+}
+
+// 交易发送结束（上链或已广播），结束 nonce 的预留
+func (t *NonceTracker) Done(nonce uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.reserved, nonce)
+}
+
+// 没有在途预留时丢弃本地分配位置和空洞，下一次预留以链上 pending nonce 为准
+func (t *NonceTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.reserved) > 0 {
+		return
+	}
+	t.nextNonce = nil
+	t.released = nil
+}
+
+// 读取链上 pending nonce，推进分配位置并丢弃已被使用的空洞
+func (t *NonceTracker) sync(ctx context.Context) (uint64, error) {
+	pending, err := t.backend.PendingNonceAt(ctx, t.from)
+	if err != nil {
+		return 0, err
+	}
+	if t.nextNonce == nil || pending > *t.nextNonce {
+		t.nextNonce = &pending
+	}
+	i, _ := slices.BinarySearch(t.released, pending)
+	t.released = t.released[i:]
+	return pending, nil
+}
+
+func (t *NonceTracker) reserveNext(n int) uint64 {
+	nonce := *t.nextNonce
+	*t.nextNonce = nonce + uint64(n)
+	for i := uint64(0); i < uint64(n); i++ {
+		t.reserved[nonce+i] = struct{}{}
+	}
+	return nonce
+}
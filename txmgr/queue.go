@@ -0,0 +1,90 @@
+package txmgr
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	交易队列：在 TxManager 之上支持并发发送多笔交易
+		- 按提交顺序分配连续的 nonce，避免多个 goroutine 同时发送时 nonce 冲突；mgr 为同一账户的 SimpleTxManager 时与其共用 NonceTracker
+		- 通过信号量限制同时在途的交易数量，达到上限时 Send 阻塞
+		- 每笔交易返回一个 Future，调用方稍后再获取结果
+		- 某笔交易失败后重新从链上读取 pending nonce，避免后续交易一直卡在空洞之后
+*/
+
+// 根据分配好的 nonce 构造交易，每次重发都会重新调用以获取最新的 gas 价格
+type BuildTxFunc = func(ctx context.Context, nonce uint64) (*types.Transaction, error)
+
+// 读取账户 pending nonce 的来源，ethclient.Client 即满足
+type NonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+type Queue struct {
+	mgr    TxManager
+	nonces *NonceTracker
+
+	pending chan struct{} // 在途交易的信号量
+	wg      sync.WaitGroup
+}
+
+func NewQueue(mgr TxManager, backend NonceSource, from common.Address, maxPending int) *Queue {
+	if maxPending <= 0 {
+		panic("txmgr: maxPending must be positive")
+	}
+	nonces := NewNonceTracker(backend, from)
+	if m, ok := mgr.(*SimpleTxManager); ok && m.nonces != nil && m.cfg.From == from {
+		nonces = m.nonces
+	}
+	return &Queue{
+		mgr:     mgr,
+		nonces:  nonces,
+		pending: make(chan struct{}, maxPending),
+	}
+}
+
+// 分配 nonce 并异步发送交易，在途交易达到上限时阻塞直到有空位或 ctx 结束
+func (q *Queue) Send(ctx context.Context, build BuildTxFunc, sendTx SendTransactionFunc) (*Future, error) {
+	select {
+	case q.pending <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	nonce, err := q.nonces.Reserve(ctx)
+	if err != nil {
+		<-q.pending
+		return nil, err
+	}
+
+	// 日志与 Send 共用同一个关联 ID
+	ctx = ensureCorrelationID(ctx)
+	future := newFuture()
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		defer func() { <-q.pending }()
+
+		updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+			return build(ctx, nonce)
+		}
+		receipt, err := q.mgr.Send(ctx, updateGasPrice, sendTx)
+		q.nonces.Done(nonce)
+		if err != nil {
+			log.Error("ContractsCaller queued transaction failed", "nonce", nonce, "correlationId", CorrelationID(ctx), "err", err)
+			q.nonces.Reset()
+		}
+		future.resolve(receipt, err)
+	}()
+	return future, nil
+}
+
+// 等待所有在途交易结束
+func (q *Queue) Wait() {
+	q.wg.Wait()
+}
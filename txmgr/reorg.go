@@ -0,0 +1,49 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	确认前的重组检查：
+		- 确认数满足后重新查询回执，交易不见了或所在区块哈希变了，说明原区块已被重组掉
+		- 后端支持按高度查询区块头时，再核对该高度的规范链区块是否就是回执所在区块
+		- 被重组掉的交易按未上链处理，继续等待重新打包（必要时由 Send 继续重发）
+*/
+
+// 按高度查询区块头，ethclient.Client 即满足
+type headerByNumberReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// 判断回执所在区块是否已被重组掉
+func receiptReorged(ctx context.Context, backend ReceiptSource, receipt *types.Receipt) (bool, error) {
+	latest, err := backend.TransactionReceipt(ctx, receipt.TxHash)
+	if errors.Is(err, ethereum.NotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if latest == nil || latest.BlockHash != receipt.BlockHash {
+		return true, nil
+	}
+
+	reader, ok := backend.(headerByNumberReader)
+	if !ok {
+		return false, nil
+	}
+	header, err := reader.HeaderByNumber(ctx, receipt.BlockNumber)
+	if errors.Is(err, ethereum.NotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return header.Hash() != receipt.BlockHash, nil
+}
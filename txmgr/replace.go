@@ -0,0 +1,252 @@
+package txmgr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+/*
+	替换已发布的交易：
+		- Cancel：用相同 nonce 构造一笔给自己的 0 值转账，原交易先上链时返回原交易的回执
+		- Replace：用相同 nonce 重新构造交易，可以替换调用数据，也可以仅加速
+		- 费用在该 nonce 上一笔已发布交易的基础上至少提价 priceBumpPercent，同时不低于当前网络建议值
+		- legacy 模式下直接构造 LegacyTx，按 eth_gasPrice 与上一笔交易的 gasPrice 定价
+		- 复用 Send 的重发和确认逻辑，直到替换交易上链
+*/
+
+var ErrSignerRequired = errors.New("txmgr: Signer is required")
+
+// 替换交易的内容，费用由 sendReplacement 按交易类型填入
+type replacementTx struct {
+	chainID    *big.Int
+	nonce      uint64
+	gas        uint64
+	to         *common.Address
+	value      *big.Int
+	data       []byte
+	accessList types.AccessList
+}
+
+// 构造未签名的替换交易，legacy 模式下 gasFeeCap 作为 gasPrice，带 access list 时构造 EIP-2930 交易
+func (r *replacementTx) build(txType TxType, gasTipCap, gasFeeCap *big.Int) *types.Transaction {
+	if txType != LegacyTxType {
+		return types.NewTx(&types.DynamicFeeTx{
+			Nonce:      r.nonce,
+			GasTipCap:  gasTipCap,
+			GasFeeCap:  gasFeeCap,
+			Gas:        r.gas,
+			To:         r.to,
+			Value:      r.value,
+			Data:       r.data,
+			AccessList: r.accessList,
+		})
+	}
+	if len(r.accessList) > 0 && r.chainID != nil && r.chainID.Sign() > 0 {
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    r.chainID,
+			Nonce:      r.nonce,
+			GasPrice:   gasFeeCap,
+			Gas:        r.gas,
+			To:         r.to,
+			Value:      r.value,
+			Data:       r.data,
+			AccessList: r.accessList,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    r.nonce,
+		GasPrice: gasFeeCap,
+		Gas:      r.gas,
+		To:       r.to,
+		Value:    r.value,
+		Data:     r.data,
+	})
+}
+
+func (m *SimpleTxManager) Cancel(ctx context.Context, nonce uint64) (*types.Receipt, error) {
+	ctx = ensureCorrelationID(ctx)
+	m.logger(ctx).Info("ContractsCaller cancelling transaction", "nonce", nonce, "from", m.cfg.From)
+
+	to := m.cfg.From
+	replacement := &replacementTx{
+		nonce: nonce,
+		gas:   params.TxGas,
+		to:    &to,
+		value: big.NewInt(0),
+	}
+
+	// 原交易仍可能先上链，与替换交易一起等待
+	last := m.lastPublished(nonce)
+	var watched []*types.Transaction
+	if last != nil {
+		watched = append(watched, last)
+	}
+	return m.sendReplacement(ctx, last, replacement, watched...)
+}
+
+func (m *SimpleTxManager) Replace(ctx context.Context, oldTx *types.Transaction, newPayload []byte) (*types.Receipt, error) {
+	if newPayload == nil {
+		newPayload = oldTx.Data()
+	}
+	ctx = ensureCorrelationID(ctx)
+	m.logger(ctx).Info("ContractsCaller replacing transaction", "txHash", oldTx.Hash(), "nonce", oldTx.Nonce())
+
+	// 调用数据变化后原 gas limit 可能不够，后端支持时重新估算
+	gasLimit := oldTx.Gas()
+	if estimator, ok := m.backend.(ethereum.GasEstimator); ok && !bytes.Equal(newPayload, oldTx.Data()) {
+		estimated, err := estimator.EstimateGas(ctx, ethereum.CallMsg{
+			From:  m.cfg.From,
+			To:    oldTx.To(),
+			Value: oldTx.Value(),
+			Data:  newPayload,
+		})
+		if err != nil {
+			return nil, err
+		}
+		gasLimit = estimated
+	}
+
+	replacement := &replacementTx{
+		chainID:    oldTx.ChainId(),
+		nonce:      oldTx.Nonce(),
+		gas:        gasLimit,
+		to:         oldTx.To(),
+		value:      oldTx.Value(),
+		data:       newPayload,
+		accessList: oldTx.AccessList(),
+	}
+
+	last := oldTx
+	watched := []*types.Transaction{oldTx}
+	if prev := m.lastPublished(oldTx.Nonce()); prev != nil && prev.Hash() != oldTx.Hash() {
+		watched = append(watched, prev)
+		if prev.GasFeeCap().Cmp(oldTx.GasFeeCap()) > 0 {
+			last = prev
+		}
+	}
+	return m.sendReplacement(ctx, last, replacement, watched...)
+}
+
+// 以提价后的费用构造、签名并发送替换交易，watched 中的交易同样会被等待上链
+// 遇到 nonce too low 说明该 nonce 已有交易上链，返回其中已上链交易的回执
+func (m *SimpleTxManager) sendReplacement(ctx context.Context, last *types.Transaction, replacement *replacementTx, watched ...*types.Transaction) (*types.Receipt, error) {
+	if m.cfg.Signer == nil {
+		return nil, ErrSignerRequired
+	}
+	sender, ok := m.backend.(ethereum.TransactionSender)
+	if !ok {
+		return nil, &ErrBackendUnsupported{Method: "eth_sendRawTransaction"}
+	}
+
+	var mu sync.Mutex
+	candidates := append([]*types.Transaction(nil), watched...)
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var gasTipCap, gasFeeCap *big.Int
+		var err error
+		if m.cfg.TxType == LegacyTxType {
+			gasFeeCap, err = m.replacementGasPrice(ctx, last)
+			gasTipCap = gasFeeCap
+		} else {
+			gasTipCap, gasFeeCap, err = m.replacementFees(ctx, last)
+		}
+		if err != nil {
+			return nil, err
+		}
+		tx, err := m.cfg.Signer(ctx, replacement.build(m.cfg.TxType, gasTipCap, gasFeeCap))
+		if err != nil {
+			return nil, err
+		}
+		last = tx
+		candidates = append(candidates, tx)
+		return tx, nil
+	}
+
+	// 替换交易已按交易类型定价，不再经过 legacyGasPriceFunc
+	receipt, err := m.sendPriced(ctx, updateGasPrice, sender.SendTransaction, watched...)
+	var sendErr *SendError
+	if !errors.As(err, &sendErr) || sendErr.Kind != SendErrorNonceTooLow {
+		return receipt, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, tx := range candidates {
+		minedReceipt, lookupErr := m.backend.TransactionReceipt(ctx, tx.Hash())
+		if lookupErr == nil && minedReceipt != nil {
+			m.logger(ctx).Info("ContractsCaller transaction already mined, replacement not needed", "txHash", tx.Hash(), "nonce", tx.Nonce())
+			m.forgetPublished(tx.Nonce())
+			return minedReceipt, nil
+		}
+	}
+	return nil, err
+}
+
+// 计算 legacy 替换交易的 gasPrice：取节点建议价格与上一笔交易提价后的较大值
+func (m *SimpleTxManager) replacementGasPrice(ctx context.Context, last *types.Transaction) (*big.Int, error) {
+	gasPricer, ok := m.backend.(ethereum.GasPricer)
+	if !ok {
+		return nil, &ErrBackendUnsupported{Method: "eth_gasPrice"}
+	}
+	gasPrice, err := gasPricer.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		if bumped := BumpFee(last.GasPrice()); bumped.Cmp(gasPrice) > 0 {
+			gasPrice = bumped
+		}
+	}
+	return gasPrice, nil
+}
+
+// 计算替换交易的费用：取网络建议值与上一笔交易提价后的较大值
+func (m *SimpleTxManager) replacementFees(ctx context.Context, last *types.Transaction) (*big.Int, *big.Int, error) {
+	var gasTipCap, gasFeeCap *big.Int
+
+	tipSource, hasTip := m.backend.(ethereum.GasPricer1559)
+	headSource, hasHead := m.backend.(ethereum.ChainReader)
+	if hasTip && hasHead {
+		tip, err := tipSource.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		head, err := headSource.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		gasTipCap = tip
+		if head.BaseFee != nil {
+			gasFeeCap = CalcGasFeeCap(head.BaseFee, tip)
+		} else {
+			gasFeeCap = new(big.Int).Set(tip)
+		}
+	}
+
+	if last != nil {
+		if bumped := BumpFee(last.GasTipCap()); gasTipCap == nil || bumped.Cmp(gasTipCap) > 0 {
+			gasTipCap = bumped
+		}
+		if bumped := BumpFee(last.GasFeeCap()); gasFeeCap == nil || bumped.Cmp(gasFeeCap) > 0 {
+			gasFeeCap = bumped
+		}
+	}
+
+	if gasTipCap == nil {
+		return nil, nil, &ErrBackendUnsupported{Method: "eth_maxPriorityFeePerGas"}
+	}
+	if gasFeeCap.Cmp(gasTipCap) < 0 {
+		gasFeeCap = new(big.Int).Set(gasTipCap)
+	}
+	return gasTipCap, gasFeeCap, nil
+}
@@ -0,0 +1,105 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+/*
+	回滚交易检测：
+		- Config.FailOnRevert 开启后，status=0 的回执不再视为成功
+		- 在交易所在区块上通过 eth_call 重放交易，从返回的 revert data 中解析原因
+		- 以 ErrTxReverted 的形式返回给调用方
+*/
+
+// 交易上链但执行失败时 Send 返回的错误
+type ErrTxReverted struct {
+	TxHash     common.Hash
+	Receipt    *types.Receipt
+	Reason     string // 解析出的回滚原因，无法解析时为空
+	RevertData []byte // eth_call 返回的原始 revert data
+}
+
+func (e *ErrTxReverted) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("txmgr: transaction %s reverted", e.TxHash)
+	}
+	return fmt.Sprintf("txmgr: transaction %s reverted: %s", e.TxHash, e.Reason)
+}
+
+// 重放回滚的交易，构造带原因的 ErrTxReverted
+func (m *SimpleTxManager) revertError(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) error {
+	revertErr := &ErrTxReverted{TxHash: receipt.TxHash, Receipt: receipt}
+
+	caller, ok := m.backend.(ethereum.ContractCaller)
+	if !ok {
+		return revertErr
+	}
+
+	_, err := caller.CallContract(ctx, m.callMsg(tx), receipt.BlockNumber)
+	if err == nil {
+		m.logger(ctx).Warn("ContractsCaller reverted transaction succeeded on replay", "txHash", receipt.TxHash)
+		return revertErr
+	}
+
+	revertErr.RevertData, revertErr.Reason = RevertReason(err)
+	return revertErr
+}
+
+// 按交易内容构造 eth_call 的参数
+func (m *SimpleTxManager) callMsg(tx *types.Transaction) ethereum.CallMsg {
+	// 优先使用交易签名恢复出的发送方
+	from := m.cfg.From
+	if chainID := tx.ChainId(); chainID.Sign() > 0 {
+		if sender, err := types.Sender(types.LatestSignerForChainID(chainID), tx); err == nil {
+			from = sender
+		}
+	}
+	msg := ethereum.CallMsg{
+		From:       from,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}
+	if tx.Type() == types.LegacyTxType {
+		msg.GasPrice = tx.GasPrice()
+	} else {
+		msg.GasFeeCap = tx.GasFeeCap()
+		msg.GasTipCap = tx.GasTipCap()
+	}
+	return msg
+}
+
+// 从 eth_call 的错误中提取 revert data 并解析 Error(string) / Panic(uint256)
+// 无法解析时返回节点给出的错误信息
+func RevertReason(err error) ([]byte, string) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, err.Error()
+	}
+
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, err.Error()
+	}
+	data, decodeErr := hexutil.Decode(hexData)
+	if decodeErr != nil {
+		return nil, err.Error()
+	}
+
+	reason, unpackErr := abi.UnpackRevert(data)
+	if unpackErr != nil {
+		return data, err.Error()
+	}
+	return data, reason
+}
@@ -1,13 +1,87 @@
 package txmgr
 
 import (
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core"
+	"fmt"
 	"strings"
 	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+)
+
+// 广播交易时节点返回错误的分类
+type SendErrorKind int
+
+const (
+	SendErrorNone              SendErrorKind = iota // 没有错误
+	SendErrorUnknown                                // 无法识别的错误
+	SendErrorNonceTooLow                            // nonce too low：同 nonce 交易可能已经上链
+	SendErrorUnderpriced                            // 价格过低或替换交易提价不足：等待下一轮提价
+	SendErrorAlreadyKnown                           // 交易已在 mempool 中：视为发布成功，不再提价
+	SendErrorInsufficientFunds                      // 余额不足：重发无意义，立即终止
+	SendErrorExceedsGasLimit                        // gas limit 超过区块上限：重发无意义，立即终止
 )
 
+func (k SendErrorKind) String() string {
+	switch k {
+	case SendErrorNone:
+		return "none"
+	case SendErrorNonceTooLow:
+		return "nonce_too_low"
+	case SendErrorUnderpriced:
+		return "underpriced"
+	case SendErrorAlreadyKnown:
+		return "already_known"
+	case SendErrorInsufficientFunds:
+		return "insufficient_funds"
+	case SendErrorExceedsGasLimit:
+		return "exceeds_gas_limit"
+	default:
+		return "unknown"
+	}
+}
+
+// 按节点返回的错误信息对广播错误分类
+// 节点通过 RPC 返回的是字符串，只能按错误信息匹配
+func ClassifySendError(err error) SendErrorKind {
+	if err == nil {
+		return SendErrorNone
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, core.ErrNonceTooLow.Error()):
+		return SendErrorNonceTooLow
+	case strings.Contains(msg, txpool.ErrReplaceUnderpriced.Error()),
+		strings.Contains(msg, txpool.ErrUnderpriced.Error()):
+		return SendErrorUnderpriced
+	case strings.Contains(msg, txpool.ErrAlreadyKnown.Error()):
+		return SendErrorAlreadyKnown
+	case strings.Contains(msg, "insufficient funds"):
+		return SendErrorInsufficientFunds
+	case strings.Contains(msg, txpool.ErrGasLimit.Error()):
+		return SendErrorExceedsGasLimit
+	default:
+		return SendErrorUnknown
+	}
+}
+
+// 因广播错误导致 Send 提前终止时返回的错误
+type SendError struct {
+	Kind SendErrorKind
+	Err  error
+}
+
+func (e *SendError) Error() string {
+	return fmt.Sprintf("txmgr: send failed (%s): %v", e.Kind, e.Err)
+}
+
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
 type SendState struct {
+	publishedTxs              map[common.Hash]struct{} // 保存已发布交易的hash（包括被替换的交易）
 	minedTxs                  map[common.Hash]struct{} // 保存已上链交易的hash
 	nonceTooLowCount          uint64                   // nonce太低次数
 	mu                        sync.RWMutex
@@ -21,6 +95,7 @@ func NewSendState(safeAbortNonceTooLowCount uint64) *SendState {
 	}
 
 	return &SendState{
+		publishedTxs:              make(map[common.Hash]struct{}),
 		minedTxs:                  make(map[common.Hash]struct{}),
 		nonceTooLowCount:          0,
 		safeAbortNonceTooLowCount: safeAbortNonceTooLowCount,
@@ -29,23 +104,41 @@ func NewSendState(safeAbortNonceTooLowCount uint64) *SendState {
 }
 
 /*
-检查传入错误是否是 nonce too low
-  - 如果是则增加nonceTooLowCount
+对广播错误分类并返回分类结果
+  - 如果是 nonce too low 则增加nonceTooLowCount
   - 如果交易已经被矿工打包，重新发送同样 nonce 的交易会触发 nonce too low
   - 多次遇到这个错误可推测原交易已经被成功打包
 */
-func (s *SendState) ProcessSendError(err error) {
-	if err == nil {
-		return
-	}
-
-	if !strings.Contains(err.Error(), core.ErrNonceTooLow.Error()) {
-		return
+func (s *SendState) ProcessSendError(err error) SendErrorKind {
+	kind := ClassifySendError(err)
+	if kind != SendErrorNonceTooLow {
+		return kind
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.nonceTooLowCount++
+	return kind
+}
+
+// 记录已发布的交易，同一 nonce 的多笔交易任何一笔上链即可
+func (s *SendState) TxPublished(txHash common.Hash) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.publishedTxs[txHash] = struct{}{}
+}
+
+// 返回所有已发布交易的 hash
+func (s *SendState) PublishedTxs() []common.Hash {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hashes := make([]common.Hash, 0, len(s.publishedTxs))
+	for txHash := range s.publishedTxs {
+		hashes = append(hashes, txHash)
+	}
+	return hashes
 }
 
 // 标记交易已经上链
@@ -92,3 +185,35 @@ func (s *SendState) IsWaitingForConfirmation() bool {
 	defer s.mu.RUnlock()
 	return len(s.minedTxs) > 0
 }
+
+// SendState 某一时刻的只读快照，供状态查询使用
+type SendStateSnapshot struct {
+	PublishedTxs           []common.Hash // 已发布交易的 hash（包括被替换的交易）
+	MinedTxs               []common.Hash // 已上链、等待确认的交易 hash
+	Bumps                  int           // 提价重发次数
+	NonceTooLowCount       uint64        // 遇到 nonce too low 的次数
+	WaitingForConfirmation bool          // 是否已有交易上链、正在等待确认
+}
+
+// 返回当前状态的快照，返回值与 SendState 不共享内存
+func (s *SendState) Snapshot() SendStateSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := SendStateSnapshot{
+		PublishedTxs:           make([]common.Hash, 0, len(s.publishedTxs)),
+		MinedTxs:               make([]common.Hash, 0, len(s.minedTxs)),
+		NonceTooLowCount:       s.nonceTooLowCount,
+		WaitingForConfirmation: len(s.minedTxs) > 0,
+	}
+	for txHash := range s.publishedTxs {
+		snapshot.PublishedTxs = append(snapshot.PublishedTxs, txHash)
+	}
+	for txHash := range s.minedTxs {
+		snapshot.MinedTxs = append(snapshot.MinedTxs, txHash)
+	}
+	if len(s.publishedTxs) > 1 {
+		snapshot.Bumps = len(s.publishedTxs) - 1
+	}
+	return snapshot
+}
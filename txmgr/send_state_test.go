@@ -144,6 +144,64 @@ func TestSendStateIsWaitingForConfirmationAfterTxMined(t *testing.T) {
 	require.True(t, sendState.IsWaitingForConfirmation())
 }
 
+func TestSendStateTracksPublishedTxs(t *testing.T) {
+	sendState := newSendState()
+
+	testHash2 := common.HexToHash("0x02")
+
+	sendState.TxPublished(testHash)
+	sendState.TxPublished(testHash2)
+	sendState.TxPublished(testHash)
+	require.ElementsMatch(t, []common.Hash{testHash, testHash2}, sendState.PublishedTxs())
+}
+
+func TestSendStateSnapshot(t *testing.T) {
+	sendState := newSendState()
+
+	testHash2 := common.HexToHash("0x02")
+
+	sendState.TxPublished(testHash)
+	sendState.TxPublished(testHash2)
+	sendState.ProcessSendError(core.ErrNonceTooLow)
+	sendState.TxMined(testHash2)
+
+	snapshot := sendState.Snapshot()
+	require.ElementsMatch(t, []common.Hash{testHash, testHash2}, snapshot.PublishedTxs)
+	require.Equal(t, []common.Hash{testHash2}, snapshot.MinedTxs)
+	require.Equal(t, 1, snapshot.Bumps)
+	require.Equal(t, uint64(1), snapshot.NonceTooLowCount)
+	require.True(t, snapshot.WaitingForConfirmation)
+}
+
+func TestClassifySendError(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind txmgr.SendErrorKind
+	}{
+		{nil, txmgr.SendErrorNone},
+		{errors.New("other error"), txmgr.SendErrorUnknown},
+		{core.ErrNonceTooLow, txmgr.SendErrorNonceTooLow},
+		{errors.New("replacement transaction underpriced"), txmgr.SendErrorUnderpriced},
+		{errors.New("transaction underpriced: tip needed 1, tip permitted 0"), txmgr.SendErrorUnderpriced},
+		{errors.New("already known"), txmgr.SendErrorAlreadyKnown},
+		{errors.New("insufficient funds for gas * price + value: address 0x01 have 0 want 1"), txmgr.SendErrorInsufficientFunds},
+		{errors.New("exceeds block gas limit"), txmgr.SendErrorExceedsGasLimit},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.kind, txmgr.ClassifySendError(test.err), "err: %v", test.err)
+	}
+}
+
+// 只有 nonce too low 计入终止计数
+func TestSendStateOnlyCountsNonceTooLow(t *testing.T) {
+	sendState := newSendState()
+
+	kind := sendState.ProcessSendError(errors.New("replacement transaction underpriced"))
+	require.Equal(t, txmgr.SendErrorUnderpriced, kind)
+	processNSendErrors(sendState, errors.New("already known"), testSafeAbortNonceTooLowCount)
+	require.False(t, sendState.ShouldAbortImmediately())
+}
+
 func TestSendStateIsNotWaitingForConfirmationAfterTxUnmined(t *testing.T) {
 	sendState := newSendState()
 
@@ -0,0 +1,127 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	卡住交易的检测与救援：
+		- 定期检查每个 nonce 最近一次发布的交易，超过 Config.StuckTxThreshold 仍未上链视为卡住
+		- 节点上查不到（被 mempool 丢弃）时原样重新广播
+		- 仍在 mempool 中或重新广播失败时，通过 Replace 提价加速
+	主要针对 Send 已经返回（超时、ctx 取消）但交易仍未上链的情况，
+	进行中的 Send 会按重发策略定期重新发布，不会被误判为卡住
+*/
+
+// 后台检测并救援卡住的交易，阻塞直到 ctx 结束，未配置 StuckTxThreshold 时直接返回
+func (m *SimpleTxManager) MonitorStuckTxs(ctx context.Context) {
+	if m.cfg.StuckTxThreshold <= 0 {
+		return
+	}
+	interval := m.cfg.StuckTxCheckInterval
+	if interval <= 0 {
+		interval = m.cfg.StuckTxThreshold
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, tx := range m.stuckTxs() {
+				m.rescueStuckTx(ctx, tx, &wg)
+			}
+		}
+	}
+}
+
+// 返回发布后超过阈值、且没有在救援中的交易
+func (m *SimpleTxManager) stuckTxs() []*types.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var txs []*types.Transaction
+	for nonce, at := range m.publishedAt {
+		if _, ok := m.rescuing[nonce]; ok {
+			continue
+		}
+		if time.Since(at) >= m.cfg.StuckTxThreshold {
+			txs = append(txs, m.published[nonce])
+		}
+	}
+	return txs
+}
+
+func (m *SimpleTxManager) rescueStuckTx(ctx context.Context, tx *types.Transaction, wg *sync.WaitGroup) {
+	txHash := tx.Hash()
+	nonce := tx.Nonce()
+
+	// Send 返回后才上链的交易，清理记录即可
+	if receipt, err := m.backend.TransactionReceipt(ctx, txHash); err == nil && receipt != nil {
+		m.forgetPublished(nonce)
+		return
+	}
+
+	inMempool := true
+	if reader, ok := m.backend.(txByHashReader); ok {
+		_, _, err := reader.TransactionByHash(ctx, txHash)
+		inMempool = !errors.Is(err, ethereum.NotFound)
+	}
+
+	if !inMempool {
+		if sender, ok := m.backend.(ethereum.TransactionSender); ok {
+			err := sender.SendTransaction(ctx, tx)
+			switch ClassifySendError(err) {
+			case SendErrorNone, SendErrorAlreadyKnown:
+				m.l.Info("ContractsCaller rebroadcast stuck transaction", "txHash", txHash, "nonce", nonce)
+				m.recordPublished(tx)
+				return
+			case SendErrorNonceTooLow:
+				// nonce 已被其他交易占用，不再跟踪
+				m.l.Info("ContractsCaller stuck transaction nonce already used", "txHash", txHash, "nonce", nonce)
+				m.forgetPublished(nonce)
+				return
+			}
+			m.l.Warn("ContractsCaller unable to rebroadcast stuck transaction", "txHash", txHash, "nonce", nonce, "err", err)
+		}
+	}
+
+	if m.cfg.Signer == nil {
+		m.l.Warn("ContractsCaller stuck transaction cannot be replaced without signer", "txHash", txHash, "nonce", nonce)
+		return
+	}
+
+	m.mu.Lock()
+	m.rescuing[nonce] = struct{}{}
+	m.mu.Unlock()
+
+	m.l.Warn("ContractsCaller replacing stuck transaction", "txHash", txHash, "nonce", nonce, "inMempool", inMempool)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			m.mu.Lock()
+			delete(m.rescuing, nonce)
+			m.mu.Unlock()
+		}()
+
+		receipt, err := m.Replace(ctx, tx, nil)
+		if err != nil {
+			m.l.Error("ContractsCaller unable to replace stuck transaction", "txHash", txHash, "nonce", nonce, "err", err)
+			return
+		}
+		m.l.Info("ContractsCaller stuck transaction rescued", "txHash", receipt.TxHash, "nonce", nonce)
+	}()
+}
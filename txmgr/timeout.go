@@ -0,0 +1,65 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+	Send 自身的超时控制：
+		- Config.TxSendTimeout：整个 Send 的最长耗时，超时后返回 ErrSendTimeout
+		- Config.TxNotInMempoolTimeout：超时仍没有任何交易发布成功则返回 ErrTxNotInMempool；
+		  已发布的交易若在节点上查不到（被 mempool 丢弃），则原样重新广播
+*/
+
+var (
+	ErrSendTimeout    = errors.New("txmgr: send timed out")
+	ErrTxNotInMempool = errors.New("txmgr: transaction was never accepted into the mempool")
+)
+
+// 按哈希查询交易，ethclient.Client 即满足
+type txByHashReader interface {
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+}
+
+// 每隔 TxNotInMempoolTimeout 检查一次交易是否还在节点上，查不到时重新广播
+// 交易上链、被同 nonce 的新交易替换或 ctx 结束时退出
+func (m *SimpleTxManager) rebroadcastIfMissing(ctx context.Context, tx *types.Transaction, sendTx SendTransactionFunc, sendState *SendState) {
+	reader, ok := m.backend.(txByHashReader)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(m.cfg.TxNotInMempoolTimeout)
+	defer ticker.Stop()
+
+	l := m.logger(ctx)
+	txHash := tx.Hash()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if sendState.IsWaitingForConfirmation() || m.lastPublished(tx.Nonce()) != tx {
+			return
+		}
+
+		_, _, err := reader.TransactionByHash(ctx, txHash)
+		if !errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+
+		l.Warn("ContractsCaller transaction not found in mempool, rebroadcasting", "txHash", txHash, "nonce", tx.Nonce())
+		m.cfg.Metrics.RecordPublishAttempt()
+		if err := sendTx(ctx, tx); err != nil {
+			l.Warn("ContractsCaller unable to rebroadcast transaction", "txHash", txHash, "err", err)
+		}
+	}
+}
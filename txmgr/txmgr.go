@@ -2,12 +2,16 @@ package txmgr
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
 
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/WJX2001/contract-caller/synchronizer/retry"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
@@ -26,15 +30,72 @@ type UpdateGasPriceFunc = func(ctx context.Context) (*types.Transaction, error)
 type SendTransactionFunc = func(ctx context.Context, tx *types.Transaction) error
 
 type Config struct {
-	ResubmissionTimeout       time.Duration // 重发交易的时间间隔
-	ReceiptQueryInterval      time.Duration // 轮询 receipt 的时间间隔
-	NumConfirmations          uint64        // 交易所需确认数
-	SafeAbortNonceTooLowCount uint64        // 遇到 nonce too low 错误的容忍次数
+	ResubmissionTimeout       time.Duration         // 重发交易的时间间隔
+	ResubmissionStrategy      retry.Strategy        // 重发间隔策略，按已重发次数计算下一次等待时长，为 nil 时固定使用 ResubmissionTimeout
+	ReceiptQueryInterval      time.Duration         // 轮询 receipt 的时间间隔
+	NumConfirmations          uint64                // 交易所需确认数
+	SafeAbortNonceTooLowCount uint64                // 遇到 nonce too low 错误的容忍次数
+	MaxGasFeeCap              *big.Int              // gasFeeCap 上限，为 nil 表示不限制
+	MaxGasTipCap              *big.Int              // gasTipCap 上限，为 nil 表示不限制
+	MinGasFeeCap              *big.Int              // gasFeeCap 下限，为 nil 表示不限制，设置后需要 Signer
+	MinGasTipCap              *big.Int              // gasTipCap 下限，为 nil 表示不限制，设置后需要 Signer
+	TxType                    TxType                // 交易类型，默认 EIP-1559
+	Signer                    SignerFn              // 交易签名函数，legacy 模式和 Cancel 必填
+	From                      common.Address        // 发送交易的地址，Cancel 时作为自转账的目标
+	Metrics                   Metrics               // 指标采集，为 nil 时不采集
+	FailOnRevert              bool                  // 为 true 时执行失败（status=0）的交易返回 ErrTxReverted
+	TxSendTimeout             time.Duration         // 单次 Send 的最长耗时，为 0 表示只受调用方 ctx 控制
+	TxNotInMempoolTimeout     time.Duration         // 交易未进入 mempool 时放弃或重新广播的等待时间，为 0 表示不检查
+	BroadcastTo               []SendTransactionFunc // 额外广播交易的节点，与 Send 传入的 sendTx 同时广播
+	ReestimateGas             bool                  // 为 true 时每次发布前重新估算 gas limit，需要 Signer
+	GasLimitMultiplier        float64               // 重新估算 gas limit 时的放大倍数，小于等于 1 时不放大
+	Budget                    Budget                // 交易花费预算，为 nil 表示不限制
+	Logger                    log.Logger            // 日志，为 nil 时使用 log.Root()
+	SubscribeNewHeads         bool                  // 后端支持订阅时在每个新区块检查回执，代替按 ReceiptQueryInterval 轮询
+	DryRun                    bool                  // 演练模式：只通过 eth_call 模拟执行，不广播交易，返回合成回执
+	StuckTxThreshold          time.Duration         // 交易发布后超过该时长未上链视为卡住，为 0 表示不检测，应明显大于 ResubmissionTimeout
+	StuckTxCheckInterval      time.Duration         // 检测卡住交易的间隔，为 0 时使用 StuckTxThreshold
+	OnPublished               TxHookFn              // 交易广播成功后回调
+	OnBumped                  TxBumpHookFn          // 提价替换旧交易后回调
+	OnMined                   ReceiptHookFn         // 交易首次上链时回调
+	OnConfirmed               ReceiptHookFn         // 交易满足确认数时回调
+}
+
+// 交易费用超出配置上限时 Send 返回的错误
+type ErrFeeCeilingExceeded struct {
+	Field   string   // 超限的字段：gasFeeCap 或 gasTipCap
+	Fee     *big.Int // 本次交易的费用
+	Ceiling *big.Int // 配置的上限
+}
+
+func (e *ErrFeeCeilingExceeded) Error() string {
+	return fmt.Sprintf("txmgr: %s %v exceeds ceiling %v", e.Field, e.Fee, e.Ceiling)
+}
+
+// 后端没有实现某项可选能力时返回的错误
+type ErrBackendUnsupported struct {
+	Method string
+}
+
+func (e *ErrBackendUnsupported) Error() string {
+	return fmt.Sprintf("txmgr: backend does not support %s", e.Method)
 }
 
 type TxManager interface {
 	// 负责发送交易并等待其确认
 	Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTxn SendTransactionFunc) (*types.Receipt, error)
+	// 在后台执行 Send，立即返回 Future，调用方稍后通过 Done/Receipt/Err 获取结果
+	SendAsync(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTxn SendTransactionFunc) *Future
+	// 从 pending nonce 开始为每笔交易分配连续的 nonce 并同时发送，按顺序返回回执
+	// 前面的 nonce 失败时取消后续交易并返回 ErrBatchItemFailed
+	SendBatch(ctx context.Context, builds []BuildTxFunc, sendTxn SendTransactionFunc) ([]*types.Receipt, error)
+	// 用同 nonce 的 0 值转账给自己替换掉卡住的交易，返回替换交易的回执
+	Cancel(ctx context.Context, nonce uint64) (*types.Receipt, error)
+	// 用同 nonce、提价后的新交易替换已发布的交易，newPayload 为空时仅加速原交易
+	// 新旧交易任何一笔先上链都视为完成
+	Replace(ctx context.Context, oldTx *types.Transaction, newPayload []byte) (*types.Receipt, error)
+	// 返回所有进行中的 Send 及其发送状态快照
+	ActiveSends() []ActiveSend
 }
 
 // 提供必要的 RPC 接口，包括获取区块号和获取交易数据
@@ -47,19 +108,90 @@ type SimpleTxManager struct {
 	cfg     Config        // 配置
 	backend ReceiptSource // 区块链客户端
 	l       log.Logger
+
+	mu          sync.Mutex
+	published   map[uint64]*types.Transaction // 每个 nonce 最近一次成功发布的交易，用于替换时计算提价
+	publishedAt map[uint64]time.Time          // 每个 nonce 最近一次发布的时间，用于检测卡住的交易
+	rescuing    map[uint64]struct{}           // 正在被替换救援的 nonce
+	sends       map[*SendState]*activeSend    // 进行中的 Send
+
+	nonces *NonceTracker // Config.From 的 nonce 预留，后端不支持读取 pending nonce 时为 nil
 }
 
 func NewSimpleTxManager(cfg Config, backend ReceiptSource) *SimpleTxManager {
 	if cfg.NumConfirmations == 0 {
 		panic("txmgr: NumConfirmations cannot be zero")
 	}
+	if cfg.TxType == LegacyTxType && cfg.Signer == nil {
+		panic("txmgr: Signer is required in legacy mode")
+	}
+	if cfg.ReestimateGas && cfg.Signer == nil {
+		panic("txmgr: Signer is required to re-estimate gas")
+	}
+	if (cfg.MinGasFeeCap != nil || cfg.MinGasTipCap != nil) && cfg.Signer == nil {
+		panic("txmgr: Signer is required to apply fee floors")
+	}
+	if cfg.Metrics == nil {
+		cfg.Metrics = NoopMetrics
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Root()
+	}
+	var nonces *NonceTracker
+	if nonceSource, ok := backend.(NonceSource); ok {
+		nonces = NewNonceTracker(nonceSource, cfg.From)
+	}
 	return &SimpleTxManager{
-		cfg:     cfg,
-		backend: backend,
+		cfg:         cfg,
+		backend:     backend,
+		l:           cfg.Logger,
+		published:   make(map[uint64]*types.Transaction),
+		publishedAt: make(map[uint64]time.Time),
+		rescuing:    make(map[uint64]struct{}),
+		sends:       make(map[*SendState]*activeSend),
+		nonces:      nonces,
 	}
 }
 
 func (m *SimpleTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc) (*types.Receipt, error) {
+	return m.send(ctx, updateGasPrice, sendTx)
+}
+
+func (m *SimpleTxManager) SendAsync(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc) *Future {
+	future := newFuture()
+	go func() {
+		future.resolve(m.send(ctx, updateGasPrice, sendTx))
+	}()
+	return future
+}
+
+// Send 的实现，watched 为已经发布过的同 nonce 交易，它们与新发布的交易一起等待上链
+func (m *SimpleTxManager) send(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc, watched ...*types.Transaction) (*types.Receipt, error) {
+	// legacy 模式下由 txmgr 自行构造并提价 LegacyTx
+	if m.cfg.TxType == LegacyTxType {
+		updateGasPrice = m.legacyGasPriceFunc(updateGasPrice)
+	}
+	return m.sendPriced(ctx, updateGasPrice, sendTx, watched...)
+}
+
+// 发送 updateGasPrice 生成的交易，交易类型和价格由 updateGasPrice 决定
+func (m *SimpleTxManager) sendPriced(ctx context.Context, updateGasPrice UpdateGasPriceFunc, sendTx SendTransactionFunc, watched ...*types.Transaction) (*types.Receipt, error) {
+	// 同一个 Send 的所有日志和回调共用一个关联 ID
+	ctx = ensureCorrelationID(ctx)
+	l := m.logger(ctx)
+
+	if m.cfg.ReestimateGas {
+		updateGasPrice = m.reestimateGasFunc(updateGasPrice)
+	}
+	if m.cfg.MinGasFeeCap != nil || m.cfg.MinGasTipCap != nil {
+		updateGasPrice = m.feeFloorFunc(updateGasPrice)
+	}
+	sendTx = m.broadcastFunc(sendTx)
+
+	if m.cfg.DryRun {
+		return m.dryRun(ctx, updateGasPrice)
+	}
+
 	// 使用 sync.WaitGroup 来等待所有 goroutine 执行完成，确保函数退出时所有异步操作结束
 	var wg sync.WaitGroup
 	defer wg.Wait()
@@ -67,10 +199,96 @@ func (m *SimpleTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPric
 	// 创建一个可取消的上下文 ctx, 便于在某些情况下直接终止 goroutine，比如错误发生时
 	ctxc, cancel := context.WithCancel(ctx)
 	defer cancel()
+	// 超时上下文从 ctxc 派生，abort 取消 ctxc 时同样会终止
+	if m.cfg.TxSendTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctxc, cancelTimeout = context.WithTimeoutCause(ctxc, m.cfg.TxSendTimeout, ErrSendTimeout)
+		defer cancelTimeout()
+	}
+	// 用于统计上链耗时和提价次数
+	start := time.Now()
+	var publishCount atomic.Int64
 	// 初始化 sendState 用于追踪 nonceTooLow 错误等状态
 	sendState := NewSendState(m.cfg.SafeAbortNonceTooLowCount)
-	// 缓冲为1的 channel 用于传回成功上链的回执
-	receiptChan := make(chan *types.Receipt, 1)
+	defer m.trackSend(ctx, sendState)()
+	// 缓冲为1的 channel 用于传回成功上链的交易及其回执
+	type minedTx struct {
+		tx      *types.Transaction
+		receipt *types.Receipt
+	}
+	receiptChan := make(chan minedTx, 1)
+
+	// 记录导致 Send 提前终止的错误，只保留第一个
+	var abortMu sync.Mutex
+	var abortErr error
+	recordAbort := func(reason string, err error) {
+		m.cfg.Metrics.RecordAbort(reason)
+		abortMu.Lock()
+		if abortErr == nil {
+			abortErr = err
+		}
+		abortMu.Unlock()
+	}
+	abort := func(reason string, err error) {
+		recordAbort(reason, err)
+		cancel()
+	}
+
+	// 费用超出上限或预算时不再提价重发，但已发布的交易仍可能上链
+	// 此时继续等待它们的回执直到超时，没有任何交易发布过才立即终止
+	var bumpingStopped atomic.Bool
+	stopBumping := func(reason string, err error) {
+		bumpingStopped.Store(true)
+		if publishCount.Load() == 0 && len(watched) == 0 {
+			abort(reason, err)
+			return
+		}
+		recordAbort(reason, err)
+	}
+
+	// 等待交易上链确认，并把回执交给 receiptChan
+	waitTxMined := func(tx *types.Transaction) {
+		txHash := tx.Hash()
+		nonce := tx.Nonce()
+		gasTipCap := tx.GasTipCap()
+		gasFeeCap := tx.GasFeeCap()
+
+		// 调用 waitMined 等待交易上链 并满足指定确认数
+		onMined := func(receipt *types.Receipt) {
+			m.cfg.Metrics.RecordTimeToMined(time.Since(start))
+			m.onMined(ctx, tx, receipt)
+		}
+		receipt, err := waitMined(
+			ctxc, m.backend, tx, m.cfg.ReceiptQueryInterval,
+			m.cfg.NumConfirmations, sendState, onMined, l, m.cfg.SubscribeNewHeads,
+		)
+
+		if err != nil {
+			l.Debug("ContractsCaller send tx failed", "hash", txHash, "nonce", nonce, "gasTipCap", gasTipCap, "gasFeeCap", gasFeeCap, "err", err)
+		}
+
+		if receipt != nil {
+			m.forgetPublished(nonce)
+			select {
+			// 如果收到回执，尝试发送到 receiptChan. 使用 select-default 避免阻塞
+			case receiptChan <- minedTx{tx: tx, receipt: receipt}:
+				l.Trace("ContractsCaller send tx succeeded", "hash", txHash,
+					"nonce", nonce, "gasTipCap", gasTipCap,
+					"gasFeeCap", gasFeeCap)
+			default:
+			}
+		}
+	}
+
+	// 已发布的交易同样需要等待上链
+	for _, tx := range watched {
+		sendState.TxPublished(tx.Hash())
+		wg.Add(1)
+		go func(tx *types.Transaction) {
+			defer wg.Done()
+			waitTxMined(tx)
+		}(tx)
+	}
 
 	// 定义异步发送交易逻辑
 	sendTxAsync := func() {
@@ -84,8 +302,8 @@ func (m *SimpleTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPric
 				return
 			}
 
-			log.Error("ContractsCaller update txn gas price fail", "err", err)
-			cancel()
+			l.Error("ContractsCaller update txn gas price fail", "err", err)
+			abort(AbortReasonUpdateGasPrice, err)
 			return
 		}
 
@@ -96,49 +314,77 @@ func (m *SimpleTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPric
 		gasTipCap := tx.GasTipCap()
 		gasFeeCap := tx.GasFeeCap()
 
-		log.Debug("ContractsCaller publishing transaction", "txHash", txHash, "nonce", nonce, "gasTipCap", gasTipCap, "gasFeeCap", gasFeeCap)
+		// 提价后的费用超出上限则不再重发，直接终止
+		if err := m.checkFeeCeiling(tx); err != nil {
+			l.Error("ContractsCaller transaction fee exceeds ceiling", "txHash", txHash, "nonce", nonce, "err", err)
+			stopBumping(AbortReasonFeeCeiling, err)
+			return
+		}
+
+		// 超出花费预算则暂停发送
+		if m.cfg.Budget != nil {
+			if err := m.cfg.Budget.Allow(ctxc, maxTxCost(tx)); err != nil {
+				l.Error("ContractsCaller transaction cost exceeds budget", "txHash", txHash, "nonce", nonce, "err", err)
+				stopBumping(AbortReasonBudget, err)
+				return
+			}
+		}
+
+		l.Debug("ContractsCaller publishing transaction", "txHash", txHash, "nonce", nonce, "gasTipCap", gasTipCap, "gasFeeCap", gasFeeCap)
 
 		// 发送交易 记录错误状态
+		m.cfg.Metrics.RecordPublishAttempt()
 		err = sendTx(ctxc, tx)
-		sendState.ProcessSendError(err)
-
-		if err != nil {
+		switch kind := sendState.ProcessSendError(err); kind {
+		case SendErrorNone:
+		case SendErrorAlreadyKnown:
+			// 交易已在 mempool 中，按发布成功处理，等待上链即可
+			l.Debug("ContractsCaller transaction already known", "txHash", txHash, "nonce", nonce)
+		case SendErrorUnderpriced:
+			l.Warn("ContractsCaller transaction underpriced, waiting for next bump", "txHash", txHash, "nonce", nonce, "err", err)
+			return
+		case SendErrorInsufficientFunds, SendErrorExceedsGasLimit:
+			l.Error("ContractsCaller unable to publish transaction", "txHash", txHash, "nonce", nonce, "err", err)
+			abort(kind.String(), &SendError{Kind: kind, Err: err})
+			return
+		default:
+			if kind == SendErrorNonceTooLow {
+				m.cfg.Metrics.RecordNonceTooLow()
+			}
 			if err == context.Canceled || strings.Contains(err.Error(), "context canceled") {
 				return
 			}
 
-			log.Error("ContractsCaller unable to publish transaction", "err", err)
+			l.Error("ContractsCaller unable to publish transaction", "err", err)
 
 			if sendState.ShouldAbortImmediately() {
-				cancel()
+				abort(AbortReasonNonceTooLow, &SendError{Kind: SendErrorNonceTooLow, Err: err})
 			}
 
 			return
 		}
 
-		log.Debug("ContractsCaller transaction published successfully", "hash", txHash, "nonce", nonce, "gasTipCap", gasTipCap, "gasFeeCap", gasFeeCap)
-
-		// 等待上链确认
-		// 调用 waitMined 等待交易上链 并满足指定确认数
-		receipt, err := waitMined(
-			ctxc, m.backend, tx, m.cfg.ReceiptQueryInterval,
-			m.cfg.NumConfirmations, sendState,
-		)
-
-		if err != nil {
-			log.Debug("ContractsCaller send tx failed", "hash", txHash, "nonce", nonce, "gasTipCap", gasTipCap, "gasFeeCap", gasFeeCap, "err", err)
+		l.Debug("ContractsCaller transaction published successfully", "hash", txHash, "nonce", nonce, "gasTipCap", gasTipCap, "gasFeeCap", gasFeeCap)
+		prev := m.lastPublished(nonce)
+		m.recordPublished(tx)
+		m.onPublished(ctx, tx)
+		if prev != nil && prev.Hash() != txHash {
+			m.onBumped(ctx, prev, tx)
 		}
+		sendState.TxPublished(txHash)
+		publishCount.Add(1)
 
-		if receipt != nil {
-			select {
-			// 如果收到回执，尝试发送到 receiptChan. 使用 select-default 避免阻塞
-			case receiptChan <- receipt:
-				log.Trace("ContractsCaller send tx succeeded", "hash", txHash,
-					"nonce", nonce, "gasTipCap", gasTipCap,
-					"gasFeeCap", gasFeeCap)
-			default:
-			}
+		// 交易被 mempool 丢弃时重新广播
+		if m.cfg.TxNotInMempoolTimeout > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m.rebroadcastIfMissing(ctxc, tx, sendTx, sendState)
+			}()
 		}
+
+		// 等待上链确认
+		waitTxMined(tx)
 	}
 
 	// 即将启动一个 goroutine, 要计入等待列表
@@ -147,30 +393,116 @@ func (m *SimpleTxManager) Send(ctx context.Context, updateGasPrice UpdateGasPric
 	go sendTxAsync()
 
 	// 启动定时器重试机制
-	// 每隔一段时间尝试重新发送交易
-	ticker := time.NewTicker(m.cfg.ResubmissionTimeout)
-	defer ticker.Stop()
+	// 按重发策略等待一段时间后尝试重新发送交易，拥堵期间可用指数退避减少重复广播
+	strategy := m.cfg.ResubmissionStrategy
+	if strategy == nil {
+		strategy = retry.Fixed(m.cfg.ResubmissionTimeout)
+	}
+	resubmissions := 0
+	resubmit := time.NewTimer(strategy.Duration(resubmissions))
+	defer resubmit.Stop()
+
+	// 超时仍没有交易发布成功则放弃
+	var notInMempool <-chan time.Time
+	if m.cfg.TxNotInMempoolTimeout > 0 {
+		timer := time.NewTimer(m.cfg.TxNotInMempoolTimeout)
+		defer timer.Stop()
+		notInMempool = timer.C
+	}
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-resubmit.C:
 			// 如果不是在等上链 就触发新一轮重发（gas 价格可能已经变化）
 			if sendState.IsWaitingForConfirmation() {
+				resubmit.Reset(strategy.Duration(resubmissions))
+				continue
+			}
+			resubmissions++
+			resubmit.Reset(strategy.Duration(resubmissions))
+			if bumpingStopped.Load() {
 				continue
 			}
 			wg.Add(1)
 
 			go sendTxAsync()
 
+		case <-notInMempool:
+			if publishCount.Load() == 0 && len(watched) == 0 {
+				l.Error("ContractsCaller no transaction published before timeout", "timeout", m.cfg.TxNotInMempoolTimeout)
+				abort(AbortReasonNotInMempool, ErrTxNotInMempool)
+			}
+
 		case <-ctxc.Done():
+			abortMu.Lock()
+			err := abortErr
+			abortMu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			if errors.Is(context.Cause(ctxc), ErrSendTimeout) {
+				m.cfg.Metrics.RecordAbort(AbortReasonSendTimeout)
+				return nil, ErrSendTimeout
+			}
 			return nil, ctxc.Err()
 		// 一旦收到回执，说明交易成功，直接返回
-		case receipt := <-receiptChan:
-			return receipt, nil
+		case mined := <-receiptChan:
+			// 在 Send 返回前完成记账、指标和回调
+			if m.cfg.Budget != nil {
+				if err := m.cfg.Budget.Spend(ctx, receiptTxCost(mined.tx, mined.receipt)); err != nil {
+					l.Warn("ContractsCaller unable to record transaction cost", "txHash", mined.receipt.TxHash, "err", err)
+				}
+			}
+			m.cfg.Metrics.RecordTimeToConfirmed(time.Since(start))
+			if bumps := publishCount.Load() - 1; bumps >= 0 {
+				m.cfg.Metrics.RecordBumps(int(bumps))
+			}
+			m.onConfirmed(ctx, mined.tx, mined.receipt)
+
+			if m.cfg.FailOnRevert && mined.receipt.Status == types.ReceiptStatusFailed {
+				return nil, m.revertError(ctx, mined.tx, mined.receipt)
+			}
+			return mined.receipt, nil
 		}
 	}
 }
 
+// 记录该 nonce 最近一次发布的交易
+func (m *SimpleTxManager) recordPublished(tx *types.Transaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.published[tx.Nonce()] = tx
+	m.publishedAt[tx.Nonce()] = time.Now()
+}
+
+// 交易确认后清理对应 nonce 的记录
+func (m *SimpleTxManager) forgetPublished(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.published, nonce)
+	delete(m.publishedAt, nonce)
+}
+
+func (m *SimpleTxManager) lastPublished(nonce uint64) *types.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.published[nonce]
+}
+
+// 检查交易费用是否超出配置的上限
+func (m *SimpleTxManager) checkFeeCeiling(tx *types.Transaction) error {
+	if m.cfg.MaxGasFeeCap != nil && tx.GasFeeCap().Cmp(m.cfg.MaxGasFeeCap) > 0 {
+		return &ErrFeeCeilingExceeded{Field: "gasFeeCap", Fee: tx.GasFeeCap(), Ceiling: m.cfg.MaxGasFeeCap}
+	}
+	if m.cfg.MaxGasTipCap != nil && tx.GasTipCap().Cmp(m.cfg.MaxGasTipCap) > 0 {
+		return &ErrFeeCeilingExceeded{Field: "gasTipCap", Fee: tx.GasTipCap(), Ceiling: m.cfg.MaxGasTipCap}
+	}
+	return nil
+}
+
 func WaitMined(
 	ctx context.Context,
 	backend ReceiptSource,
@@ -178,7 +510,7 @@ func WaitMined(
 	queryInterval time.Duration,
 	numConfirmations uint64,
 ) (*types.Receipt, error) {
-	return waitMined(ctx, backend, tx, queryInterval, numConfirmations, nil)
+	return waitMined(ctx, backend, tx, queryInterval, numConfirmations, nil, nil, log.Root(), false)
 }
 
 func waitMined(
@@ -188,13 +520,32 @@ func waitMined(
 	queryInterval time.Duration, // 每隔多久轮训一次链上交易回执
 	numConfirmations uint64, // 要求的确认区块数
 	sendState *SendState, // 状态记录器，用于控制是否继续重发
+	onMined func(*types.Receipt), // 交易首次被观察到上链时回调，可为 nil
+	l log.Logger, // 日志
+	useNewHeads bool, // 后端支持时改为在每个新区块到来时检查回执
 ) (*types.Receipt, error) {
 	// 创建轮询定时器
 
 	queryTicker := time.NewTicker(queryInterval)
 	defer queryTicker.Stop()
+	poll := queryTicker.C
+
+	// 订阅成功后由新区块触发检查，订阅失败或中断时退回定时轮询
+	var heads chan *types.Header
+	var subErr <-chan error
+	if useNewHeads {
+		if sub, err := subscribeNewHeads(ctx, backend); err == nil {
+			defer sub.Unsubscribe()
+			heads = sub.heads
+			subErr = sub.Err()
+			poll = nil
+		} else {
+			l.Debug("ContractsCaller unable to subscribe to new heads, polling instead", "err", err)
+		}
+	}
 
 	txHash := tx.Hash()
+	mined := false
 
 	for {
 		// 查询交易是否已经上链（mined）
@@ -205,6 +556,10 @@ func waitMined(
 			if sendState != nil {
 				sendState.TxMined(txHash)
 			}
+			if !mined && onMined != nil {
+				onMined(receipt)
+			}
+			mined = true
 
 			// 拿到交易所在的区块高度
 			txHeight := receipt.BlockNumber.Uint64()
@@ -212,43 +567,64 @@ func waitMined(
 			tipHeight, err := backend.BlockNumber(ctx)
 
 			if err != nil {
-				log.Error("ContractsCaller Unable to fetch block number", "err", err)
+				l.Error("ContractsCaller Unable to fetch block number", "err", err)
 				break
 			}
 
-			log.Trace("ContractsCaller Transaction mined, checking confirmations",
+			l.Trace("ContractsCaller Transaction mined, checking confirmations",
 				"txHash", txHash, "txHeight", txHeight,
 				"tipHeight", tipHeight,
 				"numConfirmations", numConfirmations)
 
 			// 判断是否已经获取足够确认数
 			if txHeight+numConfirmations <= tipHeight+1 {
-				log.Debug("ContractsCaller Transaction confirmed", "txHash", txHash)
+				// 返回前确认交易所在区块没有被重组掉
+				reorged, err := receiptReorged(ctx, backend, receipt)
+				if err != nil {
+					l.Warn("ContractsCaller unable to verify confirmed receipt", "txHash", txHash, "err", err)
+					break
+				}
+				if reorged {
+					l.Warn("ContractsCaller transaction reorged out before confirmation", "txHash", txHash,
+						"blockHash", receipt.BlockHash, "blockNumber", txHeight)
+					mined = false
+					if sendState != nil {
+						sendState.TxNotMined(txHash)
+					}
+					break
+				}
+				l.Debug("ContractsCaller Transaction confirmed", "txHash", txHash)
 				return receipt, nil
 			}
 
 			// 计算还差几个确认才满足条件，打印日志
 			confsRemaining := (txHeight + numConfirmations) - (tipHeight + 1)
-			log.Info("ContractsCaller Transaction not yet confirmed", "txHash", txHash,
+			l.Info("ContractsCaller Transaction not yet confirmed", "txHash", txHash,
 				"confsRemaining", confsRemaining)
 
 		case err != nil:
-			log.Trace("ContractsCaller Receipt retrieve failed", "hash", txHash,
+			l.Trace("ContractsCaller Receipt retrieve failed", "hash", txHash,
 				"err", err)
 
 		default:
 			// 交易还没有被打包
+			mined = false
 			if sendState != nil {
 				// 通知 SendState 这笔交易还未上链
 				sendState.TxNotMined(txHash)
 			}
-			log.Trace("ContractsCaller Transaction not yet mined", "hash", txHash)
+			l.Trace("ContractsCaller Transaction not yet mined", "hash", txHash)
 		}
 
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-queryTicker.C:
+		case <-poll:
+		case <-heads:
+		case err := <-subErr:
+			l.Warn("ContractsCaller new heads subscription failed, polling instead", "err", err)
+			heads, subErr = nil, nil
+			poll = queryTicker.C
 		}
 
 	}
@@ -261,3 +637,16 @@ func CalcGasFeeCap(baseFee, gasTipCap *big.Int) *big.Int {
 		new(big.Int).Mul(baseFee, big.NewInt(2)),
 	)
 }
+
+// 替换同 nonce 交易时节点要求的最小提价百分比
+const priceBumpPercent = 10
+
+// 按 priceBumpPercent 提价，结果至少比原值大 1
+func BumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+priceBumpPercent))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(fee) <= 0 {
+		bumped.Add(fee, big.NewInt(1))
+	}
+	return bumped
+}
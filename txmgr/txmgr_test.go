@@ -1,17 +1,23 @@
 package txmgr_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"math/big"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/WJX2001/contract-caller/txmgr"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/stretchr/testify/require"
 )
 
@@ -108,11 +114,18 @@ type mockBackend struct {
 	mu          sync.RWMutex
 	blockHeight uint64
 	minedTxs    map[common.Hash]minedTxInfo // 存储哪些交易已经上链，以及他们在哪个区块上链
+	gasPrice    *big.Int                    // eth_gasPrice 返回的建议价格
+	dropSends   bool                        // 为 true 时广播的交易不会被打包
+	revertData  string                      // 非空时交易执行失败，eth_call 返回该 revert data
+	nonce       uint64                      // eth_getTransactionCount(pending) 返回的 nonce
+	gasEstimate uint64                      // eth_estimateGas 返回的 gas
 }
 
 func newMockBackend() *mockBackend {
 	return &mockBackend{
-		minedTxs: make(map[common.Hash]minedTxInfo),
+		minedTxs:    make(map[common.Hash]minedTxInfo),
+		gasPrice:    big.NewInt(100),
+		gasEstimate: 21_000,
 	}
 }
 
@@ -150,13 +163,83 @@ func (b *mockBackend) TransactionReceipt(
 		return nil, nil
 	}
 
+	status := types.ReceiptStatusSuccessful
+	if b.revertData != "" {
+		status = types.ReceiptStatusFailed
+	}
 	return &types.Receipt{
 		TxHash:      txHash,
+		Status:      status,
 		GasUsed:     txInfo.gasFeeCap.Uint64(),
 		BlockNumber: big.NewInt(int64(txInfo.blockNumber)),
 	}, nil
 }
 
+// 带 revert data 的 eth_call 错误
+type revertError struct {
+	data string
+}
+
+func (e *revertError) Error() string          { return "execution reverted" }
+func (e *revertError) ErrorCode() int         { return 3 }
+func (e *revertError) ErrorData() interface{} { return e.data }
+
+func (b *mockBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.revertData != "" {
+		return nil, &revertError{data: b.revertData}
+	}
+	return nil, nil
+}
+
+// 模拟广播交易，交易会被立即打包
+func (b *mockBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mu.RLock()
+	dropSends := b.dropSends
+	b.mu.RUnlock()
+	if dropSends {
+		return nil
+	}
+
+	txHash := tx.Hash()
+	b.mine(&txHash, tx.GasFeeCap())
+	return nil
+}
+
+func (b *mockBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return new(big.Int).Set(b.gasPrice), nil
+}
+
+// 只有已打包的交易能查到，模拟未进入 mempool 的交易
+func (b *mockBackend) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if _, ok := b.minedTxs[txHash]; !ok {
+		return nil, false, ethereum.NotFound
+	}
+	return nil, false, nil
+}
+
+func (b *mockBackend) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.gasEstimate, nil
+}
+
+func (b *mockBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.nonce, nil
+}
+
 // 测试模块是否能在最低 gas价格下 成功发送确认交易
 
 func TestTxMgrConfirmAtMinGasPrice(t *testing.T) {
@@ -306,6 +389,57 @@ func TestTxMgrConfirmsMinGasPriceAfterBumping(t *testing.T) {
 	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), receipt.GasUsed)
 }
 
+// 记录每次请求的重发间隔
+type recordingStrategy struct {
+	mu       sync.Mutex
+	attempts []int
+}
+
+func (r *recordingStrategy) Duration(attempt int) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts = append(r.attempts, attempt)
+	return time.Duration(attempt+1) * 100 * time.Millisecond
+}
+
+// 测试 重发间隔由 ResubmissionStrategy 决定，每次重发后按次数递增
+func TestTxMgrUsesResubmissionStrategy(t *testing.T) {
+	t.Parallel()
+
+	strategy := &recordingStrategy{}
+	cfg := configWithNumConfs(1)
+	cfg.ResubmissionTimeout = time.Hour
+	cfg.ResubmissionStrategy = strategy
+	h := newTestHarnessWithConfig(cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if h.gasPricer.shouldMine(tx.GasFeeCap()) {
+			txHash := tx.Hash()
+			h.backend.mine(&txHash, tx.GasFeeCap())
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+
+	strategy.mu.Lock()
+	defer strategy.mu.Unlock()
+	require.GreaterOrEqual(t, len(strategy.attempts), 3)
+	require.Equal(t, []int{0, 1, 2}, strategy.attempts[:3])
+}
+
 // 测试一个极其重要的边界条件，即使收到 nonce too low, 只要有交易上链，TxManager 也不应该终止发送流程
 func TestTxMgrDoesntAbortNonceTooLowAfterMiningTx(t *testing.T) {
 
@@ -345,21 +479,1461 @@ func TestTxMgrDoesntAbortNonceTooLowAfterMiningTx(t *testing.T) {
 	require.Equal(t, h.gasPricer.expGasFeeCap().Uint64(), receipt.GasUsed)
 }
 
-// 测试验证 当交易一开始就被挖出来时， WaitMined 会立刻成功返回交易回执
-func TestWaitMinedReturnsReceiptOnFirstSuccess(t *testing.T) {
+// 测试 提价后的交易费用超过配置上限时，Send 停止提价，已发布的交易超时仍未上链则返回 ErrFeeCeilingExceeded
+func TestTxMgrAbortsWhenFeeExceedsCeiling(t *testing.T) {
 	t.Parallel()
 
-	h := newTestHarness()
+	cfg := configWithNumConfs(1)
+	gasPricer := newGasPricer(3)
+	_, cfg.MaxGasFeeCap = gasPricer.feesForEpoch(1)
+	h := newTestHarnessWithConfig(cfg)
 
-	tx := types.NewTx(&types.LegacyTx{}) // 创建一个旧版的交易
-	txHash := tx.Hash()
-	h.backend.mine(&txHash, new(big.Int))
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Nil(t, receipt)
+
+	var ceilingErr *txmgr.ErrFeeCeilingExceeded
+	require.ErrorAs(t, err, &ceilingErr)
+	require.Equal(t, "gasFeeCap", ceilingErr.Field)
+	require.Equal(t, cfg.MaxGasFeeCap, ceilingErr.Ceiling)
+}
+
+// 测试 费用超出上限时已发布的交易仍会被等待，上链后 Send 返回其回执而不是错误
+func TestTxMgrWaitsForPublishedTxAfterFeeCeiling(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.ResubmissionTimeout = 100 * time.Millisecond
+	gasPricer := newGasPricer(3)
+	_, cfg.MaxGasFeeCap = gasPricer.feesForEpoch(1)
+	h := newTestHarnessWithConfig(cfg)
+	h.backend.dropSends = true
+
+	var published atomic.Pointer[types.Transaction]
+	var publishes atomic.Int32
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		published.Store(tx)
+		publishes.Add(1)
+		return nil
+	}
+
+	// 第二次提价超出上限后再让第一笔交易上链
+	time.AfterFunc(500*time.Millisecond, func() {
+		tx := published.Load()
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, published.Load().Hash(), receipt.TxHash)
+	require.Equal(t, int32(1), publishes.Load())
+}
+
+// 测试 legacy 模式下 txmgr 自行构造 LegacyTx，并在每次重发时按 10% 提价
+func TestTxMgrLegacyModeBumpsGasPrice(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.TxType = txmgr.LegacyTxType
+	cfg.Signer = func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		return tx, nil
+	}
+	h := newTestHarnessWithConfig(cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			Nonce:     7,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+		}), nil
+	}
+
+	// 建议价格 100，第三次发送时应为 100 -> 110 -> 121
+	expGasPrice := big.NewInt(121)
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		require.Equal(t, uint8(types.LegacyTxType), tx.Type())
+		require.Equal(t, uint64(7), tx.Nonce())
+		if tx.GasPrice().Cmp(expGasPrice) == 0 {
+			txHash := tx.Hash()
+			h.backend.mine(&txHash, tx.GasPrice())
+		}
+		return nil
+	}
 
 	ctx := context.Background()
-	receipt, err := txmgr.WaitMined(ctx, h.backend, tx, 50*time.Millisecond, 1)
+	receipt, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
 	require.Nil(t, err)
 	require.NotNil(t, receipt)
-	require.Equal(t, receipt.TxHash, txHash)
+	require.Equal(t, expGasPrice.Uint64(), receipt.GasUsed)
+}
+
+// 测试 legacy 模式下带 access list 的候选交易以 EIP-2930 交易发送
+func TestTxMgrLegacyModeKeepsAccessList(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.TxType = txmgr.LegacyTxType
+	cfg.Signer = func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		return tx, nil
+	}
+	h := newTestHarnessWithConfig(cfg)
+
+	accessList := types.AccessList{{
+		Address:     common.HexToAddress("0xabc"),
+		StorageKeys: []common.Hash{common.HexToHash("0x01")},
+	}}
+	updateGasPrice := txmgr.WithAccessList(func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     7,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+		}), nil
+	}, accessList, cfg.Signer)
+
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		require.Equal(t, uint8(types.AccessListTxType), tx.Type())
+		require.Equal(t, accessList, tx.AccessList())
+		require.Equal(t, big.NewInt(100), tx.GasPrice())
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasPrice())
+		return nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+}
+
+// 测试 Cancel 会以提价后的费用发送同 nonce 的自转账，并返回替换交易的回执
+func TestTxMgrCancelReplacesStuckTx(t *testing.T) {
+	t.Parallel()
+
+	from := common.HexToAddress("0xabc")
+	cfg := configWithNumConfs(1)
+	cfg.From = from
+	var cancelTx *types.Transaction
+	cfg.Signer = func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		cancelTx = tx
+		return tx, nil
+	}
+	h := newTestHarnessWithConfig(cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			Nonce:     3,
+			GasTipCap: big.NewInt(5),
+			GasFeeCap: big.NewInt(19),
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return nil
+	}
+
+	// 先让一笔交易卡住
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	receipt, err := h.mgr.Cancel(context.Background(), 3)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, cancelTx.Hash(), receipt.TxHash)
+	require.Equal(t, uint64(3), cancelTx.Nonce())
+	require.Equal(t, from, *cancelTx.To())
+	require.Equal(t, 0, cancelTx.Value().Sign())
+	require.Equal(t, big.NewInt(6), cancelTx.GasTipCap())
+	require.Equal(t, big.NewInt(20), cancelTx.GasFeeCap())
+}
+
+// 测试 Cancel 等待期间原交易先上链时，返回原交易的回执
+func TestTxMgrCancelReturnsOriginalIfMinedFirst(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.Signer = func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		return tx, nil
+	}
+	h := newTestHarnessWithConfig(cfg)
+	h.backend.dropSends = true
+
+	var original atomic.Pointer[types.Transaction]
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			Nonce:     3,
+			GasTipCap: big.NewInt(5),
+			GasFeeCap: big.NewInt(19),
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		original.Store(tx)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err := h.mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	time.AfterFunc(200*time.Millisecond, func() {
+		txHash := original.Load().Hash()
+		h.backend.mine(&txHash, original.Load().GasFeeCap())
+	})
+
+	receipt, err := h.mgr.Cancel(context.Background(), 3)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, original.Load().Hash(), receipt.TxHash)
+}
+
+// 测试 legacy 模式下 Cancel 直接构造 LegacyTx，gasPrice 取节点建议价格
+func TestTxMgrLegacyModeCancel(t *testing.T) {
+	t.Parallel()
+
+	from := common.HexToAddress("0xabc")
+	cfg := configWithNumConfs(1)
+	cfg.TxType = txmgr.LegacyTxType
+	cfg.From = from
+	var signed []*types.Transaction
+	var mu sync.Mutex
+	cfg.Signer = func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		signed = append(signed, tx)
+		return tx, nil
+	}
+	h := newTestHarnessWithConfig(cfg)
+
+	receipt, err := h.mgr.Cancel(context.Background(), 4)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, signed, 1)
+	require.Equal(t, uint8(types.LegacyTxType), signed[0].Type())
+	require.Equal(t, uint64(4), signed[0].Nonce())
+	require.Equal(t, from, *signed[0].To())
+	require.Equal(t, big.NewInt(100), signed[0].GasPrice())
+	require.Equal(t, signed[0].Hash(), receipt.TxHash)
+}
+
+func newReplaceTestHarness() *testHarness {
+	cfg := configWithNumConfs(1)
+	cfg.Signer = func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		return tx, nil
+	}
+	return newTestHarnessWithConfig(cfg)
+}
+
+// 测试 Replace 使用新的调用数据和提价后的费用替换原交易
+func TestTxMgrReplaceWithNewPayload(t *testing.T) {
+	t.Parallel()
+
+	h := newReplaceTestHarness()
+	to := common.HexToAddress("0xdef")
+	oldTx := types.NewTx(&types.DynamicFeeTx{
+		Nonce:     9,
+		GasTipCap: big.NewInt(10),
+		GasFeeCap: big.NewInt(100),
+		Gas:       50_000,
+		To:        &to,
+		Data:      []byte{0x01},
+	})
+
+	receipt, err := h.mgr.Replace(context.Background(), oldTx, []byte{0x02})
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.NotEqual(t, oldTx.Hash(), receipt.TxHash)
+	require.Equal(t, uint64(110), receipt.GasUsed)
+}
+
+// 测试 Replace 等待期间原交易先上链时，直接返回原交易的回执
+func TestTxMgrReplaceReturnsOldTxIfMinedFirst(t *testing.T) {
+	t.Parallel()
+
+	h := newReplaceTestHarness()
+	h.backend.dropSends = true
+	oldTx := types.NewTx(&types.DynamicFeeTx{
+		Nonce:     9,
+		GasTipCap: big.NewInt(10),
+		GasFeeCap: big.NewInt(100),
+	})
+	time.AfterFunc(200*time.Millisecond, func() {
+		txHash := oldTx.Hash()
+		h.backend.mine(&txHash, oldTx.GasFeeCap())
+	})
+
+	receipt, err := h.mgr.Replace(context.Background(), oldTx, nil)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, oldTx.Hash(), receipt.TxHash)
+}
+
+// 记录 txmgr 上报的指标
+type recordingMetrics struct {
+	mu              sync.Mutex
+	publishAttempts int
+	bumps           []int
+	mined           int
+	confirmed       int
+	aborts          []string
+}
+
+func (r *recordingMetrics) RecordPublishAttempt() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.publishAttempts++
+}
+
+func (r *recordingMetrics) RecordBumps(bumps int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bumps = append(r.bumps, bumps)
+}
+
+func (r *recordingMetrics) RecordTimeToMined(time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mined++
+}
+
+func (r *recordingMetrics) RecordTimeToConfirmed(time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.confirmed++
+}
+
+func (r *recordingMetrics) RecordNonceTooLow() {}
+
+func (r *recordingMetrics) RecordAbort(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aborts = append(r.aborts, reason)
+}
+
+// 测试 Send 在提价后确认时上报发布次数、提价次数和耗时指标
+func TestTxMgrRecordsMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := &recordingMetrics{}
+	cfg := configWithNumConfs(1)
+	cfg.Metrics = metrics
+	h := newTestHarnessWithConfig(cfg)
+	gasPricer := newGasPricer(2)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if gasPricer.shouldMine(tx.GasFeeCap()) {
+			txHash := tx.Hash()
+			h.backend.mine(&txHash, tx.GasFeeCap())
+		}
+		return nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	require.Equal(t, 2, metrics.publishAttempts)
+	require.Equal(t, []int{1}, metrics.bumps)
+	require.Equal(t, 1, metrics.mined)
+	require.Equal(t, 1, metrics.confirmed)
+	require.Empty(t, metrics.aborts)
+}
+
+// 测试 开启 FailOnRevert 后，执行失败的交易返回带回滚原因的 ErrTxReverted
+func TestTxMgrReturnsRevertReason(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.FailOnRevert = true
+	h := newTestHarnessWithConfig(cfg)
+	// Error("request fulfilled") 的 ABI 编码
+	h.backend.revertData = "0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000011" +
+		"726571756573742066756c66696c6c6564000000000000000000000000000000"
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, receipt)
+
+	var revertErr *txmgr.ErrTxReverted
+	require.ErrorAs(t, err, &revertErr)
+	require.Equal(t, "request fulfilled", revertErr.Reason)
+	require.Equal(t, types.ReceiptStatusFailed, revertErr.Receipt.Status)
+}
+
+// 测试 演练模式下不广播交易，返回按 eth_estimateGas 构造的合成回执
+func TestTxMgrDryRun(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.DryRun = true
+	h := newTestHarnessWithConfig(cfg)
+	h.backend.gasEstimate = 42_000
+
+	var tx *types.Transaction
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(2),
+			Gas:       100_000,
+		})
+		return tx, nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		t.Fatal("dry run must not broadcast")
+		return nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.Equal(t, tx.Hash(), receipt.TxHash)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	require.Equal(t, uint64(42_000), receipt.GasUsed)
+	require.Equal(t, uint64(1), receipt.BlockNumber.Uint64())
+}
+
+// 测试 演练模式下 eth_call 回滚时返回带原因的 ErrTxReverted
+func TestTxMgrDryRunReverts(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.DryRun = true
+	h := newTestHarnessWithConfig(cfg)
+	// Error("request fulfilled") 的 ABI 编码
+	h.backend.revertData = "0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000011" +
+		"726571756573742066756c66696c6c6564000000000000000000000000000000"
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(2),
+		}), nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, h.backend.SendTransaction)
+	require.Nil(t, receipt)
+
+	var revertErr *txmgr.ErrTxReverted
+	require.ErrorAs(t, err, &revertErr)
+	require.Equal(t, "request fulfilled", revertErr.Reason)
+	require.Nil(t, revertErr.Receipt)
+	require.Empty(t, h.backend.minedTxs)
+}
+
+// 测试 Queue 按提交顺序分配连续 nonce，且在途交易数量不超过上限
+func TestQueueAssignsSequentialNonces(t *testing.T) {
+	t.Parallel()
+
+	const maxPending = 2
+
+	h := newTestHarness()
+	h.backend.nonce = 5
+	queue := txmgr.NewQueue(h.mgr, h.backend, common.Address{}, maxPending)
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+	build := func(ctx context.Context, nonce uint64) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > peak {
+			peak = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return nil
+	}
+
+	ctx := context.Background()
+	var futures []*txmgr.Future
+	var hashes []common.Hash
+	for i := 0; i < 5; i++ {
+		future, err := queue.Send(ctx, build, sendTx)
+		require.Nil(t, err)
+		futures = append(futures, future)
+		tx, _ := build(ctx, uint64(5+i))
+		hashes = append(hashes, tx.Hash())
+	}
+	queue.Wait()
+
+	for i, future := range futures {
+		receipt, err := future.Wait(ctx)
+		require.Nil(t, err)
+		require.Equal(t, hashes[i], receipt.TxHash)
+	}
+	require.LessOrEqual(t, peak, maxPending)
+}
+
+// 测试 提价后确认的交易依次触发 OnPublished、OnBumped、OnMined、OnConfirmed
+func TestTxMgrLifecycleHooks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu        sync.Mutex
+		published []common.Hash
+		bumped    [][2]common.Hash
+		mined     []common.Hash
+		confirmed []common.Hash
+	)
+	cfg := configWithNumConfs(1)
+	cfg.OnPublished = func(ctx context.Context, tx *types.Transaction) {
+		mu.Lock()
+		defer mu.Unlock()
+		published = append(published, tx.Hash())
+	}
+	cfg.OnBumped = func(ctx context.Context, oldTx, newTx *types.Transaction) {
+		mu.Lock()
+		defer mu.Unlock()
+		bumped = append(bumped, [2]common.Hash{oldTx.Hash(), newTx.Hash()})
+	}
+	cfg.OnMined = func(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) {
+		mu.Lock()
+		defer mu.Unlock()
+		mined = append(mined, receipt.TxHash)
+	}
+	cfg.OnConfirmed = func(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) {
+		mu.Lock()
+		defer mu.Unlock()
+		confirmed = append(confirmed, receipt.TxHash)
+	}
+	h := newTestHarnessWithConfig(cfg)
+	gasPricer := newGasPricer(2)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if gasPricer.shouldMine(tx.GasFeeCap()) {
+			txHash := tx.Hash()
+			h.backend.mine(&txHash, tx.GasFeeCap())
+		}
+		return nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, published, 2)
+	require.Equal(t, [][2]common.Hash{{published[0], published[1]}}, bumped)
+	require.Equal(t, []common.Hash{receipt.TxHash}, mined)
+	require.Equal(t, []common.Hash{receipt.TxHash}, confirmed)
+}
+
+// 测试 余额不足时 Send 立即终止并返回带分类的 SendError
+func TestTxMgrAbortsOnInsufficientFunds(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return core.ErrInsufficientFunds
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, receipt)
+
+	var sendErr *txmgr.SendError
+	require.ErrorAs(t, err, &sendErr)
+	require.Equal(t, txmgr.SendErrorInsufficientFunds, sendErr.Kind)
+	require.ErrorIs(t, err, core.ErrInsufficientFunds)
+}
+
+// 测试 节点返回 already known 时按发布成功处理，继续等待交易上链
+func TestTxMgrTreatsAlreadyKnownAsPublished(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+		return errors.New("already known")
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+}
+
+// 测试 主节点拒绝交易时，由 BroadcastTo 中的节点广播成功即可上链
+func TestTxMgrBroadcastsToExtraEndpoints(t *testing.T) {
+	t.Parallel()
+
+	var extraCalls atomic.Int32
+	h := newTestHarness()
+	cfg := configWithNumConfs(1)
+	cfg.BroadcastTo = []txmgr.SendTransactionFunc{
+		func(ctx context.Context, tx *types.Transaction) error {
+			extraCalls.Add(1)
+			txHash := tx.Hash()
+			h.backend.mine(&txHash, tx.GasFeeCap())
+			return nil
+		},
+	}
+	h.mgr = txmgr.NewSimpleTxManager(cfg, h.backend)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return errRpcFailure
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, int32(1), extraCalls.Load())
+}
+
+// 测试 Send 超时返回后仍未上链的交易，会被卡住交易检测重新广播
+func TestTxMgrMonitorRebroadcastsStuckTx(t *testing.T) {
+	t.Parallel()
+
+	var publishedHash atomic.Value
+	cfg := configWithNumConfs(1)
+	cfg.ResubmissionTimeout = time.Minute
+	cfg.StuckTxThreshold = 100 * time.Millisecond
+	cfg.StuckTxCheckInterval = 50 * time.Millisecond
+	cfg.OnPublished = func(ctx context.Context, tx *types.Transaction) {
+		publishedHash.Store(tx.Hash())
+	}
+	h := newTestHarnessWithConfig(cfg)
+	h.backend.dropSends = true
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	sendCtx, sendCancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer sendCancel()
+	_, err := h.mgr.Send(sendCtx, updateGasPrice, h.backend.SendTransaction)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 交易被 mempool 丢弃，节点恢复后由检测任务重新广播
+	h.backend.mu.Lock()
+	h.backend.dropSends = false
+	h.backend.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.mgr.(*txmgr.SimpleTxManager).MonitorStuckTxs(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	txHash := publishedHash.Load().(common.Hash)
+	require.Eventually(t, func() bool {
+		receipt, _ := h.backend.TransactionReceipt(context.Background(), txHash)
+		return receipt != nil
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+// 测试 开启 ReestimateGas 后按重新估算并放大后的 gas limit 发布交易
+func TestTxMgrReestimatesGasLimit(t *testing.T) {
+	t.Parallel()
+
+	var publishedGas atomic.Uint64
+	cfg := configWithNumConfs(1)
+	cfg.ReestimateGas = true
+	cfg.GasLimitMultiplier = 1.2
+	cfg.Signer = func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		return tx, nil
+	}
+	cfg.OnPublished = func(ctx context.Context, tx *types.Transaction) {
+		publishedGas.Store(tx.Gas())
+	}
+	h := newTestHarnessWithConfig(cfg)
+	h.backend.gasEstimate = 50_000
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			Gas:       21_000,
+		}), nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, h.backend.SendTransaction)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, uint64(60_000), publishedGas.Load())
+}
+
+// 测试 UpdateGasPriceFunc 返回的费用低于下限时被抬到下限，且 gasFeeCap 不低于 gasTipCap
+func TestTxMgrAppliesFeeFloors(t *testing.T) {
+	t.Parallel()
+
+	var published atomic.Pointer[types.Transaction]
+	cfg := configWithNumConfs(1)
+	cfg.MinGasTipCap = big.NewInt(50)
+	cfg.MinGasFeeCap = big.NewInt(40)
+	cfg.Signer = func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		return tx, nil
+	}
+	cfg.OnPublished = func(ctx context.Context, tx *types.Transaction) {
+		published.Store(tx)
+	}
+	h := newTestHarnessWithConfig(cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(2),
+		}), nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, h.backend.SendTransaction)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, big.NewInt(50), published.Load().GasTipCap())
+	require.Equal(t, big.NewInt(50), published.Load().GasFeeCap())
+}
+
+// 测试 预算耗尽后 Send 不再发布交易并返回 ErrBudgetExceeded
+func TestTxMgrBudgetExceeded(t *testing.T) {
+	t.Parallel()
+
+	budget := txmgr.NewWindowBudget(time.Hour, big.NewInt(10))
+	cfg := configWithNumConfs(1)
+	cfg.Budget = budget
+	h := newTestHarnessWithConfig(cfg)
+
+	// 最大花费 10 * 1，实际花费 gasUsed(1) * gasFeeCap(1)
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+			Gas:       10,
+		}), nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, h.backend.SendTransaction)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, big.NewInt(1), budget.Spent())
+
+	receipt, err = h.mgr.Send(context.Background(), updateGasPrice, h.backend.SendTransaction)
+	require.Nil(t, receipt)
+	var budgetErr *txmgr.ErrBudgetExceeded
+	require.ErrorAs(t, err, &budgetErr)
+	require.Equal(t, big.NewInt(1), budgetErr.Spent)
+	require.Equal(t, big.NewInt(10), budgetErr.Cost)
+}
+
+// 并发安全的日志缓冲
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// 测试 Send 使用注入的日志，并在每条日志上带上 ctx 中的关联 ID
+func TestTxMgrLogsCorrelationID(t *testing.T) {
+	t.Parallel()
+
+	var out syncBuffer
+	cfg := configWithNumConfs(1)
+	cfg.Logger = log.NewLogger(log.NewTerminalHandlerWithLevel(&out, log.LevelTrace, false))
+	var hookID string
+	cfg.OnConfirmed = func(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) {
+		hookID = txmgr.CorrelationID(ctx)
+	}
+	h := newTestHarnessWithConfig(cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	ctx := txmgr.WithCorrelationID(context.Background(), "request-42")
+	receipt, err := h.mgr.Send(ctx, updateGasPrice, h.backend.SendTransaction)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, "request-42", hookID)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		require.Contains(t, line, "correlationId=request-42")
+	}
+}
+
+func batchBuild(ctx context.Context, nonce uint64) (*types.Transaction, error) {
+	return types.NewTx(&types.DynamicFeeTx{
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+	}), nil
+}
+
+// 测试 SendBatch 从 pending nonce 开始分配连续 nonce，并按顺序返回回执
+func TestTxMgrSendBatch(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+	h.backend.nonce = 3
+
+	builds := []txmgr.BuildTxFunc{batchBuild, batchBuild, batchBuild}
+	receipts, err := h.mgr.SendBatch(context.Background(), builds, h.backend.SendTransaction)
+	require.Nil(t, err)
+	require.Len(t, receipts, 3)
+	for i, receipt := range receipts {
+		tx, _ := batchBuild(context.Background(), uint64(3+i))
+		require.Equal(t, tx.Hash(), receipt.TxHash)
+	}
+}
+
+// 测试 SendBatch 中前面的交易失败时取消后续交易并返回失败位置
+func TestTxMgrSendBatchFailsFast(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+	failing := func(ctx context.Context, nonce uint64) (*types.Transaction, error) {
+		return nil, errRpcFailure
+	}
+	// 最后一笔交易永远不会被打包，只能被取消
+	pending := func(ctx context.Context, nonce uint64) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(2),
+			GasFeeCap: big.NewInt(2),
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		if tx.GasFeeCap().Cmp(big.NewInt(1)) == 0 {
+			return h.backend.SendTransaction(ctx, tx)
+		}
+		return nil
+	}
+
+	builds := []txmgr.BuildTxFunc{batchBuild, failing, pending}
+	receipts, err := h.mgr.SendBatch(context.Background(), builds, sendTx)
+	require.Len(t, receipts, 1)
+
+	var batchErr *txmgr.ErrBatchItemFailed
+	require.ErrorAs(t, err, &batchErr)
+	require.Equal(t, 1, batchErr.Index)
+	require.Equal(t, uint64(1), batchErr.Nonce)
+	require.ErrorIs(t, err, errRpcFailure)
+}
+
+// 测试 NonceTracker 跳过已预留的 nonce，归还后复用，链上 pending nonce 更高时以链上为准
+func TestNonceTrackerReserve(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+	h.backend.nonce = 5
+	nonces := h.mgr.(*txmgr.SimpleTxManager).Nonces()
+	ctx := context.Background()
+
+	for _, want := range []uint64{5, 6} {
+		nonce, err := nonces.Reserve(ctx)
+		require.Nil(t, err)
+		require.Equal(t, want, nonce)
+	}
+	nonces.Release(6)
+	nonce, err := nonces.Reserve(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint64(6), nonce)
+
+	h.backend.mu.Lock()
+	h.backend.nonce = 10
+	h.backend.mu.Unlock()
+	nonce, err = nonces.Reserve(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint64(10), nonce)
+}
+
+// 测试多个发送方同时预留时，归还中间的 nonce 形成空洞并被复用，在途预留存在时 Reset 不会回到链上 pending nonce
+func TestNonceTrackerReusesReleasedGaps(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+	h.backend.nonce = 5
+	nonces := h.mgr.(*txmgr.SimpleTxManager).Nonces()
+	ctx := context.Background()
+
+	for _, want := range []uint64{5, 6, 7} {
+		nonce, err := nonces.Reserve(ctx)
+		require.Nil(t, err)
+		require.Equal(t, want, nonce)
+	}
+	// 6 没有广播，7 仍在途
+	nonces.Release(6)
+	nonce, err := nonces.Reserve(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint64(6), nonce)
+
+	// 5 发送失败，6、7 仍在途，不能回到链上 pending nonce 5
+	nonces.Done(5)
+	nonces.Reset()
+	nonce, err = nonces.Reserve(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint64(8), nonce)
+
+	// 链上 pending nonce 超过的空洞被丢弃
+	nonces.Release(6)
+	h.backend.mu.Lock()
+	h.backend.nonce = 7
+	h.backend.mu.Unlock()
+	nonce, err = nonces.Reserve(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint64(9), nonce)
+
+	// 在途预留全部结束后 Reset 以链上 pending nonce 为准
+	for _, n := range []uint64{7, 8, 9} {
+		nonces.Done(n)
+	}
+	nonces.Reset()
+	nonce, err = nonces.Reserve(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint64(7), nonce)
+}
+
+// 测试 ReleaseIfLast 只在没有后续预留时归还 nonce，避免在在途交易前留下空洞
+func TestNonceTrackerReleaseIfLast(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+	h.backend.nonce = 5
+	nonces := h.mgr.(*txmgr.SimpleTxManager).Nonces()
+	ctx := context.Background()
+
+	for _, want := range []uint64{5, 6} {
+		nonce, err := nonces.Reserve(ctx)
+		require.Nil(t, err)
+		require.Equal(t, want, nonce)
+	}
+	// 6 仍在途，5 不能归还
+	require.False(t, nonces.ReleaseIfLast(5))
+	nonce, err := nonces.Reserve(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint64(7), nonce)
+
+	// 7 是最后一个预留，归还后分配位置回退
+	require.True(t, nonces.ReleaseIfLast(7))
+	nonce, err = nonces.Reserve(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint64(7), nonce)
+}
+
+// 测试 SendBatch 与 NonceTracker 共用预留，不会复用已经分配出去的 nonce
+func TestTxMgrSendBatchSkipsReservedNonces(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+	h.backend.nonce = 3
+	reserved, err := h.mgr.(*txmgr.SimpleTxManager).Nonces().Reserve(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, uint64(3), reserved)
+
+	builds := []txmgr.BuildTxFunc{batchBuild, batchBuild}
+	receipts, err := h.mgr.SendBatch(context.Background(), builds, h.backend.SendTransaction)
+	require.Nil(t, err)
+	for i, receipt := range receipts {
+		tx, _ := batchBuild(context.Background(), uint64(4+i))
+		require.Equal(t, tx.Hash(), receipt.TxHash)
+	}
+}
+
+// 支持 newHeads 订阅的后端，出块时推送区块头
+type newHeadsBackend struct {
+	*mockBackend
+	feed event.Feed
+}
+
+func (b *newHeadsBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return b.feed.Subscribe(ch), nil
+}
+
+func (b *newHeadsBackend) mine(txHash *common.Hash, gasFeeCap *big.Int) {
+	b.mockBackend.mine(txHash, gasFeeCap)
+	height, _ := b.BlockNumber(context.Background())
+	b.feed.Send(&types.Header{Number: new(big.Int).SetUint64(height)})
+}
+
+// 测试 开启 SubscribeNewHeads 后由新区块触发回执检查，不依赖轮询间隔
+func TestTxMgrWaitsForReceiptOnNewHeads(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.ReceiptQueryInterval = time.Minute
+	cfg.SubscribeNewHeads = true
+	backend := &newHeadsBackend{mockBackend: newMockBackend()}
+	mgr := txmgr.NewSimpleTxManager(cfg, backend)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		// 发布后稍晚出块，此时 waitMined 已完成首次检查
+		txHash := tx.Hash()
+		time.AfterFunc(200*time.Millisecond, func() {
+			backend.mine(&txHash, tx.GasFeeCap())
+		})
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := mgr.Send(ctx, updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+}
+
+// 测试 ActiveSends 列出进行中的 Send，Send 返回后注销
+func TestTxMgrActiveSends(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+
+	published := make(chan *types.Transaction, 1)
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		select {
+		case published <- tx:
+		default:
+		}
+		return nil
+	}
+
+	ctx := txmgr.WithCorrelationID(context.Background(), "request-1")
+	future := h.mgr.SendAsync(ctx, updateGasPrice, sendTx)
+	tx := <-published
+
+	require.Eventually(t, func() bool {
+		active := h.mgr.ActiveSends()
+		return len(active) == 1 && len(active[0].State.PublishedTxs) == 1
+	}, time.Second, 10*time.Millisecond)
+	active := h.mgr.ActiveSends()
+	require.Equal(t, "request-1", active[0].CorrelationID)
+	require.Equal(t, tx.Hash(), active[0].State.PublishedTxs[0])
+	require.False(t, active[0].State.WaitingForConfirmation)
+
+	txHash := tx.Hash()
+	h.backend.mine(&txHash, tx.GasFeeCap())
+	_, err := future.Wait(context.Background())
+	require.Nil(t, err)
+	require.Empty(t, h.mgr.ActiveSends())
+}
+
+// 测试 SendAsync 立即返回，交易确认后通过 Future 拿到回执
+func TestTxMgrSendAsync(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+	release := make(chan struct{})
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		<-release
+		txHash := tx.Hash()
+		h.backend.mine(&txHash, tx.GasFeeCap())
+		return nil
+	}
+
+	future := h.mgr.SendAsync(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, future.Receipt())
+	require.Nil(t, future.Err())
+
+	close(release)
+	select {
+	case <-future.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("SendAsync did not complete")
+	}
+	require.Nil(t, future.Err())
+	require.NotNil(t, future.Receipt())
+}
+
+// 测试 交易迟迟不上链时 Send 在 TxSendTimeout 后返回 ErrSendTimeout
+func TestTxMgrSendTimeout(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.TxSendTimeout = 200 * time.Millisecond
+	h := newTestHarnessWithConfig(cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, receipt)
+	require.ErrorIs(t, err, txmgr.ErrSendTimeout)
+}
+
+// 测试 一直发布失败时 Send 在 TxNotInMempoolTimeout 后返回 ErrTxNotInMempool
+func TestTxMgrGivesUpWhenNeverPublished(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.TxNotInMempoolTimeout = 200 * time.Millisecond
+	h := newTestHarnessWithConfig(cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		return errRpcFailure
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, receipt)
+	require.ErrorIs(t, err, txmgr.ErrTxNotInMempool)
+}
+
+// 测试 已发布的交易在节点上查不到时会被原样重新广播
+func TestTxMgrRebroadcastsTxMissingFromMempool(t *testing.T) {
+	t.Parallel()
+
+	cfg := configWithNumConfs(1)
+	cfg.ResubmissionTimeout = time.Minute
+	cfg.TxNotInMempoolTimeout = 100 * time.Millisecond
+	h := newTestHarnessWithConfig(cfg)
+
+	updateGasPrice := func(ctx context.Context) (*types.Transaction, error) {
+		gasTipCap, gasFeeCap := h.gasPricer.sample()
+		return types.NewTx(&types.DynamicFeeTx{
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+		}), nil
+	}
+
+	var mu sync.Mutex
+	sent := make(map[common.Hash]int)
+	sendTx := func(ctx context.Context, tx *types.Transaction) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// 第一次广播被丢弃，重新广播后才被打包
+		txHash := tx.Hash()
+		sent[txHash]++
+		if sent[txHash] > 1 {
+			h.backend.mine(&txHash, tx.GasFeeCap())
+		}
+		return nil
+	}
+
+	receipt, err := h.mgr.Send(context.Background(), updateGasPrice, sendTx)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, sent, 1)
+	require.Equal(t, 2, sent[receipt.TxHash])
+}
+
+type feeHistoryBackend struct {
+	history *ethereum.FeeHistory
+}
+
+func (b *feeHistoryBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return b.history, nil
+}
+
+// 测试 GasPricer 取小费中位数，并按下一个区块的 baseFee 计算 gasFeeCap
+func TestGasPricerSuggestFees(t *testing.T) {
+	t.Parallel()
+
+	backend := &feeHistoryBackend{history: &ethereum.FeeHistory{
+		Reward: [][]*big.Int{
+			{big.NewInt(3)},
+			{big.NewInt(1)},
+			{big.NewInt(2)},
+		},
+		BaseFee: []*big.Int{big.NewInt(10), big.NewInt(11), big.NewInt(12), big.NewInt(13)},
+	}}
+	pricer := txmgr.NewGasPricer(txmgr.GasPricerConfig{
+		BlockCount:        3,
+		TipPercentile:     50,
+		BaseFeeMultiplier: 1.5,
+	}, backend)
+
+	gasTipCap, gasFeeCap, err := pricer.SuggestFees(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(2), gasTipCap)
+	// 13 * 1.5 + 2
+	require.Equal(t, big.NewInt(21), gasFeeCap)
+}
+
+// 测试 GasPricer 生成的 UpdateGasPriceFunc 在价格不变时每次重发都会提价
+func TestGasPricerBumpsOnResubmit(t *testing.T) {
+	t.Parallel()
+
+	backend := &feeHistoryBackend{history: &ethereum.FeeHistory{
+		Reward:  [][]*big.Int{{big.NewInt(100)}},
+		BaseFee: []*big.Int{big.NewInt(100), big.NewInt(100)},
+	}}
+	pricer := txmgr.NewGasPricer(txmgr.DefaultGasPricerConfig, backend)
+	candidate := types.NewTx(&types.DynamicFeeTx{Nonce: 7, Gas: 21000})
+	signer := func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		return tx, nil
+	}
+
+	updateGasPrice := pricer.UpdateGasPriceFunc(candidate, signer)
+	tx, err := updateGasPrice(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, uint64(7), tx.Nonce())
+	require.Equal(t, big.NewInt(100), tx.GasTipCap())
+	require.Equal(t, big.NewInt(300), tx.GasFeeCap())
+
+	tx, err = updateGasPrice(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(110), tx.GasTipCap())
+	require.Equal(t, big.NewInt(330), tx.GasFeeCap())
+}
+
+func TestBumpFee(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, big.NewInt(110), txmgr.BumpFee(big.NewInt(100)))
+	require.Equal(t, big.NewInt(2), txmgr.BumpFee(big.NewInt(1)))
+}
+
+// 测试验证 当交易一开始就被挖出来时， WaitMined 会立刻成功返回交易回执
+func TestWaitMinedReturnsReceiptOnFirstSuccess(t *testing.T) {
+	t.Parallel()
+
+	h := newTestHarness()
+
+	tx := types.NewTx(&types.LegacyTx{}) // 创建一个旧版的交易
+	txHash := tx.Hash()
+	h.backend.mine(&txHash, new(big.Int))
+
+	ctx := context.Background()
+	receipt, err := txmgr.WaitMined(ctx, h.backend, tx, 50*time.Millisecond, 1)
+	require.Nil(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, receipt.TxHash, txHash)
+}
+
+// 回执在确认后被重组掉一次，之后重新打包进另一个区块
+type reorgBackend struct {
+	*mockBackend
+	calls atomic.Int32
+}
+
+var (
+	orphanedBlockHash = common.HexToHash("0xaa")
+	canonicalHeader   = &types.Header{Number: big.NewInt(1), Extra: []byte("canonical")}
+)
+
+func (b *reorgBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := b.mockBackend.TransactionReceipt(ctx, txHash)
+	if receipt == nil || err != nil {
+		return receipt, err
+	}
+	switch b.calls.Add(1) {
+	case 1:
+		receipt.BlockHash = orphanedBlockHash
+	case 2:
+		return nil, ethereum.NotFound
+	default:
+		receipt.BlockHash = canonicalHeader.Hash()
+	}
+	return receipt, nil
+}
+
+// 测试 确认数满足后回执消失时 WaitMined 继续等待，并返回重新打包后的回执
+func TestWaitMinedIgnoresReorgedReceipt(t *testing.T) {
+	t.Parallel()
+
+	backend := &reorgBackend{mockBackend: newMockBackend()}
+	tx := types.NewTx(&types.LegacyTx{})
+	txHash := tx.Hash()
+	backend.mine(&txHash, new(big.Int))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := txmgr.WaitMined(ctx, backend, tx, 50*time.Millisecond, 1)
+	require.Nil(t, err)
+	require.Equal(t, canonicalHeader.Hash(), receipt.BlockHash)
+	require.Equal(t, int32(4), backend.calls.Load())
+}
+
+// 规范链区块头固定为 canonicalHeader
+type canonicalBackend struct {
+	*mockBackend
+	blockHash common.Hash
+}
+
+func (b *canonicalBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := b.mockBackend.TransactionReceipt(ctx, txHash)
+	if receipt != nil {
+		b.mu.RLock()
+		receipt.BlockHash = b.blockHash
+		b.mu.RUnlock()
+	}
+	return receipt, err
+}
+
+func (b *canonicalBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return canonicalHeader, nil
+}
+
+// 测试 回执所在区块不在规范链上时不视为确认
+func TestWaitMinedChecksCanonicalBlock(t *testing.T) {
+	t.Parallel()
+
+	backend := &canonicalBackend{mockBackend: newMockBackend(), blockHash: orphanedBlockHash}
+	tx := types.NewTx(&types.LegacyTx{})
+	txHash := tx.Hash()
+	backend.mine(&txHash, new(big.Int))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	receipt, err := txmgr.WaitMined(ctx, backend, tx, 50*time.Millisecond, 1)
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Nil(t, receipt)
+
+	backend.mu.Lock()
+	backend.blockHash = canonicalHeader.Hash()
+	backend.mu.Unlock()
+
+	receipt, err = txmgr.WaitMined(context.Background(), backend, tx, 50*time.Millisecond, 1)
+	require.Nil(t, err)
+	require.Equal(t, canonicalHeader.Hash(), receipt.BlockHash)
 }
 
 // 测试 WaitMined 方法在等待交易上链期间，如果 context 超时取消了，应该正确返回context.DeadlineExceeded 错误，并且不会返回任何交易回执（receipt）。
@@ -0,0 +1,225 @@
+package vrf_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/WJX2001/contract-caller/vrf"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+var order = crypto.S256().Params().N
+
+// 社区 secp256k1_sha256_tai suite 的测试向量，来自 github.com/vechain/go-ecvrf（MIT）
+type knownAnswer struct {
+	Sk    string `json:"sk"`
+	Pk    string `json:"pk"`
+	Alpha string `json:"alpha"`
+	Pi    string `json:"pi"`
+	Beta  string `json:"beta"`
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func newTestKey(t *testing.T, d int64) *vrf.PrivateKey {
+	key, err := vrf.PrivateKeyFromBytes(math.U256Bytes(big.NewInt(d)))
+	require.NoError(t, err)
+	return key
+}
+
+// 按 Gamma || c || s 拼接 pi_string
+func encodeProof(gamma []byte, c, s *big.Int) []byte {
+	proof := append([]byte{}, gamma...)
+	proof = append(proof, c.FillBytes(make([]byte, 16))...)
+	return append(proof, s.FillBytes(make([]byte, 32))...)
+}
+
+func TestProveVerifyRoundTrip(t *testing.T) {
+	key, err := vrf.GenerateKey()
+	require.NoError(t, err)
+	alpha := []byte("request seed")
+
+	proof, beta, err := key.Prove(alpha)
+	require.NoError(t, err)
+	require.Len(t, beta, 32)
+
+	verified, err := key.PublicKey().Verify(alpha, proof)
+	require.NoError(t, err)
+	require.Equal(t, beta, verified)
+
+	decoded, err := vrf.DecodeProof(proof.Bytes())
+	require.NoError(t, err)
+	verified, err = key.PublicKey().Verify(alpha, decoded)
+	require.NoError(t, err)
+	require.Equal(t, beta, verified)
+
+	// 同一私钥和输入得到同一个证明
+	again, _, err := key.Prove(alpha)
+	require.NoError(t, err)
+	require.Equal(t, proof.Bytes(), again.Bytes())
+}
+
+func TestVerifyRejectsTamperedProof(t *testing.T) {
+	key := newTestKey(t, 12345)
+	alpha := []byte("request seed")
+	proof, _, err := key.Prove(alpha)
+	require.NoError(t, err)
+	other, _, err := key.Prove([]byte("another seed"))
+	require.NoError(t, err)
+	pi := proof.Bytes()
+
+	tampered := map[string][]byte{
+		"gamma": append(append([]byte{}, other.Bytes()[:33]...), pi[33:]...),
+		"c":     encodeProof(pi[:33], new(big.Int).Add(proof.C, big.NewInt(1)), proof.S),
+		"s":     encodeProof(pi[:33], proof.C, new(big.Int).Add(proof.S, big.NewInt(1))),
+	}
+	for name, b := range tampered {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := vrf.DecodeProof(b)
+			require.NoError(t, err)
+			_, err = key.PublicKey().Verify(alpha, decoded)
+			require.ErrorIs(t, err, vrf.ErrInvalidProof)
+		})
+	}
+
+	t.Run("alpha", func(t *testing.T) {
+		_, err := key.PublicKey().Verify([]byte("another seed"), proof)
+		require.ErrorIs(t, err, vrf.ErrInvalidProof)
+	})
+	t.Run("public key", func(t *testing.T) {
+		_, err := newTestKey(t, 54321).PublicKey().Verify(alpha, proof)
+		require.ErrorIs(t, err, vrf.ErrInvalidProof)
+	})
+}
+
+func TestKnownAnswerVectors(t *testing.T) {
+	content, err := os.ReadFile("testdata/secp256k1_sha256_tai.json")
+	require.NoError(t, err)
+	var vectors []knownAnswer
+	require.NoError(t, json.Unmarshal(content, &vectors))
+	require.NotEmpty(t, vectors)
+
+	for _, vector := range vectors {
+		t.Run(vector.Sk, func(t *testing.T) {
+			key, err := vrf.PrivateKeyFromBytes(math.U256Bytes(new(big.Int).SetBytes(decodeHex(t, vector.Sk))))
+			require.NoError(t, err)
+			require.Equal(t, decodeHex(t, vector.Pk), key.PublicKey().Bytes())
+
+			alpha := decodeHex(t, vector.Alpha)
+			proof, beta, err := key.Prove(alpha)
+			require.NoError(t, err)
+			require.Equal(t, vector.Pi, hex.EncodeToString(proof.Bytes()))
+			require.Equal(t, vector.Beta, hex.EncodeToString(beta))
+
+			publicKey, err := vrf.PublicKeyFromBytes(decodeHex(t, vector.Pk))
+			require.NoError(t, err)
+			decoded, err := vrf.DecodeProof(decodeHex(t, vector.Pi))
+			require.NoError(t, err)
+			verified, err := publicKey.Verify(alpha, decoded)
+			require.NoError(t, err)
+			require.Equal(t, vector.Beta, hex.EncodeToString(verified))
+		})
+	}
+}
+
+// s*B 与 c*Y 相同时 U 为无穷远点，互为相反数时计算 U 需要倍点，两种证明都必须被拒绝而不是得到错误的点
+func TestVerifyRejectsDegenerateWitnesses(t *testing.T) {
+	const d = 12345
+	key := newTestKey(t, d)
+	alpha := []byte("request seed")
+	proof, _, err := key.Prove(alpha)
+	require.NoError(t, err)
+	gamma := proof.Bytes()[:33]
+
+	c := big.NewInt(7)
+	cd := new(big.Int).Mul(c, big.NewInt(d))
+	cases := map[string]*big.Int{
+		"identity": new(big.Int).Mod(cd, order),
+		"doubling": new(big.Int).Mod(new(big.Int).Neg(cd), order),
+		"zero s":   new(big.Int),
+	}
+	for name, s := range cases {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := vrf.DecodeProof(encodeProof(gamma, c, s))
+			require.NoError(t, err)
+			_, err = key.PublicKey().Verify(alpha, decoded)
+			require.ErrorIs(t, err, vrf.ErrInvalidProof)
+		})
+	}
+}
+
+// 链上验证合约的参数
+const verifierAbi = `[{"type":"function","name":"verify","stateMutability":"view","inputs":[
+	{"name":"publicKey","type":"uint256[2]"},
+	{"name":"gamma","type":"uint256[2]"},
+	{"name":"c","type":"uint256"},
+	{"name":"s","type":"uint256"},
+	{"name":"alpha","type":"bytes"},
+	{"name":"hPoint","type":"uint256[2]"},
+	{"name":"uPoint","type":"uint256[2]"},
+	{"name":"vPoint","type":"uint256[2]"}
+],"outputs":[{"name":"","type":"bool"}]}]`
+
+func TestMarshalABI(t *testing.T) {
+	key := newTestKey(t, 12345)
+	publicKey := key.PublicKey()
+	alpha := []byte("request seed")
+	proof, _, err := key.Prove(alpha)
+	require.NoError(t, err)
+
+	encoded, err := proof.MarshalABI(publicKey, alpha)
+	require.NoError(t, err)
+
+	// 静态部分 13 个字，alpha 以偏移量引用，长度和数据紧随其后
+	word := func(i int) *big.Int {
+		return new(big.Int).SetBytes(encoded[32*i : 32*(i+1)])
+	}
+	pkX, pkY := publicKey.Coordinates()
+	gammaX, gammaY := proof.Gamma()
+	require.Equal(t, pkX, word(0))
+	require.Equal(t, pkY, word(1))
+	require.Equal(t, gammaX, word(2))
+	require.Equal(t, gammaY, word(3))
+	require.Equal(t, proof.C, word(4))
+	require.Equal(t, proof.S, word(5))
+	require.Equal(t, big.NewInt(13*32), word(6))
+	require.Equal(t, big.NewInt(int64(len(alpha))), word(13))
+	require.Equal(t, alpha, encoded[14*32:14*32+len(alpha)])
+
+	parsed, err := abi.JSON(strings.NewReader(verifierAbi))
+	require.NoError(t, err)
+	packed, err := parsed.Pack("verify", [2]*big.Int{pkX, pkY}, [2]*big.Int{gammaX, gammaY}, proof.C, proof.S, alpha,
+		[2]*big.Int{word(7), word(8)}, [2]*big.Int{word(9), word(10)}, [2]*big.Int{word(11), word(12)})
+	require.NoError(t, err)
+	require.Equal(t, packed[4:], encoded)
+
+	// 见证点满足 U = s*B - c*Y、V = s*H - c*Gamma
+	curve := crypto.S256()
+	args, err := parsed.Methods["verify"].Inputs.Unpack(encoded)
+	require.NoError(t, err)
+	h, u, v := args[5].([2]*big.Int), args[6].([2]*big.Int), args[7].([2]*big.Int)
+	require.True(t, curve.IsOnCurve(h[0], h[1]))
+
+	sBx, sBy := curve.ScalarBaseMult(proof.S.Bytes())
+	cYx, cYy := curve.ScalarMult(pkX, pkY, proof.C.Bytes())
+	ux, uy := curve.Add(u[0], u[1], cYx, cYy)
+	require.Equal(t, sBx, ux)
+	require.Equal(t, sBy, uy)
+
+	sHx, sHy := curve.ScalarMult(h[0], h[1], proof.S.Bytes())
+	cGx, cGy := curve.ScalarMult(gammaX, gammaY, proof.C.Bytes())
+	vx, vy := curve.Add(v[0], v[1], cGx, cGy)
+	require.Equal(t, sHx, vx)
+	require.Equal(t, sHy, vy)
+}
@@ -0,0 +1,103 @@
+package vrf
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+/*
+	VRF 密钥管理：
+		- 私钥为 secp256k1 上的标量，与调用者的交易签名私钥分开保管，轮换时链上验证合约需要同步登记新的公钥
+		- 密钥文件保存十六进制编码的私钥，写入时权限为 0600 且不覆盖已有文件
+		- 公钥以 SEC1 压缩格式（33 字节）发布，链上验证使用其坐标
+*/
+
+type PrivateKey struct {
+	d *big.Int
+}
+
+type PublicKey struct {
+	point point
+}
+
+func GenerateKey() (*PrivateKey, error) {
+	for {
+		b := make([]byte, qLen)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		if key, err := PrivateKeyFromBytes(b); err == nil {
+			return key, nil
+		}
+	}
+}
+
+// 从 32 字节大端编码的标量构造私钥
+func PrivateKeyFromBytes(b []byte) (*PrivateKey, error) {
+	if len(b) != qLen {
+		return nil, fmt.Errorf("vrf: invalid private key length %d", len(b))
+	}
+	d := new(big.Int).SetBytes(b)
+	if d.Sign() == 0 || d.Cmp(order) >= 0 {
+		return nil, errors.New("vrf: private key out of range")
+	}
+	return &PrivateKey{d: d}, nil
+}
+
+func (k *PrivateKey) Bytes() []byte {
+	return int2octets(k.d)
+}
+
+func (k *PrivateKey) PublicKey() *PublicKey {
+	return &PublicKey{point: baseMul(k.d)}
+}
+
+// 解码 SEC1 压缩格式的公钥
+func PublicKeyFromBytes(b []byte) (*PublicKey, error) {
+	p, err := pointFromBytes(b)
+	if err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return &PublicKey{point: p}, nil
+}
+
+// SEC1 压缩格式
+func (pk *PublicKey) Bytes() []byte {
+	return pk.point.bytes()
+}
+
+func (pk *PublicKey) Coordinates() (*big.Int, *big.Int) {
+	return pk.point.x, pk.point.y
+}
+
+// 读取十六进制编码的私钥文件
+func LoadKeyFile(path string) (*PrivateKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := hexutil.Decode(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("vrf: invalid key file %s: %w", path, err)
+	}
+	return PrivateKeyFromBytes(b)
+}
+
+// 把私钥写入新文件，文件已存在时返回错误
+func SaveKeyFile(path string, key *PrivateKey) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(hexutil.Encode(key.Bytes()) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,92 @@
+package vrf
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+/*
+	证明的序列化：
+		- Bytes / DecodeProof：RFC 9381 的 pi_string，Gamma（33 字节压缩点）|| c（16 字节）|| s（32 字节）
+		- MarshalABI：供链上验证合约使用的 ABI 编码，点展开为 uint256[2] 坐标，并附带 H、U = s*B - c*Y、V = s*H - c*Gamma 三个见证点
+		  验证合约检查见证点与 proof 一致（可借助 ecrecover 校验标量乘法）后按 challenge 的定义重新计算 c，避免在链上做完整的标量乘法和 hash_to_curve
+*/
+
+type Proof struct {
+	gamma point
+	C     *big.Int
+	S     *big.Int
+	h     point // hash_to_curve(Y, alpha)，解码得到的证明在 Verify 之前为空
+}
+
+// pi_string 编码
+func (p *Proof) Bytes() []byte {
+	buf := make([]byte, 0, ProofLen)
+	buf = append(buf, p.gamma.bytes()...)
+	buf = append(buf, p.C.FillBytes(make([]byte, cLen))...)
+	return append(buf, p.S.FillBytes(make([]byte, qLen))...)
+}
+
+// 解码 pi_string
+func DecodeProof(b []byte) (*Proof, error) {
+	if len(b) != ProofLen {
+		return nil, fmt.Errorf("vrf: invalid proof length %d", len(b))
+	}
+	gamma, err := pointFromBytes(b[:ptLen])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	return &Proof{
+		gamma: gamma,
+		C:     new(big.Int).SetBytes(b[ptLen : ptLen+cLen]),
+		S:     new(big.Int).SetBytes(b[ptLen+cLen:]),
+	}, nil
+}
+
+// ECVRF_proof_to_hash，cofactor 为 1
+func (p *Proof) Hash() []byte {
+	beta := sha256.Sum256(concat([]byte{suiteString, 0x03}, p.gamma.bytes()))
+	return beta[:]
+}
+
+// Gamma 的坐标
+func (p *Proof) Gamma() (*big.Int, *big.Int) {
+	return p.gamma.x, p.gamma.y
+}
+
+var (
+	uint256Pair, _ = abi.NewType("uint256[2]", "", nil)
+	uint256Type, _ = abi.NewType("uint256", "", nil)
+	bytesType, _   = abi.NewType("bytes", "", nil)
+
+	proofArguments = abi.Arguments{
+		{Name: "publicKey", Type: uint256Pair},
+		{Name: "gamma", Type: uint256Pair},
+		{Name: "c", Type: uint256Type},
+		{Name: "s", Type: uint256Type},
+		{Name: "alpha", Type: bytesType},
+		{Name: "hPoint", Type: uint256Pair},
+		{Name: "uPoint", Type: uint256Pair},
+		{Name: "vPoint", Type: uint256Pair},
+	}
+)
+
+// 按 (uint256[2] publicKey, uint256[2] gamma, uint256 c, uint256 s, bytes alpha, uint256[2] hPoint, uint256[2] uPoint, uint256[2] vPoint) 编码
+func (p *Proof) MarshalABI(pk *PublicKey, alpha []byte) ([]byte, error) {
+	if _, err := pk.Verify(alpha, p); err != nil {
+		return nil, err
+	}
+	u := baseMul(p.S).add(pk.point.mul(p.C).neg())
+	v := p.h.mul(p.S).add(p.gamma.mul(p.C).neg())
+	return proofArguments.Pack(
+		coordinates(pk.point), coordinates(p.gamma), p.C, p.S, alpha,
+		coordinates(p.h), coordinates(u), coordinates(v),
+	)
+}
+
+func coordinates(p point) [2]*big.Int {
+	return [2]*big.Int{p.x, p.y}
+}
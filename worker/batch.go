@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/WJX2001/contract-caller/driver"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	批量回填：
+		- 驱动引擎配置了 Multicall 聚合合约时，未处理的请求按 vrf_address 分组，同一组的请求通过聚合合约批量回填
+		- 组内的请求逐个检查并生成随机数，可以回填的请求置为 in_flight 后按 FulfillBatchSize 拆成多笔交易依次发送
+		- 每笔交易的结果对组内每个请求分别处理：失败时每个请求各自记录失败次数，成功时花费按交易中的请求数平摊
+		- 批量交易模拟执行回滚时没有广播，改为逐个回填，找出导致回滚的请求；RPC 失败等其他错误不逐个回填，按失败处理等待重试
+*/
+
+// 可以回填的请求及其随机数
+type preparedRequest struct {
+	request    worker.RequestSend
+	randomList []*big.Int
+}
+
+// 按 vrf_address 分组，保持请求的原有顺序
+func groupByVrfAddress(requests []worker.RequestSend) [][]worker.RequestSend {
+	index := make(map[common.Address]int)
+	var groups [][]worker.RequestSend
+	for _, request := range requests {
+		i, ok := index[request.VrfAddress]
+		if !ok {
+			i = len(groups)
+			index[request.VrfAddress] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], request)
+	}
+	return groups
+}
+
+// 批量回填同一个 VRF 合约或代理上的请求
+func (wk *Worker) processBatch(ctx context.Context, requests []worker.RequestSend, batchSize int) error {
+	var ready []preparedRequest
+	for _, request := range requests {
+		if ctx.Err() != nil {
+			break
+		}
+		request, randomList, ok, err := wk.prepareRequest(request)
+		if err != nil {
+			return err
+		}
+		if ok {
+			ready = append(ready, preparedRequest{request: request, randomList: randomList})
+		}
+	}
+
+	for start := 0; start < len(ready); start += batchSize {
+		chunk := ready[start:min(start+batchSize, len(ready))]
+		batch := make([]driver.FulfillRequest, 0, len(chunk))
+		for _, prepared := range chunk {
+			batch = append(batch, driver.FulfillRequest{RequestId: prepared.request.RequestId, RandomWords: prepared.randomList})
+		}
+		vrfAddress := chunk[0].request.VrfAddress
+		log.Info("batch fulfilling random words", "vrfAddress", vrfAddress, "requests", len(batch))
+		txReceipt, err := wk.deg.FulfillRandomWordsBatchAt(vrfAddress, batch)
+		var simErr *driver.ErrSimulationReverted
+		if errors.As(err, &simErr) && len(chunk) > 1 {
+			// 模拟执行回滚的批量交易没有广播，逐个回填，避免一个无效请求让同批的请求都记为失败
+			log.Warn("batch simulation reverted, fulfilling requests one by one", "vrfAddress", vrfAddress, "requests", len(chunk), "reason", simErr.Reason)
+			for _, prepared := range chunk {
+				txReceipt, err := wk.deg.FulfillRandomWordsAt(vrfAddress, prepared.request.RequestId, prepared.randomList)
+				if err := wk.finishRequest(prepared.request, prepared.randomList, txReceipt, err, 1); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for _, prepared := range chunk {
+			if err := wk.finishRequest(prepared.request, prepared.randomList, txReceipt, err, len(chunk)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
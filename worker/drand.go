@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	drand 随机信标（League of Entropy）作为随机数来源：
+		- 启动时通过 HTTP API 读取网络的 genesis_time 和 period，按请求所在区块的时间戳选择区块之后发布的第一轮，发起请求时该轮随机数尚不可知
+		- 该轮尚未发布时返回 ErrRandomnessNotReady；信标超时、服务端错误、查询区块头失败返回普通错误，工作器同样跳过该请求下一轮重试
+		- 校验 randomness = sha256(signature)；BLS 签名的验证需要网络公钥，轮次和签名随请求保存，可离线用 drand 工具验证
+		- 第 i 个随机数为 keccak256(randomness || vrfAddress || uint256(requestId) || uint256(i))，任何人可以用保存的轮次复现
+*/
+
+const (
+	defaultDrandUrl = "https://api.drand.sh"
+	drandTimeout    = 10 * time.Second
+)
+
+// 查询区块头，node.EthClient 满足该接口
+type HeaderSource interface {
+	BlockHeaderByNumber(*big.Int) (*types.Header, error)
+}
+
+type drandInfo struct {
+	Period      uint64 `json:"period"`
+	GenesisTime uint64 `json:"genesis_time"`
+	Hash        string `json:"hash"`
+}
+
+type drandBeacon struct {
+	Round      uint64 `json:"round"`
+	Randomness string `json:"randomness"`
+	Signature  string `json:"signature"`
+}
+
+type DrandRandomSource struct {
+	url     string
+	headers HeaderSource
+	http    *http.Client
+	info    drandInfo
+}
+
+// 连接 drand HTTP API 并读取网络参数，chainHash 为空时使用节点的默认网络
+func NewDrandRandomSource(ctx context.Context, url string, chainHash string, headers HeaderSource) (*DrandRandomSource, error) {
+	if headers == nil {
+		return nil, errors.New("drand random source requires a header source")
+	}
+	if url == "" {
+		url = defaultDrandUrl
+	}
+	url = strings.TrimRight(url, "/")
+	if chainHash != "" {
+		url += "/" + chainHash
+	}
+	s := &DrandRandomSource{url: url, headers: headers, http: &http.Client{Timeout: drandTimeout}}
+	if err := s.get(ctx, "/info", &s.info); err != nil {
+		return nil, fmt.Errorf("drand info: %w", err)
+	}
+	if s.info.Period == 0 || s.info.GenesisTime == 0 {
+		return nil, fmt.Errorf("drand info from %s has no period or genesis time", url)
+	}
+	log.Info("using drand random source", "url", url, "chainHash", s.info.Hash, "period", s.info.Period, "genesisTime", s.info.GenesisTime)
+	return s, nil
+}
+
+// 使用请求所在区块之后的第一轮随机数，轮次和签名记录在 request 上
+func (s *DrandRandomSource) RandomWords(ctx context.Context, request *worker.RequestSend) ([]*big.Int, error) {
+	header, err := s.headers.BlockHeaderByNumber(request.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("query request block %s: %w", request.BlockNumber, err)
+	}
+	round := s.roundAfter(header.Time)
+	if uint64(time.Now().Unix()) < s.roundTime(round) {
+		return nil, fmt.Errorf("%w: drand round %d is not published yet", ErrRandomnessNotReady, round)
+	}
+
+	var beacon drandBeacon
+	if err := s.get(ctx, fmt.Sprintf("/public/%d", round), &beacon); err != nil {
+		return nil, fmt.Errorf("drand round %d: %w", round, err)
+	}
+	randomness, err := hexutil.Decode("0x" + beacon.Randomness)
+	if err != nil {
+		return nil, fmt.Errorf("drand round %d has invalid randomness: %w", round, err)
+	}
+	signature, err := hexutil.Decode("0x" + beacon.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("drand round %d has invalid signature: %w", round, err)
+	}
+	if beacon.Round != round {
+		return nil, fmt.Errorf("drand returned round %d, expected %d", beacon.Round, round)
+	}
+	if digest := sha256.Sum256(signature); !bytes.Equal(digest[:], randomness) {
+		return nil, fmt.Errorf("drand round %d randomness does not match its signature", round)
+	}
+
+	numWords := int(request.NumWords.Int64())
+	words := make([]*big.Int, 0, numWords)
+	for i := 0; i < numWords; i++ {
+		word := crypto.Keccak256(randomness, request.VrfAddress.Bytes(), common.BigToHash(request.RequestId).Bytes(), common.BigToHash(big.NewInt(int64(i))).Bytes())
+		words = append(words, new(big.Int).SetBytes(word))
+	}
+	request.DrandRound = round
+	request.DrandSignature = beacon.Signature
+	return words, nil
+}
+
+// 时间 t 之后发布的第一轮
+func (s *DrandRandomSource) roundAfter(t uint64) uint64 {
+	if t < s.info.GenesisTime {
+		return 1
+	}
+	return (t-s.info.GenesisTime)/s.info.Period + 2
+}
+
+// 第 round 轮的发布时间
+func (s *DrandRandomSource) roundTime(round uint64) uint64 {
+	return s.info.GenesisTime + (round-1)*s.info.Period
+}
+
+func (s *DrandRandomSource) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrRandomnessNotReady
+	}
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
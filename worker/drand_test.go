@@ -0,0 +1,136 @@
+package worker_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dbworker "github.com/WJX2001/contract-caller/database/worker"
+	"github.com/WJX2001/contract-caller/worker"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	drandPeriod    = 30
+	drandChainHash = "8990e7a9"
+)
+
+// 请求所在区块的时间
+type blockTimes map[int64]uint64
+
+func (b blockTimes) BlockHeaderByNumber(number *big.Int) (*types.Header, error) {
+	t, ok := b[number.Int64()]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", number)
+	}
+	return &types.Header{Number: number, Time: t}, nil
+}
+
+// 模拟 drand HTTP API，beacons 中的轮次已发布，签名可以替换为与随机数不匹配的值
+type drandServer struct {
+	genesisTime uint64
+	beacons     map[uint64]string // 轮次 -> 十六进制签名
+	randomness  map[uint64]string // 覆盖轮次的随机数
+}
+
+func (s *drandServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/"+drandChainHash+"/info" {
+		_ = json.NewEncoder(w).Encode(map[string]any{"period": drandPeriod, "genesis_time": s.genesisTime, "hash": drandChainHash})
+		return
+	}
+	var round uint64
+	if _, err := fmt.Sscanf(r.URL.Path, "/"+drandChainHash+"/public/%d", &round); err != nil {
+		http.Error(w, "unknown path", http.StatusBadRequest)
+		return
+	}
+	signature, ok := s.beacons[round]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	randomness, ok := s.randomness[round]
+	if !ok {
+		decoded, _ := hex.DecodeString(signature)
+		digest := sha256.Sum256(decoded)
+		randomness = hex.EncodeToString(digest[:])
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"round": round, "randomness": randomness, "signature": signature})
+}
+
+func newDrandSource(t *testing.T, server *drandServer, headers worker.HeaderSource) *worker.DrandRandomSource {
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	source, err := worker.NewDrandRandomSource(context.Background(), httpServer.URL, drandChainHash, headers)
+	require.NoError(t, err)
+	return source
+}
+
+func drandRequest(blockNumber int64) *dbworker.RequestSend {
+	return &dbworker.RequestSend{
+		RequestId:   big.NewInt(7),
+		VrfAddress:  testVrfAddress,
+		NumWords:    big.NewInt(2),
+		BlockNumber: big.NewInt(blockNumber),
+	}
+}
+
+func TestDrandRandomSourceUsesFirstRoundAfterBlock(t *testing.T) {
+	genesis := uint64(time.Now().Unix()) - 1000
+	signature := hex.EncodeToString([]byte("round five signature"))
+	server := &drandServer{genesisTime: genesis, beacons: map[uint64]string{5: signature}}
+	// 区块在第 4 轮（genesis+90）之后出块，使用第 5 轮
+	source := newDrandSource(t, server, blockTimes{10: genesis + 95})
+
+	request := drandRequest(10)
+	words, err := source.RandomWords(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), request.DrandRound)
+	require.Equal(t, signature, request.DrandSignature)
+
+	decoded, _ := hex.DecodeString(signature)
+	randomness := sha256.Sum256(decoded)
+	require.Len(t, words, 2)
+	for i, word := range words {
+		want := crypto.Keccak256(randomness[:], testVrfAddress.Bytes(), common.BigToHash(request.RequestId).Bytes(), common.BigToHash(big.NewInt(int64(i))).Bytes())
+		require.Equal(t, new(big.Int).SetBytes(want), word)
+	}
+}
+
+func TestDrandRandomSourceNotReady(t *testing.T) {
+	now := uint64(time.Now().Unix())
+	server := &drandServer{genesisTime: now - 1000, beacons: map[uint64]string{}}
+	// 第 5 轮应当已经发布，但节点还没有返回
+	source := newDrandSource(t, server, blockTimes{10: now - 905, 11: now})
+
+	_, err := source.RandomWords(context.Background(), drandRequest(10))
+	require.ErrorIs(t, err, worker.ErrRandomnessNotReady)
+	// 区块之后的轮次还没有到发布时间
+	_, err = source.RandomWords(context.Background(), drandRequest(11))
+	require.ErrorIs(t, err, worker.ErrRandomnessNotReady)
+}
+
+func TestDrandRandomSourceRejectsMismatchedRandomness(t *testing.T) {
+	genesis := uint64(time.Now().Unix()) - 1000
+	server := &drandServer{
+		genesisTime: genesis,
+		beacons:     map[uint64]string{5: hex.EncodeToString([]byte("signature"))},
+		randomness:  map[uint64]string{5: hex.EncodeToString(make([]byte, 32))},
+	}
+	source := newDrandSource(t, server, blockTimes{10: genesis + 95})
+
+	request := drandRequest(10)
+	_, err := source.RandomWords(context.Background(), request)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, worker.ErrRandomnessNotReady)
+	require.Zero(t, request.DrandRound)
+}
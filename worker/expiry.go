@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	请求有效期：
+		- 配置 RequestTTL 后，请求事件所在区块的时间早于 now - RequestTTL 的请求标记为 expired，不再回填，避免长时间停机后回填已经没有意义的请求
+		- 旧数据没有区块时间时按写入数据库的时间计算
+		- 每个过期的请求记录指标，配置 ExpiredWebhookUrl 时以 JSON POST 通知，通知失败只记录日志
+		- 链上已回填的请求即使被标记为过期，也会由事件处理器根据 FillRandomWords 事件标记为 fulfilled
+*/
+
+// webhook 通知的超时
+const webhookTimeout = 10 * time.Second
+
+// 请求过期的 webhook 通知
+type RequestExpiredMessage struct {
+	Type           string `json:"type"`
+	RequestId      string `json:"requestId"`
+	VrfAddress     string `json:"vrfAddress"`
+	BlockNumber    string `json:"blockNumber"`
+	BlockTimestamp uint64 `json:"blockTimestamp"`
+	AgeSeconds     uint64 `json:"ageSeconds"`
+}
+
+// 请求超过有效期时标记为过期并返回 true
+func (wk *Worker) expireRequest(request worker.RequestSend) (bool, error) {
+	ttl := wk.workerConfig.RequestTTL
+	if ttl <= 0 {
+		return false, nil
+	}
+	createdAt := request.BlockTimestamp
+	if createdAt == 0 {
+		createdAt = request.Timestamp
+	}
+	now := uint64(time.Now().Unix())
+	if createdAt == 0 || now < createdAt || time.Duration(now-createdAt)*time.Second <= ttl {
+		return false, nil
+	}
+
+	age := now - createdAt
+	request.LastError = fmt.Sprintf("request older than ttl %s", ttl)
+	err := wk.db.RequestSend.MarkRequestSendExpired(request)
+	if errors.Is(err, worker.ErrRequestStatusConflict) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	log.Warn("request expired, not fulfilling", "requestId", request.RequestId, "vrfAddress", request.VrfAddress, "age", time.Duration(age)*time.Second, "ttl", ttl)
+	wk.metrics.RecordRequestExpired(request.VrfAddress)
+
+	if wk.workerConfig.ExpiredWebhookUrl != "" {
+		message := RequestExpiredMessage{
+			Type:           "request_expired",
+			RequestId:      request.RequestId.String(),
+			VrfAddress:     request.VrfAddress.String(),
+			BlockNumber:    request.BlockNumber.String(),
+			BlockTimestamp: request.BlockTimestamp,
+			AgeSeconds:     age,
+		}
+		if err := postWebhook(wk.resourceCtx, wk.workerConfig.ExpiredWebhookUrl, message); err != nil {
+			log.Warn("notify expired request webhook fail", "requestId", request.RequestId, "err", err)
+		}
+	}
+	return true, nil
+}
+
+// 以 JSON POST 一条通知，非 2xx 响应视为失败
+func postWebhook(ctx context.Context, url string, message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"errors"
+
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	发送中请求的恢复：
+		- 工作器发送回填交易前把请求置为 in_flight，每一轮开始时本进程没有正在发送的请求，此时仍为 in_flight 的请求来自发送过程中退出的进程，
+		  或发送失败时交易可能已经广播（发送超时等）而保留的请求
+		- 调用者还有已广播未上链的交易时不释放，等待交易上链或被丢弃
+		- 链上已回填的请求保持 in_flight，由事件处理器根据 FillRandomWords 事件标记为 fulfilled
+		- 其余请求的交易没有上链，恢复为 pending 重新回填，不计为失败
+*/
+
+// 释放上一次运行遗留的发送中请求
+func (wk *Worker) recoverInFlight() error {
+	requests, err := wk.db.RequestSend.QueryRequestSendListByStatus(worker.RequestSendInFlight)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+	pending, err := wk.deg.HasPendingTxs(wk.resourceCtx)
+	if err != nil {
+		log.Warn("query pending txs fail, keeping in-flight requests", "requests", len(requests), "err", err)
+		return nil
+	}
+	if pending {
+		log.Info("caller has pending txs, keeping in-flight requests", "requests", len(requests))
+		return nil
+	}
+
+	for _, request := range requests {
+		if wk.fulfilledOnChain(request.VrfAddress, request.RequestId) {
+			log.Info("in-flight request fulfilled on chain, waiting for fill event", "requestId", request.RequestId)
+			continue
+		}
+		err := wk.db.RequestSend.MarkRequestSendPending(request)
+		if errors.Is(err, worker.ErrRequestStatusConflict) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		log.Warn("released in-flight request without a mined tx", "requestId", request.RequestId, "vrfAddress", request.VrfAddress)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package worker_test
+
+import (
+	"math/big"
+	"testing"
+
+	dbworker "github.com/WJX2001/contract-caller/database/worker"
+	"github.com/WJX2001/contract-caller/worker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverInFlightReleasesUnminedRequests(t *testing.T) {
+	deg := newFakeDriver()
+	deg.onChain["2"] = true
+	wk, db := newTestWorker(t, deg, worker.WorkerConfig{})
+	storeRequests(t, db, dbworker.RequestSendInFlight, 1, 2)
+
+	require.NoError(t, wk.ProcessCallerVrf())
+
+	// 没有上链的请求恢复为 pending 后在同一轮回填，不计为失败
+	require.Equal(t, []*big.Int{big.NewInt(1)}, deg.fulfilledRequests())
+	fulfilled := requestsByStatus(t, db, dbworker.RequestSendFulfilled)
+	require.Len(t, fulfilled, 1)
+	require.Equal(t, big.NewInt(1), fulfilled[0].RequestId)
+	require.Zero(t, fulfilled[0].AttemptCount)
+	// 链上已回填的请求等待 FillRandomWords 事件
+	inFlight := requestsByStatus(t, db, dbworker.RequestSendInFlight)
+	require.Len(t, inFlight, 1)
+	require.Equal(t, big.NewInt(2), inFlight[0].RequestId)
+}
+
+func TestRecoverInFlightKeepsRequestsWithPendingTxs(t *testing.T) {
+	deg := newFakeDriver()
+	deg.pendingTxs = true
+	wk, db := newTestWorker(t, deg, worker.WorkerConfig{})
+	storeRequests(t, db, dbworker.RequestSendInFlight, 1)
+	storeRequests(t, db, dbworker.RequestSendPending, 2)
+
+	require.NoError(t, wk.ProcessCallerVrf())
+
+	// 调用者还有在途交易时不释放，pending 的请求照常回填
+	require.Equal(t, []*big.Int{big.NewInt(2)}, deg.fulfilledRequests())
+	inFlight := requestsByStatus(t, db, dbworker.RequestSendInFlight)
+	require.Len(t, inFlight, 1)
+	require.Equal(t, big.NewInt(1), inFlight[0].RequestId)
+}
+
+func TestRecoverInFlightAfterPossiblyBroadcastError(t *testing.T) {
+	deg := newFakeDriver()
+	deg.fulfillErr = errTimeout
+	deg.pendingTxs = true
+	wk, db := newTestWorker(t, deg, worker.WorkerConfig{})
+	storeRequests(t, db, dbworker.RequestSendPending, 1)
+
+	require.NoError(t, wk.ProcessCallerVrf())
+	require.Len(t, requestsByStatus(t, db, dbworker.RequestSendInFlight), 1)
+
+	// 交易仍在途时下一轮不会重复回填
+	require.NoError(t, wk.ProcessCallerVrf())
+	require.Len(t, deg.fulfilledRequests(), 1)
+
+	// 交易被丢弃后释放请求并重新回填
+	deg.fulfillErr = nil
+	deg.pendingTxs = false
+	require.NoError(t, wk.ProcessCallerVrf())
+	require.Len(t, deg.fulfilledRequests(), 2)
+	require.Len(t, requestsByStatus(t, db, dbworker.RequestSendFulfilled), 1)
+}
@@ -0,0 +1,49 @@
+package worker
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+/*
+	工作器的指标采集：
+		- 按 VRF 合约/代理累计超过有效期、不再回填的请求数
+	WorkerConfig.Metrics 为空时不采集，主程序通过 PrometheusMetrics.Collectors 注册到自己的 registry
+*/
+
+type Metrics interface {
+	RecordRequestExpired(vrfAddress common.Address) // 一个请求超过有效期被标记为过期
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) RecordRequestExpired(common.Address) {}
+
+var NoopMetrics Metrics = noopMetrics{}
+
+type PrometheusMetrics struct {
+	expired *prometheus.CounterVec
+}
+
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	const subsystem = "worker"
+	return &PrometheusMetrics{
+		expired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_expired_total",
+			Help:      "Requests marked expired instead of fulfilled because they exceeded the request ttl, by vrf contract",
+		}, []string{"contract"}),
+	}
+}
+
+// 返回全部指标，供主程序注册
+func (m *PrometheusMetrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.expired,
+	}
+}
+
+func (m *PrometheusMetrics) RecordRequestExpired(vrfAddress common.Address) {
+	m.expired.WithLabelValues(vrfAddress.Hex()).Inc()
+}
@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/WJX2001/contract-caller/vrf"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/*
+	回填使用的随机数来源：
+		- crypto：默认，通过 crypto/rand 生成均匀分布的 uint256
+		- hmac：以 HMAC-SHA256(key, vrfAddress || requestId || 序号) 生成，同一个请求的结果可以用密钥复现，便于审计；密钥泄露后结果可被预测
+		- ecvrf：以 VRF 私钥对 vrfAddress || requestId 生成 ECVRF 证明，第 i 个随机数为 keccak256(beta || i)，证明随请求保存，任何人可以用公钥验证
+		- drand：使用 drand 随机信标中请求所在区块之后的第一轮随机数，见 drand.go
+	来源通过配置选择，WorkerConfig.RandomSource 为 nil 时使用 crypto
+	来源可以在请求上记录审计信息（ECVRF 证明、drand 轮次和签名），工作器回填成功后随请求一起保存
+*/
+
+type RandomSource interface {
+	// 为请求生成 request.NumWords 个 uint256 随机数
+	RandomWords(ctx context.Context, request *worker.RequestSend) ([]*big.Int, error)
+}
+
+// 随机数暂时无法生成（如信标轮次尚未发布），工作器跳过该请求，下一轮重试
+var ErrRandomnessNotReady = errors.New("randomness not ready")
+
+var maxUint256 = new(big.Int).Lsh(big.NewInt(1), 256)
+
+type RandomSourceConfig struct {
+	Kind           string       // crypto、hmac、ecvrf 或 drand，空字符串为 crypto
+	Key            []byte       // hmac 的密钥或 ecvrf 的私钥
+	DrandUrl       string       // drand HTTP API 地址
+	DrandChainHash string       // drand 网络的 chain hash，为空时使用节点的默认网络
+	Headers        HeaderSource // 查询请求所在区块的时间戳，drand 使用
+}
+
+// 按配置创建随机数来源
+func NewRandomSource(ctx context.Context, cfg RandomSourceConfig) (RandomSource, error) {
+	switch cfg.Kind {
+	case "", "crypto":
+		return CryptoRandomSource{}, nil
+	case "hmac":
+		if len(cfg.Key) < 32 {
+			return nil, errors.New("hmac random source requires a key of at least 32 bytes")
+		}
+		return &HMACRandomSource{key: cfg.Key}, nil
+	case "ecvrf":
+		vrfKey, err := vrf.PrivateKeyFromBytes(cfg.Key)
+		if err != nil {
+			return nil, err
+		}
+		return &ECVRFRandomSource{key: vrfKey}, nil
+	case "drand":
+		return NewDrandRandomSource(ctx, cfg.DrandUrl, cfg.DrandChainHash, cfg.Headers)
+	default:
+		return nil, fmt.Errorf("unknown random source %q, expected crypto, hmac, ecvrf or drand", cfg.Kind)
+	}
+}
+
+type CryptoRandomSource struct{}
+
+func (CryptoRandomSource) RandomWords(_ context.Context, request *worker.RequestSend) ([]*big.Int, error) {
+	numWords := int(request.NumWords.Int64())
+	words := make([]*big.Int, 0, numWords)
+	for i := 0; i < numWords; i++ {
+		word, err := rand.Int(rand.Reader, maxUint256)
+		if err != nil {
+			return nil, fmt.Errorf("generate random word: %w", err)
+		}
+		words = append(words, word)
+	}
+	return words, nil
+}
+
+type HMACRandomSource struct {
+	key []byte
+}
+
+func (s *HMACRandomSource) RandomWords(_ context.Context, request *worker.RequestSend) ([]*big.Int, error) {
+	numWords := int(request.NumWords.Int64())
+	words := make([]*big.Int, 0, numWords)
+	for i := 0; i < numWords; i++ {
+		mac := hmac.New(sha256.New, s.key)
+		mac.Write(request.VrfAddress.Bytes())
+		mac.Write(common.BigToHash(request.RequestId).Bytes())
+		mac.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+		words = append(words, new(big.Int).SetBytes(mac.Sum(nil)))
+	}
+	return words, nil
+}
+
+type ECVRFRandomSource struct {
+	key *vrf.PrivateKey
+}
+
+// 输入 alpha 为 vrfAddress || uint256(requestId)，RFC 9381 编码的证明记录在 request.VrfProof
+func (s *ECVRFRandomSource) RandomWords(_ context.Context, request *worker.RequestSend) ([]*big.Int, error) {
+	alpha := append(request.VrfAddress.Bytes(), common.BigToHash(request.RequestId).Bytes()...)
+	proof, beta, err := s.key.Prove(alpha)
+	if err != nil {
+		return nil, err
+	}
+	numWords := int(request.NumWords.Int64())
+	words := make([]*big.Int, 0, numWords)
+	for i := 0; i < numWords; i++ {
+		index := common.BigToHash(big.NewInt(int64(i)))
+		words = append(words, new(big.Int).SetBytes(crypto.Keccak256(beta, index.Bytes())))
+	}
+	request.VrfProof = hexutil.Encode(proof.Bytes())
+	return words, nil
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/WJX2001/contract-caller/publisher"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+/*
+	请求回填重试：
+		- 每次回填失败（模拟执行回滚、交易回滚、广播前失败）记录失败次数、错误和下一次重试时间，到时间前工作器不处理该请求
+		- 第 n 次失败后等待 RetryBackoff * 2^(n-1)，最长 MaxRetryBackoff
+		- 失败后请求从 in_flight 恢复为 pending；失败次数达到 MaxAttempts 时置为 failed，不再重试，记录错误日志并通过消息总线发布告警
+		- 余额不足、随机数未就绪或来源查询失败、链上已回填不计为失败
+		- 交易可能已经广播的发送错误（超时等）不计为失败，请求保持 in_flight，见 in_flight.go
+*/
+
+const (
+	// 默认最多回填次数
+	defaultMaxAttempts = 10
+	// 默认第一次失败后的重试间隔
+	defaultRetryBackoff = 30 * time.Second
+	// 默认最长重试间隔
+	defaultMaxRetryBackoff = time.Hour
+)
+
+// 记录一次回填失败，达到最大次数时请求置为失败
+func (wk *Worker) recordFailure(request worker.RequestSend, lastError string) {
+	request.AttemptCount++
+	if request.AttemptCount >= wk.maxAttempts() {
+		wk.failRequest(request, lastError)
+		return
+	}
+	backoff := wk.retryBackoff(request.AttemptCount)
+	request.Status = worker.RequestSendPending
+	request.LastError = lastError
+	request.NextRetryAt = uint64(time.Now().Add(backoff).Unix())
+	if err := wk.db.RequestSend.MarkRequestSendAttemptFailed(request); err != nil {
+		log.Warn("record fulfill failure fail", "requestId", request.RequestId, "err", err)
+		return
+	}
+	log.Warn("fulfill random words failed, retry scheduled", "requestId", request.RequestId, "attempts", request.AttemptCount, "retryIn", backoff, "err", lastError)
+}
+
+// 把请求置为失败不再重试，并发出告警
+func (wk *Worker) failRequest(request worker.RequestSend, lastError string) {
+	request.Status = worker.RequestSendFailed
+	request.LastError = lastError
+	request.NextRetryAt = 0
+	if err := wk.db.RequestSend.MarkRequestSendAttemptFailed(request); err != nil {
+		log.Warn("record fulfill failure fail", "requestId", request.RequestId, "err", err)
+		return
+	}
+	log.Error("request failed, giving up", "requestId", request.RequestId, "vrfAddress", request.VrfAddress, "attempts", request.AttemptCount, "err", lastError)
+
+	message := publisher.RequestFailedMessage{
+		Type:       publisher.MessageTypeRequestFailed,
+		RequestId:  request.RequestId.String(),
+		VrfAddress: request.VrfAddress.String(),
+		Attempts:   request.AttemptCount,
+		LastError:  lastError,
+	}
+	key := fmt.Sprintf("%s-%s", message.VrfAddress, message.RequestId)
+	if err := publisher.PublishJSON(wk.resourceCtx, wk.workerConfig.Publisher, key, message); err != nil {
+		log.Warn("publish request failed alert fail", "requestId", request.RequestId, "err", err)
+	}
+}
+
+// 第 attempts 次失败后的重试间隔
+func (wk *Worker) retryBackoff(attempts int) time.Duration {
+	backoff, maxBackoff := wk.workerConfig.RetryBackoff, wk.workerConfig.MaxRetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxRetryBackoff
+	}
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+func (wk *Worker) maxAttempts() int {
+	if wk.workerConfig.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return wk.workerConfig.MaxAttempts
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/database/worker"
+	"github.com/WJX2001/contract-caller/driver"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/google/uuid"
+)
+
+/*
+	回填交易记录：
+		- 注册为驱动引擎的交易生命周期回调，从 ctx 中取出请求 ID 和 VRF 合约，把每一笔广播的交易哈希按请求写入 fulfillment_txs
+		- 提价替换时标记旧交易，上链时记录区块高度和回执状态
+		- 没有业务上下文的交易（如部署代理）不记录；写库失败只记录日志，不影响交易发送
+*/
+
+type TxRecorder struct {
+	db *database.DB
+}
+
+func NewTxRecorder(db *database.DB) *TxRecorder {
+	return &TxRecorder{db: db}
+}
+
+func (r *TxRecorder) OnPublished(ctx context.Context, tx *types.Transaction) {
+	metadata := driver.RequestMetadataFromContext(ctx)
+	if metadata == nil {
+		return
+	}
+	now := uint64(time.Now().Unix())
+	txs := make([]worker.FulfillmentTx, 0, len(metadata.RequestIds))
+	for _, requestId := range metadata.RequestIds {
+		txs = append(txs, worker.FulfillmentTx{
+			GUID:            uuid.New(),
+			RequestId:       requestId,
+			VrfAddress:      metadata.VrfAddress,
+			TransactionHash: tx.Hash(),
+			Nonce:           tx.Nonce(),
+			Status:          worker.FulfillmentTxPublished,
+			Timestamp:       now,
+		})
+	}
+	if err := r.db.FulfillmentTx.StoreFulfillmentTxs(txs); err != nil {
+		log.Warn("record fulfillment tx fail", "tx", tx.Hash(), "requests", len(txs), "err", err)
+	}
+}
+
+func (r *TxRecorder) OnBumped(ctx context.Context, oldTx, newTx *types.Transaction) {
+	if driver.RequestMetadataFromContext(ctx) == nil {
+		return
+	}
+	if err := r.db.FulfillmentTx.MarkFulfillmentTxReplaced(oldTx.Hash(), newTx.Hash()); err != nil {
+		log.Warn("record replaced fulfillment tx fail", "tx", oldTx.Hash(), "replacedBy", newTx.Hash(), "err", err)
+	}
+}
+
+func (r *TxRecorder) OnMined(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) {
+	if driver.RequestMetadataFromContext(ctx) == nil {
+		return
+	}
+	if err := r.db.FulfillmentTx.MarkFulfillmentTxMined(tx.Hash(), receipt.BlockNumber, receipt.Status); err != nil {
+		log.Warn("record mined fulfillment tx fail", "tx", tx.Hash(), "err", err)
+	}
+}
@@ -2,36 +2,88 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
 
 	"github.com/WJX2001/contract-caller/common/tasks"
 	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/database/worker"
 	"github.com/WJX2001/contract-caller/driver"
+	"github.com/WJX2001/contract-caller/publisher"
+	"github.com/WJX2001/contract-caller/txmgr"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// 单个请求最多回填的随机数个数
+	maxNumWords = 500
+	// 默认同时回填的请求数
+	defaultMaxConcurrentFulfillments = 4
 )
 
 type WorkerConfig struct {
 	LoopInterval time.Duration
+	Publisher    publisher.Publisher // 发布随机数回填结果，为 nil 时不发布
+	RandomSource RandomSource        // 回填使用的随机数来源，为 nil 时使用 crypto/rand
+	Metrics      Metrics             // 工作器指标，为 nil 时不采集
+
+	MaxConcurrentFulfillments int // 同时回填的请求数，0 使用默认值
+
+	MaxAttempts     int           // 请求最多回填次数，达到后置为失败，0 使用默认值
+	RetryBackoff    time.Duration // 第一次失败后的重试间隔，之后每次翻倍，0 使用默认值
+	MaxRetryBackoff time.Duration // 最长重试间隔，0 使用默认值
+
+	RequestTTL        time.Duration // 请求有效期，超过后标记为过期不再回填，0 表示不过期
+	ExpiredWebhookUrl string        // 请求过期时通知的 webhook，为空时不通知
+}
+
+// 工作器使用的驱动引擎方法，由 *driver.DriverEngine 实现
+type Driver interface {
+	FulfillRandomWordsAt(vrfAddress common.Address, requestId *big.Int, randomList []*big.Int) (*types.Receipt, error)
+	FulfillRandomWordsBatchAt(vrfAddress common.Address, batch []driver.FulfillRequest) (*types.Receipt, error)
+	FulfillBatchSize() int
+	RequestStatus(ctx context.Context, vrfAddress common.Address, requestId *big.Int) (*driver.RequestStatus, error)
+	HasPendingTxs(ctx context.Context) (bool, error)
+	ReceiptRevertReason(ctx context.Context, receipt *types.Receipt) (string, error)
+	MonitorStuckTxs(ctx context.Context)
+	MonitorBalance(ctx context.Context)
+	DryRun() bool
 }
 
 type Worker struct {
 	workerConfig   *WorkerConfig
 	db             *database.DB
-	deg            *driver.DriverEngine
+	deg            Driver
+	randomSource   RandomSource
+	metrics        Metrics
 	resourceCtx    context.Context
 	resourceCancel context.CancelFunc
 	tasks          tasks.Group
 }
 
-func NewWorker(db *database.DB, deg *driver.DriverEngine, workerConfig *WorkerConfig, shutdown context.CancelCauseFunc) (*Worker, error) {
+func NewWorker(db *database.DB, deg Driver, workerConfig *WorkerConfig, shutdown context.CancelCauseFunc) (*Worker, error) {
 	resCtx, resCancel := context.WithCancel(context.Background())
 
+	randomSource := workerConfig.RandomSource
+	if randomSource == nil {
+		randomSource = CryptoRandomSource{}
+	}
+	metrics := workerConfig.Metrics
+	if metrics == nil {
+		metrics = NoopMetrics
+	}
+
 	return &Worker{
 		db:             db,
 		deg:            deg,
 		workerConfig:   workerConfig,
+		randomSource:   randomSource,
+		metrics:        metrics,
 		resourceCtx:    resCtx,
 		resourceCancel: resCancel,
 		tasks: tasks.Group{HandleCrit: func(err error) {
@@ -44,7 +96,15 @@ func (wk *Worker) Start() error {
 	log.Info("starting worker processor...")
 	tickerEventWorker := time.NewTicker(wk.workerConfig.LoopInterval) // 每隔 几s 执行一次 ticker
 	wk.tasks.Go(func() error {
-		for range tickerEventWorker.C {
+		defer tickerEventWorker.Stop()
+		for {
+			// Close 取消 resourceCtx 后立即退出，不再等待下一次 tick
+			select {
+			case <-wk.resourceCtx.Done():
+				log.Info("worker processor stopped")
+				return nil
+			case <-tickerEventWorker.C:
+			}
 			log.Info("start handler random for vrf")
 			// 每隔一段时间 会发一笔交易更新一下ProcessCallerVrf
 			err := wk.ProcessCallerVrf()
@@ -53,34 +113,238 @@ func (wk *Worker) Start() error {
 				return err
 			}
 		}
+	})
+	// 检测并救援卡住的交易
+	wk.tasks.Go(func() error {
+		wk.deg.MonitorStuckTxs(wk.resourceCtx)
+		return nil
+	})
+	// 监控调用者余额，余额不足时暂停回填
+	wk.tasks.Go(func() error {
+		wk.deg.MonitorBalance(wk.resourceCtx)
 		return nil
 	})
 	return nil
 }
 
 // 组织数据通过 FulfillRandomWords 调用合约的方法，将数据写入合约
-
+// 从数据库读取未处理且到了重试时间的请求，按请求的随机数个数生成随机数并回填
+// 最多 MaxConcurrentFulfillments 个请求同时回填，每笔交易各自预留 nonce；一个请求返回错误后不再开始新的请求
+// 配置了批量回填时按 vrf_address 分组，每组作为一个任务批量回填
 func (wk *Worker) ProcessCallerVrf() error {
-	// 获取 RequestSent 合约事件
-	var randomList []*big.Int
+	if err := wk.recoverInFlight(); err != nil {
+		log.Error("recover in-flight requests fail", "err", err)
+		return err
+	}
+	requests, err := wk.db.RequestSend.QueryUnHandleRequestSendList(uint64(time.Now().Unix()))
+	if err != nil {
+		log.Error("query unhandled requests fail", "err", err)
+		return err
+	}
+	if len(requests) > 0 {
+		log.Info("fulfilling pending requests", "requests", len(requests), "concurrency", wk.maxConcurrentFulfillments())
+	}
 
-	randomList = append(randomList, big.NewInt(1000))
-	randomList = append(randomList, big.NewInt(1001))
-	randomList = append(randomList, big.NewInt(1002))
+	group, ctx := errgroup.WithContext(wk.resourceCtx)
+	group.SetLimit(wk.maxConcurrentFulfillments())
+	if batchSize := wk.deg.FulfillBatchSize(); batchSize > 0 {
+		for _, batch := range groupByVrfAddress(requests) {
+			if ctx.Err() != nil {
+				break
+			}
+			group.Go(func() error {
+				return wk.processBatch(ctx, batch, batchSize)
+			})
+		}
+		return group.Wait()
+	}
+	for _, request := range requests {
+		if ctx.Err() != nil {
+			break
+		}
+		group.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return wk.processRequest(request)
+		})
+	}
+	return group.Wait()
+}
 
-	txReceipt, err := wk.deg.FulfillRandomWords(big.NewInt(22222222), randomList)
-	if err != nil {
-		log.Error("fulfill random words fail", "err", err)
+func (wk *Worker) maxConcurrentFulfillments() int {
+	if wk.workerConfig.MaxConcurrentFulfillments <= 0 {
+		return defaultMaxConcurrentFulfillments
+	}
+	return wk.workerConfig.MaxConcurrentFulfillments
+}
+
+// 回填一个请求，发送交易前把请求置为 in_flight，回填失败只记录原因，等待重试间隔后重试
+func (wk *Worker) processRequest(request worker.RequestSend) error {
+	request, randomList, ready, err := wk.prepareRequest(request)
+	if err != nil || !ready {
 		return err
 	}
-	if txReceipt.Status == 1 {
-		log.Info("call contract success ......")
+	txReceipt, err := wk.deg.FulfillRandomWordsAt(request.VrfAddress, request.RequestId, randomList)
+	return wk.finishRequest(request, randomList, txReceipt, err, 1)
+}
+
+// 检查请求并生成随机数，请求可以回填时置为 in_flight 并返回 ready；过期、无效、链上已回填、随机数未就绪或已被其他进程占用时跳过
+func (wk *Worker) prepareRequest(request worker.RequestSend) (worker.RequestSend, []*big.Int, bool, error) {
+	requestId := request.RequestId
+	if expired, err := wk.expireRequest(request); err != nil || expired {
+		return request, nil, false, err
+	}
+	if request.NumWords == nil || request.NumWords.Sign() <= 0 || request.NumWords.Cmp(big.NewInt(maxNumWords)) > 0 {
+		log.Warn("skip fulfill random words, invalid number of words", "requestId", requestId, "numWords", request.NumWords)
+		request.LastError = fmt.Sprintf("invalid number of words %s", request.NumWords)
+		if err := wk.db.RequestSend.MarkRequestSendSkipped(request); err != nil && !errors.Is(err, worker.ErrRequestStatusConflict) {
+			return request, nil, false, err
+		}
+		return request, nil, false, nil
+	}
+	if wk.fulfilledOnChain(request.VrfAddress, requestId) {
+		// 链上已经回填，数据库状态由事件处理器根据 FillRandomWords 事件更新
+		log.Info("skip fulfill random words, request already fulfilled on chain", "requestId", requestId)
+		return request, nil, false, nil
+	}
+	randomList, err := wk.randomSource.RandomWords(wk.resourceCtx, &request)
+	if errors.Is(err, ErrRandomnessNotReady) {
+		log.Info("skip fulfill random words, randomness not ready", "requestId", requestId, "err", err)
+		return request, nil, false, nil
+	}
+	if err != nil {
+		// 信标请求超时、服务端错误或查询区块头失败通常是暂时的，跳过该请求下一轮重试，不能让工作器退出
+		log.Warn("skip fulfill random words, random source fail", "requestId", requestId, "err", err)
+		return request, nil, false, nil
 	}
-	return nil
 
+	// 占用请求，其他进程已经修改了请求状态时跳过
+	err = wk.db.RequestSend.MarkRequestSendInFlight(request)
+	if errors.Is(err, worker.ErrRequestStatusConflict) {
+		log.Info("skip fulfill random words, request no longer pending", "requestId", requestId)
+		return request, nil, false, nil
+	}
+	if err != nil {
+		return request, nil, false, err
+	}
+	request.Status = worker.RequestSendInFlight
+	return request, randomList, true, nil
+}
+
+// 处理发送中请求的回填结果，txReceipt 为包含 requests 个请求的交易的回执，花费按请求数平摊
+func (wk *Worker) finishRequest(request worker.RequestSend, randomList []*big.Int, txReceipt *types.Receipt, err error, requests int) error {
+	requestId := request.RequestId
+	if errors.Is(err, driver.ErrBalanceTooLow) {
+		// 余额恢复前不发送交易
+		log.Warn("skip fulfill random words, caller balance too low", "requestId", requestId)
+		return ignoreStatusConflict(requestId, wk.db.RequestSend.MarkRequestSendPending(request))
+	}
+	var simErr *driver.ErrSimulationReverted
+	if errors.As(err, &simErr) {
+		// 模拟执行回滚的交易没有广播，跳过本次回填
+		log.Warn("skip fulfill random words, simulation reverted", "requestId", requestId, "reason", simErr.Reason)
+		request.FailureReason = simErr.Reason
+		wk.recordFailure(request, fmt.Sprintf("simulation reverted: %s", simErr.Reason))
+		return nil
+	}
+	var revertErr *txmgr.ErrTxReverted
+	if errors.As(err, &revertErr) {
+		log.Error("fulfill random words tx reverted", "requestId", requestId, "tx", revertErr.TxHash, "reason", revertErr.Reason)
+		wk.recordCost(request, revertErr.Receipt, requests)
+		request.FailureReason = revertErr.Reason
+		wk.recordFailure(request, fmt.Sprintf("tx %s reverted: %s", revertErr.TxHash, revertErr.Reason))
+		return nil
+	}
+	if errors.Is(err, driver.ErrTxNotSent) || errors.Is(err, txmgr.ErrTxNotInMempool) {
+		// 交易没有广播，计为一次失败后重试
+		log.Error("fulfill random words fail", "requestId", requestId, "err", err)
+		wk.recordFailure(request, err.Error())
+		return nil
+	}
+	if err != nil {
+		// 发送超时、等待回执失败等错误发生时交易可能已经广播，恢复为 pending 会重复回填
+		// 请求保持 in_flight，由 recoverInFlight 在调用者没有在途交易时按链上状态释放，或由 FillRandomWords 事件标记为 fulfilled
+		log.Error("fulfill random words fail, keeping request in flight", "requestId", requestId, "err", err)
+		return nil
+	}
+	wk.publishFulfillment(requestId, txReceipt)
+	if txReceipt.Status != types.ReceiptStatusSuccessful {
+		reason, err := wk.deg.ReceiptRevertReason(wk.resourceCtx, txReceipt)
+		if err != nil {
+			log.Warn("unable to decode revert reason", "requestId", requestId, "tx", txReceipt.TxHash, "err", err)
+		}
+		log.Error("fulfill random words tx reverted", "requestId", requestId, "tx", txReceipt.TxHash, "reason", reason)
+		wk.recordCost(request, txReceipt, requests)
+		request.FailureReason = reason
+		wk.recordFailure(request, fmt.Sprintf("tx %s reverted: %s", txReceipt.TxHash, reason))
+		return nil
+	}
+
+	log.Info("fulfilled random words", "requestId", requestId, "vrfAddress", request.VrfAddress, "words", len(randomList), "tx", txReceipt.TxHash)
+	if !wk.fulfilledOnChain(request.VrfAddress, requestId) {
+		log.Warn("fulfill tx succeeded but request not fulfilled on chain", "requestId", requestId, "tx", txReceipt.TxHash)
+	}
+	if wk.deg.DryRun() {
+		// 演练模式不广播交易，请求恢复为 pending
+		return ignoreStatusConflict(requestId, wk.db.RequestSend.MarkRequestSendPending(request))
+	}
+	// 在同一个事务中标记请求已处理并记录花费
+	err = wk.db.Transaction(func(tx *database.DB) error {
+		if err := tx.RequestSend.MarkRequestSendFinish(request); err != nil {
+			return err
+		}
+		cost := worker.FulfillmentCostFromReceipt(requestId, request.VrfAddress, txReceipt, requests)
+		return tx.FulfillmentCost.StoreFulfillmentCosts([]worker.FulfillmentCost{cost})
+	})
+	return ignoreStatusConflict(requestId, err)
+}
+
+// 事件处理器可能已根据 FillRandomWords 事件把请求标记为 fulfilled，此时状态冲突不是错误，不能让工作器退出
+func ignoreStatusConflict(requestId *big.Int, err error) error {
+	if errors.Is(err, worker.ErrRequestStatusConflict) {
+		log.Info("request status changed concurrently, leaving it as is", "requestId", requestId)
+		return nil
+	}
+	return err
+}
+
+// 查询请求在链上是否已回填，查询失败时视为未回填
+func (wk *Worker) fulfilledOnChain(vrfAddress common.Address, requestId *big.Int) bool {
+	status, err := wk.deg.RequestStatus(wk.resourceCtx, vrfAddress, requestId)
+	if err != nil {
+		log.Warn("query request status fail", "requestId", requestId, "err", err)
+		return false
+	}
+	return status.Fulfilled
+}
+
+// 记录执行失败的回填交易的 gas 花费，记录失败只写日志
+func (wk *Worker) recordCost(request worker.RequestSend, txReceipt *types.Receipt, requests int) {
+	if txReceipt == nil || wk.deg.DryRun() {
+		return
+	}
+	cost := worker.FulfillmentCostFromReceipt(request.RequestId, request.VrfAddress, txReceipt, requests)
+	if err := wk.db.FulfillmentCost.StoreFulfillmentCosts([]worker.FulfillmentCost{cost}); err != nil {
+		log.Warn("record fulfillment cost fail", "requestId", request.RequestId, "tx", txReceipt.TxHash, "err", err)
+	}
 }
 
 func (wk *Worker) Close() error {
 	wk.resourceCancel()
 	return wk.tasks.Wait()
 }
+
+// 发布回填交易的执行结果，发布失败只记录日志
+func (wk *Worker) publishFulfillment(requestId *big.Int, txReceipt *types.Receipt) {
+	message := publisher.FulfillmentMessage{
+		Type:            publisher.MessageTypeFulfillment,
+		RequestId:       requestId.String(),
+		TransactionHash: txReceipt.TxHash.String(),
+		BlockNumber:     txReceipt.BlockNumber.String(),
+		Status:          txReceipt.Status,
+	}
+	if err := publisher.PublishJSON(wk.resourceCtx, wk.workerConfig.Publisher, message.TransactionHash, message); err != nil {
+		log.Warn("publish fulfillment fail", "requestId", requestId, "tx", message.TransactionHash, "err", err)
+	}
+}
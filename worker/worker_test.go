@@ -0,0 +1,212 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WJX2001/contract-caller/database"
+	"github.com/WJX2001/contract-caller/database/dbtest"
+	dbworker "github.com/WJX2001/contract-caller/database/worker"
+	"github.com/WJX2001/contract-caller/driver"
+	"github.com/WJX2001/contract-caller/worker"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testVrfAddress = common.HexToAddress("0x1234")
+	// 发送超时，交易可能已经广播
+	errTimeout = errors.New("context deadline exceeded")
+)
+
+// 模拟驱动引擎，记录回填的请求和同时回填的请求数
+type fakeDriver struct {
+	mu           sync.Mutex
+	fulfillDelay time.Duration
+	fulfillErr   error
+	pendingTxs   bool
+	onChain      map[string]bool // 链上已回填的请求
+	fulfilled    []*big.Int
+	active       int
+	maxActive    int
+}
+
+func newFakeDriver() *fakeDriver {
+	return &fakeDriver{onChain: make(map[string]bool)}
+}
+
+func (d *fakeDriver) FulfillRandomWordsAt(vrfAddress common.Address, requestId *big.Int, randomList []*big.Int) (*types.Receipt, error) {
+	d.mu.Lock()
+	d.active++
+	d.maxActive = max(d.maxActive, d.active)
+	d.mu.Unlock()
+
+	time.Sleep(d.fulfillDelay)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	d.fulfilled = append(d.fulfilled, requestId)
+	if d.fulfillErr != nil {
+		return nil, d.fulfillErr
+	}
+	d.onChain[requestId.String()] = true
+	return &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      common.BigToHash(requestId),
+		BlockNumber: big.NewInt(100),
+		GasUsed:     21000,
+	}, nil
+}
+
+func (d *fakeDriver) FulfillRandomWordsBatchAt(common.Address, []driver.FulfillRequest) (*types.Receipt, error) {
+	return nil, errors.New("batch fulfillment not configured")
+}
+
+func (d *fakeDriver) FulfillBatchSize() int {
+	return 0
+}
+
+func (d *fakeDriver) RequestStatus(_ context.Context, _ common.Address, requestId *big.Int) (*driver.RequestStatus, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &driver.RequestStatus{Fulfilled: d.onChain[requestId.String()]}, nil
+}
+
+func (d *fakeDriver) HasPendingTxs(context.Context) (bool, error) {
+	return d.pendingTxs, nil
+}
+
+func (d *fakeDriver) ReceiptRevertReason(context.Context, *types.Receipt) (string, error) {
+	return "", nil
+}
+
+func (d *fakeDriver) MonitorStuckTxs(context.Context) {}
+
+func (d *fakeDriver) MonitorBalance(context.Context) {}
+
+func (d *fakeDriver) DryRun() bool {
+	return false
+}
+
+func (d *fakeDriver) fulfilledRequests() []*big.Int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*big.Int{}, d.fulfilled...)
+}
+
+func newTestWorker(t *testing.T, deg worker.Driver, cfg worker.WorkerConfig) (*worker.Worker, *database.DB) {
+	db := dbtest.NewDB(t)
+	wk, err := worker.NewWorker(db, deg, &cfg, func(error) {})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = wk.Close() })
+	return wk, db
+}
+
+// 写入 status 状态的请求
+func storeRequests(t *testing.T, db *database.DB, status uint8, requestIds ...int64) []dbworker.RequestSend {
+	requests := make([]dbworker.RequestSend, 0, len(requestIds))
+	for _, requestId := range requestIds {
+		txHash := common.BigToHash(big.NewInt(requestId))
+		logIndex := uint64(1)
+		request := dbworker.RequestSend{
+			GUID:            uuid.New(),
+			RequestId:       big.NewInt(requestId),
+			VrfAddress:      testVrfAddress,
+			NumWords:        big.NewInt(2),
+			BlockNumber:     big.NewInt(10),
+			TransactionHash: &txHash,
+			LogIndex:        &logIndex,
+			Timestamp:       uint64(time.Now().Unix()),
+		}
+		require.NoError(t, db.RequestSend.StoreRequestSend([]dbworker.RequestSend{request}))
+		if status == dbworker.RequestSendInFlight {
+			require.NoError(t, db.RequestSend.MarkRequestSendInFlight(request))
+		}
+		requests = append(requests, request)
+	}
+	return requests
+}
+
+func requestsByStatus(t *testing.T, db *database.DB, status uint8) []dbworker.RequestSend {
+	requests, err := db.RequestSend.QueryRequestSendListByStatus(status)
+	require.NoError(t, err)
+	return requests
+}
+
+func TestProcessCallerVrfBoundsConcurrentFulfillments(t *testing.T) {
+	deg := newFakeDriver()
+	deg.fulfillDelay = 50 * time.Millisecond
+	wk, db := newTestWorker(t, deg, worker.WorkerConfig{MaxConcurrentFulfillments: 3})
+	storeRequests(t, db, dbworker.RequestSendPending, 1, 2, 3, 4, 5, 6, 7, 8)
+
+	require.NoError(t, wk.ProcessCallerVrf())
+
+	require.Len(t, deg.fulfilledRequests(), 8)
+	require.Equal(t, 3, deg.maxActive)
+	require.Len(t, requestsByStatus(t, db, dbworker.RequestSendFulfilled), 8)
+	spend, err := db.FulfillmentCost.QueryFulfillmentSpend()
+	require.NoError(t, err)
+	require.Len(t, spend, 1)
+	require.Equal(t, int64(8), spend[0].Fulfillments)
+}
+
+func TestProcessCallerVrfSkipsRequestsFulfilledOnChain(t *testing.T) {
+	deg := newFakeDriver()
+	deg.onChain["1"] = true
+	wk, db := newTestWorker(t, deg, worker.WorkerConfig{})
+	storeRequests(t, db, dbworker.RequestSendPending, 1, 2)
+
+	require.NoError(t, wk.ProcessCallerVrf())
+
+	require.Equal(t, []*big.Int{big.NewInt(2)}, deg.fulfilledRequests())
+	// 链上已回填的请求由事件处理器标记为 fulfilled
+	pending := requestsByStatus(t, db, dbworker.RequestSendPending)
+	require.Len(t, pending, 1)
+	require.Equal(t, big.NewInt(1), pending[0].RequestId)
+}
+
+func TestProcessCallerVrfSendErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		maxAttempts int
+		status      uint8
+		attempts    int
+	}{
+		// 交易没有广播，计为一次失败后重试
+		{name: "not sent", err: driver.ErrTxNotSent, status: dbworker.RequestSendPending, attempts: 1},
+		{name: "simulation reverted", err: &driver.ErrSimulationReverted{Reason: "bad request"}, status: dbworker.RequestSendPending, attempts: 1},
+		{name: "attempts exhausted", err: driver.ErrTxNotSent, maxAttempts: 1, status: dbworker.RequestSendFailed, attempts: 1},
+		// 交易可能已经广播，请求保持 in_flight，不计为失败
+		{name: "possibly broadcast", err: errTimeout, status: dbworker.RequestSendInFlight},
+		// 余额不足时释放请求，不计为失败
+		{name: "balance too low", err: driver.ErrBalanceTooLow, status: dbworker.RequestSendPending},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deg := newFakeDriver()
+			deg.fulfillErr = test.err
+			wk, db := newTestWorker(t, deg, worker.WorkerConfig{MaxAttempts: test.maxAttempts})
+			storeRequests(t, db, dbworker.RequestSendPending, 1)
+
+			require.NoError(t, wk.ProcessCallerVrf())
+
+			requests := requestsByStatus(t, db, test.status)
+			require.Len(t, requests, 1)
+			require.Equal(t, test.attempts, requests[0].AttemptCount)
+			if test.attempts > 0 {
+				require.NotEmpty(t, requests[0].LastError)
+			}
+			if test.status == dbworker.RequestSendPending && test.attempts > 0 {
+				require.Greater(t, requests[0].NextRetryAt, uint64(time.Now().Unix()))
+			}
+		})
+	}
+}